@@ -1,20 +1,288 @@
 package matrixprofile
 
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+)
+
 // AnalyzeOpts contains all the parameters needed for basic features to discover from
 // a matrix profile. This is currently limited to motif, discord, and segmentation discovery.
 type AnalyzeOpts struct {
-	kMotifs        int     // the top k motifs to find
-	rMotifs        float64 // the max radius to find motifs
-	kDiscords      int     // the top k discords to find
-	OutputFilename string  // relative or absolute filepath for the visualization output
+	Motifs         bool         // enables motif discovery
+	KMotifs        int          // the top k motifs to find
+	RMotifs        float64      // the max radius to find motifs
+	MotifRank      MotifRank    // order of the returned motif groups. Discovery order if empty
+	Discords       bool         // enables discord discovery
+	KDiscords      int          // the top k discords to find
+	Period         int          // known seasonality in points. Discords in the same phase of the period as a found discord are suppressed if greater than 0
+	Segments       bool         // enables segmentation with the corrected arc curve
+	Regimes        int          // number of regimes to split a self join into before discovering motifs and discords within each one. Disabled if less than 2
+	SegmentOpts    *SegmentOpts // ideal arc curve used for segmentation. Defaults to the parabola if nil
+	AV             av.AV        // annotation vector applied before motif and discord discovery. av.Auto applies the one recommended by av.Recommend, reported in the result
+	ExclusionZone  int          // exclusion zone around found motifs and discords. Defaults to half the subsequence length if 0
+	Subsequences   bool         // attaches the subsequence values of every motif member and discord to the result
+	OutputFilename string       // relative or absolute filepath for the visualization output. No visualization is created if empty
+	OutputFormat   string       // format of the visualization output. Inferred from the filename if empty
 }
 
 // NewAnalyzeOpts creates a default set of parameters to analyze the matrix profile.
 func NewAnalyzeOpts() *AnalyzeOpts {
 	return &AnalyzeOpts{
-		kMotifs:        3,
-		rMotifs:        2,
-		kDiscords:      3,
+		Motifs:         true,
+		KMotifs:        3,
+		RMotifs:        2,
+		Discords:       true,
+		KDiscords:      3,
+		Segments:       true,
+		AV:             av.Default,
 		OutputFilename: "mp.png",
 	}
 }
+
+// AnalysisResult holds the features discovered while analyzing a matrix profile.
+// Features for disabled analysis steps are left empty.
+type AnalysisResult struct {
+	Motifs       []MotifGroup `json:"motifs"`        // discovered motifs
+	Discords     []int        `json:"discords"`      // starting index of each discovered discord
+	CAC          []float64    `json:"cac"`           // corrected arc curve
+	SegmentIdx   int          `json:"segment_idx"`   // index of the most likely regime change
+	SegmentScore float64      `json:"segment_score"` // corrected arc curve value at the segment index
+
+	// annotation vector selected for the time series and why, only set if
+	// the analyze options request av.Auto
+	AVRecommendation *av.Recommendation `json:"av_recommendation,omitempty"`
+
+	// motifs and discords discovered within each regime, only set if regimes
+	// are requested in the analyze options
+	Regimes []RegimeResult `json:"regimes,omitempty"`
+
+	// subsequence values of the discovered features, only set if requested in
+	// the analyze options
+	MotifSubsequences   [][]Subsequence `json:"motif_subsequences,omitempty"`   // subsequence of each motif group member
+	DiscordSubsequences []Subsequence   `json:"discord_subsequences,omitempty"` // subsequence of each discord
+
+	// timestamps of the discovered features, only set if the matrix profile
+	// was created from a time series
+	MotifTimes   [][]time.Time `json:"motif_times,omitempty"`   // timestamp of each motif group member
+	DiscordTimes []time.Time   `json:"discord_times,omitempty"` // timestamp of each discord
+	SegmentTime  *time.Time    `json:"segment_time,omitempty"`  // timestamp of the most likely regime change
+}
+
+// RegimeResult holds the features discovered within a single regime of the
+// time series. Indexes refer to the full time series.
+type RegimeResult struct {
+	Start    int          `json:"start"`    // index of the first point of the regime
+	End      int          `json:"end"`      // index after the last point of the regime
+	Motifs   []MotifGroup `json:"motifs"`   // motifs with every member inside the regime
+	Discords []int        `json:"discords"` // starting index of each discord inside the regime
+}
+
+// analyzeRegimes splits the time series at the boundaries found by
+// DiscoverRegimes and discovers motifs and discords within each regime on its
+// own matrix profile, so that subsequences spanning a regime change are never
+// matched.
+func (mp MatrixProfile) analyzeRegimes(ao *AnalyzeOpts, exclusionZone int) ([]RegimeResult, error) {
+	if !mp.SelfJoin {
+		return nil, newError(ErrNotSelfJoin, "can only split a self join into regimes")
+	}
+	regimes, err := mp.DiscoverRegimes(ao.Regimes, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := []int{0}
+	for _, r := range regimes {
+		bounds = append(bounds, r.Idx)
+	}
+	bounds = append(bounds, len(mp.A))
+
+	results := make([]RegimeResult, len(bounds)-1)
+	for i := range results {
+		start, end := bounds[i], bounds[i+1]
+		results[i] = RegimeResult{Start: start, End: end}
+
+		rmp, err := New(mp.A[start:end], nil, mp.W)
+		if err != nil {
+			return nil, err
+		}
+		rmp.AV = mp.AV
+		rmp.Logger = mp.Logger
+		o := *mp.Opts
+		if o.Mask != nil {
+			o.Mask = o.Mask[start : end-mp.W+1]
+		}
+		o.Boundaries = nil
+		for _, b := range mp.Opts.Boundaries {
+			if b > start && b < end {
+				o.Boundaries = append(o.Boundaries, b-start)
+			}
+		}
+		if err = rmp.Compute(&o); err != nil {
+			return nil, err
+		}
+
+		if ao.Motifs {
+			motifs, err := rmp.DiscoverMotifs(ao.KMotifs, ao.RMotifs, 10, exclusionZone)
+			if err != nil {
+				return nil, err
+			}
+			if err = RankMotifs(motifs, ao.MotifRank); err != nil {
+				return nil, err
+			}
+			// groups without members are left out since fewer than KMotifs
+			// motifs are often found in a short regime
+			for _, m := range motifs {
+				if len(m.Idx) == 0 {
+					continue
+				}
+				for j := range m.Idx {
+					m.Idx[j] += start
+				}
+				m.Center += start
+				results[i].Motifs = append(results[i].Motifs, m)
+			}
+		}
+
+		if ao.Discords {
+			discords, err := rmp.DiscoverSeasonalDiscords(ao.KDiscords, exclusionZone, ao.Period)
+			if err != nil {
+				return nil, err
+			}
+			for j := range discords {
+				discords[j] += start
+			}
+			results[i].Discords = discords
+		}
+	}
+	return results, nil
+}
+
+// setTimes reports the discovered features as timestamps
+func (r *AnalysisResult) setTimes(times []time.Time) {
+	if times == nil {
+		return
+	}
+	if r.Motifs != nil {
+		r.MotifTimes = make([][]time.Time, len(r.Motifs))
+		for i, m := range r.Motifs {
+			r.MotifTimes[i] = timesOf(times, m.Idx)
+		}
+	}
+	r.DiscordTimes = timesOf(times, r.Discords)
+	if r.CAC != nil && r.SegmentIdx < len(times) {
+		t := times[r.SegmentIdx]
+		r.SegmentTime = &t
+	}
+}
+
+// setSubsequences attaches the subsequence values of the discovered features
+func (r *AnalysisResult) setSubsequences(mp MatrixProfile) error {
+	var err error
+	if r.Motifs != nil {
+		r.MotifSubsequences = make([][]Subsequence, len(r.Motifs))
+		for i, m := range r.Motifs {
+			if r.MotifSubsequences[i], err = mp.MotifSubsequences(m); err != nil {
+				return err
+			}
+		}
+	}
+	if r.Discords != nil {
+		if r.DiscordSubsequences, err = mp.DiscordSubsequences(r.Discords); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BatchError reports every series that failed during AnalyzeAll.
+type BatchError struct {
+	Errs []error // error for each series in input order, nil if the series was analyzed
+}
+
+func (e *BatchError) Error() string {
+	var failed, first int
+	first = -1
+	for i, err := range e.Errs {
+		if err != nil {
+			if first < 0 {
+				first = i
+			}
+			failed++
+		}
+	}
+	if first < 0 {
+		return "no series failed"
+	}
+	return fmt.Sprintf("failed to analyze %d of %d series, first failure at series %d: %v", failed, len(e.Errs), first, e.Errs[first])
+}
+
+// AnalyzeAll analyzes a collection of independent time series with a
+// subsequence length of w. The series are spread across a pool of
+// mo.NJobs workers with each matrix profile computed by a single goroutine,
+// which suits many short series better than parallelizing each one. Series
+// of equal length share fourier transform plans through the plan cache. The
+// results are returned in input order. If any series fails, its result is nil
+// and a *BatchError listing every failure is returned with the remaining
+// results. Visualizations are not supported so the output filename of the
+// analyze options must be empty.
+func AnalyzeAll(series [][]float64, w int, mo *MPOpts, ao *AnalyzeOpts) ([]*AnalysisResult, error) {
+	if mo == nil {
+		mo = NewMPOpts()
+	}
+	if ao == nil {
+		ao = NewAnalyzeOpts()
+		ao.OutputFilename = ""
+	}
+	if ao.OutputFilename != "" {
+		return nil, errors.New("output filename must be empty when analyzing many series")
+	}
+
+	workers := mo.NJobs
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(series) {
+		workers = len(series)
+	}
+
+	// each matrix profile is computed by its worker alone
+	so := *mo
+	so.NJobs = 1
+
+	results := make([]*AnalysisResult, len(series))
+	errs := make([]error, len(series))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				mp, err := New(series[idx], nil, w)
+				if err != nil {
+					errs[idx] = err
+					continue
+				}
+				opts := so
+				results[idx], errs[idx] = mp.Analyze(&opts, ao)
+			}
+		}()
+	}
+	for i := range series {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return results, &BatchError{Errs: errs}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,214 @@
+package matrixprofile
+
+import (
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestAnalyze(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Sin(0.25, 10, 0, 0.75, 100, 1))
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+
+	mp, err := New(sig, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ao := NewAnalyzeOpts()
+	ao.OutputFilename = ""
+	res, err := mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(res.Motifs) != ao.KMotifs {
+		t.Errorf("Expected %d motifs, but got %d", ao.KMotifs, len(res.Motifs))
+	}
+	if len(res.Discords) != ao.KDiscords {
+		t.Errorf("Expected %d discords, but got %d", ao.KDiscords, len(res.Discords))
+	}
+	if len(res.CAC) != len(sig)-32+1 {
+		t.Errorf("Expected a corrected arc curve of length %d, but got %d", len(sig)-32+1, len(res.CAC))
+	}
+	if res.MotifSubsequences != nil || res.DiscordSubsequences != nil {
+		t.Errorf("Expected no subsequences unless requested")
+	}
+
+	ao.Subsequences = true
+	res, err = mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(res.MotifSubsequences) != len(res.Motifs) || len(res.DiscordSubsequences) != len(res.Discords) {
+		t.Fatalf("Expected subsequences for %d motifs and %d discords, but got %d and %d", len(res.Motifs), len(res.Discords), len(res.MotifSubsequences), len(res.DiscordSubsequences))
+	}
+	for i, d := range res.Discords {
+		if sub := res.DiscordSubsequences[i]; sub.Idx != d || len(sub.Values) != 32 || sub.Values[0] != sig[d] {
+			t.Errorf("Expected the subsequence of discord %d, but got %+v", d, sub)
+		}
+	}
+	ao.Subsequences = false
+
+	ao.Motifs = false
+	ao.Segments = false
+	res, err = mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if res.Motifs != nil || res.CAC != nil {
+		t.Errorf("Expected disabled steps to be empty, but got %+v", res)
+	}
+	if len(res.Discords) != ao.KDiscords {
+		t.Errorf("Expected %d discords, but got %d", ao.KDiscords, len(res.Discords))
+	}
+	if res.AVRecommendation != nil {
+		t.Errorf("Expected no annotation vector recommendation unless requested")
+	}
+
+	ao.AV = av.Auto
+	res, err = mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	expected, err := av.Recommend(sig, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AVRecommendation == nil || *res.AVRecommendation != *expected {
+		t.Errorf("Expected the recommendation %+v, but got %+v", expected, res.AVRecommendation)
+	}
+}
+
+func TestAnalyzeRegimes(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 3), siggen.Sin(0.25, 10, 0, 0.75, 100, 3))
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+	w := 32
+
+	mp, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ao := NewAnalyzeOpts()
+	ao.OutputFilename = ""
+	ao.Regimes = 2
+	res, err := mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(res.Regimes) != 2 {
+		t.Fatalf("Expected 2 regimes, but got %+v", res.Regimes)
+	}
+	if res.Regimes[0].Start != 0 || res.Regimes[1].End != len(sig) || res.Regimes[0].End != res.Regimes[1].Start {
+		t.Errorf("Expected regimes covering the time series, but got %+v", res.Regimes)
+	}
+	if b := res.Regimes[1].Start; b < 300-w || b > 300+w {
+		t.Errorf("Expected a regime change near 300, but got %d", b)
+	}
+
+	// every feature lies entirely within its regime
+	for _, r := range res.Regimes {
+		if len(r.Motifs) == 0 || len(r.Discords) == 0 {
+			t.Errorf("Expected motifs and discords in every regime, but got %+v", r)
+		}
+		for _, m := range r.Motifs {
+			if len(m.Idx) == 0 {
+				t.Errorf("Expected only motifs with members in the regime from %d to %d", r.Start, r.End)
+			}
+			for _, idx := range m.Idx {
+				if idx < r.Start || idx+w > r.End {
+					t.Errorf("Expected motif member %d inside the regime from %d to %d", idx, r.Start, r.End)
+				}
+			}
+
+			// the center is offset into the whole time series like the members
+			var found bool
+			for _, idx := range m.Idx {
+				found = found || idx == m.Center
+			}
+			if !found {
+				t.Errorf("Expected motif center %d to be one of the members %v", m.Center, m.Idx)
+			}
+		}
+		for _, idx := range r.Discords {
+			if idx < r.Start || idx+w > r.End {
+				t.Errorf("Expected discord %d inside the regime from %d to %d", idx, r.Start, r.End)
+			}
+		}
+	}
+
+	ab, err := New(sig, sig[:200], w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ao.Motifs = false
+	ao.Discords = false
+	ao.Segments = false
+	if _, err = ab.Analyze(nil, ao); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin for regimes of an AB join, but got %v", err)
+	}
+}
+
+func TestAnalyzeAll(t *testing.T) {
+	series := [][]float64{
+		siggen.Sin(1, 5, 0, 0, 100, 2),
+		siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 1), siggen.Sin(0.25, 10, 0, 0.75, 100, 1)),
+		siggen.Sin(1, 3, 0, 0, 100, 2),
+		{1, 2, 3},
+		siggen.Sin(0.5, 7, 0, 0, 100, 3),
+	}
+	w := 16
+
+	mo := NewMPOpts()
+	mo.NJobs = 2
+	ao := NewAnalyzeOpts()
+	ao.OutputFilename = ""
+
+	results, err := AnalyzeAll(series, w, mo, ao)
+	batchErr, ok := err.(*BatchError)
+	if !ok {
+		t.Fatalf("Expected a batch error, but got %v", err)
+	}
+	for i, s := range series {
+		if len(s) < w {
+			if batchErr.Errs[i] == nil || results[i] != nil {
+				t.Errorf("Expected an error and no result for series %d", i)
+			}
+			continue
+		}
+		if batchErr.Errs[i] != nil {
+			t.Errorf("Did not expect an error, %v, for series %d", batchErr.Errs[i], i)
+			continue
+		}
+
+		mp, err := New(s, nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := mp.Analyze(mo, ao)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(results[i].Discords) != len(expected.Discords) {
+			t.Errorf("Expected %d discords, but got %d for series %d", len(expected.Discords), len(results[i].Discords), i)
+			continue
+		}
+		for j := range expected.Discords {
+			if results[i].Discords[j] != expected.Discords[j] {
+				t.Errorf("Expected discords %v, but got %v for series %d", expected.Discords, results[i].Discords, i)
+				break
+			}
+		}
+		if results[i].SegmentIdx != expected.SegmentIdx {
+			t.Errorf("Expected segment index %d, but got %d for series %d", expected.SegmentIdx, results[i].SegmentIdx, i)
+		}
+	}
+
+	if _, err = AnalyzeAll(series[:1], w, nil, NewAnalyzeOpts()); err == nil {
+		t.Errorf("Expected an error for an output filename")
+	}
+	if _, err = AnalyzeAll(series[:3], w, nil, nil); err != nil {
+		t.Errorf("Did not expect an error, %v", err)
+	}
+}
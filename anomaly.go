@@ -0,0 +1,100 @@
+package matrixprofile
+
+import (
+	"fmt"
+)
+
+// MPDistDetector scores a stream by the MPDist between its most recent window
+// and a trailing reference window that ends Lag points before it. A window
+// made of patterns already seen in the reference scores low, while a window
+// with new behavior scores high, even when no single subsequence is a
+// discord. MPDist only needs a few percent of the subsequences to match, so
+// once the reference holds a single occurrence of the new behavior the score
+// drops back. The lag keeps a new regime scoring high until it reaches the
+// reference, roughly Lag points after it fills the recent window. Only the
+// last Ref+Lag+Recent points of the stream are kept.
+type MPDistDetector struct {
+	W      int         // length of a subsequence
+	Ref    int         // length of the trailing reference window
+	Lag    int         // number of points between the reference and recent windows
+	Recent int         // length of the most recent window
+	Step   int         // number of points pushed between scores
+	Opts   *MPDistOpts // options used to compute each MPDist
+	buf    []float64
+	seen   int
+}
+
+// NewMPDistDetector creates a detector scoring every step points once
+// ref+lag+recent points have been pushed. Both windows must hold at least one
+// subsequence of length w. Default MPDist options are used if o is nil.
+func NewMPDistDetector(w, ref, lag, recent, step int, o *MPDistOpts) (*MPDistDetector, error) {
+	if w < 2 {
+		return nil, newError(ErrWindowTooSmall, "subsequence length must be at least 2, got %d", w)
+	}
+	if ref < w || recent < w {
+		return nil, newError(ErrWindowTooLarge, "reference, %d, and recent, %d, windows must be at least the subsequence length, %d", ref, recent, w)
+	}
+	if lag < 0 {
+		return nil, fmt.Errorf("lag must be at least 0, got %d", lag)
+	}
+	if step < 1 {
+		return nil, fmt.Errorf("step must be at least 1, got %d", step)
+	}
+	if o == nil {
+		o = NewMPDistOpts()
+	}
+	return &MPDistDetector{
+		W:      w,
+		Ref:    ref,
+		Lag:    lag,
+		Recent: recent,
+		Step:   step,
+		Opts:   o,
+		buf:    make([]float64, 0, ref+lag+recent),
+	}, nil
+}
+
+// Push appends new values to the stream and returns the anomaly scores due
+// within them, one every Step points once Ref+Lag+Recent points have been
+// pushed.
+// Scores are z-normalized euclidean distances, also for pearson correlation
+// options, so that larger values are more anomalous.
+func (d *MPDistDetector) Push(newValues []float64) ([]float64, error) {
+	size := d.Ref + d.Lag + d.Recent
+	var scores []float64
+	for _, val := range newValues {
+		if len(d.buf) == size {
+			copy(d.buf, d.buf[1:])
+			d.buf = d.buf[:size-1]
+		}
+		d.buf = append(d.buf, val)
+		d.seen++
+		if d.seen < size || (d.seen-size)%d.Step != 0 {
+			continue
+		}
+
+		score, err := MPDist(d.buf[:d.Ref], d.buf[d.Ref+d.Lag:], d.W, d.Opts)
+		if err != nil {
+			return scores, err
+		}
+		if d.Opts.Opts != nil && !d.Opts.Opts.Euclidean {
+			s := []float64{score}
+			pearsonToEuclidean(s, d.W)
+			score = s[0]
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
+// RollingMPDist computes the anomaly score series of a whole time series with
+// an MPDistDetector. The i-th score compares the recent window ending right
+// before index ref+lag+recent+i*step with the ref points ending lag points
+// before it.
+func RollingMPDist(ts []float64, w, ref, lag, recent, step int, o *MPDistOpts) ([]float64, error) {
+	d, err := NewMPDistDetector(w, ref, lag, recent, step, o)
+	if err != nil {
+		return nil, err
+	}
+	return d.Push(ts)
+}
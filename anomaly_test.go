@@ -0,0 +1,66 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestRollingMPDist(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 4), siggen.Sin(1, 10, 0, 0, 100, 2))
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+
+	scores, err := RollingMPDist(sig, 10, 100, 100, 50, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (len(sig)-250)/10 + 1; len(scores) != expected {
+		t.Fatalf("Expected %d scores, but got %d", expected, len(scores))
+	}
+
+	// windows of the first regime score lower than recent windows of the
+	// second regime, from the first recent window fully in the second regime
+	// until the reference reaches it after the lag
+	maxBefore := math.Inf(-1)
+	for _, s := range scores[:19] {
+		maxBefore = math.Max(maxBefore, s)
+	}
+	for i, s := range scores[20:31] {
+		if s <= maxBefore {
+			t.Errorf("Expected score %d, %.4f, to be above the first regime's scores of at most %.4f", i+20, s, maxBefore)
+		}
+	}
+
+	// pushing in chunks emits the same scores
+	d, err := NewMPDistDetector(10, 100, 100, 50, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pushed []float64
+	for _, chunk := range [][]float64{sig[:77], sig[77:300], sig[300:]} {
+		out, err := d.Push(chunk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pushed = append(pushed, out...)
+	}
+	if len(pushed) != len(scores) {
+		t.Fatalf("Expected %d scores, but got %d", len(scores), len(pushed))
+	}
+	for i := range scores {
+		if math.Abs(pushed[i]-scores[i]) > 1e-9 {
+			t.Errorf("Expected score %.6f, but got %.6f at %d", scores[i], pushed[i], i)
+		}
+	}
+
+	if _, err = NewMPDistDetector(10, 5, 0, 50, 10, nil); Cause(err) != ErrWindowTooLarge {
+		t.Errorf("Expected ErrWindowTooLarge, but got %v", err)
+	}
+	if _, err = NewMPDistDetector(10, 100, -1, 50, 10, nil); err == nil {
+		t.Errorf("Expected an error for a negative lag")
+	}
+	if _, err = NewMPDistDetector(10, 100, 0, 50, 0, nil); err == nil {
+		t.Errorf("Expected an error for a step of 0")
+	}
+}
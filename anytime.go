@@ -0,0 +1,157 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+const (
+	// anytimeInitialPct is the sample of rows STAMP computes first to time the
+	// computation
+	anytimeInitialPct = 0.01
+
+	// anytimeSafety is the share of the remaining time budget planned for the
+	// escalated computation, leaving room for the estimate being off and for
+	// the analysis itself
+	anytimeSafety = 0.8
+
+	// anytimeQualityRows is the number of subsequences whose nearest neighbor
+	// is computed exactly to estimate the quality of a sampled matrix profile
+	anytimeQualityRows = 8
+)
+
+// AnytimeReport describes the matrix profile AnalyzeWithin settled on within
+// its time budget.
+type AnytimeReport struct {
+	Algorithm   Algo          `json:"algorithm"`    // algorithm of the matrix profile the features were discovered from
+	SamplePct   float64       `json:"sample_pct"`   // fraction of the rows computed, 1 for the exact matrix profile
+	Exact       bool          `json:"exact"`        // whether the exact matrix profile was computed
+	Quality     float64       `json:"quality"`      // mean ratio of the exact to the sampled nearest neighbor distance of the checked subsequences, between 0 and 1. 1 if exact
+	QualityRows int           `json:"quality_rows"` // number of subsequences checked against their exact nearest neighbor. 0 if exact or not estimated for weighted or mean-centered distances
+	Elapsed     time.Duration `json:"elapsed"`      // time spent computing, estimating the quality and analyzing
+}
+
+// AnalyzeWithin performs the same analysis as Analyze while aiming to finish
+// within a time budget. STAMP first computes a small random sample of the
+// rows to time the computation. If the exact matrix profile is expected to fit
+// in the remaining budget it is computed with the options, otherwise the
+// sample is escalated to the largest one expected to fit. The quality of a
+// sampled matrix profile is estimated by computing the exact nearest neighbor
+// of a few subsequences. The budget is a target rather than a hard deadline,
+// since a computation can't be interrupted once started.
+func (mp MatrixProfile) AnalyzeWithin(budget time.Duration, mo *MPOpts, ao *AnalyzeOpts) (*AnalysisResult, *AnytimeReport, error) {
+	if budget <= 0 {
+		return nil, nil, fmt.Errorf("time budget must be positive, got %s", budget)
+	}
+	if mo == nil {
+		mo = NewMPOpts()
+	}
+	start := time.Now()
+
+	// sampling requires STAMP without the options that need the full matrix
+	// profile
+	o := *mo
+	o.Algorithm, o.Verify, o.KeepCorr = AlgoSTAMP, false, false
+	rows := len(mp.A) - mp.W + 1
+	o.SamplePct = anytimeInitialPct
+	if rows > 0 {
+		o.SamplePct = math.Min(1, math.Max(anytimeInitialPct, float64(4*o.NJobs)/float64(rows)))
+	}
+	if err := mp.Compute(&o); err != nil {
+		return nil, nil, err
+	}
+
+	report := &AnytimeReport{Algorithm: AlgoSTAMP, SamplePct: o.SamplePct, Exact: o.SamplePct >= 1}
+	perRow := float64(time.Since(start)) / (o.SamplePct * float64(rows))
+	full := perRow * float64(rows)
+	remaining := anytimeSafety * float64(budget-time.Since(start))
+	switch {
+	case report.Exact:
+	case full <= remaining:
+		if err := mp.Compute(mo); err != nil {
+			return nil, nil, err
+		}
+		report.Algorithm, report.SamplePct, report.Exact = mp.Opts.Algorithm, mp.Opts.SamplePct, mp.Opts.SamplePct >= 1
+	case remaining/full > o.SamplePct:
+		o.SamplePct = remaining / full
+		if err := mp.Compute(&o); err != nil {
+			return nil, nil, err
+		}
+		report.SamplePct = o.SamplePct
+	}
+
+	if report.Exact {
+		report.Quality = 1
+	} else {
+		report.Quality, report.QualityRows = mp.sampleQuality(anytimeQualityRows)
+	}
+
+	res, err := mp.analyze(ao)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Elapsed = time.Since(start)
+	return res, report, nil
+}
+
+// sampleQuality estimates how close a sampled STAMP matrix profile is to the
+// exact one by computing the exact nearest neighbor distance of up to rows
+// random subsequences with MASS. Returns the mean ratio of the exact to the
+// sampled distance along with the number of subsequences checked, or 0 rows
+// for weighted or mean-centered distances.
+func (mp MatrixProfile) sampleQuality(rows int) (float64, int) {
+	if mp.Opts.Weights != nil || mp.meanCentered() {
+		return 0, 0
+	}
+
+	// STAMP indexes the matrix profile by the subsequences of b
+	r := rand.New(rand.NewSource(mp.Opts.Seed))
+	var sum float64
+	var count int
+	for _, i := range r.Perm(len(mp.MP)) {
+		if count == rows {
+			break
+		}
+		if mp.SelfJoin && mp.masked(i) {
+			continue
+		}
+		exact, err := util.MassV2(mp.B[i:i+mp.W], mp.A)
+		if err != nil {
+			// constant subsequences have no defined distance
+			continue
+		}
+		if mp.SelfJoin {
+			mp.applyTrivialMatchZone(exact, i)
+			if mp.Opts.Mask != nil {
+				util.ApplyExclusionMask(exact, mp.Opts.Mask)
+			}
+		}
+		want := floats.Min(exact)
+
+		got := []float64{mp.MP[i]}
+		if !mp.Opts.Euclidean {
+			pearsonToEuclidean(got, mp.W)
+		}
+
+		var q float64
+		switch {
+		case math.IsInf(want, 1):
+			continue
+		case got[0] <= want:
+			q = 1
+		case !math.IsInf(got[0], 1):
+			q = want / got[0]
+		}
+		sum += q
+		count++
+	}
+	if count == 0 {
+		return 0, 0
+	}
+	return sum / float64(count), count
+}
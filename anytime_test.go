@@ -0,0 +1,53 @@
+package matrixprofile
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeWithin(t *testing.T) {
+	mp, err := New(setupData(2000), nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ao := NewAnalyzeOpts()
+	ao.OutputFilename = ""
+
+	mo := NewMPOpts()
+	mo.NJobs = 1
+	expected, err := mp.Analyze(mo, ao)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, report, err := mp.AnalyzeWithin(time.Minute, mo, ao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Exact || report.Algorithm != AlgoMPX || report.Quality != 1 || report.QualityRows != 0 {
+		t.Errorf("Expected the exact matrix profile within a generous budget, but got %+v", report)
+	}
+	if !reflect.DeepEqual(res.Discords, expected.Discords) {
+		t.Errorf("Expected discords %v, but got %v", expected.Discords, res.Discords)
+	}
+
+	// a budget that has already passed keeps the initial sample
+	res, report, err = mp.AnalyzeWithin(time.Nanosecond, mo, ao)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Exact || report.Algorithm != AlgoSTAMP || report.SamplePct != anytimeInitialPct {
+		t.Errorf("Expected the initial STAMP sample, but got %+v", report)
+	}
+	if report.QualityRows == 0 || report.Quality < 0 || report.Quality > 1 {
+		t.Errorf("Expected an estimated quality between 0 and 1, but got %+v", report)
+	}
+	if res == nil || len(res.Discords) == 0 {
+		t.Errorf("Expected discords from the sampled matrix profile, but got %+v", res)
+	}
+
+	if _, _, err = mp.AnalyzeWithin(0, mo, ao); err == nil {
+		t.Errorf("Expected an error for an empty time budget")
+	}
+}
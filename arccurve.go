@@ -0,0 +1,98 @@
+package matrixprofile
+
+import (
+	"math"
+)
+
+// ArcCurve is the arc curve of a matrix profile index that is kept up to date
+// as entries of the index change. Each entry draws an arc from its
+// subsequence to its nearest neighbor and the curve counts the arcs crossing
+// every index. Changing an entry only removes its old arc and adds its new
+// one, so segmentation can be maintained online without recomputing the
+// histogram over the entire index. Entries pointing outside of the index are
+// ignored.
+type ArcCurve struct {
+	idx   []int
+	histo []float64
+}
+
+// NewArcCurve creates the arc curve of a matrix profile index. The index is
+// copied so that it can keep changing independently.
+func NewArcCurve(mpIdx []int) *ArcCurve {
+	idx := make([]int, len(mpIdx))
+	copy(idx, mpIdx)
+	return &ArcCurve{
+		idx:   idx,
+		histo: arcCurve(idx),
+	}
+}
+
+// Len returns the number of matrix profile index entries in the arc curve
+func (ac ArcCurve) Len() int {
+	return len(ac.idx)
+}
+
+// Set changes the matrix profile index entry at i to idx
+func (ac *ArcCurve) Set(i, idx int) {
+	ac.arc(i, ac.idx[i], -1)
+	ac.idx[i] = idx
+	ac.arc(i, idx, 1)
+}
+
+// Append adds a matrix profile index entry of idx to the end of the arc curve
+func (ac *ArcCurve) Append(idx int) {
+	ac.idx = append(ac.idx, math.MaxInt64)
+	ac.histo = append(ac.histo, 0)
+	ac.Set(len(ac.idx)-1, idx)
+}
+
+// arc adds delta to every index crossed by the arc from i to idx in the same
+// manner as arcCurve
+func (ac *ArcCurve) arc(i, idx int, delta float64) {
+	switch {
+	case idx < 0 || idx >= len(ac.idx):
+	case idx > i+1:
+		for j := i + 1; j < idx; j++ {
+			ac.histo[j] += delta
+		}
+	case idx < i-1:
+		for j := i - 1; j > idx; j-- {
+			ac.histo[j] += delta
+		}
+	}
+}
+
+// Counts returns a copy of the number of arcs crossing each index, the
+// uncorrected arc curve
+func (ac ArcCurve) Counts() []float64 {
+	out := make([]float64, len(ac.histo))
+	copy(out, ac.histo)
+	return out
+}
+
+// Corrected returns the corrected arc curve, CAC, dividing the counts by the
+// ideal arc curve in the same manner as DiscoverSegmentsWithOpts. The parabola
+// is used if ideal is nil.
+func (ac ArcCurve) Corrected(ideal []float64) []float64 {
+	return correctArcCurve(ac.Counts(), ideal)
+}
+
+// TrackArcs starts maintaining the arc curve of the matrix profile index in
+// mp.Arcs. Update and UpdateWindow keep it up to date and a new Compute
+// rebuilds it, while segment discovery reads it instead of recomputing the
+// arc curve.
+func (mp *MatrixProfile) TrackArcs() error {
+	if !mp.SelfJoin {
+		return newError(ErrNotSelfJoin, "can only track the arc curve of a self join")
+	}
+	if err := mp.checkComputed(); err != nil {
+		return err
+	}
+	mp.Arcs = NewArcCurve(mp.Idx)
+	return nil
+}
+
+// trackingArcs returns whether mp.Arcs follows the matrix profile index
+func (mp MatrixProfile) trackingArcs() bool {
+	return mp.Arcs != nil && mp.Arcs.Len() == len(mp.Idx)
+}
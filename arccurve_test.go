@@ -0,0 +1,85 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestArcCurveSet(t *testing.T) {
+	ac := NewArcCurve([]int{4, 3, 0, 1, 5})
+	ac.Set(0, 2)
+	ac.Append(0)
+	ac.Set(3, math.MaxInt64)
+
+	expected := arcCurve([]int{2, 3, 0, math.MaxInt64, 5, 0})
+	counts := ac.Counts()
+	if ac.Len() != len(expected) {
+		t.Fatalf("Expected %d entries, but got %d", len(expected), ac.Len())
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("Expected arc curve %v, but got %v", expected, counts)
+			break
+		}
+	}
+}
+
+func TestTrackArcs(t *testing.T) {
+	sig := setupData(300)
+	mp, err := New(sig[:240], nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.TrackArcs(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, chunk := range [][]float64{sig[240:241], sig[241:270], sig[270:]} {
+		if err = mp.Update(chunk); err != nil {
+			t.Fatal(err)
+		}
+		expected := arcCurve(mp.Idx)
+		counts := mp.Arcs.Counts()
+		if len(counts) != len(expected) {
+			t.Fatalf("Expected %d arc curve values, but got %d", len(expected), len(counts))
+		}
+		for i := range expected {
+			if counts[i] != expected[i] {
+				t.Errorf("Expected %.0f arcs at %d, but got %.0f", expected[i], i, counts[i])
+				break
+			}
+		}
+	}
+
+	idx, val, cac := mp.DiscoverSegments()
+	expected := correctArcCurve(arcCurve(mp.Idx), nil)
+	for i := range expected {
+		if cac[i] != expected[i] {
+			t.Errorf("Expected corrected arc curve %.4f at %d, but got %.4f", expected[i], i, cac[i])
+			break
+		}
+	}
+	if val != cac[idx] {
+		t.Errorf("Expected the segment score to be the corrected arc curve at %d, %.4f, but got %.4f", idx, cac[idx], val)
+	}
+
+	if err = mp.UpdateWindow(sig[:20], 250); err != nil {
+		t.Fatal(err)
+	}
+	if mp.Arcs.Len() != len(mp.Idx) {
+		t.Errorf("Expected the arc curve to follow the sliding window of %d entries, but got %d", len(mp.Idx), mp.Arcs.Len())
+	}
+
+	mp, err = New(sig[:100], sig[100:], 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.TrackArcs(); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin, but got %v", err)
+	}
+}
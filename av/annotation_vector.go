@@ -18,6 +18,7 @@ const (
 	Complexity AV = "complexity" // Complexity is the annotation vector that focuses on areas of high "complexity"
 	MeanStd    AV = "mean_std"   // MeanStd is the annotation vector focusing on areas where the signal is within a standard deviation of the mean
 	Clipping   AV = "clipping"   // Clipping is the annotation vector reducing the importance of areas showing clipping effects on the positive and negative regime
+	Auto       AV = "auto"       // Auto is the annotation vector recommended by Recommend for the time series
 )
 
 // Create returns the annotation vector given an input time series and a window size m
@@ -32,6 +33,12 @@ func Create(av AV, ts []float64, m int) ([]float64, error) {
 		avec = makeMeanStd(ts, m)
 	case Clipping:
 		avec = makeClipping(ts, m)
+	case Auto:
+		rec, err := Recommend(ts, m)
+		if err != nil {
+			return nil, err
+		}
+		return Create(rec.AV, ts, m)
 	default:
 		return nil, fmt.Errorf("invalid annotation vector specified with matrix profile, %s", av)
 	}
@@ -121,3 +128,179 @@ func makeClipping(d []float64, m int) []float64 {
 
 	return av
 }
+
+// Reduce determines how the covariate values spanned by a subsequence are
+// combined into its annotation vector value
+type Reduce string
+
+const (
+	ReduceMean Reduce = "mean" // ReduceMean weighs each subsequence by the mean covariate value it spans, such as proportionally to load
+	ReduceMin  Reduce = "min"  // ReduceMin weighs each subsequence by the smallest covariate value it spans, such as only where a binary "machine on" flag is 1 throughout
+	ReduceMax  Reduce = "max"  // ReduceMax weighs each subsequence by the largest covariate value it spans
+)
+
+// FromCovariate creates an annotation vector for a time series of n points
+// with a window size m from a covariate series aligned with it. The covariate
+// covers the same span as the time series but may be sampled at a different
+// rate, so it is linearly resampled to n points first. Covariate values are
+// clamped to [0, 1], with NaN counted as 0, and then combined over each
+// subsequence using reduce.
+func FromCovariate(cov []float64, n, m int, reduce Reduce) ([]float64, error) {
+	if len(cov) == 0 {
+		return nil, fmt.Errorf("covariate series is empty")
+	}
+	if m < 1 || m > n {
+		return nil, fmt.Errorf("window size, %d, must be between 1 and the length of the time series, %d", m, n)
+	}
+
+	c := resample(cov, n)
+	for i, v := range c {
+		switch {
+		case math.IsNaN(v) || v < 0:
+			c[i] = 0
+		case v > 1:
+			c[i] = 1
+		}
+	}
+
+	av := make([]float64, n-m+1)
+	for i := range av {
+		switch reduce {
+		case ReduceMean:
+			av[i] = floats.Sum(c[i:i+m]) / float64(m)
+		case ReduceMin:
+			av[i] = floats.Min(c[i : i+m])
+		case ReduceMax:
+			av[i] = floats.Max(c[i : i+m])
+		default:
+			return nil, fmt.Errorf("invalid covariate reduction specified, %s", reduce)
+		}
+	}
+	return av, nil
+}
+
+// resample linearly interpolates a series spanning the same range onto n
+// evenly spaced points
+func resample(s []float64, n int) []float64 {
+	out := make([]float64, n)
+	if len(s) == n {
+		copy(out, s)
+		return out
+	}
+	if len(s) == 1 || n == 1 {
+		for i := range out {
+			out[i] = s[0]
+		}
+		return out
+	}
+
+	scale := float64(len(s)-1) / float64(n-1)
+	for i := range out {
+		pos := float64(i) * scale
+		j := int(pos)
+		if j >= len(s)-1 {
+			out[i] = s[len(s)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = s[j]*(1-frac) + s[j+1]*frac
+	}
+	return out
+}
+
+const (
+	// clipShareThreshold is the fraction of points at the minimum or maximum
+	// value above which a time series is considered clipped
+	clipShareThreshold = 0.01
+
+	// flatTolerance is the standard deviation of a subsequence, relative to
+	// the standard deviation of the time series, below which it is flat
+	flatTolerance = 0.01
+
+	// flatShareThreshold is the fraction of flat subsequences above which
+	// the time series is considered to have flat regions
+	flatShareThreshold = 0.1
+
+	// noiseLevelThreshold is the noise level above which the time series is
+	// considered noisy
+	noiseLevelThreshold = 0.5
+)
+
+// Stats are the simple statistics of a time series Recommend selects an
+// annotation vector from
+type Stats struct {
+	ClipShare  float64 `json:"clip_share"`  // fraction of points at the minimum or maximum value of the time series
+	FlatShare  float64 `json:"flat_share"`  // fraction of subsequences with a standard deviation below 1% of that of the time series
+	NoiseLevel float64 `json:"noise_level"` // standard deviation of the differences between consecutive points relative to that of white noise with the same variance. Near 0 for smooth signals and near 1 or above for noise
+}
+
+// Recommendation is the annotation vector Recommend selects for a time series
+// along with the statistics it was selected from and why
+type Recommendation struct {
+	AV     AV     `json:"annotation_vector"` // recommended annotation vector
+	Stats  Stats  `json:"stats"`             // statistics of the time series
+	Reason string `json:"reason"`            // why the annotation vector was recommended
+}
+
+// Recommend evaluates simple statistics of a time series to select one of the
+// built-in annotation vectors for a window size m. Clipping is recommended if
+// many points sit at the extremes of the time series, Complexity if a share
+// of the subsequences is flat, since flat regions form spurious motifs, and
+// MeanStd if the time series is noisy so that calmer regions are favored.
+// Otherwise Default is recommended. The checks are made in that order.
+func Recommend(ts []float64, m int) (*Recommendation, error) {
+	if m < 2 || m > len(ts) {
+		return nil, fmt.Errorf("window size must be between 2 and the time series length, %d, got %d", len(ts), m)
+	}
+
+	maxVal, minVal := floats.Max(ts), floats.Min(ts)
+	if maxVal == minVal {
+		return &Recommendation{AV: Default, Stats: Stats{ClipShare: 1, FlatShare: 1}, Reason: "the time series is constant"}, nil
+	}
+
+	var s Stats
+	var clipped int
+	for _, v := range ts {
+		if v == maxVal || v == minVal {
+			clipped++
+		}
+	}
+	s.ClipShare = float64(clipped) / float64(len(ts))
+
+	std := stat.StdDev(ts, nil)
+	_, movStd, err := util.MovMeanStd(ts, m)
+	if err != nil {
+		return nil, err
+	}
+	var flat int
+	for _, v := range movStd {
+		if v < flatTolerance*std {
+			flat++
+		}
+	}
+	s.FlatShare = float64(flat) / float64(len(movStd))
+
+	diffs := make([]float64, len(ts)-1)
+	for i := range diffs {
+		diffs[i] = ts[i+1] - ts[i]
+	}
+	if len(diffs) > 1 {
+		s.NoiseLevel = stat.StdDev(diffs, nil) / (math.Sqrt2 * std)
+	}
+
+	rec := &Recommendation{AV: Default, Stats: s}
+	switch {
+	case s.ClipShare > clipShareThreshold:
+		rec.AV = Clipping
+		rec.Reason = fmt.Sprintf("%.1f%% of points sit at the minimum or maximum value, which suggests clipping", 100*s.ClipShare)
+	case s.FlatShare > flatShareThreshold:
+		rec.AV = Complexity
+		rec.Reason = fmt.Sprintf("%.1f%% of subsequences are flat, which form spurious motifs", 100*s.FlatShare)
+	case s.NoiseLevel > noiseLevelThreshold:
+		rec.AV = MeanStd
+		rec.Reason = fmt.Sprintf("the noise level of %.2f is high, so calmer regions are favored", s.NoiseLevel)
+	default:
+		rec.Reason = "no clipping, flat regions or strong noise were found"
+	}
+	return rec, nil
+}
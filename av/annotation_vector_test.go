@@ -2,6 +2,7 @@ package av
 
 import (
 	"math"
+	"math/rand"
 	"testing"
 )
 
@@ -106,3 +107,106 @@ func TestMakeClipping(t *testing.T) {
 		}
 	}
 }
+
+func TestFromCovariate(t *testing.T) {
+	testdata := []struct {
+		cov      []float64
+		n        int
+		m        int
+		reduce   Reduce
+		expected []float64
+	}{
+		{[]float64{0, 1, 1, 1, 0, 1}, 6, 3, ReduceMin, []float64{0, 1, 0, 0}},
+		{[]float64{0, 1, 1, 1, 0, 1}, 6, 3, ReduceMax, []float64{1, 1, 1, 1}},
+		{[]float64{0, 1, 1, 1, 0, 1}, 6, 3, ReduceMean, []float64{2.0 / 3, 1, 2.0 / 3, 2.0 / 3}},
+		{[]float64{-2, 0.5, math.NaN(), 4}, 4, 2, ReduceMean, []float64{0.25, 0.25, 0.5}},
+		{[]float64{0, 1}, 5, 2, ReduceMean, []float64{0.125, 0.375, 0.625, 0.875}},
+		{[]float64{0, 0.5, 1, 0.5, 0}, 3, 1, ReduceMean, []float64{0, 1, 0}},
+		{[]float64{0.3}, 4, 2, ReduceMin, []float64{0.3, 0.3, 0.3}},
+		{[]float64{}, 4, 2, ReduceMin, nil},
+		{[]float64{1, 1}, 4, 5, ReduceMin, nil},
+		{[]float64{1, 1}, 4, 2, "median", nil},
+	}
+	for _, d := range testdata {
+		out, err := FromCovariate(d.cov, d.n, d.m, d.reduce)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error, but got none for %v", d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %v", err, d)
+			continue
+		}
+
+		if len(out) != len(d.expected) {
+			t.Errorf("Expected length %d, but got %d for %v", len(d.expected), len(out), d)
+			continue
+		}
+		for i, val := range out {
+			if math.Abs(val-d.expected[i]) > 1e-7 {
+				t.Errorf("Expected value of %.3f, but got %.3f for %v", d.expected[i], val, d)
+			}
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	n := 1000
+	sine := make([]float64, n)
+	clipped := make([]float64, n)
+	flat := make([]float64, n)
+	noise := make([]float64, n)
+	r := rand.New(rand.NewSource(7))
+	for i := range sine {
+		sine[i] = math.Sin(2 * math.Pi * float64(i) / 47.3)
+		clipped[i] = math.Max(-0.8, math.Min(0.8, sine[i]))
+		if i < 600 {
+			flat[i] = sine[i]
+		}
+		noise[i] = r.NormFloat64()
+	}
+
+	testdata := []struct {
+		name     string
+		ts       []float64
+		expected AV
+	}{
+		{"sine", sine, Default},
+		{"clipped", clipped, Clipping},
+		{"flat", flat, Complexity},
+		{"noise", noise, MeanStd},
+		{"constant", make([]float64, 100), Default},
+	}
+	for _, d := range testdata {
+		rec, err := Recommend(d.ts, 20)
+		if err != nil {
+			t.Fatalf("Did not expect an error for %s, %v", d.name, err)
+		}
+		if rec.AV != d.expected || rec.Reason == "" {
+			t.Errorf("Expected %s with a reason for %s, but got %+v", d.expected, d.name, rec)
+		}
+
+		avec, err := Create(Auto, d.ts, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := Create(rec.AV, d.ts, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range expected {
+			if avec[i] != expected[i] && !(math.IsNaN(avec[i]) && math.IsNaN(expected[i])) {
+				t.Errorf("Expected the auto annotation vector to match %s for %s", rec.AV, d.name)
+				break
+			}
+		}
+	}
+
+	for _, m := range []int{1, n + 1} {
+		if _, err := Recommend(sine, m); err == nil {
+			t.Errorf("Expected an error for a window size of %d", m)
+		}
+	}
+}
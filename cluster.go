@@ -0,0 +1,307 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// ClusterMethod is the algorithm used to group time series together
+type ClusterMethod string
+
+const (
+	ClusterKMedoids     ClusterMethod = "kmedoids"     // partitions around medoids
+	ClusterHierarchical ClusterMethod = "hierarchical" // agglomerative clustering
+)
+
+// Linkage determines how the distance between two clusters is measured during
+// hierarchical clustering
+type Linkage string
+
+const (
+	LinkageSingle   Linkage = "single"   // minimum distance between members of each cluster
+	LinkageComplete Linkage = "complete" // maximum distance between members of each cluster
+	LinkageAverage  Linkage = "average"  // average distance between members of each cluster
+)
+
+// ClusterOpts are parameters to vary the clustering of a set of time series
+type ClusterOpts struct {
+	Method     ClusterMethod // algorithm used to form clusters
+	Linkage    Linkage       // only applicable to hierarchical clustering
+	MaxIter    int           // maximum number of refinement iterations for k-medoids
+	MPDistOpts *MPDistOpts   // options used to compute the pairwise MPDist
+}
+
+// NewClusterOpts returns a default ClusterOpts
+func NewClusterOpts() *ClusterOpts {
+	return &ClusterOpts{
+		Method:     ClusterKMedoids,
+		Linkage:    LinkageAverage,
+		MaxIter:    100,
+		MPDistOpts: NewMPDistOpts(),
+	}
+}
+
+// Clusters is the result of grouping a set of time series together.
+type Clusters struct {
+	Labels  []int       // cluster assignment for each input time series
+	Medoids []int       // index of the medoid time series for each cluster
+	Dist    [][]float64 // pairwise MPDist between all input time series
+}
+
+// Cluster groups a set of time series into k clusters using the pairwise
+// matrix profile distance with a subsequence window of w. The time series do
+// not need to be the same length, but each must be at least w long.
+func Cluster(ts [][]float64, k, w int, o *ClusterOpts) (*Clusters, error) {
+	if o == nil {
+		o = NewClusterOpts()
+	}
+
+	if k < 1 || k > len(ts) {
+		return nil, fmt.Errorf("number of clusters, %d, must be between 1 and the number of time series, %d", k, len(ts))
+	}
+
+	dist, err := PairwiseMPDist(ts, w, o.MPDistOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels []int
+	switch o.Method {
+	case ClusterKMedoids:
+		labels = kMedoids(dist, k, o.MaxIter)
+	case ClusterHierarchical:
+		labels, err = agglomerate(dist, k, o.Linkage)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid clustering method, %s", o.Method)
+	}
+
+	return &Clusters{
+		Labels:  labels,
+		Medoids: medoids(dist, labels, k),
+		Dist:    dist,
+	}, nil
+}
+
+// PairwiseMPDist computes the symmetric matrix of MPDist between every pair of
+// time series using a subsequence window of w. Distances are always returned
+// as euclidean distances even if the options request pearson correlation.
+func PairwiseMPDist(ts [][]float64, w int, o *MPDistOpts) ([][]float64, error) {
+	if o == nil {
+		o = NewMPDistOpts()
+	}
+
+	dist := make([][]float64, len(ts))
+	for i := range dist {
+		dist[i] = make([]float64, len(ts))
+	}
+
+	d := make([]float64, 1)
+	var err error
+	for i := 0; i < len(ts); i++ {
+		for j := i + 1; j < len(ts); j++ {
+			d[0], err = MPDist(ts[i], ts[j], w, o)
+			if err != nil {
+				return nil, fmt.Errorf("failed to compute MPDist between time series %d and %d, %v", i, j, err)
+			}
+			if o.Opts != nil && !o.Opts.Euclidean {
+				util.P2E(d, w)
+			}
+			dist[i][j] = d[0]
+			dist[j][i] = d[0]
+		}
+	}
+	return dist, nil
+}
+
+// kMedoids partitions the points described by the distance matrix into k
+// clusters. Initial medoids are chosen greedily using the PAM build step and
+// then refined by alternating assignment and medoid updates.
+func kMedoids(dist [][]float64, k, maxIter int) []int {
+	n := len(dist)
+	meds := make([]int, 0, k)
+	isMed := make([]bool, n)
+
+	// nearest holds the distance of each point to its closest medoid so far
+	nearest := make([]float64, n)
+	for i := range nearest {
+		nearest[i] = math.Inf(1)
+	}
+
+	for len(meds) < k {
+		// the first unused point is the medoid when no candidate has a
+		// finite cost, such as when distances are undefined
+		first, best, bestCost := -1, -1, math.Inf(1)
+		for c := 0; c < n; c++ {
+			if isMed[c] {
+				continue
+			}
+			if first < 0 {
+				first = c
+			}
+			var cost float64
+			for i := 0; i < n; i++ {
+				cost += math.Min(nearest[i], dist[i][c])
+			}
+			if cost < bestCost {
+				best, bestCost = c, cost
+			}
+		}
+		if best < 0 {
+			best = first
+		}
+		meds = append(meds, best)
+		isMed[best] = true
+		for i := 0; i < n; i++ {
+			nearest[i] = math.Min(nearest[i], dist[i][best])
+		}
+	}
+
+	labels := make([]int, n)
+	for iter := 0; iter < maxIter; iter++ {
+		for i := 0; i < n; i++ {
+			labels[i] = closestMedoid(dist, meds, i)
+		}
+
+		changed := false
+		for c, m := range meds {
+			bestMed, bestCost := m, math.Inf(1)
+			for i := 0; i < n; i++ {
+				if labels[i] != c {
+					continue
+				}
+				var cost float64
+				for j := 0; j < n; j++ {
+					if labels[j] == c {
+						cost += dist[i][j]
+					}
+				}
+				if cost < bestCost {
+					bestMed, bestCost = i, cost
+				}
+			}
+			if bestMed != m {
+				meds[c] = bestMed
+				changed = true
+			}
+		}
+
+		if !changed {
+			break
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		labels[i] = closestMedoid(dist, meds, i)
+	}
+	return labels
+}
+
+// closestMedoid returns the position in meds of the medoid closest to point i
+func closestMedoid(dist [][]float64, meds []int, i int) int {
+	best, bestDist := 0, math.Inf(1)
+	for c, m := range meds {
+		if m == i {
+			return c
+		}
+		if dist[i][m] < bestDist {
+			best, bestDist = c, dist[i][m]
+		}
+	}
+	return best
+}
+
+// agglomerate performs bottom up hierarchical clustering merging the two
+// closest clusters until k clusters remain.
+func agglomerate(dist [][]float64, k int, linkage Linkage) ([]int, error) {
+	switch linkage {
+	case LinkageSingle, LinkageComplete, LinkageAverage:
+	default:
+		return nil, fmt.Errorf("invalid linkage, %s", linkage)
+	}
+
+	groups := make([][]int, len(dist))
+	for i := range groups {
+		groups[i] = []int{i}
+	}
+
+	for len(groups) > k {
+		bi, bj, bestDist := 0, 1, math.Inf(1)
+		for i := 0; i < len(groups); i++ {
+			for j := i + 1; j < len(groups); j++ {
+				d := linkageDist(dist, groups[i], groups[j], linkage)
+				if d < bestDist {
+					bi, bj, bestDist = i, j, d
+				}
+			}
+		}
+		groups[bi] = append(groups[bi], groups[bj]...)
+		groups = append(groups[:bj], groups[bj+1:]...)
+	}
+
+	labels := make([]int, len(dist))
+	for c, g := range groups {
+		for _, i := range g {
+			labels[i] = c
+		}
+	}
+	return labels, nil
+}
+
+// linkageDist computes the distance between two groups of points
+func linkageDist(dist [][]float64, a, b []int, linkage Linkage) float64 {
+	var out float64
+	switch linkage {
+	case LinkageSingle:
+		out = math.Inf(1)
+	case LinkageComplete:
+		out = math.Inf(-1)
+	}
+
+	for _, i := range a {
+		for _, j := range b {
+			switch linkage {
+			case LinkageSingle:
+				out = math.Min(out, dist[i][j])
+			case LinkageComplete:
+				out = math.Max(out, dist[i][j])
+			case LinkageAverage:
+				out += dist[i][j]
+			}
+		}
+	}
+
+	if linkage == LinkageAverage {
+		out /= float64(len(a) * len(b))
+	}
+	return out
+}
+
+// medoids finds the member of each cluster with the smallest total distance to
+// all other members of the same cluster
+func medoids(dist [][]float64, labels []int, k int) []int {
+	meds := make([]int, k)
+	costs := make([]float64, k)
+	for c := range costs {
+		meds[c] = -1
+		costs[c] = math.Inf(1)
+	}
+
+	for i, ci := range labels {
+		var cost float64
+		for j, cj := range labels {
+			if ci == cj {
+				cost += dist[i][j]
+			}
+		}
+		if cost < costs[ci] {
+			meds[ci] = i
+			costs[ci] = cost
+		}
+	}
+	return meds
+}
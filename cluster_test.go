@@ -0,0 +1,102 @@
+package matrixprofile
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestCluster(t *testing.T) {
+	ts := [][]float64{
+		siggen.Sin(1, 2, 0, 0, 50, 2),
+		siggen.Square(1, 2, 0, 0, 50, 2),
+		siggen.Sin(1, 2, 0.5, 0, 50, 2.5),
+		siggen.Square(1, 2, 0.3, 0, 50, 2.5),
+		siggen.Sin(2, 2, 1, 1, 50, 3),
+		siggen.Square(2, 2, 1, 1, 50, 3),
+	}
+	expected := []int{0, 1, 0, 1, 0, 1}
+
+	for _, method := range []ClusterMethod{ClusterKMedoids, ClusterHierarchical} {
+		for _, linkage := range []Linkage{LinkageSingle, LinkageComplete, LinkageAverage} {
+			o := NewClusterOpts()
+			o.Method = method
+			o.Linkage = linkage
+
+			c, err := Cluster(ts, 2, 10, o)
+			if err != nil {
+				t.Fatalf("Did not expect an error for %s, %v", method, err)
+			}
+
+			if len(c.Labels) != len(ts) {
+				t.Fatalf("Expected %d labels, but got %d", len(ts), len(c.Labels))
+			}
+			for i := range expected {
+				if (c.Labels[i] == c.Labels[0]) != (expected[i] == expected[0]) {
+					t.Errorf("Expected grouping %v, but got %v for %s %s", expected, c.Labels, method, linkage)
+					break
+				}
+			}
+
+			if len(c.Medoids) != 2 {
+				t.Fatalf("Expected 2 medoids, but got %d", len(c.Medoids))
+			}
+			for cl, m := range c.Medoids {
+				if c.Labels[m] != cl {
+					t.Errorf("Expected medoid %d to belong to cluster %d, but got %d", m, cl, c.Labels[m])
+				}
+			}
+		}
+	}
+
+	if _, err := Cluster(ts, 0, 10, nil); err == nil {
+		t.Errorf("Expected an error for 0 clusters")
+	}
+	if _, err := Cluster(ts, len(ts)+1, 10, nil); err == nil {
+		t.Errorf("Expected an error for more clusters than time series")
+	}
+	o := NewClusterOpts()
+	o.Method = "unknown"
+	if _, err := Cluster(ts, 2, 10, o); err == nil {
+		t.Errorf("Expected an error for an invalid clustering method")
+	}
+}
+
+func TestKMedoidsUndefinedDistances(t *testing.T) {
+	nan := math.NaN()
+	dist := [][]float64{
+		{0, nan, nan},
+		{nan, 0, nan},
+		{nan, nan, 0},
+	}
+	labels := kMedoids(dist, 2, 10)
+	if len(labels) != len(dist) {
+		t.Fatalf("Expected %d labels, but got %v", len(dist), labels)
+	}
+	for _, l := range labels {
+		if l < 0 || l > 1 {
+			t.Errorf("Expected labels of 2 clusters, but got %v", labels)
+		}
+	}
+}
+
+func TestPairwiseMPDistNilOpts(t *testing.T) {
+	ts := [][]float64{
+		siggen.Sin(1, 2, 0, 0, 50, 2),
+		siggen.Square(1, 2, 0, 0, 50, 2),
+	}
+	expected, err := PairwiseMPDist(ts, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dist, err := PairwiseMPDist(ts, 10, &MPDistOpts{AV: av.Default})
+	if err != nil {
+		t.Fatalf("Did not expect an error without matrix profile options, %v", err)
+	}
+	if !reflect.DeepEqual(dist, expected) {
+		t.Errorf("Expected %v, but got %v", expected, dist)
+	}
+}
@@ -0,0 +1,167 @@
+//go:build js && wasm
+// +build js,wasm
+
+// Command mpwasm exposes the matrix profile to javascript when compiled to
+// WebAssembly so that it can be computed in the browser. Build it without the
+// plotting dependencies with
+//
+//	GOOS=js GOARCH=wasm go build -tags noplot -o mp.wasm ./cmd/mpwasm
+//
+// and load it with the wasm_exec.js shipped with go. Once running, a global
+// matrixProfile object provides:
+//
+//	compute(ts, w[, query])        -> {mp, pi} or {error}
+//	discords(ts, w, k)             -> {discords} or {error}
+//	motifs(ts, w, k, radius)       -> {motifs} or {error}
+//	segments(ts, w)                -> {idx, score, cac} or {error}
+//
+// Time series can be arrays or typed arrays of numbers. Indexes without a
+// nearest neighbor are reported as -1.
+package main
+
+import (
+	"errors"
+	"math"
+	"syscall/js"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+func main() {
+	js.Global().Set("matrixProfile", js.ValueOf(map[string]interface{}{
+		"compute":  js.FuncOf(compute),
+		"discords": js.FuncOf(discords),
+		"motifs":   js.FuncOf(motifs),
+		"segments": js.FuncOf(segments),
+	}))
+
+	// keep the functions available for as long as the page is open
+	select {}
+}
+
+// toFloats copies a javascript array or typed array of numbers
+func toFloats(v js.Value) []float64 {
+	out := make([]float64, v.Length())
+	for i := range out {
+		out[i] = v.Index(i).Float()
+	}
+	return out
+}
+
+// fromFloats converts a slice to a value that can be passed to javascript
+func fromFloats(vals []float64) []interface{} {
+	out := make([]interface{}, len(vals))
+	for i, v := range vals {
+		out[i] = v
+	}
+	return out
+}
+
+// fromIdx converts an index to a value that can be passed to javascript with
+// -1 for entries without a nearest neighbor
+func fromIdx(idx []int) []interface{} {
+	out := make([]interface{}, len(idx))
+	for i, v := range idx {
+		if v == math.MaxInt64 {
+			v = -1
+		}
+		out[i] = v
+	}
+	return out
+}
+
+// errorResult reports an error to javascript
+func errorResult(err error) interface{} {
+	return map[string]interface{}{"error": err.Error()}
+}
+
+// errArgs is returned when a function is called with too few arguments
+var errArgs = errors.New("missing arguments")
+
+// newProfile computes the matrix profile of the time series ts with a window
+// of w, joined with the query time series if it is set
+func newProfile(ts, w, query js.Value) (*mp.MatrixProfile, error) {
+	var b []float64
+	if query.Truthy() {
+		b = toFloats(query)
+	}
+
+	p, err := mp.New(toFloats(ts), b, w.Int())
+	if err != nil {
+		return nil, err
+	}
+	o := mp.NewMPOpts()
+	o.NJobs = 1
+	if err = p.Compute(o); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func compute(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return errorResult(errArgs)
+	}
+	query := js.Undefined()
+	if len(args) > 2 {
+		query = args[2]
+	}
+	p, err := newProfile(args[0], args[1], query)
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]interface{}{
+		"mp": fromFloats(p.MP),
+		"pi": fromIdx(p.Idx),
+	}
+}
+
+func discords(this js.Value, args []js.Value) interface{} {
+	if len(args) < 3 {
+		return errorResult(errArgs)
+	}
+	p, err := newProfile(args[0], args[1], js.Undefined())
+	if err != nil {
+		return errorResult(err)
+	}
+	idxs, err := p.DiscoverDiscords(args[2].Int(), p.W/2)
+	if err != nil {
+		return errorResult(err)
+	}
+	return map[string]interface{}{"discords": fromIdx(idxs)}
+}
+
+func motifs(this js.Value, args []js.Value) interface{} {
+	if len(args) < 4 {
+		return errorResult(errArgs)
+	}
+	p, err := newProfile(args[0], args[1], js.Undefined())
+	if err != nil {
+		return errorResult(err)
+	}
+	groups, err := p.DiscoverMotifs(args[2].Int(), args[3].Float(), 10, p.W/2)
+	if err != nil {
+		return errorResult(err)
+	}
+	out := make([]interface{}, len(groups))
+	for i, g := range groups {
+		out[i] = fromIdx(g.Idx)
+	}
+	return map[string]interface{}{"motifs": out}
+}
+
+func segments(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 {
+		return errorResult(errArgs)
+	}
+	p, err := newProfile(args[0], args[1], js.Undefined())
+	if err != nil {
+		return errorResult(err)
+	}
+	idx, score, cac := p.DiscoverSegments()
+	return map[string]interface{}{
+		"idx":   idx,
+		"score": score,
+		"cac":   fromFloats(cac),
+	}
+}
@@ -0,0 +1,145 @@
+package matrixprofile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// ProfileDiff is the element-wise difference between two matrix profiles of
+// the same time series, such as before and after a deployment, over the
+// subsequences they have in common.
+type ProfileDiff struct {
+	W      int       `json:"w"`       // length of a subsequence
+	AStart int       `json:"a_start"` // index in the first matrix profile of the first aligned subsequence
+	BStart int       `json:"b_start"` // index in the second matrix profile of the first aligned subsequence
+	Diff   []float64 `json:"diff"`    // second minus first euclidean distance of each aligned subsequence. NaN where either distance is undefined
+}
+
+// ChangedRegion is a contiguous run of subsequences whose distance changed by
+// more than a threshold between two matrix profiles.
+type ChangedRegion struct {
+	Start   int     `json:"start"`    // index in the first matrix profile of the first changed subsequence
+	End     int     `json:"end"`      // index in the first matrix profile after the last changed subsequence
+	MaxDiff float64 `json:"max_diff"` // difference with the largest magnitude in the region
+}
+
+// DiffSummary summarizes the differences between two matrix profiles over the
+// aligned subsequences where both distances are defined.
+type DiffSummary struct {
+	Count     int     `json:"count"`       // number of subsequences with a defined difference
+	Mean      float64 `json:"mean"`        // mean difference. Positive if distances grew
+	Std       float64 `json:"std"`         // standard deviation of the differences
+	RMSE      float64 `json:"rmse"`        // root mean squared difference
+	MaxAbs    float64 `json:"max_abs"`     // largest absolute difference
+	MaxAbsIdx int     `json:"max_abs_idx"` // index in the first matrix profile of the largest absolute difference
+}
+
+// DiffProfiles aligns two computed matrix profiles of the same time series and
+// returns the difference of the second from the first. The profiles must have
+// the same subsequence length. If both were created from time series with
+// timestamps, they are aligned on the first shared timestamp, otherwise they
+// are assumed to start at the same point, such as a profile extended with
+// Update. Pearson correlation profiles are converted to euclidean distances.
+func DiffProfiles(a, b *MatrixProfile) (*ProfileDiff, error) {
+	if a == nil || b == nil {
+		return nil, errors.New("both matrix profiles must be set")
+	}
+	if a.W != b.W {
+		return nil, fmt.Errorf("subsequence lengths must match, got %d and %d", a.W, b.W)
+	}
+	if a.MP == nil || b.MP == nil {
+		return nil, newError(ErrNotComputed, "both matrix profiles must be computed")
+	}
+
+	d := &ProfileDiff{W: a.W}
+	if a.Times != nil && b.Times != nil {
+		var ok bool
+		if d.AStart, d.BStart, ok = alignTimes(a.Times, b.Times); !ok {
+			return nil, errors.New("matrix profiles share no timestamps")
+		}
+	}
+
+	n := len(a.MP) - d.AStart
+	if len(b.MP)-d.BStart < n {
+		n = len(b.MP) - d.BStart
+	}
+	if n <= 0 {
+		return nil, errors.New("matrix profiles share no subsequences")
+	}
+
+	aProf := euclideanProfile(a, d.AStart, n)
+	bProf := euclideanProfile(b, d.BStart, n)
+	d.Diff = make([]float64, n)
+	for i := range d.Diff {
+		d.Diff[i] = bProf[i] - aProf[i]
+		if math.IsInf(aProf[i], 0) || math.IsInf(bProf[i], 0) {
+			d.Diff[i] = math.NaN()
+		}
+	}
+	return d, nil
+}
+
+// euclideanProfile returns n values of the matrix profile starting at start
+// as euclidean distances
+func euclideanProfile(mp *MatrixProfile, start, n int) []float64 {
+	out := make([]float64, n)
+	copy(out, mp.MP[start:start+n])
+	if mp.Opts != nil && !mp.Opts.Euclidean {
+		pearsonToEuclidean(out, mp.W)
+	}
+	return out
+}
+
+// ChangedRegions returns the contiguous runs of subsequences whose distance
+// changed by more than threshold in either direction, in ascending order of
+// index. Undefined differences end a region.
+func (d ProfileDiff) ChangedRegions(threshold float64) []ChangedRegion {
+	var regions []ChangedRegion
+	var cur *ChangedRegion
+	for i, v := range d.Diff {
+		if math.IsNaN(v) || math.Abs(v) <= threshold {
+			cur = nil
+			continue
+		}
+		if cur == nil {
+			regions = append(regions, ChangedRegion{Start: d.AStart + i, MaxDiff: v})
+			cur = &regions[len(regions)-1]
+		}
+		cur.End = d.AStart + i + 1
+		if math.Abs(v) > math.Abs(cur.MaxDiff) {
+			cur.MaxDiff = v
+		}
+	}
+	return regions
+}
+
+// Summary computes statistics of the defined differences
+func (d ProfileDiff) Summary() DiffSummary {
+	s := DiffSummary{MaxAbsIdx: -1}
+	vals := make([]float64, 0, len(d.Diff))
+	var sse float64
+	for i, v := range d.Diff {
+		if math.IsNaN(v) {
+			continue
+		}
+		vals = append(vals, v)
+		sse += v * v
+		if math.Abs(v) > s.MaxAbs || s.MaxAbsIdx < 0 {
+			s.MaxAbs, s.MaxAbsIdx = math.Abs(v), d.AStart+i
+		}
+	}
+
+	s.Count = len(vals)
+	if s.Count == 0 {
+		return s
+	}
+	s.Mean = stat.Mean(vals, nil)
+	if s.Count > 1 {
+		s.Std = stat.StdDev(vals, nil)
+	}
+	s.RMSE = math.Sqrt(sse / float64(s.Count))
+	return s
+}
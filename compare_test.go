@@ -0,0 +1,126 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDiffProfiles(t *testing.T) {
+	before := make([]float64, 200)
+	for i := range before {
+		before[i] = math.Sin(2 * math.Pi * float64(i) / 20)
+	}
+	after := append([]float64(nil), before...)
+	for i := 120; i < 130; i++ {
+		after[i] += 2 * math.Sin(float64(i))
+	}
+
+	compute := func(ts []float64) *MatrixProfile {
+		mp, err := New(ts, nil, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mp.Compute(nil); err != nil {
+			t.Fatal(err)
+		}
+		return mp
+	}
+	a, b := compute(before), compute(after)
+
+	d, err := DiffProfiles(a, b)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(d.Diff) != len(a.MP) || d.AStart != 0 || d.BStart != 0 {
+		t.Fatalf("Expected %d aligned differences from 0, but got %d from %d and %d", len(a.MP), len(d.Diff), d.AStart, d.BStart)
+	}
+	s := d.Summary()
+	if s.Count != len(d.Diff) || s.MaxAbsIdx < 120-16 || s.MaxAbsIdx >= 130 {
+		t.Errorf("Expected the largest change around the modified points, but got %+v", s)
+	}
+	regions := d.ChangedRegions(s.MaxAbs / 2)
+	if len(regions) == 0 || regions[0].Start < 120-16 || regions[len(regions)-1].End > 130+16 {
+		t.Errorf("Expected changed regions around the modified points, but got %+v", regions)
+	}
+
+	// a profile extended with update is compared over the shared subsequences
+	ext := compute(before[:150])
+	if err = ext.Update(before[150:]); err != nil {
+		t.Fatal(err)
+	}
+	short := compute(before[:150])
+	if d, err = DiffProfiles(short, ext); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(d.Diff) != len(short.MP) {
+		t.Errorf("Expected %d aligned differences, but got %d", len(short.MP), len(d.Diff))
+	}
+	if s = d.Summary(); s.Mean > 0 {
+		t.Errorf("Expected distances to only shrink with more data, but got a mean difference of %.3f", s.Mean)
+	}
+
+	// profiles of time series are aligned on their timestamps
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := make([]time.Time, len(before))
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+	a.Times = times
+	b = compute(before[10:])
+	b.Times = times[10:]
+	if d, err = DiffProfiles(a, b); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if d.AStart != 10 || d.BStart != 0 || len(d.Diff) != len(b.MP) {
+		t.Errorf("Expected alignment at 10 and 0 over %d subsequences, but got %d and %d over %d", len(b.MP), d.AStart, d.BStart, len(d.Diff))
+	}
+
+	b.Times = make([]time.Time, len(b.A))
+	for i := range b.Times {
+		b.Times[i] = start.Add(time.Duration(i)*time.Minute + time.Second)
+	}
+	if _, err = DiffProfiles(a, b); err == nil {
+		t.Errorf("Expected an error for profiles with no shared timestamps")
+	}
+
+	other, err := New(before, nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = DiffProfiles(a, other); err == nil {
+		t.Errorf("Expected an error for different subsequence lengths")
+	}
+	other.W = a.W
+	if _, err = DiffProfiles(a, other); err == nil {
+		t.Errorf("Expected an error for a profile that was not computed")
+	}
+}
+
+func TestProfileDiffRegions(t *testing.T) {
+	d := ProfileDiff{AStart: 5, Diff: []float64{0, 2, -3, math.NaN(), 1.5, 0}}
+
+	expected := []ChangedRegion{{6, 8, -3}, {9, 10, 1.5}}
+	regions := d.ChangedRegions(1)
+	if len(regions) != len(expected) {
+		t.Fatalf("Expected %+v, but got %+v", expected, regions)
+	}
+	for i := range regions {
+		if regions[i] != expected[i] {
+			t.Errorf("Expected %+v, but got %+v", expected, regions)
+			break
+		}
+	}
+
+	s := d.Summary()
+	if s.Count != 5 || math.Abs(s.Mean-0.1) > 1e-9 || s.MaxAbs != 3 || s.MaxAbsIdx != 7 {
+		t.Errorf("Expected a summary of 5 values with a mean of 0.1 and largest change of 3 at 7, but got %+v", s)
+	}
+	if math.Abs(s.RMSE-math.Sqrt(15.25/5)) > 1e-9 {
+		t.Errorf("Expected an rmse of %.3f, but got %.3f", math.Sqrt(15.25/5), s.RMSE)
+	}
+
+	if s = (ProfileDiff{Diff: []float64{math.NaN()}}).Summary(); s.Count != 0 || s.MaxAbsIdx != -1 {
+		t.Errorf("Expected an empty summary, but got %+v", s)
+	}
+}
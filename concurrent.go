@@ -0,0 +1,128 @@
+package matrixprofile
+
+import (
+	"sync"
+	"time"
+)
+
+// Snapshot is a deep copy of the results of a matrix profile at a point in
+// time. It shares no memory with the matrix profile it was taken from so it
+// can be read freely while the matrix profile continues to be updated.
+type Snapshot struct {
+	N        int         `json:"n"`               // length of the timeseries when the snapshot was taken
+	W        int         `json:"w"`               // length of a subsequence
+	SelfJoin bool        `json:"self_join"`       // indicates whether a self join was performed
+	MP       []float64   `json:"mp"`              // matrix profile
+	Idx      []int       `json:"pi"`              // matrix profile index
+	MPB      []float64   `json:"mp_ba"`           // matrix profile for the BA join
+	IdxB     []int       `json:"pi_ba"`           // matrix profile index for the BA join
+	LMP      []float64   `json:"lmp,omitempty"`   // left matrix profile
+	LIdx     []int       `json:"lpi,omitempty"`   // left matrix profile index
+	RMP      []float64   `json:"rmp,omitempty"`   // right matrix profile
+	RIdx     []int       `json:"rpi,omitempty"`   // right matrix profile index
+	Times    []time.Time `json:"times,omitempty"` // timestamp of each point in a if created from a time series
+	Opts     *MPOpts     `json:"options"`         // options used for the computation
+}
+
+// Snapshot returns a deep copy of the matrix profile results. This does not
+// guard against concurrent modification of the matrix profile itself, use a
+// SyncProfile to take snapshots while another goroutine updates it.
+func (mp MatrixProfile) Snapshot() *Snapshot {
+	s := &Snapshot{
+		N:        mp.N,
+		W:        mp.W,
+		SelfJoin: mp.SelfJoin,
+		MP:       copyFloats(mp.MP),
+		Idx:      copyInts(mp.Idx),
+		MPB:      copyFloats(mp.MPB),
+		IdxB:     copyInts(mp.IdxB),
+		LMP:      copyFloats(mp.LMP),
+		LIdx:     copyInts(mp.LIdx),
+		RMP:      copyFloats(mp.RMP),
+		RIdx:     copyInts(mp.RIdx),
+	}
+	if mp.Times != nil {
+		s.Times = make([]time.Time, len(mp.Times))
+		copy(s.Times, mp.Times)
+	}
+	if mp.Opts != nil {
+		opts := *mp.Opts
+		if opts.Mask != nil {
+			opts.Mask = make([]bool, len(mp.Opts.Mask))
+			copy(opts.Mask, mp.Opts.Mask)
+		}
+		opts.Weights = copyFloats(opts.Weights)
+		opts.Boundaries = copyInts(opts.Boundaries)
+		s.Opts = &opts
+	}
+	return s
+}
+
+// copyFloats returns a copy of a slice or nil if the slice is nil
+func copyFloats(a []float64) []float64 {
+	if a == nil {
+		return nil
+	}
+	out := make([]float64, len(a))
+	copy(out, a)
+	return out
+}
+
+// copyInts returns a copy of a slice or nil if the slice is nil
+func copyInts(a []int) []int {
+	if a == nil {
+		return nil
+	}
+	out := make([]int, len(a))
+	copy(out, a)
+	return out
+}
+
+// SyncProfile guards a matrix profile with a read write lock so that it can be
+// safely shared between goroutines, such as a server answering queries while a
+// streaming goroutine appends new values. Computations and updates hold the
+// write lock while snapshots only hold the read lock.
+type SyncProfile struct {
+	mu sync.RWMutex
+	mp *MatrixProfile
+}
+
+// NewSyncProfile guards a matrix profile for concurrent access. The matrix
+// profile must no longer be accessed directly afterwards.
+func NewSyncProfile(mp *MatrixProfile) *SyncProfile {
+	return &SyncProfile{mp: mp}
+}
+
+// Compute calculates the matrix profile in the same manner as
+// MatrixProfile.Compute while holding the write lock
+func (s *SyncProfile) Compute(o *MPOpts) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mp.Compute(o)
+}
+
+// Update appends new values to the matrix profile in the same manner as
+// MatrixProfile.Update while holding the write lock
+func (s *SyncProfile) Update(newValues []float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mp.Update(newValues)
+}
+
+// Snapshot returns a deep copy of the current matrix profile results while
+// holding the read lock. Snapshots always reflect a complete update.
+func (s *SyncProfile) Snapshot() *Snapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.mp.Snapshot()
+}
+
+// Do calls fn with the guarded matrix profile while holding the write lock. It
+// is used for any other operation, such as discovering motifs or discords,
+// which may modify the matrix profile. The matrix profile must not be
+// retained by fn.
+func (s *SyncProfile) Do(fn func(mp *MatrixProfile) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return fn(s.mp)
+}
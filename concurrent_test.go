@@ -0,0 +1,82 @@
+package matrixprofile
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	mp, err := New(setupData(100), nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	o.Mask = make([]bool, 93)
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	s := mp.Snapshot()
+	if s.W != mp.W || s.N != mp.N || len(s.MP) != len(mp.MP) || len(s.LMP) != len(mp.LMP) {
+		t.Fatalf("Expected snapshot to match the matrix profile")
+	}
+
+	// modifying the snapshot leaves the matrix profile untouched
+	s.MP[0], s.Idx[0], s.RMP[0] = -1, -1, -1
+	s.Opts.Mask[0] = true
+	if mp.MP[0] == -1 || mp.Idx[0] == -1 || mp.RMP[0] == -1 || mp.Opts.Mask[0] {
+		t.Errorf("Expected the snapshot to share no memory with the matrix profile")
+	}
+	if s.MPB != nil || s.IdxB != nil || s.Times != nil {
+		t.Errorf("Expected unset slices to remain nil in the snapshot")
+	}
+}
+
+func TestSyncProfile(t *testing.T) {
+	sig := setupData(300)
+	mp, err := New(sig[:100], nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewSyncProfile(mp)
+	if err = s.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 100; i < len(sig); i += 10 {
+			if err := s.Update(sig[i : i+10]); err != nil {
+				t.Errorf("Did not expect an error while updating, %v", err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 50; i++ {
+			snap := s.Snapshot()
+			if len(snap.MP) != len(snap.Idx) || len(snap.MP) != snap.N-snap.W+1 {
+				t.Errorf("Expected a consistent snapshot, but got %d profile values, %d indexes and n of %d", len(snap.MP), len(snap.Idx), snap.N)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	var discords []int
+	err = s.Do(func(mp *MatrixProfile) error {
+		var err error
+		discords, err = mp.DiscoverDiscords(1, 4)
+		return err
+	})
+	if err != nil || len(discords) != 1 {
+		t.Errorf("Expected a discord, but got %v, %v", discords, err)
+	}
+	if snap := s.Snapshot(); snap.N != len(sig) {
+		t.Errorf("Expected a time series length of %d, but got %d", len(sig), snap.N)
+	}
+}
@@ -0,0 +1,105 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// Contrast holds the contrast profile of a positive time series against a
+// negative one. High values mark subsequences that are conserved within the
+// positive time series but have no close match in the negative time series.
+type Contrast struct {
+	W      int       `json:"w"`       // length of a subsequence
+	CP     []float64 `json:"cp"`      // contrast profile between 0 and 1
+	SelfMP []float64 `json:"self_mp"` // normalized self join matrix profile of the positive time series
+	JoinMP []float64 `json:"join_mp"` // normalized AB join matrix profile of the positive against the negative time series
+}
+
+// Plato is a subsequence of the positive time series that best contrasts it
+// with the negative time series.
+type Plato struct {
+	Idx      int     `json:"idx"`      // starting index of the plato in the positive time series
+	Contrast float64 `json:"contrast"` // contrast profile value at the plato
+}
+
+// ContrastProfile computes the contrast profile of a positive time series
+// against a negative time series with a subsequence length of w. Both the self
+// join of the positive time series and its AB join with the negative time
+// series are normalized by the largest possible z-normalized euclidean
+// distance, sqrt(2w), and the contrast profile is their difference clipped at
+// 0. This is based on the UCR paper, Matrix Profile XXIII: Contrast Profile:
+// A Novel Time Series Primitive that Allows Real World Classification, by
+// Mercer et al.
+func ContrastProfile(positive, negative []float64, w int) (*Contrast, error) {
+	if negative == nil {
+		return nil, fmt.Errorf("negative time series must not be nil")
+	}
+
+	self, err := New(positive, nil, w)
+	if err != nil {
+		return nil, err
+	}
+	if err = self.Compute(nil); err != nil {
+		return nil, err
+	}
+
+	join, err := New(positive, negative, w)
+	if err != nil {
+		return nil, err
+	}
+	if err = join.Compute(nil); err != nil {
+		return nil, err
+	}
+
+	norm := math.Sqrt(2 * float64(w))
+	c := &Contrast{
+		W:      w,
+		CP:     make([]float64, len(self.MP)),
+		SelfMP: make([]float64, len(self.MP)),
+		JoinMP: make([]float64, len(join.MP)),
+	}
+	for i := range c.CP {
+		c.SelfMP[i] = self.MP[i] / norm
+		c.JoinMP[i] = join.MP[i] / norm
+
+		d := c.JoinMP[i] - c.SelfMP[i]
+		// subsequences without a defined distance in either join carry no contrast
+		if d > 0 && !math.IsInf(d, 0) {
+			c.CP[i] = d
+		}
+	}
+
+	return c, nil
+}
+
+// Platos finds the top k subsequences of the positive time series with the
+// highest contrast. Each discovery applies an exclusion zone around the found
+// index so that trivially overlapping subsequences are not returned. Defaults
+// to half the subsequence length if the exclusion zone is 0.
+func (c Contrast) Platos(k, exclusionZone int) []Plato {
+	if exclusionZone <= 0 {
+		exclusionZone = c.W / 2
+	}
+
+	cp := make([]float64, len(c.CP))
+	copy(cp, c.CP)
+
+	platos := make([]Plato, 0, k)
+	for len(platos) < k {
+		maxIdx, maxVal := -1, 0.0
+		for i, v := range cp {
+			if !math.IsInf(v, 0) && v > maxVal {
+				maxIdx, maxVal = i, v
+			}
+		}
+		if maxIdx < 0 {
+			break
+		}
+
+		platos = append(platos, Plato{Idx: maxIdx, Contrast: maxVal})
+		util.ApplyExclusionZone(cp, maxIdx, exclusionZone)
+	}
+	return platos
+}
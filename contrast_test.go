@@ -0,0 +1,94 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestContrastProfile(t *testing.T) {
+	pattern := []float64{0, 1, 3, 6, 3, 1, 0, -1, -2, -1}
+	background := func(n int, phase float64) []float64 {
+		out := make([]float64, n)
+		for i := range out {
+			out[i] = math.Sin(float64(i)*0.3+phase) + 0.3*math.Sin(float64(i)*1.7+phase)
+		}
+		return out
+	}
+
+	positive := background(150, 0)
+	copy(positive[30:], pattern)
+	copy(positive[100:], pattern)
+	negative := background(150, 0.5)
+
+	c, err := ContrastProfile(positive, negative, len(pattern))
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(c.CP) != len(positive)-len(pattern)+1 {
+		t.Fatalf("Expected a contrast profile of length %d, but got %d", len(positive)-len(pattern)+1, len(c.CP))
+	}
+	for i, v := range c.CP {
+		if v < 0 || v > 1 || math.IsNaN(v) {
+			t.Errorf("Expected contrast between 0 and 1 at %d, but got %v", i, v)
+		}
+	}
+
+	platos := c.Platos(2, 0)
+	if len(platos) != 2 {
+		t.Fatalf("Expected 2 platos, but got %v", platos)
+	}
+	if platos[0].Idx != 30 && platos[0].Idx != 100 {
+		t.Errorf("Expected the top plato at 30 or 100, but got %d", platos[0].Idx)
+	}
+	if platos[0].Contrast < platos[1].Contrast {
+		t.Errorf("Expected platos in descending order of contrast, but got %v", platos)
+	}
+	if math.Abs(float64(platos[0].Idx-platos[1].Idx)) < float64(len(pattern)/2) {
+		t.Errorf("Expected platos outside each other's exclusion zone, but got %v", platos)
+	}
+
+	testdata := []struct {
+		positive []float64
+		negative []float64
+		w        int
+	}{
+		{positive, nil, 10},
+		{nil, negative, 10},
+		{positive, negative[:5], 10},
+		{positive, negative, 1},
+	}
+	for _, d := range testdata {
+		if _, err = ContrastProfile(d.positive, d.negative, d.w); err == nil {
+			t.Errorf("Expected an error for a window of %d with series of length %d and %d", d.w, len(d.positive), len(d.negative))
+		}
+	}
+}
+
+func TestContrastPlatos(t *testing.T) {
+	c := Contrast{W: 4, CP: []float64{0, 0.5, 0.6, 0.1, 0, 0, 0.3, 0, 0, 0}}
+
+	testdata := []struct {
+		k        int
+		zone     int
+		expected []int
+	}{
+		{1, 2, []int{2}},
+		{3, 2, []int{2, 6}},
+		{3, 1, []int{2, 6, 3}},
+		{10, 0, []int{2, 6}},
+	}
+
+	for _, d := range testdata {
+		out := c.Platos(d.k, d.zone)
+		if len(out) != len(d.expected) {
+			t.Errorf("Expected %v, but got %v", d.expected, out)
+			continue
+		}
+		for i, p := range out {
+			if p.Idx != d.expected[i] {
+				t.Errorf("Expected %v, but got %v", d.expected, out)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package matrixprofile
+
+// directMaxW is the subsequence length below which sliding dot products are
+// computed directly rather than through a fourier transform. For such short
+// subsequences the O(n*w) direct kernel does less work than the forward and
+// inverse transforms over the whole time series. In
+// BenchmarkCrossCorrelateKernels over 1000 and 10000 points the direct kernel
+// is 3 to 5 times faster at a length of 16, still ahead at 32, and even with
+// the transforms around 64, so 16 leaves a margin across machines.
+const directMaxW = 16
+
+// slidingDot computes the dot product of q with every subsequence of ts of
+// the same length directly. The inner loop is unrolled by four so the
+// compiler can keep independent partial sums in registers.
+func slidingDot(q, ts []float64) []float64 {
+	w := len(q)
+	dot := make([]float64, len(ts)-w+1)
+	for i := range dot {
+		s := ts[i : i+w : i+w]
+		var s0, s1, s2, s3 float64
+		k := 0
+		for ; k+4 <= w; k += 4 {
+			s0 += q[k] * s[k]
+			s1 += q[k+1] * s[k+1]
+			s2 += q[k+2] * s[k+2]
+			s3 += q[k+3] * s[k+3]
+		}
+		for ; k < w; k++ {
+			s0 += q[k] * s[k]
+		}
+		dot[i] = (s0 + s1) + (s2 + s3)
+	}
+	return dot
+}
@@ -0,0 +1,39 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+func TestSlidingDot(t *testing.T) {
+	sig := setupData(500)
+	for _, w := range []int{2, 3, 4, 7, directMaxW - 1, directMaxW, 32} {
+		mp, err := New(sig[:100], sig, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mp.initCaches(); err != nil {
+			t.Fatal(err)
+		}
+
+		q := sig[40 : 40+w]
+		expected := mp.fftCrossCorrelate(q, fourier.NewFFT(mp.N))
+		out := slidingDot(q, sig)
+		if len(out) != len(expected) {
+			t.Fatalf("Expected %d dot products, but got %d for w %d", len(expected), len(out), w)
+		}
+		// rounding of the fourier transform scales with the largest dot product
+		var scale float64
+		for _, v := range expected {
+			scale = math.Max(scale, math.Abs(v))
+		}
+		for i := range out {
+			if math.Abs(out[i]-expected[i]) > 1e-9*math.Max(1, scale) {
+				t.Errorf("Expected %.6f, but got %.6f at %d for w %d", expected[i], out[i], i, w)
+				break
+			}
+		}
+	}
+}
@@ -1,14 +1,389 @@
 package matrixprofile
 
 import (
+	"fmt"
 	"math"
+	"sort"
+	"sync"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
 )
 
 // MotifGroup stores a list of indices representing a similar motif along
 // with the minimum distance that this set of motif composes of.
 type MotifGroup struct {
-	Idx     []int
-	MinDist float64
+	Idx       []int
+	MinDist   float64   // z-normalized euclidean distance of the closest pair, also for pearson correlation profiles
+	Center    int       // index of the subsequence the group was grown from
+	Dists     []float64 // z-normalized euclidean distance of each member in Idx to the center
+	Consensus []float64 // z-normalized mean of the z-normalized member subsequences
+	DTWDist   float64   // constrained DTW distance of the z-normalized center to its closest member. Only set when re-ranked by DiscoverMotifsWithOpts with a DTW band.
+}
+
+// MeanDist returns the average distance of the group members to the center,
+// excluding the center itself. Smaller values indicate a tighter group.
+func (m MotifGroup) MeanDist() float64 {
+	var sum float64
+	var n int
+	for i, idx := range m.Idx {
+		if idx == m.Center || i >= len(m.Dists) {
+			continue
+		}
+		sum += m.Dists[i]
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// MotifRank determines the order in which motif groups are returned
+type MotifRank string
+
+const (
+	// RankByDistance orders motif groups by the distance of their initial pair.
+	// This is the order motifs are discovered in.
+	RankByDistance MotifRank = "distance"
+
+	// RankBySize orders motif groups by the number of members, largest first,
+	// breaking ties by tightness.
+	RankBySize MotifRank = "size"
+
+	// RankByTightness orders motif groups by the mean distance of the members
+	// to the group center, tightest first.
+	RankByTightness MotifRank = "tightness"
+)
+
+// RankMotifs sorts the motif groups in place using the given ranking. An
+// empty ranking leaves the groups in discovery order. Groups without members,
+// which DiscoverMotifs returns when fewer than k motifs are found, are always
+// sorted last.
+func RankMotifs(motifs []MotifGroup, rank MotifRank) error {
+	var less func(i, j int) bool
+	switch rank {
+	case "":
+		return nil
+	case RankByDistance:
+		less = func(i, j int) bool { return motifs[i].MinDist < motifs[j].MinDist }
+	case RankBySize:
+		less = func(i, j int) bool {
+			if len(motifs[i].Idx) != len(motifs[j].Idx) {
+				return len(motifs[i].Idx) > len(motifs[j].Idx)
+			}
+			return motifs[i].MeanDist() < motifs[j].MeanDist()
+		}
+	case RankByTightness:
+		less = func(i, j int) bool { return motifs[i].MeanDist() < motifs[j].MeanDist() }
+	default:
+		return fmt.Errorf("unsupported motif ranking, %q", rank)
+	}
+	sort.SliceStable(motifs, func(i, j int) bool {
+		if (len(motifs[i].Idx) == 0) != (len(motifs[j].Idx) == 0) {
+			return len(motifs[j].Idx) == 0
+		}
+		return less(i, j)
+	})
+	return nil
+}
+
+// refineMotif fills in the distance of every group member to the center and
+// the consensus subsequence of the group. centerDists is the distance profile
+// of the center before any exclusion zones were applied.
+func (mp MatrixProfile) refineMotif(g *MotifGroup, center int, centerDists []float64) {
+	g.Center = center
+	g.Dists = make([]float64, len(g.Idx))
+	g.Consensus = make([]float64, mp.W)
+
+	var n float64
+	for i, idx := range g.Idx {
+		if idx != center {
+			g.Dists[i] = centerDists[idx]
+		}
+
+		z, err := util.ZNormalize(mp.A[idx : idx+mp.W])
+		if err != nil {
+			// constant subsequences have no shape to contribute
+			continue
+		}
+		floats.Add(g.Consensus, z)
+		n++
+	}
+	if n == 0 {
+		return
+	}
+	floats.Scale(1/n, g.Consensus)
+	if z, err := util.ZNormalize(g.Consensus); err == nil {
+		g.Consensus = z
+	}
+}
+
+// rerankDTW sets the constrained DTW distance of every motif group and sorts
+// them by it. Each group is scored by its closest member to the center, so
+// only a handful of DTW distances are computed per group. Groups with a
+// constant center or no other members sort last.
+func (mp MatrixProfile) rerankDTW(motifs []MotifGroup, band int) error {
+	for i := range motifs {
+		g := &motifs[i]
+		g.DTWDist = math.Inf(1)
+		center, err := util.ZNormalize(mp.A[g.Center : g.Center+mp.W])
+		if err != nil {
+			continue
+		}
+		for _, idx := range g.Idx {
+			if idx == g.Center {
+				continue
+			}
+			z, err := util.ZNormalize(mp.A[idx : idx+mp.W])
+			if err != nil {
+				continue
+			}
+			d, err := util.DTW(center, z, band)
+			if err != nil {
+				return err
+			}
+			if d < g.DTWDist {
+				g.DTWDist = d
+			}
+		}
+	}
+	sort.SliceStable(motifs, func(i, j int) bool { return motifs[i].DTWDist < motifs[j].DTWDist })
+	return nil
+}
+
+// Subsequence holds the values of a subsequence returned by discovery so that
+// callers don't have to slice the time series themselves, which is easy to
+// get wrong for AB joins where the matrix profile may be indexed by b.
+type Subsequence struct {
+	Series      string    `json:"series"`                 // time series the subsequence is taken from, "a" or "b"
+	Idx         int       `json:"idx"`                    // starting index of the subsequence in the series
+	W           int       `json:"w"`                      // length of the subsequence
+	Values      []float64 `json:"values"`                 // raw values of the subsequence
+	ZNormalized []float64 `json:"z_normalized,omitempty"` // z-normalized values, nil for a constant subsequence
+	NeighborIdx int       `json:"neighbor_idx"`           // starting index of the nearest neighbor in the other series of an AB join or the same series of a self join
+	Trend       []float64 `json:"trend,omitempty"`        // trend removed from the values if the matrix profile was created from a decomposition
+	Seasonal    []float64 `json:"seasonal,omitempty"`     // seasonal component removed from the values if the matrix profile was created from a decomposition
+}
+
+// Subsequence returns the subsequence at index idx of the matrix profile
+// along with its nearest neighbor. For AB joins this resolves whether the
+// matrix profile is indexed by a, as computed by MPX, or by b.
+func (mp MatrixProfile) Subsequence(idx int) (Subsequence, error) {
+	if idx < 0 || idx >= len(mp.MP) || idx >= len(mp.Idx) {
+		return Subsequence{}, fmt.Errorf("index %d is outside of the matrix profile of length %d", idx, len(mp.MP))
+	}
+
+	series, ts := "a", mp.A
+	if !mp.SelfJoin && !mp.indexedByA() {
+		series, ts = "b", mp.B
+	}
+	if idx+mp.W > len(ts) {
+		return Subsequence{}, fmt.Errorf("subsequence at %d extends past the end of %s", idx, series)
+	}
+
+	sub := Subsequence{
+		Series:      series,
+		Idx:         idx,
+		W:           mp.W,
+		Values:      append([]float64(nil), ts[idx:idx+mp.W]...),
+		NeighborIdx: mp.Idx[idx],
+	}
+	if z, err := util.ZNormalize(sub.Values); err == nil {
+		sub.ZNormalized = z
+	}
+	if d := mp.Decomposition; d != nil && series == "a" {
+		if len(d.Trend) == len(ts) {
+			sub.Trend = append([]float64(nil), d.Trend[idx:idx+mp.W]...)
+		}
+		if len(d.Seasonal) == len(ts) {
+			sub.Seasonal = append([]float64(nil), d.Seasonal[idx:idx+mp.W]...)
+		}
+	}
+	return sub, nil
+}
+
+// MotifSubsequences returns the subsequence of every member of a motif group
+// in the same order as the group indexes.
+func (mp MatrixProfile) MotifSubsequences(g MotifGroup) ([]Subsequence, error) {
+	subs := make([]Subsequence, len(g.Idx))
+	var err error
+	for i, idx := range g.Idx {
+		if subs[i], err = mp.Subsequence(idx); err != nil {
+			return nil, err
+		}
+	}
+	return subs, nil
+}
+
+// DiscordSubsequences returns the subsequence of every discord index in the
+// same order as the given indexes.
+func (mp MatrixProfile) DiscordSubsequences(idxs []int) ([]Subsequence, error) {
+	subs := make([]Subsequence, len(idxs))
+	var err error
+	for i, idx := range idxs {
+		if subs[i], err = mp.Subsequence(idx); err != nil {
+			return nil, err
+		}
+	}
+	return subs, nil
+}
+
+// Discord stores the starting index of a time series discord along with scores
+// describing how anomalous it is relative to the rest of the matrix profile.
+type Discord struct {
+	Idx          int     `json:"idx"`           // starting index of the discord
+	Dist         float64 `json:"dist"`          // matrix profile value at the discord, or the distance to the k-th nearest neighbor with the KNeighbor option
+	ZScore       float64 `json:"z_score"`       // standard score of the discord distance relative to the matrix profile
+	Percentile   float64 `json:"percentile"`    // fraction of the matrix profile with a distance at most the discord distance
+	NeighborIdx  int     `json:"neighbor_idx"`  // starting index of the nearest neighbor of the discord
+	NeighborDist float64 `json:"neighbor_dist"` // euclidean distance to the nearest neighbor, also for pearson correlation profiles
+}
+
+// ExclusionPolicy is how a found discord is excluded from the search for the
+// remaining discords
+type ExclusionPolicy string
+
+const (
+	// ExcludeNone only excludes the found discord itself so that the k
+	// largest matrix profile values are returned even if they overlap
+	ExcludeNone ExclusionPolicy = "none"
+
+	// ExcludeZone excludes a fixed zone around the found discord, and around
+	// every index in the same phase of the period if one is set
+	ExcludeZone ExclusionPolicy = "zone"
+
+	// ExcludeMask never returns subsequences marked true in the mask and
+	// otherwise only excludes the found discord itself
+	ExcludeMask ExclusionPolicy = "mask"
+)
+
+// DiscordOpts are parameters to vary the discovery of discords.
+type DiscordOpts struct {
+	K             int             `json:"k"`                           // number of discords to find
+	Policy        ExclusionPolicy `json:"policy"`                      // how found discords are excluded. Defaults to ExcludeZone if empty.
+	ExclusionZone int             `json:"exclusion_zone"`              // size of the zone excluded around each discord by ExcludeZone. Defaults to the exclusion zone of the matrix profile if 0.
+	Period        int             `json:"period"`                      // seasonality of the time series in points for ExcludeZone. Ignored if 0 or less.
+	Mask          []bool          `json:"mask,omitempty"`              // subsequences marked true are never returned by ExcludeMask. Must have one entry per matrix profile value.
+	AV            av.AV           `json:"annotation_vector,omitempty"` // annotation vector guiding this search only. Defaults to the annotation vector of the matrix profile if empty.
+	AVec          []float64       `json:"avec,omitempty"`              // annotation vector values guiding this search only with one value between 0 and 1 per matrix profile value. Takes precedence over AV if set.
+	KNeighbor     int             `json:"k_neighbor,omitempty"`        // ranks discords by the distance to their k-th nearest neighbor instead of the first, so that anomalies occurring a few times are still found. Requires a self join and computes every distance profile again if greater than 1. Defaults to the nearest neighbor if 0.
+}
+
+// MotifOpts are parameters to vary the discovery of motifs.
+type MotifOpts struct {
+	K             int       `json:"k"`                           // number of motif groups to find
+	Radius        float64   `json:"radius"`                      // maximum distance of a member to the group as a multiple of the closest pair distance
+	NeighborCount int       `json:"neighbor_count"`              // maximum number of members in a group, including the closest pair. Defaults to 10 if 0.
+	MinSeparation int       `json:"min_separation,omitempty"`    // minimum number of points between the starts of members added to the closest pair of a group, such as a multiple of the period of a periodic signal. Only applies if wider than the exclusion zone.
+	ExclusionZone int       `json:"exclusion_zone"`              // size of the zone excluded around each member. Defaults to half the subsequence length if 0.
+	AV            av.AV     `json:"annotation_vector,omitempty"` // annotation vector guiding this search only. Defaults to the annotation vector of the matrix profile if empty.
+	AVec          []float64 `json:"avec,omitempty"`              // annotation vector values guiding this search only with one value between 0 and 1 per matrix profile value. Takes precedence over AV if set.
+	DTWBand       int       `json:"dtw_band,omitempty"`          // width of the Sakoe-Chiba band used to re-rank the motif candidates by constrained DTW distance, which tolerates small warps the euclidean distance penalizes. Disabled if 0.
+	Candidates    int       `json:"candidates,omitempty"`        // number of motif groups discovered before re-ranking by DTW distance and keeping the top K. Defaults to twice K if less than K.
+	NJobs         int       `json:"n_jobs,omitempty"`            // number of goroutines scanning long profiles for the closest matches. Defaults to the number of CPUs if 0.
+}
+
+// NewMotifOpts returns a default MotifOpts which finds the top 3 motifs with
+// a radius of twice the closest pair distance
+func NewMotifOpts() *MotifOpts {
+	return &MotifOpts{
+		K:      3,
+		Radius: 2,
+	}
+}
+
+// NewDiscordOpts returns a default DiscordOpts which finds the top 3 discords
+// with the exclusion zone of the matrix profile around each one
+func NewDiscordOpts() *DiscordOpts {
+	return &DiscordOpts{
+		K:      3,
+		Policy: ExcludeZone,
+	}
+}
+
+// Novelty stores the starting index of a newly emerging behavior, a
+// subsequence unlike anything before it that repeats afterwards.
+type Novelty struct {
+	Idx       int     `json:"idx"`        // starting index of the novelty
+	LeftDist  float64 `json:"left_dist"`  // euclidean distance to the nearest neighbor before the novelty
+	RightDist float64 `json:"right_dist"` // euclidean distance to the nearest neighbor after the novelty
+}
+
+// Regime stores the starting index of a regime found by segmentation along
+// with the corrected arc curve value at the boundary. Lower scores are more
+// likely regime changes.
+type Regime struct {
+	Idx   int     `json:"idx"`   // index of the boundary where the regime starts
+	Score float64 `json:"score"` // corrected arc curve value at the boundary between 0 and 1
+}
+
+// JoinMatch stores a subsequence of the b time series from an AB join along
+// with its nearest neighbor in the a time series.
+type JoinMatch struct {
+	BIdx int     `json:"b_idx"` // starting index of the subsequence in b
+	AIdx int     `json:"a_idx"` // starting index of the nearest neighbor in a
+	Dist float64 `json:"dist"`  // euclidean distance to the nearest neighbor
+}
+
+// JoinMotifGroup is a subsequence of the a time series of an AB join along
+// with its occurrences in the b time series.
+type JoinMotifGroup struct {
+	AIdx    int       `json:"a_idx"`    // starting index of the pattern in a
+	BIdx    []int     `json:"b_idx"`    // starting index of each occurrence in b, in ascending order
+	Dists   []float64 `json:"dists"`    // euclidean distance of each occurrence to the pattern
+	MinDist float64   `json:"min_dist"` // distance of the closest occurrence
+}
+
+// RangeMatch stores the starting index of a subsequence in the b time series
+// found by a range query along with its distance to the query.
+type RangeMatch struct {
+	Idx  int     `json:"idx"`  // starting index of the match in b
+	Dist float64 `json:"dist"` // z-normalized euclidean distance to the query
+}
+
+// scoreDiscords computes the standard score and percentile of each discord
+// index relative to the finite values of the matrix profile. Pearson
+// correlation profiles are converted to euclidean distances first so that a
+// higher score is always more anomalous.
+func scoreDiscords(mp []float64, idxs []int, w int, euclidean bool) []Discord {
+	dists := make([]float64, 0, len(mp))
+	for _, d := range mp {
+		if !math.IsInf(d, 0) && !math.IsNaN(d) {
+			dists = append(dists, d)
+		}
+	}
+	if !euclidean {
+		util.P2E(dists, w)
+	}
+	sort.Float64s(dists)
+
+	var mean, std float64
+	if len(dists) > 0 {
+		mean = stat.Mean(dists, nil)
+	}
+	if len(dists) > 1 {
+		std = stat.StdDev(dists, nil)
+	}
+
+	discords := make([]Discord, len(idxs))
+	d := make([]float64, 1)
+	for i, idx := range idxs {
+		d[0] = mp[idx]
+		discords[i] = Discord{Idx: idx, Dist: mp[idx]}
+		if !euclidean {
+			util.P2E(d, w)
+		}
+		if std > 0 {
+			discords[i].ZScore = (d[0] - mean) / std
+		}
+		if len(dists) > 0 {
+			discords[i].Percentile = float64(sort.Search(len(dists), func(j int) bool { return dists[j] > d[0] })) / float64(len(dists))
+		}
+	}
+	return discords
 }
 
 // arcCurve computes the arc curve (histogram) which is uncorrected for.
@@ -41,3 +416,46 @@ func arcCurve(mpIdx []int) []float64 {
 func iac(x float64, n int) float64 {
 	return -math.Pow(math.Sqrt(2/float64(n))*(x-float64(n)/2.0), 2.0) + float64(n)/2.0
 }
+
+// minParallelScan is the shortest profile scanned by more than one goroutine
+const minParallelScan = 1 << 14
+
+// scanMinIdx returns the index of the smallest value of x in the same manner
+// as floats.MinIdx, the first one on ties, splitting long profiles into one
+// chunk per job scanned concurrently. Returns -1 if x is empty.
+func scanMinIdx(x []float64, jobs int) int {
+	if len(x) == 0 {
+		return -1
+	}
+	if jobs <= 1 || len(x) < minParallelScan {
+		return floats.MinIdx(x)
+	}
+
+	size := (len(x) + jobs - 1) / jobs
+	mins := make([]int, 0, jobs)
+	for start := 0; start < len(x); start += size {
+		mins = append(mins, start)
+	}
+	var wg sync.WaitGroup
+	for c := range mins {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			end := mins[c] + size
+			if end > len(x) {
+				end = len(x)
+			}
+			mins[c] += floats.MinIdx(x[mins[c]:end])
+		}(c)
+	}
+	wg.Wait()
+
+	// earlier chunks win ties so the first smallest value is kept
+	best := mins[0]
+	for _, i := range mins[1:] {
+		if x[i] < x[best] {
+			best = i
+		}
+	}
+	return best
+}
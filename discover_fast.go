@@ -0,0 +1,239 @@
+package matrixprofile
+
+import (
+	"math"
+	"sort"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+// minPAASegments is the fewest number of PAA segments per subsequence for
+// the downsampled profile to be worth computing
+const minPAASegments = 4
+
+// paaSegments is the target number of PAA segments per subsequence
+const paaSegments = 16
+
+// DiscoverMotifsFast finds the top k motifs with a given radius without
+// requiring the full matrix profile to be computed. A matrix profile of a
+// piecewise aggregate approximation (PAA) of the time series is computed first
+// to order the search so that close pairs are found early. Each subsequence is
+// then compared at full resolution against all others, pruning with a PAA lower
+// bound and early abandoning the exact distance once it exceeds the best pair
+// found so far. The motif pairs found are exact. Only applies to self joins,
+// the annotation vector is not applied, and MinDist is always a euclidean
+// distance.
+func (mp *MatrixProfile) DiscoverMotifsFast(k int, radius float64) ([]MotifGroup, error) {
+	if !mp.SelfJoin {
+		return nil, newError(ErrNotSelfJoin, "can only find top motifs if a self join is performed")
+	}
+
+	neighborCount := 10
+	exclusionZone := mp.W / 2
+
+	if err := mp.initCaches(); err != nil {
+		return nil, err
+	}
+
+	s := newPAASearch(mp.A, mp.W, mp.AMean, mp.AStd)
+
+	order, err := mp.paaOrder(s.f)
+	if err != nil {
+		return nil, err
+	}
+
+	// excl tracks subsequences that are part of previously discovered motifs
+	excl := make([]float64, len(mp.AMean))
+
+	motifs := make([]MotifGroup, 0, k)
+	prof := make([]float64, len(mp.AMean))
+	centerDists := make([]float64, len(mp.AMean))
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	var minDistIdx int
+
+	for j := 0; j < k; j++ {
+		a, b, motifDistance := s.closestPair(order, excl, exclusionZone)
+		if a < 0 {
+			break
+		}
+
+		motifSet := map[int]struct{}{a: {}, b: {}}
+
+		if err = mp.distanceProfile(a, prof, fft); err != nil {
+			return nil, err
+		}
+		copy(centerDists, prof)
+
+		util.ApplyExclusionZone(prof, a, exclusionZone)
+		util.ApplyExclusionZone(prof, b, exclusionZone)
+		for _, m := range motifs {
+			for _, idx := range m.Idx {
+				util.ApplyExclusionZone(prof, idx, exclusionZone)
+			}
+		}
+
+		for len(motifSet) < neighborCount {
+			minDistIdx = floats.MinIdx(prof)
+			if prof[minDistIdx] >= motifDistance*radius {
+				break
+			}
+			motifSet[minDistIdx] = struct{}{}
+			util.ApplyExclusionZone(prof, minDistIdx, exclusionZone)
+		}
+
+		group := MotifGroup{
+			Idx:     make([]int, 0, len(motifSet)),
+			MinDist: motifDistance,
+		}
+		for idx := range motifSet {
+			group.Idx = append(group.Idx, idx)
+			util.ApplyExclusionZone(excl, idx, exclusionZone)
+		}
+		sort.IntSlice(group.Idx).Sort()
+		mp.refineMotif(&group, a, centerDists)
+		motifs = append(motifs, group)
+	}
+	mp.Motifs = motifs
+
+	return motifs, nil
+}
+
+// paaOrder computes the matrix profile of the time series downsampled by a
+// factor of f and returns the subsequence indexes of the full resolution time
+// series ordered by the downsampled profile value of their segment. If the
+// time series is too short to downsample, the natural order is returned.
+func (mp MatrixProfile) paaOrder(f int) ([]int, error) {
+	n := len(mp.A) - mp.W + 1
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+
+	w := mp.W / f
+	paa := make([]float64, len(mp.A)/f)
+	if w < minPAASegments || len(paa) < 2*w {
+		return order, nil
+	}
+	for i := range paa {
+		paa[i] = floats.Sum(mp.A[i*f:(i+1)*f]) / float64(f)
+	}
+
+	dmp, err := New(paa, nil, w)
+	if err != nil {
+		return nil, err
+	}
+	o := NewMPOpts()
+	if mp.Opts != nil {
+		o.NJobs = mp.Opts.NJobs
+	}
+	if err = dmp.Compute(o); err != nil {
+		return nil, err
+	}
+
+	key := func(i int) float64 {
+		di := i / f
+		if di >= len(dmp.MP) {
+			di = len(dmp.MP) - 1
+		}
+		if math.IsNaN(dmp.MP[di]) {
+			return math.Inf(1)
+		}
+		return dmp.MP[di]
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return key(order[i]) < key(order[j])
+	})
+	return order, nil
+}
+
+// paaSearch holds the z-normalized PAA representation of every subsequence
+// of a time series used to lower bound the distance between subsequences.
+type paaSearch struct {
+	ts  []float64
+	w   int
+	f   int         // number of points averaged into each PAA segment
+	mu  []float64   // mean of each subsequence
+	sig []float64   // standard deviation of each subsequence
+	paa [][]float64 // z-normalized PAA of each subsequence
+}
+
+func newPAASearch(ts []float64, w int, mu, sig []float64) *paaSearch {
+	f := w / paaSegments
+	if f < 1 {
+		f = 1
+	}
+	d := w / f
+
+	c := make([]float64, len(ts)+1)
+	for i, v := range ts {
+		c[i+1] = c[i] + v
+	}
+
+	s := &paaSearch{ts: ts, w: w, f: f, mu: mu, sig: sig, paa: make([][]float64, len(mu))}
+	for i := range mu {
+		s.paa[i] = make([]float64, d)
+		if sig[i] == 0 {
+			continue
+		}
+		for j := 0; j < d; j++ {
+			s.paa[i][j] = ((c[i+(j+1)*f]-c[i+j*f])/float64(f) - mu[i]) / sig[i]
+		}
+	}
+	return s
+}
+
+// closestPair finds the pair of non-trivially matching subsequences with the
+// smallest z-normalized euclidean distance where the first subsequence is not
+// excluded. Rows are visited in the given order. Returns -1 indexes if no
+// pair exists.
+func (s *paaSearch) closestPair(order []int, excl []float64, exclusionZone int) (int, int, float64) {
+	bestA, bestB := -1, -1
+	bsf := math.Inf(1)
+	scale := float64(s.f)
+
+	for _, i := range order {
+		if math.IsInf(excl[i], 1) || s.sig[i] == 0 {
+			continue
+		}
+		for j := range s.mu {
+			if j-i < exclusionZone && i-j < exclusionZone || s.sig[j] == 0 {
+				continue
+			}
+
+			// PAA lower bound with early abandonment
+			var lb float64
+			for x, v := range s.paa[i] {
+				diff := v - s.paa[j][x]
+				lb += scale * diff * diff
+				if lb >= bsf {
+					break
+				}
+			}
+			if lb >= bsf {
+				continue
+			}
+
+			if d := s.dist(i, j, bsf); d < bsf {
+				bestA, bestB, bsf = i, j, d
+			}
+		}
+	}
+
+	return bestA, bestB, math.Sqrt(bsf)
+}
+
+// dist computes the squared z-normalized euclidean distance between the
+// subsequences at i and j, abandoning once the distance exceeds limit.
+func (s *paaSearch) dist(i, j int, limit float64) float64 {
+	var d float64
+	for x := 0; x < s.w; x++ {
+		diff := (s.ts[i+x]-s.mu[i])/s.sig[i] - (s.ts[j+x]-s.mu[j])/s.sig[j]
+		d += diff * diff
+		if d >= limit {
+			return d
+		}
+	}
+	return d
+}
@@ -0,0 +1,62 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+func TestDiscoverMotifsFast(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 3, 0, 0, 100, 2), siggen.Noise(0.5, 300), siggen.Sin(1, 3, 0, 0, 100, 1))
+	sig = siggen.Add(sig, siggen.Noise(0.1, len(sig)))
+	w := 64
+
+	mp, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	motifs, err := mp.DiscoverMotifsFast(2, 2)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(motifs) != 2 {
+		t.Fatalf("Expected 2 motifs, but got %d", len(motifs))
+	}
+
+	// brute force search for the closest non-trivial pair of subsequences
+	best := math.Inf(1)
+	for i := 0; i < len(sig)-w+1; i++ {
+		a, _ := util.ZNormalize(sig[i : i+w])
+		for j := i + w/2; j < len(sig)-w+1; j++ {
+			b, _ := util.ZNormalize(sig[j : j+w])
+			var d float64
+			for x := range a {
+				d += (a[x] - b[x]) * (a[x] - b[x])
+			}
+			if d < best {
+				best = d
+			}
+		}
+	}
+
+	if math.Abs(motifs[0].MinDist-math.Sqrt(best)) > 1e-7 {
+		t.Errorf("Expected exact motif distance %.7f, but got %.7f", math.Sqrt(best), motifs[0].MinDist)
+	}
+	if motifs[1].MinDist < motifs[0].MinDist {
+		t.Errorf("Expected motifs in ascending distance, but got %+v", motifs)
+	}
+	if len(motifs[0].Idx) < 2 {
+		t.Errorf("Expected at least a motif pair, but got %v", motifs[0].Idx)
+	}
+
+	ab, err := New(sig, sig, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.DiscoverMotifsFast(2, 2); err == nil {
+		t.Errorf("Expected an error for an AB join")
+	}
+}
@@ -2,6 +2,7 @@ package matrixprofile
 
 import (
 	"math"
+	"reflect"
 	"testing"
 )
 
@@ -52,3 +53,176 @@ func TestIac(t *testing.T) {
 		}
 	}
 }
+
+func TestRankMotifs(t *testing.T) {
+	motifs := []MotifGroup{
+		{Idx: []int{0, 10}, MinDist: 0.1, Center: 0, Dists: []float64{0, 0.1}},
+		{Idx: []int{5, 15, 25}, MinDist: 0.2, Center: 15, Dists: []float64{0.9, 0, 0.7}},
+		{Idx: []int{3, 30, 40}, MinDist: 0.3, Center: 3, Dists: []float64{0, 0.3, 0.4}},
+	}
+
+	testdata := []struct {
+		rank        MotifRank
+		expectedMin []float64
+		expectedErr bool
+	}{
+		{"", []float64{0.1, 0.2, 0.3}, false},
+		{RankByDistance, []float64{0.1, 0.2, 0.3}, false},
+		{RankBySize, []float64{0.3, 0.2, 0.1}, false},
+		{RankByTightness, []float64{0.1, 0.3, 0.2}, false},
+		{"unknown", nil, true},
+	}
+
+	for _, d := range testdata {
+		out := append([]MotifGroup{}, motifs...)
+		err := RankMotifs(out, d.rank)
+		if d.expectedErr {
+			if err == nil {
+				t.Errorf("Expected an error for ranking %q", d.rank)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for ranking %q", err, d.rank)
+			continue
+		}
+		for i, m := range out {
+			if m.MinDist != d.expectedMin[i] {
+				t.Errorf("Expected group %d to have min distance %v for ranking %q, but got %v", i, d.expectedMin[i], d.rank, m.MinDist)
+			}
+		}
+	}
+}
+
+func TestRankMotifsFewerThanK(t *testing.T) {
+	a := []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}
+	mp, err := New(a, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rank := range []MotifRank{"", RankByDistance, RankBySize, RankByTightness} {
+		motifs, err := mp.DiscoverMotifs(5, 2, 10, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(motifs[len(motifs)-1].Idx) != 0 {
+			t.Fatalf("Expected fewer than %d motifs to be found", len(motifs))
+		}
+		if err = RankMotifs(motifs, rank); err != nil {
+			t.Fatalf("Did not expect an error, %v, for ranking %q", err, rank)
+		}
+		if len(motifs[0].Idx) == 0 {
+			t.Errorf("Expected the first group to have members for ranking %q", rank)
+		}
+		for i := 1; i < len(motifs); i++ {
+			if len(motifs[i-1].Idx) == 0 && len(motifs[i].Idx) != 0 {
+				t.Errorf("Expected group %d without members to be ranked after group %d for ranking %q", i-1, i, rank)
+			}
+		}
+	}
+}
+
+func TestMotifGroupMeanDist(t *testing.T) {
+	testdata := []struct {
+		mg       MotifGroup
+		expected float64
+	}{
+		{MotifGroup{}, 0},
+		{MotifGroup{Idx: []int{4}, Center: 4, Dists: []float64{0}}, 0},
+		{MotifGroup{Idx: []int{0, 4, 9}, Center: 4, Dists: []float64{1, 0, 2}}, 1.5},
+	}
+
+	for _, d := range testdata {
+		if out := d.mg.MeanDist(); math.Abs(out-d.expected) > 1e-9 {
+			t.Errorf("Expected %v, but got %v for %v", d.expected, out, d.mg)
+		}
+	}
+}
+
+func TestSubsequence(t *testing.T) {
+	a := []float64{0, 1, 2, 3, 2, 1, 0, 1, 2, 3}
+	b := []float64{5, 5, 5, 5, 0, 1, 2, 3}
+	w := 4
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		mp, err := New(a, b, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		// MPX indexes the matrix profile by a while STOMP indexes it by b
+		series, ts := "b", b
+		if algo == AlgoMPX {
+			series, ts = "a", a
+		}
+		for idx := range mp.MP {
+			sub, err := mp.Subsequence(idx)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+			}
+			if sub.Series != series || sub.Idx != idx || sub.W != w || sub.NeighborIdx != mp.Idx[idx] {
+				t.Errorf("Expected subsequence %d of %s, but got %+v for %s", idx, series, sub, algo)
+			}
+			if !reflect.DeepEqual(sub.Values, ts[idx:idx+w]) {
+				t.Errorf("Expected values %v, but got %v for %s", ts[idx:idx+w], sub.Values, algo)
+			}
+			if (sub.ZNormalized == nil) != (ts[idx] == 5 && ts[idx+w-1] == 5) {
+				t.Errorf("Expected z-normalized values only for non-constant subsequences, got %v at %d for %s", sub.ZNormalized, idx, algo)
+			}
+		}
+		if _, err = mp.Subsequence(len(mp.MP)); err == nil {
+			t.Errorf("Expected an error for an index outside of the matrix profile for %s", algo)
+		}
+	}
+
+	mp, err := New(a, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	subs, err := mp.MotifSubsequences(MotifGroup{Idx: []int{0, 6}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(subs) != 2 || !reflect.DeepEqual(subs[0].Values, subs[1].Values) || subs[1].Idx != 6 {
+		t.Errorf("Expected matching motif subsequences at 0 and 6, but got %+v", subs)
+	}
+	if _, err = mp.DiscordSubsequences([]int{-1}); err == nil {
+		t.Errorf("Expected an error for a negative index")
+	}
+}
+
+func TestScanMinIdx(t *testing.T) {
+	x := make([]float64, 3*minParallelScan+7)
+	for i := range x {
+		x[i] = float64((i * 7919) % 1000)
+	}
+	x[len(x)-3] = -1
+	x[len(x)-1] = -1
+
+	for _, jobs := range []int{0, 1, 3, 8} {
+		if got := scanMinIdx(x, jobs); got != len(x)-3 {
+			t.Errorf("Expected index %d, but got %d for %d jobs", len(x)-3, got, jobs)
+		}
+	}
+	for i := range x {
+		x[i] = math.Inf(1)
+	}
+	if got := scanMinIdx(x, 4); got != 0 {
+		t.Errorf("Expected index 0 for a profile of +Inf, but got %d", got)
+	}
+	if got := scanMinIdx(nil, 4); got != -1 {
+		t.Errorf("Expected -1 for an empty profile, but got %d", got)
+	}
+}
@@ -0,0 +1,99 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// DownsampledProfile is a reduced resolution matrix profile where each value
+// pools Factor consecutive values of the original matrix profile. Bin i covers
+// the original indexes from i*Factor up to, but not including,
+// min((i+1)*Factor, Len).
+type DownsampledProfile struct {
+	Factor    int         `json:"factor"`          // number of original values pooled into each bin
+	Len       int         `json:"len"`             // length of the original matrix profile
+	W         int         `json:"w"`               // length of a subsequence
+	Euclidean bool        `json:"euclidean"`       // whether the values are euclidean distances rather than pearson correlations
+	MP        []float64   `json:"mp"`              // closest value of each bin, the minimum distance or maximum correlation
+	Idx       []int       `json:"pi"`              // matrix profile index of the closest value of each bin
+	Src       []int       `json:"src"`             // index in the original matrix profile of the closest value of each bin
+	Times     []time.Time `json:"times,omitempty"` // timestamp of the first subsequence of each bin if the original had timestamps
+}
+
+// Downsample pools every factor consecutive values of the matrix profile into
+// one, keeping the closest match of each bin along with its matrix profile
+// index and its position in the original matrix profile. Pooling keeps the
+// closest value so that motifs are never averaged away, while discords remain
+// visible as bins whose closest value is still large. Ties are broken toward
+// the smaller index. This is suited to storing and plotting the matrix
+// profile of very long time series.
+func (mp MatrixProfile) Downsample(factor int) (*DownsampledProfile, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("downsampling factor must be at least 1, got %d", factor)
+	}
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+
+	euclidean := mp.Opts == nil || mp.Opts.Euclidean
+	n := (len(mp.MP) + factor - 1) / factor
+	d := &DownsampledProfile{
+		Factor:    factor,
+		Len:       len(mp.MP),
+		W:         mp.W,
+		Euclidean: euclidean,
+		MP:        make([]float64, n),
+		Idx:       make([]int, n),
+		Src:       make([]int, n),
+	}
+	if len(mp.Times) >= len(mp.MP) {
+		d.Times = make([]time.Time, n)
+	}
+
+	for bin := 0; bin < n; bin++ {
+		start, end := d.Span(bin)
+		best := start
+		for i := start + 1; i < end; i++ {
+			if closerValue(mp.MP[i], mp.MP[best], euclidean) {
+				best = i
+			}
+		}
+		d.MP[bin], d.Idx[bin], d.Src[bin] = mp.MP[best], mp.Idx[best], best
+		if d.Times != nil {
+			d.Times[bin] = mp.Times[start]
+		}
+	}
+	return d, nil
+}
+
+// closerValue returns whether the matrix profile value v is a strictly closer
+// match than cur. Undefined values are never closer.
+func closerValue(v, cur float64, euclidean bool) bool {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return false
+	}
+	if math.IsNaN(cur) || math.IsInf(cur, 0) {
+		return true
+	}
+	if euclidean {
+		return v < cur
+	}
+	return v > cur
+}
+
+// Span returns the range of indexes of the original matrix profile pooled
+// into a bin, from start up to, but not including, end
+func (d DownsampledProfile) Span(bin int) (int, int) {
+	start := bin * d.Factor
+	end := start + d.Factor
+	if end > d.Len {
+		end = d.Len
+	}
+	return start, end
+}
+
+// Bin returns the bin holding an index of the original matrix profile
+func (d DownsampledProfile) Bin(idx int) int {
+	return idx / d.Factor
+}
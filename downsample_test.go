@@ -0,0 +1,62 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDownsample(t *testing.T) {
+	a := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	mp := MatrixProfile{A: a, B: a, W: 3, SelfJoin: true, Opts: NewMPOpts(),
+		MP:  []float64{4, 2, 3, 2, math.Inf(1), 5, 1},
+		Idx: []int{3, 3, 6, 1, math.MaxInt64, 1, 2},
+	}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range a {
+		mp.Times = append(mp.Times, start.Add(time.Duration(i)*time.Minute))
+	}
+
+	d, err := mp.Downsample(3)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	expectedMP := []float64{2, 2, 1}
+	expectedIdx := []int{3, 1, 2}
+	expectedSrc := []int{1, 3, 6}
+	if len(d.MP) != len(expectedMP) {
+		t.Fatalf("Expected %d bins, but got %v", len(expectedMP), d.MP)
+	}
+	for i := range expectedMP {
+		if d.MP[i] != expectedMP[i] || d.Idx[i] != expectedIdx[i] || d.Src[i] != expectedSrc[i] {
+			t.Errorf("Expected bin %d to be (%.1f, %d, %d), but got (%.1f, %d, %d)", i, expectedMP[i], expectedIdx[i], expectedSrc[i], d.MP[i], d.Idx[i], d.Src[i])
+		}
+		if s, e := d.Span(i); d.Src[i] < s || d.Src[i] >= e || d.Bin(d.Src[i]) != i {
+			t.Errorf("Expected source %d within bin %d spanning [%d, %d)", d.Src[i], i, s, e)
+		}
+		if !d.Times[i].Equal(mp.Times[i*3]) {
+			t.Errorf("Expected bin %d to start at %v, but got %v", i, mp.Times[i*3], d.Times[i])
+		}
+	}
+	if s, e := d.Span(2); s != 6 || e != 7 {
+		t.Errorf("Expected the last bin to span [6, 7), but got [%d, %d)", s, e)
+	}
+
+	// pearson correlations keep the highest value of each bin
+	mp.Opts.Euclidean = false
+	if d, err = mp.Downsample(3); err != nil {
+		t.Fatal(err)
+	}
+	if d.MP[1] != 5 || d.Src[1] != 5 {
+		t.Errorf("Expected the highest correlation, 5 at 5, but got %.1f at %d", d.MP[1], d.Src[1])
+	}
+
+	if _, err = mp.Downsample(0); err == nil {
+		t.Errorf("Expected an error for a factor of 0")
+	}
+	mp.MP = nil
+	if _, err = mp.Downsample(2); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed, but got %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+)
+
+// DriftOpts are parameters to vary concept drift scoring from the left and
+// right matrix profiles.
+type DriftOpts struct {
+	Window    int     `json:"window"`    // number of consecutive subsequences pooled into each score. Defaults to 10 subsequence lengths if 0
+	Threshold float64 `json:"threshold"` // score at or above which a drift alert is raised
+}
+
+// NewDriftOpts returns a default DriftOpts which raises an alert once the
+// left nearest neighbors are on average twice as far as the right ones
+func NewDriftOpts() *DriftOpts {
+	return &DriftOpts{
+		Threshold: 2,
+	}
+}
+
+// DriftAlert is a run of consecutive drift scores at or above the threshold
+type DriftAlert struct {
+	Start int     `json:"start"` // index of the first subsequence covered by the run
+	End   int     `json:"end"`   // index after the last subsequence covered by the run
+	Peak  int     `json:"peak"`  // index of the first subsequence of the highest scoring window
+	Score float64 `json:"score"` // highest score of the run
+}
+
+// DriftScores computes the emergence of new behavior over time as the ratio
+// of the summed left to right matrix profile distances over each window of
+// consecutive subsequences. The i-th score covers the subsequences from i up
+// to, but not including, i plus the window. A subsequence whose nearest
+// neighbor after it is much closer than the one before it belongs to a
+// behavior that was not seen before, so scores well above 1 indicate concept
+// drift while stable behavior scores around 1. Subsequences without a
+// neighbor on either side are left out. As with DiscoverNovelties, windows
+// near the start of the time series have few earlier neighbors to match and
+// tend to score highly as well. Requires the left and right matrix profiles
+// to be computed with the LeftRight option.
+func (mp MatrixProfile) DriftScores(o *DriftOpts) ([]float64, error) {
+	if o == nil {
+		o = NewDriftOpts()
+	}
+	if mp.LMP == nil || mp.RMP == nil {
+		return nil, newError(ErrNotComputed, "left and right matrix profiles must be computed to score drift")
+	}
+	window := o.Window
+	if window < 0 {
+		return nil, fmt.Errorf("drift window must be at least 0, got %d", window)
+	}
+	if window == 0 {
+		window = 10 * mp.W
+	}
+	if window > len(mp.LMP) {
+		window = len(mp.LMP)
+	}
+	if window < 1 {
+		window = 1
+	}
+
+	lmp := append([]float64(nil), mp.LMP...)
+	rmp := append([]float64(nil), mp.RMP...)
+	if mp.Opts != nil && !mp.Opts.Euclidean {
+		pearsonToEuclidean(lmp, mp.W)
+		pearsonToEuclidean(rmp, mp.W)
+	}
+	for i := range lmp {
+		if math.IsInf(lmp[i], 0) || math.IsInf(rmp[i], 0) || math.IsNaN(lmp[i]) || math.IsNaN(rmp[i]) {
+			lmp[i], rmp[i] = 0, 0
+		}
+	}
+
+	scores := make([]float64, len(lmp)-window+1)
+	var sumL, sumR float64
+	for i := range lmp {
+		sumL += lmp[i]
+		sumR += rmp[i]
+		if i >= window {
+			sumL -= lmp[i-window]
+			sumR -= rmp[i-window]
+		}
+		if i < window-1 {
+			continue
+		}
+
+		switch {
+		case sumR > 0:
+			scores[i-window+1] = sumL / sumR
+		case sumL > 0:
+			scores[i-window+1] = math.Inf(1)
+		default:
+			scores[i-window+1] = 1
+		}
+	}
+	return scores, nil
+}
+
+// DiscoverDrift finds the runs of drift scores from DriftScores at or above
+// the threshold of the options, in ascending order of index.
+func (mp MatrixProfile) DiscoverDrift(o *DriftOpts) ([]DriftAlert, error) {
+	if o == nil {
+		o = NewDriftOpts()
+	}
+	scores, err := mp.DriftScores(o)
+	if err != nil {
+		return nil, err
+	}
+	window := len(mp.LMP) - len(scores) + 1
+
+	var alerts []DriftAlert
+	for i, s := range scores {
+		if s < o.Threshold {
+			continue
+		}
+		if n := len(alerts); n > 0 && alerts[n-1].End == i-1+window {
+			a := &alerts[n-1]
+			a.End = i + window
+			if s > a.Score {
+				a.Peak, a.Score = i, s
+			}
+			continue
+		}
+		alerts = append(alerts, DriftAlert{Start: i, End: i + window, Peak: i, Score: s})
+	}
+	return alerts, nil
+}
+
+// DriftMonitor is a ready-made concept drift monitor over a stream. It
+// appends new points to a self join with Update, which keeps the left and
+// right matrix profiles, and reports each drift alert once.
+type DriftMonitor struct {
+	MP       *MatrixProfile // self join computed with the LeftRight option
+	Opts     *DriftOpts     // options used to score drift
+	reported int            // index of the first subsequence not covered by a reported alert
+}
+
+// NewDriftMonitor creates a monitor over a computed self join. Alerts already
+// present in the matrix profile are reported by the first Push.
+func NewDriftMonitor(mp *MatrixProfile, o *DriftOpts) (*DriftMonitor, error) {
+	if !mp.SelfJoin {
+		return nil, newError(ErrNotSelfJoin, "can only monitor drift of a self join")
+	}
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+	if mp.LMP == nil || mp.RMP == nil {
+		return nil, newError(ErrNotComputed, "left and right matrix profiles must be computed to monitor drift")
+	}
+	if o == nil {
+		o = NewDriftOpts()
+	}
+	return &DriftMonitor{MP: mp, Opts: o}, nil
+}
+
+// Push appends new values to the stream and returns the drift alerts that
+// start after every previously reported alert. The right neighbors of the
+// newest subsequences are not known yet, which lowers their scores, so an
+// alert is typically raised once enough points past the drift have arrived.
+func (m *DriftMonitor) Push(newValues []float64) ([]DriftAlert, error) {
+	if err := m.MP.Update(newValues); err != nil {
+		return nil, err
+	}
+	alerts, err := m.MP.DiscoverDrift(m.Opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []DriftAlert
+	for _, a := range alerts {
+		if a.Start >= m.reported {
+			out = append(out, a)
+			m.reported = a.End
+		}
+	}
+	return out, nil
+}
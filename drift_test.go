@@ -0,0 +1,99 @@
+package matrixprofile
+
+import (
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestDiscoverDrift(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 6), siggen.Sin(1, 10, 0, 0, 100, 3))
+	sig = siggen.Add(sig, siggen.Noise(0.05, len(sig)))
+
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DriftScores(nil); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed without left and right matrix profiles, but got %v", err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	o.LeftRight = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	scores, err := mp.DriftScores(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scores) != len(mp.LMP)-200+1 {
+		t.Fatalf("Expected %d scores, but got %d", len(mp.LMP)-200+1, len(scores))
+	}
+
+	alerts, err := mp.DiscoverDrift(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, a := range alerts {
+		if a.Start < 600 && a.End > 600 {
+			found = true
+		}
+		if a.Start >= 300 && a.Start <= 380 {
+			t.Errorf("Expected no drift within the first regime, but got %+v", a)
+		}
+		if a.Score < 2 || a.Peak < a.Start || a.Peak >= a.End {
+			t.Errorf("Expected a peak scoring at least the threshold within the alert, but got %+v", a)
+		}
+	}
+	if !found {
+		t.Errorf("Expected an alert covering the change at 600, but got %+v", alerts)
+	}
+}
+
+func TestDriftMonitor(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 6), siggen.Sin(1, 10, 0, 0, 100, 3))
+	sig = siggen.Add(sig, siggen.Noise(0.05, len(sig)))
+
+	mp, err := New(sig[:500], nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewDriftMonitor(mp, nil); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed before computing, but got %v", err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	o.LeftRight = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewDriftMonitor(mp, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var alerts []DriftAlert
+	for start := 500; start < len(sig); start += 50 {
+		out, err := m.Push(sig[start : start+50])
+		if err != nil {
+			t.Fatal(err)
+		}
+		alerts = append(alerts, out...)
+	}
+
+	found := false
+	for i, a := range alerts {
+		if i > 0 && a.Start < alerts[i-1].End {
+			t.Errorf("Expected each alert to be reported once, but got %+v after %+v", a, alerts[i-1])
+		}
+		if a.Start < 600 && a.End > 600 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected an alert covering the change at 600, but got %+v", alerts)
+	}
+}
@@ -0,0 +1,66 @@
+package matrixprofile
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// Sentinel errors returned by the package so that callers can branch on the
+// cause of a failure. Errors carrying more detail are returned as an *Error
+// wrapping one of these, use Cause or errors.Is to recover it.
+var (
+	ErrEmptySeries          = errors.New("time series is nil or empty")
+	ErrWindowTooSmall       = errors.New("subsequence length is too small")
+	ErrWindowTooLarge       = errors.New("subsequence length is too large for the time series")
+	ErrNotSelfJoin          = errors.New("operation requires a self join")
+	ErrNotABJoin            = errors.New("operation requires an AB join")
+	ErrNotComputed          = errors.New("matrix profile has not been computed")
+	ErrNotImplemented       = errors.New("not implemented")
+	ErrUnsupportedAlgorithm = errors.New("unsupported algorithm")
+	ErrInvalidFormat        = errors.New("invalid format")
+	ErrInvalidProfile       = errors.New("matrix profile breaks its invariants")
+	ErrNoPlot               = errors.New("visualization is not available when built with the noplot tag")
+	ErrMemoryBudget         = errors.New("computation does not fit the memory budget")
+	ErrSeriesMismatch       = errors.New("time series does not match the one the matrix profile was computed from")
+	ErrZeroStd              = util.ErrZeroStd
+)
+
+// Error describes a failure in detail while keeping one of the sentinel
+// errors as its cause.
+type Error struct {
+	Err    error  // sentinel error describing the cause
+	Detail string // description of this failure
+}
+
+func (e *Error) Error() string {
+	return e.Detail
+}
+
+// Unwrap returns the sentinel error so that errors.Is matches it
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// newError creates an *Error with a formatted detail message
+func newError(err error, format string, args ...interface{}) error {
+	return &Error{Err: err, Detail: fmt.Sprintf(format, args...)}
+}
+
+// Cause returns the sentinel error behind err by unwrapping it, or err itself
+// if it does not wrap another error. This is equivalent to errors.Is for go
+// versions that predate it.
+func Cause(err error) error {
+	for {
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok {
+			return err
+		}
+		next := u.Unwrap()
+		if next == nil {
+			return err
+		}
+		err = next
+	}
+}
@@ -0,0 +1,61 @@
+package matrixprofile
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+func TestErrors(t *testing.T) {
+	a := setupData(100)
+
+	self, err := New(a, nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ab, err := New(a, a[:50], 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKMP([][]float64{a}, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name     string
+		fn       func() error
+		expected error
+	}{
+		{"empty series", func() error { _, err := New(nil, nil, 8); return err }, ErrEmptySeries},
+		{"window too large", func() error { _, err := New(a, nil, 200); return err }, ErrWindowTooLarge},
+		{"window too small", func() error { _, err := New(a, nil, 1); return err }, ErrWindowTooSmall},
+		{"not computed", func() error { return self.UpdateWindow(a[:5], 50) }, ErrNotComputed},
+		{"not self join", func() error { _, err := ab.DiscoverMotifs(1, 2, 10, 4); return err }, ErrNotSelfJoin},
+		{"not ab join", func() error { return self.UpdateB(a[:5]) }, ErrNotABJoin},
+		{"not implemented", func() error { _, err := k.Analyze(nil, nil); return err }, ErrNotImplemented},
+		{"unsupported algorithm", func() error { return self.Compute(&MPOpts{Algorithm: "bogus", SamplePct: 1, NJobs: 1}) }, ErrUnsupportedAlgorithm},
+		{"invalid format", func() error { return self.Encode(&bytes.Buffer{}, "bogus") }, ErrInvalidFormat},
+		{"zero std", func() error { _, err := util.ZNormalize([]float64{1, 1, 1}); return err }, ErrZeroStd},
+	}
+
+	for _, d := range testdata {
+		err := d.fn()
+		if err == nil {
+			t.Errorf("Expected an error for %s", d.name)
+			continue
+		}
+		if Cause(err) != d.expected {
+			t.Errorf("Expected cause %v, but got %v for %s", d.expected, Cause(err), d.name)
+		}
+	}
+
+	err = newError(ErrNotComputed, "matrix profile %d has not been computed", 3)
+	if err.Error() != "matrix profile 3 has not been computed" {
+		t.Errorf("Expected the detail message, but got %q", err.Error())
+	}
+	if Cause(ErrNotComputed) != ErrNotComputed {
+		t.Errorf("Expected a sentinel error to be its own cause")
+	}
+}
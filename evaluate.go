@@ -0,0 +1,132 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// EvalOpts are parameters to vary the algorithms compared by Evaluate.
+type EvalOpts struct {
+	Algorithms []Algo    `json:"algorithms"`  // algorithms to evaluate
+	SamplePcts []float64 `json:"sample_pcts"` // sampling rates to evaluate STAMP at. Other algorithms are always exact
+	NJobs      int       `json:"n_jobs"`      // number of jobs used by every algorithm
+	Seed       int64     `json:"seed"`        // seeds the random ordering of STAMP
+}
+
+// NewEvalOpts returns a default EvalOpts comparing every algorithm with STAMP
+// sampled at 10%, 50% and 100%.
+func NewEvalOpts() *EvalOpts {
+	mo := NewMPOpts()
+	return &EvalOpts{
+		Algorithms: []Algo{AlgoSTMP, AlgoSTAMP, AlgoSTOMP, AlgoMPX},
+		SamplePcts: []float64{0.1, 0.5, 1},
+		NJobs:      mo.NJobs,
+		Seed:       mo.Seed,
+	}
+}
+
+// EvalResult is the runtime and accuracy of a single algorithm and sampling
+// rate relative to the exact matrix profile.
+type EvalResult struct {
+	Algorithm   Algo          `json:"algorithm"`    // algorithm used
+	SamplePct   float64       `json:"sample_pct"`   // sampling rate used
+	Runtime     time.Duration `json:"runtime"`      // wall clock time to compute the matrix profile
+	RMSE        float64       `json:"rmse"`         // root mean squared error of the covered entries
+	MaxError    float64       `json:"max_error"`    // largest absolute error of the covered entries
+	Coverage    float64       `json:"coverage"`     // fraction of entries with a nearest neighbor where the exact profile has one
+	IdxAccuracy float64       `json:"idx_accuracy"` // fraction of entries with the same nearest neighbor as the exact profile
+}
+
+// Evaluate computes the self join matrix profile of a time series with every
+// algorithm, and every sampling rate for STAMP, in the options and reports the
+// runtime and accuracy of each relative to the exact matrix profile computed
+// with MPX. This helps choose the algorithm and sampling rate that trade off
+// speed and accuracy for a given set of data. All distances are euclidean.
+func Evaluate(ts []float64, w int, o *EvalOpts) ([]EvalResult, error) {
+	if o == nil {
+		o = NewEvalOpts()
+	}
+
+	exact, err := New(ts, nil, w)
+	if err != nil {
+		return nil, err
+	}
+	exactOpts := NewMPOpts()
+	exactOpts.NJobs = o.NJobs
+	if err = exact.Compute(exactOpts); err != nil {
+		return nil, err
+	}
+
+	var results []EvalResult
+	for _, algo := range o.Algorithms {
+		samples := []float64{1}
+		if algo == AlgoSTAMP {
+			samples = o.SamplePcts
+		}
+
+		for _, sample := range samples {
+			if sample <= 0 || sample > 1 {
+				return nil, fmt.Errorf("sample percentage must be in (0, 1], got %.3f", sample)
+			}
+
+			mp, err := New(ts, nil, w)
+			if err != nil {
+				return nil, err
+			}
+			mo := NewMPOpts()
+			mo.Algorithm = algo
+			mo.SamplePct = sample
+			mo.NJobs = o.NJobs
+			mo.Seed = o.Seed
+
+			start := time.Now()
+			if err = mp.Compute(mo); err != nil {
+				return nil, err
+			}
+			res := compareProfiles(mp, exact)
+			res.Algorithm = algo
+			res.SamplePct = sample
+			res.Runtime = time.Since(start)
+			results = append(results, res)
+		}
+	}
+
+	return results, nil
+}
+
+// compareProfiles computes the accuracy of a matrix profile relative to the
+// exact matrix profile over the entries the exact profile has a match for
+func compareProfiles(mp, exact *MatrixProfile) EvalResult {
+	var res EvalResult
+	var n, covered, sameIdx int
+	var sse float64
+	for i, d := range exact.MP {
+		if math.IsInf(d, 0) || math.IsNaN(d) {
+			continue
+		}
+		n++
+		if math.IsInf(mp.MP[i], 0) || math.IsNaN(mp.MP[i]) {
+			continue
+		}
+		covered++
+
+		diff := math.Abs(mp.MP[i] - d)
+		sse += diff * diff
+		if diff > res.MaxError {
+			res.MaxError = diff
+		}
+		if mp.Idx[i] == exact.Idx[i] {
+			sameIdx++
+		}
+	}
+
+	if covered > 0 {
+		res.RMSE = math.Sqrt(sse / float64(covered))
+	}
+	if n > 0 {
+		res.Coverage = float64(covered) / float64(n)
+		res.IdxAccuracy = float64(sameIdx) / float64(n)
+	}
+	return res
+}
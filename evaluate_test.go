@@ -0,0 +1,64 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	sig := setupData(200)
+
+	o := NewEvalOpts()
+	o.SamplePcts = []float64{0.2, 1}
+	o.Seed = 5
+	results, err := Evaluate(sig, 16, o)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, but got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Runtime <= 0 {
+			t.Errorf("Expected a positive runtime for %s at %.2f", r.Algorithm, r.SamplePct)
+		}
+		if r.SamplePct == 1 {
+			if r.RMSE > 1e-6 || r.Coverage != 1 {
+				t.Errorf("Expected an exact profile for %s, but got an rmse of %v and coverage of %v", r.Algorithm, r.RMSE, r.Coverage)
+			}
+			continue
+		}
+		if r.RMSE <= 0 || r.IdxAccuracy >= 1 {
+			t.Errorf("Expected an approximate profile for %s at %.2f, but got %+v", r.Algorithm, r.SamplePct, r)
+		}
+	}
+
+	o.SamplePcts = []float64{0}
+	if _, err = Evaluate(sig, 16, o); err == nil {
+		t.Errorf("Expected an error for a sample percentage of 0")
+	}
+	if _, err = Evaluate(sig, 1, nil); err == nil {
+		t.Errorf("Expected an error for a window of 1")
+	}
+}
+
+func TestCompareProfiles(t *testing.T) {
+	inf := math.Inf(1)
+	exact := &MatrixProfile{MP: []float64{1, 2, 3, inf}, Idx: []int{3, 2, 1, 0}}
+	mp := &MatrixProfile{MP: []float64{1, 2.5, inf, inf}, Idx: []int{3, 0, 9, 9}}
+
+	res := compareProfiles(mp, exact)
+	if res.MaxError != 0.5 {
+		t.Errorf("Expected a max error of 0.5, but got %v", res.MaxError)
+	}
+	if res.Coverage != 2.0/3 {
+		t.Errorf("Expected a coverage of 2/3, but got %v", res.Coverage)
+	}
+	if res.IdxAccuracy != 1.0/3 {
+		t.Errorf("Expected an index accuracy of 1/3, but got %v", res.IdxAccuracy)
+	}
+	if res.RMSE != 0.3535533905932738 {
+		t.Errorf("Expected an rmse of 0.3536, but got %v", res.RMSE)
+	}
+}
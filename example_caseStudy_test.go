@@ -1,3 +1,6 @@
+//go:build !noplot
+// +build !noplot
+
 package matrixprofile
 
 import (
@@ -26,7 +29,7 @@ func Example_caseStudy() {
 	ao := NewAnalyzeOpts()
 	ao.OutputFilename = "mp_sine.png"
 
-	if err = mp.Analyze(nil, ao); err != nil {
+	if _, err = mp.Analyze(nil, ao); err != nil {
 		panic(err)
 	}
 
@@ -55,7 +58,7 @@ func Example_kDimensionalCaseStudy() {
 		panic(err)
 	}
 
-	if err = mp.Compute(); err != nil {
+	if err = mp.Compute(nil); err != nil {
 		panic(err)
 	}
 
@@ -0,0 +1,40 @@
+package matrixprofile
+
+import (
+	"sync"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// fftPlans caches fourier transform plans keyed by the transform length so
+// that hot paths such as Update and the STAMP batches do not rebuild the
+// twiddle factors on every call. A plan holds scratch space and is not safe
+// for concurrent use, so each length has a pool that hands a plan to a single
+// goroutine at a time.
+var fftPlans = struct {
+	sync.Mutex
+	pools map[int]*sync.Pool
+}{pools: make(map[int]*sync.Pool)}
+
+// getFFT returns a fourier transform plan of length n from the cache or
+// creates a new one. The plan should be returned with putFFT once done.
+func getFFT(n int) *fourier.FFT {
+	fftPlans.Lock()
+	p, ok := fftPlans.pools[n]
+	if !ok {
+		p = &sync.Pool{New: func() interface{} { return fourier.NewFFT(n) }}
+		fftPlans.pools[n] = p
+	}
+	fftPlans.Unlock()
+	return p.Get().(*fourier.FFT)
+}
+
+// putFFT returns a fourier transform plan to the cache for reuse
+func putFFT(fft *fourier.FFT) {
+	fftPlans.Lock()
+	p, ok := fftPlans.pools[fft.Len()]
+	fftPlans.Unlock()
+	if ok {
+		p.Put(fft)
+	}
+}
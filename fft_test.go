@@ -0,0 +1,45 @@
+package matrixprofile
+
+import (
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestFFTPlanCache(t *testing.T) {
+	for _, n := range []int{16, 17, 100} {
+		fft := getFFT(n)
+		if fft.Len() != n {
+			t.Errorf("Expected a plan of length %d, but got %d", n, fft.Len())
+		}
+		putFFT(fft)
+	}
+
+	// plans handed out concurrently must not share scratch space
+	seq := []float64{1, 2, 3, 4, 5, 6, 7, 8}
+	want := getFFT(len(seq)).Coefficients(nil, seq)
+	var wg sync.WaitGroup
+	errs := make(chan string, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				fft := getFFT(len(seq))
+				got := fft.Coefficients(nil, seq)
+				putFFT(fft)
+				for k := range want {
+					if math.Abs(real(got[k])-real(want[k])) > 1e-9 || math.Abs(imag(got[k])-imag(want[k])) > 1e-9 {
+						errs <- "mismatched coefficients"
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for e := range errs {
+		t.Fatal(e)
+	}
+}
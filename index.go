@@ -0,0 +1,246 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// IndexOpts are parameters to vary the approximate nearest neighbor index
+// built over the subsequences of b.
+type IndexOpts struct {
+	Tables int   `json:"tables"` // number of hash tables. More tables find more true neighbors at the cost of memory.
+	Bits   int   `json:"bits"`   // number of random hyperplanes hashed per table, at most 64. More bits make smaller buckets holding closer subsequences.
+	Seed   int64 `json:"seed"`   // seeds the random hyperplanes so that indexes are reproducible. Defaults to the current time.
+}
+
+// NewIndexOpts returns a default IndexOpts with 8 tables of 12 bits each
+func NewIndexOpts() *IndexOpts {
+	return &IndexOpts{
+		Tables: 8,
+		Bits:   12,
+		Seed:   time.Now().UnixNano(),
+	}
+}
+
+// SubsequenceIndex is a random projection locality sensitive hash index over
+// the z-normalized subsequences of the b time series. Each table hashes a
+// subsequence by which side of a set of random hyperplanes it falls on, so
+// subsequences separated by a small angle, and therefore a small z-normalized
+// euclidean distance, are likely to share a bucket in at least one table.
+type SubsequenceIndex struct {
+	W       int                `json:"w"`       // length of a subsequence
+	N       int                `json:"n"`       // length of b when the index was built
+	Opts    IndexOpts          `json:"options"` // options used to build the index
+	Planes  [][]float64        `json:"planes"`  // random hyperplanes of length W, Bits for each table
+	Buckets []map[uint64][]int `json:"buckets"` // starting indexes of the subsequences of b in each bucket of each table
+}
+
+// IndexPair is a pair of subsequences of b that share a bucket in the index
+// along with their z-normalized euclidean distance.
+type IndexPair struct {
+	I    int     `json:"i"`    // starting index of the first subsequence
+	J    int     `json:"j"`    // starting index of the second subsequence
+	Dist float64 `json:"dist"` // z-normalized euclidean distance between the subsequences
+}
+
+// hash returns the bucket of the z-normalized subsequence q in table t
+func (ix SubsequenceIndex) hash(q []float64, t int) uint64 {
+	var key uint64
+	for b := 0; b < ix.Opts.Bits; b++ {
+		var dot float64
+		for i, p := range ix.Planes[t*ix.Opts.Bits+b] {
+			dot += p * q[i]
+		}
+		if dot >= 0 {
+			key |= 1 << uint(b)
+		}
+	}
+	return key
+}
+
+// BuildIndex builds an approximate nearest neighbor index over every
+// subsequence of b and stores it in mp.Index so that it is saved along with
+// the matrix profile. Constant subsequences have no z-normalized shape and
+// are left out of the index. The index must be built again once b changes.
+func (mp *MatrixProfile) BuildIndex(o *IndexOpts) error {
+	if o == nil {
+		o = NewIndexOpts()
+	}
+	if o.Tables < 1 {
+		return fmt.Errorf("index must have at least 1 table, got %d", o.Tables)
+	}
+	if o.Bits < 1 || o.Bits > 64 {
+		return fmt.Errorf("index bits must be between 1 and 64, got %d", o.Bits)
+	}
+	if mp.W < 2 || mp.W > len(mp.B) {
+		return newError(ErrWindowTooLarge, "subsequence length %d does not fit the time series of length %d", mp.W, len(mp.B))
+	}
+
+	r := rand.New(rand.NewSource(o.Seed))
+	ix := &SubsequenceIndex{
+		W:       mp.W,
+		N:       len(mp.B),
+		Opts:    *o,
+		Planes:  make([][]float64, o.Tables*o.Bits),
+		Buckets: make([]map[uint64][]int, o.Tables),
+	}
+	for i := range ix.Planes {
+		ix.Planes[i] = make([]float64, mp.W)
+		for j := range ix.Planes[i] {
+			ix.Planes[i][j] = r.NormFloat64()
+		}
+	}
+	for t := range ix.Buckets {
+		ix.Buckets[t] = make(map[uint64][]int)
+	}
+
+	for i := 0; i <= len(mp.B)-mp.W; i++ {
+		q, err := util.ZNormalize(mp.B[i : i+mp.W])
+		if err != nil {
+			continue
+		}
+		for t := range ix.Buckets {
+			key := ix.hash(q, t)
+			ix.Buckets[t][key] = append(ix.Buckets[t][key], i)
+		}
+	}
+
+	mp.Index = ix
+	return nil
+}
+
+// checkIndex returns an error if no index was built or it no longer matches
+// the b time series
+func (mp MatrixProfile) checkIndex() error {
+	if mp.Index == nil {
+		return newError(ErrNotComputed, "no subsequence index has been built")
+	}
+	if mp.Index.N != len(mp.B) || mp.Index.W != mp.W {
+		return newError(ErrNotComputed, "subsequence index was built for a time series of length %d and subsequence length %d", mp.Index.N, mp.Index.W)
+	}
+	return nil
+}
+
+// znormDist returns the z-normalized euclidean distance between the
+// z-normalized query qnorm and the subsequence of b at idx, or +Inf if the
+// subsequence is constant
+func (mp MatrixProfile) znormDist(qnorm []float64, idx int) float64 {
+	s, err := util.ZNormalize(mp.B[idx : idx+mp.W])
+	if err != nil {
+		return math.Inf(1)
+	}
+	var d float64
+	for i := range s {
+		d += (s[i] - qnorm[i]) * (s[i] - qnorm[i])
+	}
+	return math.Sqrt(d)
+}
+
+// ApproxQuery finds up to k approximate nearest neighbors of the query in the
+// b time series using the index built by BuildIndex. Only subsequences that
+// share a bucket with the query in some table are compared, so the true
+// nearest neighbor may be missed. The matches are returned closest first with
+// their exact z-normalized euclidean distance, and an exclusion zone is
+// applied around each one in the same manner as RangeQuery.
+func (mp MatrixProfile) ApproxQuery(q []float64, k, exclusionZone int) ([]RangeMatch, error) {
+	if err := mp.checkIndex(); err != nil {
+		return nil, err
+	}
+	if len(q) != mp.W {
+		return nil, fmt.Errorf("query length %d must match the subsequence length %d", len(q), mp.W)
+	}
+	qnorm, err := util.ZNormalize(q)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int]struct{})
+	var cands []RangeMatch
+	for t, buckets := range mp.Index.Buckets {
+		for _, idx := range buckets[mp.Index.hash(qnorm, t)] {
+			if _, ok := seen[idx]; ok {
+				continue
+			}
+			seen[idx] = struct{}{}
+			cands = append(cands, RangeMatch{Idx: idx, Dist: mp.znormDist(qnorm, idx)})
+		}
+	}
+	sort.Slice(cands, func(i, j int) bool {
+		return cands[i].Dist < cands[j].Dist || cands[i].Dist == cands[j].Dist && cands[i].Idx < cands[j].Idx
+	})
+
+	var matches []RangeMatch
+	for _, c := range cands {
+		if len(matches) == k {
+			break
+		}
+		trivial := false
+		for _, m := range matches {
+			if c.Idx > m.Idx-exclusionZone && c.Idx < m.Idx+exclusionZone {
+				trivial = true
+				break
+			}
+		}
+		if !trivial {
+			matches = append(matches, c)
+		}
+	}
+	return matches, nil
+}
+
+// ApproxMotifSeeds returns up to k of the closest pairs of subsequences of b
+// that share a bucket in the index while being at least exclusionZone points
+// apart, closest first. These are candidate motif pairs for seeding a motif
+// search of a series too long for the exact matrix profile. Defaults to half
+// the subsequence length if the exclusion zone is 0.
+func (mp MatrixProfile) ApproxMotifSeeds(k, exclusionZone int) ([]IndexPair, error) {
+	if err := mp.checkIndex(); err != nil {
+		return nil, err
+	}
+	if exclusionZone <= 0 {
+		exclusionZone = mp.W / 2
+	}
+
+	seen := make(map[[2]int]struct{})
+	var pairs []IndexPair
+	for _, buckets := range mp.Index.Buckets {
+		for _, members := range buckets {
+			for a := 0; a < len(members); a++ {
+				qnorm, err := util.ZNormalize(mp.B[members[a] : members[a]+mp.W])
+				if err != nil {
+					continue
+				}
+				for b := a + 1; b < len(members); b++ {
+					i, j := members[a], members[b]
+					if j-i < exclusionZone {
+						continue
+					}
+					if _, ok := seen[[2]int{i, j}]; ok {
+						continue
+					}
+					seen[[2]int{i, j}] = struct{}{}
+					pairs = append(pairs, IndexPair{I: i, J: j, Dist: mp.znormDist(qnorm, j)})
+				}
+			}
+		}
+	}
+	sort.Slice(pairs, func(a, b int) bool {
+		if pairs[a].Dist != pairs[b].Dist {
+			return pairs[a].Dist < pairs[b].Dist
+		}
+		if pairs[a].I != pairs[b].I {
+			return pairs[a].I < pairs[b].I
+		}
+		return pairs[a].J < pairs[b].J
+	})
+
+	if len(pairs) > k {
+		pairs = pairs[:k]
+	}
+	return pairs, nil
+}
@@ -0,0 +1,95 @@
+package matrixprofile
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestSubsequenceIndex(t *testing.T) {
+	pattern := []float64{0, 1, 3, 6, 3, 1, 0, -1}
+	b := make([]float64, 300)
+	for i := range b {
+		b[i] = math.Sin(float64(i)*0.37) * math.Cos(float64(i)*0.11) * 0.2
+	}
+	offsets := []int{20, 130, 250}
+	for _, idx := range offsets {
+		copy(b[idx:], pattern)
+	}
+
+	mp, err := New(setupData(100), b, len(pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.ApproxQuery(pattern, 3, 4); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed without an index, but got %v", err)
+	}
+
+	o := NewIndexOpts()
+	o.Seed = 7
+	if err = mp.BuildIndex(o); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	matches, err := mp.ApproxQuery(pattern, 3, len(pattern)/2)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(matches) != len(offsets) {
+		t.Fatalf("Expected %d matches, but got %v", len(offsets), matches)
+	}
+	found := make(map[int]bool)
+	for _, m := range matches {
+		if m.Dist > 1e-6 {
+			t.Errorf("Expected an exact match, but got %+v", m)
+		}
+		found[m.Idx] = true
+	}
+	for _, idx := range offsets {
+		if !found[idx] {
+			t.Errorf("Expected a match at %d, but got %v", idx, matches)
+		}
+	}
+
+	seeds, err := mp.ApproxMotifSeeds(2, 0)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(seeds) != 2 {
+		t.Fatalf("Expected 2 motif seeds, but got %v", seeds)
+	}
+	for _, s := range seeds {
+		if s.Dist > 1e-6 || !found[s.I] || !found[s.J] {
+			t.Errorf("Expected a seed between two planted patterns, but got %+v", s)
+		}
+	}
+
+	// the index is saved along with the matrix profile
+	var buf bytes.Buffer
+	if err = mp.Encode(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var loaded MatrixProfile
+	if err = loaded.Decode(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	out, err := loaded.ApproxQuery(pattern, 3, len(pattern)/2)
+	if err != nil {
+		t.Fatalf("Did not expect an error after loading, %v", err)
+	}
+	if len(out) != len(matches) {
+		t.Errorf("Expected %v after loading, but got %v", matches, out)
+	}
+
+	// an index no longer matching b is not used
+	mp.B = append(mp.B, 0)
+	if _, err = mp.ApproxQuery(pattern, 3, 4); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed for a stale index, but got %v", err)
+	}
+
+	for _, bad := range []*IndexOpts{{Tables: 0, Bits: 4}, {Tables: 2, Bits: 65}} {
+		if err = mp.BuildIndex(bad); err == nil {
+			t.Errorf("Expected an error for %+v", bad)
+		}
+	}
+}
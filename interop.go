@@ -0,0 +1,470 @@
+package matrixprofile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+// pyFloat is a float decoded from json written by python which may hold NaN
+// and infinite values as bare tokens or strings
+type pyFloat float64
+
+func (f *pyFloat) UnmarshalJSON(b []byte) error {
+	s := strings.Trim(string(b), `"`)
+	switch strings.ToLower(s) {
+	case "nan", "null":
+		*f = pyFloat(math.NaN())
+	case "infinity", "inf":
+		*f = pyFloat(math.Inf(1))
+	case "-infinity", "-inf":
+		*f = pyFloat(math.Inf(-1))
+	default:
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*f = pyFloat(v)
+	}
+	return nil
+}
+
+// quoteNonFinite quotes the NaN, Infinity and -Infinity tokens that python
+// writes into json so that it can be parsed by encoding/json
+func quoteNonFinite(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	var inString, escaped bool
+	for i := 0; i < len(b); i++ {
+		c := b[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			out = append(out, c)
+			continue
+		}
+
+		matched := false
+		for _, tok := range []string{"NaN", "Infinity", "-Infinity"} {
+			if bytes.HasPrefix(b[i:], []byte(tok)) {
+				out = append(out, '"')
+				out = append(out, tok...)
+				out = append(out, '"')
+				i += len(tok) - 1
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		if c == '"' {
+			inString = true
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// mpfProfile is a matrix profile as written by to_json or to_disk of the
+// python matrixprofile library
+type mpfProfile struct {
+	Class     string    `json:"class"`
+	Algorithm string    `json:"algorithm"`
+	Metric    string    `json:"metric"`
+	W         int       `json:"w"`
+	EZ        int       `json:"ez"`
+	Join      bool      `json:"join"`
+	SamplePct float64   `json:"sample_pct"`
+	MP        []pyFloat `json:"mp"`
+	PI        []pyFloat `json:"pi"`
+	LMP       []pyFloat `json:"lmp"`
+	LPI       []pyFloat `json:"lpi"`
+	RMP       []pyFloat `json:"rmp"`
+	RPI       []pyFloat `json:"rpi"`
+	Data      struct {
+		TS    []pyFloat `json:"ts"`
+		Query []pyFloat `json:"query"`
+	} `json:"data"`
+}
+
+// decodeMPF reads a matrix profile written as json by the python
+// matrixprofile library. Only the MatrixProfile class is supported. The
+// profiles of an AB join are indexed by the ts time series as with MPX.
+func (mp *MatrixProfile) decodeMPF(r io.Reader) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var p mpfProfile
+	if err = json.Unmarshal(quoteNonFinite(b), &p); err != nil {
+		return newError(ErrInvalidFormat, "invalid mpf json, %v", err)
+	}
+	if p.Class != "" && p.Class != "MatrixProfile" {
+		return newError(ErrInvalidFormat, "unsupported mpf profile class, %s", p.Class)
+	}
+	if len(p.Data.TS) == 0 {
+		return newError(ErrEmptySeries, "mpf profile has no ts time series")
+	}
+
+	out := MatrixProfile{
+		A:  toFloats(p.Data.TS),
+		W:  p.W,
+		AV: av.Default,
+	}
+	out.B = out.A
+	out.SelfJoin = true
+	if p.Join && len(p.Data.Query) > 0 {
+		out.B = toFloats(p.Data.Query)
+		out.SelfJoin = false
+	}
+	out.N = len(out.B)
+
+	o := NewMPOpts()
+	o.Algorithm = AlgoMPX
+	if Algo(p.Algorithm) == AlgoSTOMP && out.SelfJoin {
+		o.Algorithm = AlgoSTOMP
+	}
+	if p.SamplePct > 0 && p.SamplePct < 1 && out.SelfJoin {
+		o.SamplePct = p.SamplePct
+	}
+	o.Euclidean = p.Metric != "pearson"
+	o.ExclusionZone = p.EZ
+	out.Opts = o
+
+	if out.MP, out.Idx, err = importProfile(p.MP, p.PI, "mp"); err != nil {
+		return err
+	}
+	if len(p.LMP) > 0 {
+		if out.LMP, out.LIdx, err = importProfile(p.LMP, p.LPI, "lmp"); err != nil {
+			return err
+		}
+	}
+	if len(p.RMP) > 0 {
+		if out.RMP, out.RIdx, err = importProfile(p.RMP, p.RPI, "rmp"); err != nil {
+			return err
+		}
+	}
+
+	return mp.setImported(out)
+}
+
+// toFloats converts python floats to a float64 slice
+func toFloats(vals []pyFloat) []float64 {
+	out := make([]float64, len(vals))
+	for i, v := range vals {
+		out[i] = float64(v)
+	}
+	return out
+}
+
+// importProfile converts a profile and its index from another implementation
+// to the conventions of this package where entries without a neighbor, marked
+// with a negative or missing index or a non-finite value, are +Inf with an
+// index of math.MaxInt64
+func importProfile(prof, idx []pyFloat, name string) ([]float64, []int, error) {
+	if len(prof) != len(idx) {
+		return nil, nil, newError(ErrInvalidFormat, "%s has %d values but an index of %d", name, len(prof), len(idx))
+	}
+
+	outProf := make([]float64, len(prof))
+	outIdx := make([]int, len(idx))
+	for i := range prof {
+		v, j := float64(prof[i]), float64(idx[i])
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.IsNaN(j) || j < 0 || j >= math.MaxInt64 {
+			outProf[i], outIdx[i] = math.Inf(1), math.MaxInt64
+			continue
+		}
+		outProf[i], outIdx[i] = v, int(j)
+	}
+	return outProf, outIdx, nil
+}
+
+// setImported replaces the matrix profile with an imported one once its
+// lengths are checked against the time series
+func (mp *MatrixProfile) setImported(out MatrixProfile) error {
+	if out.W < 2 {
+		return newError(ErrWindowTooSmall, "subsequence length must be at least 2, got %d", out.W)
+	}
+	if out.W > len(out.A) || out.W > len(out.B) {
+		return newError(ErrWindowTooLarge, "subsequence length, %d, must be less than the time series", out.W)
+	}
+	if err := out.checkComputed(); err != nil {
+		return newError(ErrInvalidFormat, "imported profile does not match the time series, %v", err)
+	}
+	*mp = out
+	return nil
+}
+
+// npyArray is an array read from a numpy .npy file. Values are stored in row
+// major order.
+type npyArray struct {
+	shape []int
+	data  []float64
+}
+
+var (
+	npyDescr   = regexp.MustCompile(`'descr':\s*'([^']*)'`)
+	npyFortran = regexp.MustCompile(`'fortran_order':\s*(True|False)`)
+	npyShape   = regexp.MustCompile(`'shape':\s*\(([^)]*)\)`)
+)
+
+// readNpy reads a numpy .npy file of little endian floats or integers as
+// float64 values
+func readNpy(r io.Reader) (*npyArray, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 10 || string(b[:6]) != "\x93NUMPY" {
+		return nil, newError(ErrInvalidFormat, "not a npy file")
+	}
+
+	var header string
+	var offset int
+	switch b[6] {
+	case 1:
+		n := int(binary.LittleEndian.Uint16(b[8:10]))
+		offset = 10 + n
+	case 2, 3:
+		if len(b) < 12 {
+			return nil, newError(ErrInvalidFormat, "truncated npy header")
+		}
+		n := int(binary.LittleEndian.Uint32(b[8:12]))
+		offset = 12 + n
+	default:
+		return nil, newError(ErrInvalidFormat, "unsupported npy version, %d", b[6])
+	}
+	if offset > len(b) {
+		return nil, newError(ErrInvalidFormat, "truncated npy header")
+	}
+	header = string(b[:offset])
+
+	descr := npyDescr.FindStringSubmatch(header)
+	fortran := npyFortran.FindStringSubmatch(header)
+	shapeStr := npyShape.FindStringSubmatch(header)
+	if descr == nil || fortran == nil || shapeStr == nil {
+		return nil, newError(ErrInvalidFormat, "invalid npy header, %s", header)
+	}
+
+	arr := &npyArray{}
+	size := 1
+	for _, s := range strings.Split(shapeStr[1], ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		d, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, newError(ErrInvalidFormat, "invalid npy shape, %s", shapeStr[1])
+		}
+		arr.shape = append(arr.shape, d)
+		size *= d
+	}
+
+	var itemSize int
+	var read func([]byte) float64
+	switch descr[1] {
+	case "<f8":
+		itemSize, read = 8, func(p []byte) float64 { return math.Float64frombits(binary.LittleEndian.Uint64(p)) }
+	case "<f4":
+		itemSize, read = 4, func(p []byte) float64 { return float64(math.Float32frombits(binary.LittleEndian.Uint32(p))) }
+	case "<i8":
+		itemSize, read = 8, func(p []byte) float64 { return float64(int64(binary.LittleEndian.Uint64(p))) }
+	case "<i4":
+		itemSize, read = 4, func(p []byte) float64 { return float64(int32(binary.LittleEndian.Uint32(p))) }
+	default:
+		return nil, newError(ErrInvalidFormat, "unsupported npy data type, %s, arrays must be cast to float64 or int64", descr[1])
+	}
+
+	body := b[offset:]
+	if len(body) < size*itemSize {
+		return nil, newError(ErrInvalidFormat, "npy file holds %d bytes but %d are expected", len(body), size*itemSize)
+	}
+	arr.data = make([]float64, size)
+	for i := range arr.data {
+		arr.data[i] = read(body[i*itemSize:])
+	}
+
+	// transpose fortran ordered matrices to row major order
+	if fortran[1] == "True" && len(arr.shape) == 2 {
+		rows, cols := arr.shape[0], arr.shape[1]
+		data := make([]float64, size)
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				data[i*cols+j] = arr.data[j*rows+i]
+			}
+		}
+		arr.data = data
+	}
+	return arr, nil
+}
+
+// column returns column j of a two dimensional array
+func (a npyArray) column(j int) []pyFloat {
+	rows, cols := a.shape[0], a.shape[1]
+	out := make([]pyFloat, rows)
+	for i := range out {
+		out[i] = pyFloat(a.data[i*cols+j])
+	}
+	return out
+}
+
+// values returns the values of a one dimensional array
+func (a npyArray) values() []pyFloat {
+	out := make([]pyFloat, len(a.data))
+	for i, v := range a.data {
+		out[i] = pyFloat(v)
+	}
+	return out
+}
+
+// npz array names used by stumpy along with common alternatives
+var (
+	stumpyProfileNames = []string{"P_", "P", "mp"}
+	stumpyIndexNames   = []string{"I_", "I", "pi"}
+	stumpyLeftNames    = []string{"left_I_", "left_I", "lpi"}
+	stumpyRightNames   = []string{"right_I_", "right_I", "rpi"}
+)
+
+// decodeStumpy reads a matrix profile computed by stumpy and saved with
+// numpy.savez. The archive holds the matrix profile and its index as P_ and
+// I_, along with left_I_ and right_I_ for the left and right indexes, or the
+// output of stump cast to float64 as a single array of 4 columns. Since the
+// archive doesn't hold the time series, the time series and subsequence
+// length must already be set, as with New. The profiles of an AB join are
+// indexed by the first time series as with MPX, and the left and right matrix
+// profiles are computed from their indexes.
+func (mp *MatrixProfile) decodeStumpy(r io.Reader) error {
+	if len(mp.A) == 0 || len(mp.B) == 0 {
+		return newError(ErrEmptySeries, "time series must be set to decode a stumpy matrix profile")
+	}
+
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return newError(ErrInvalidFormat, "invalid npz archive, %v", err)
+	}
+
+	arrays := make(map[string]*npyArray)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		arr, err := readNpy(rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+		arrays[strings.TrimSuffix(f.Name, ".npy")] = arr
+	}
+	find := func(names []string) *npyArray {
+		for _, name := range names {
+			if arr, ok := arrays[name]; ok {
+				return arr
+			}
+		}
+		return nil
+	}
+
+	var prof, idx, left, right []pyFloat
+	p := find(stumpyProfileNames)
+	switch {
+	case p == nil:
+		return newError(ErrInvalidFormat, "npz archive has no matrix profile, expected one of %v", stumpyProfileNames)
+	case len(p.shape) == 2 && p.shape[1] == 4:
+		prof, idx, left, right = p.column(0), p.column(1), p.column(2), p.column(3)
+	case len(p.shape) == 1:
+		prof = p.values()
+		i := find(stumpyIndexNames)
+		if i == nil || len(i.shape) != 1 {
+			return newError(ErrInvalidFormat, "npz archive has no matrix profile index, expected one of %v", stumpyIndexNames)
+		}
+		idx = i.values()
+		if l := find(stumpyLeftNames); l != nil && len(l.shape) == 1 {
+			left = l.values()
+		}
+		if r := find(stumpyRightNames); r != nil && len(r.shape) == 1 {
+			right = r.values()
+		}
+	default:
+		return newError(ErrInvalidFormat, "unsupported matrix profile shape, %v", p.shape)
+	}
+
+	out := MatrixProfile{
+		A:        mp.A,
+		B:        mp.B,
+		N:        len(mp.B),
+		W:        mp.W,
+		SelfJoin: mp.SelfJoin,
+		AV:       av.Default,
+		Times:    mp.Times,
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoMPX
+	o.ExclusionZone = int(math.Ceil(float64(mp.W) / 4))
+	out.Opts = o
+
+	if out.MP, out.Idx, err = importProfile(prof, idx, "P_"); err != nil {
+		return err
+	}
+
+	// left and right indexes only apply to self joins
+	if out.SelfJoin && left != nil && right != nil {
+		if out.LMP, out.LIdx, err = out.neighborProfile(left); err != nil {
+			return err
+		}
+		if out.RMP, out.RIdx, err = out.neighborProfile(right); err != nil {
+			return err
+		}
+	}
+
+	return mp.setImported(out)
+}
+
+// neighborProfile computes the z-normalized euclidean distance from each
+// subsequence of a self join to its neighbor in the given index
+func (mp MatrixProfile) neighborProfile(idx []pyFloat) ([]float64, []int, error) {
+	n := len(mp.A) - mp.W + 1
+	if len(idx) != n {
+		return nil, nil, newError(ErrInvalidFormat, "neighbor index has a length of %d but there are %d subsequences", len(idx), n)
+	}
+
+	prof := make([]pyFloat, n)
+	for i, j := range idx {
+		prof[i] = pyFloat(math.Inf(1))
+		if j < 0 || int(j) >= n {
+			continue
+		}
+		a, err := util.ZNormalize(mp.A[i : i+mp.W])
+		if err != nil {
+			continue
+		}
+		b, err := util.ZNormalize(mp.A[int(j) : int(j)+mp.W])
+		if err != nil {
+			continue
+		}
+		prof[i] = pyFloat(floats.Distance(a, b, 2))
+	}
+	return importProfile(prof, idx, "neighbor profile")
+}
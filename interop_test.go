@@ -0,0 +1,174 @@
+package matrixprofile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDecodeMPF(t *testing.T) {
+	inf := math.Inf(1)
+	profile := `{"class": "MatrixProfile", "algorithm": "mpx", "metric": "euclidean", "w": 4, "ez": 1, "join": false,
+		"sample_pct": 1, "mp": [1, 2, Infinity, 0.5, NaN], "pi": [3, 4, -1, 0, 1],
+		"lmp": [Infinity, "Infinity", 1.5, 0.5, 1], "lpi": [-1, -1, 0, 0, 1], "rmp": null, "rpi": null,
+		"data": {"ts": [0, 1, 0, 2, 0, 1, 3, 1], "query": null}}`
+
+	var mp MatrixProfile
+	if err := mp.Decode(strings.NewReader(profile), "mpf"); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	expectedMP := []float64{1, 2, inf, 0.5, inf}
+	expectedIdx := []int{3, 4, math.MaxInt64, 0, math.MaxInt64}
+	for i := range expectedMP {
+		if mp.MP[i] != expectedMP[i] || mp.Idx[i] != expectedIdx[i] {
+			t.Fatalf("Expected %v, %v, but got %v, %v", expectedMP, expectedIdx, mp.MP, mp.Idx)
+		}
+	}
+	if !mp.SelfJoin || mp.W != 4 || len(mp.A) != 8 || mp.Opts.ExclusionZone != 1 || !mp.Opts.Euclidean {
+		t.Errorf("Expected a euclidean self join with a window of 4, but got %+v with %+v", mp, mp.Opts)
+	}
+	if mp.LMP[1] != inf || mp.LIdx[1] != math.MaxInt64 || mp.LMP[2] != 1.5 || mp.LIdx[2] != 0 || mp.RMP != nil {
+		t.Errorf("Expected a left matrix profile without a right one, but got %v, %v, %v", mp.LMP, mp.LIdx, mp.RMP)
+	}
+	if _, err := mp.DiscoverDiscords(1, 1); err != nil {
+		t.Errorf("Did not expect an error discovering discords, %v", err)
+	}
+
+	// an AB join is indexed by the ts time series
+	join := `{"class": "MatrixProfile", "w": 3, "join": true, "mp": [1, 2], "pi": [0, 1],
+		"data": {"ts": [0, 1, 0, 2], "query": [1, 2, 3]}}`
+	if err := mp.Decode(strings.NewReader(join), "mpf"); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if mp.SelfJoin || len(mp.B) != 3 || !mp.indexedByA() {
+		t.Errorf("Expected an AB join indexed by a, but got %+v", mp)
+	}
+
+	for _, bad := range []string{
+		`{"class": "PMP", "w": 4, "mp": [], "pi": [], "data": {"ts": [0, 1, 0, 2]}}`,
+		`{"class": "MatrixProfile", "w": 4, "mp": [1, 2], "pi": [0], "data": {"ts": [0, 1, 0, 2, 1]}}`,
+		`{"class": "MatrixProfile", "w": 4, "mp": [1], "pi": [0], "data": {"ts": [0, 1, 0, 2, 1]}}`,
+		`{"class": "MatrixProfile", "w": 4, "mp": [1, 2]`,
+	} {
+		if err := mp.Decode(strings.NewReader(bad), "mpf"); Cause(err) != ErrInvalidFormat {
+			t.Errorf("Expected ErrInvalidFormat, but got %v for %s", err, bad)
+		}
+	}
+}
+
+// writeNpy writes a little endian float64 or int64 array in the numpy npy
+// format
+func writeNpy(t *testing.T, zw *zip.Writer, name string, shape string, vals interface{}) {
+	f, err := zw.Create(name + ".npy")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	descr := "<f8"
+	if _, ok := vals.([]int64); ok {
+		descr = "<i8"
+	}
+	header := fmt.Sprintf("{'descr': '%s', 'fortran_order': False, 'shape': (%s), }", descr, shape)
+	header += strings.Repeat(" ", 63-(10+len(header))%64) + "\n"
+
+	var buf bytes.Buffer
+	buf.WriteString("\x93NUMPY\x01\x00")
+	binary.Write(&buf, binary.LittleEndian, uint16(len(header)))
+	buf.WriteString(header)
+	binary.Write(&buf, binary.LittleEndian, vals)
+	if _, err = f.Write(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stumpyIndex converts a matrix profile index to the stumpy convention of -1
+// for entries without a neighbor
+func stumpyIndex(idx []int) []int64 {
+	out := make([]int64, len(idx))
+	for i, j := range idx {
+		out[i] = int64(j)
+		if j == math.MaxInt64 {
+			out[i] = -1
+		}
+	}
+	return out
+}
+
+func TestDecodeStumpy(t *testing.T) {
+	a := setupData(100)
+	w := 8
+
+	exact, err := New(a, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = exact.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	n := len(exact.MP)
+
+	var separate, stacked bytes.Buffer
+	zw := zip.NewWriter(&separate)
+	writeNpy(t, zw, "P_", fmt.Sprintf("%d,", n), exact.MP)
+	writeNpy(t, zw, "I_", fmt.Sprintf("%d,", n), stumpyIndex(exact.Idx))
+	writeNpy(t, zw, "left_I_", fmt.Sprintf("%d,", n), stumpyIndex(exact.LIdx))
+	writeNpy(t, zw, "right_I_", fmt.Sprintf("%d,", n), stumpyIndex(exact.RIdx))
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the output of stump cast to float64
+	out := make([]float64, 0, 4*n)
+	for i := 0; i < n; i++ {
+		out = append(out, exact.MP[i])
+		for _, idx := range [][]int64{stumpyIndex(exact.Idx), stumpyIndex(exact.LIdx), stumpyIndex(exact.RIdx)} {
+			out = append(out, float64(idx[i]))
+		}
+	}
+	zw = zip.NewWriter(&stacked)
+	writeNpy(t, zw, "mp", fmt.Sprintf("%d, 4", n), out)
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, archive := range [][]byte{separate.Bytes(), stacked.Bytes()} {
+		mp, err := New(a, nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mp.Decode(bytes.NewReader(archive), "stumpy"); err != nil {
+			t.Fatalf("Did not expect an error, %v", err)
+		}
+
+		for i := range exact.MP {
+			if mp.MP[i] != exact.MP[i] || mp.Idx[i] != exact.Idx[i] || mp.LIdx[i] != exact.LIdx[i] || mp.RIdx[i] != exact.RIdx[i] {
+				t.Fatalf("Expected the matrix profile and indexes to match at %d", i)
+			}
+			if math.Abs(mp.LMP[i]-exact.LMP[i]) > 1e-6 && !(math.IsInf(mp.LMP[i], 1) && math.IsInf(exact.LMP[i], 1)) {
+				t.Fatalf("Expected a left matrix profile value of %.6f at %d, but got %.6f", exact.LMP[i], i, mp.LMP[i])
+			}
+		}
+		if issues, err := mp.Validate(nil); err != nil {
+			t.Errorf("Expected a valid matrix profile, but got %v, %+v", err, issues)
+		}
+	}
+
+	var mp MatrixProfile
+	if err = mp.Decode(bytes.NewReader(separate.Bytes()), "stumpy"); Cause(err) != ErrEmptySeries {
+		t.Errorf("Expected ErrEmptySeries without a time series, but got %v", err)
+	}
+	short, _ := New(a[:50], nil, w)
+	if err = short.Decode(bytes.NewReader(separate.Bytes()), "stumpy"); Cause(err) != ErrInvalidFormat {
+		t.Errorf("Expected ErrInvalidFormat for a mismatched time series, but got %v", err)
+	}
+	if err = short.Decode(strings.NewReader("not a zip"), "stumpy"); Cause(err) != ErrInvalidFormat {
+		t.Errorf("Expected ErrInvalidFormat for an invalid archive, but got %v", err)
+	}
+}
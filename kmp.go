@@ -2,16 +2,18 @@ package matrixprofile
 
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
-	"os"
+	"runtime"
 	"sort"
+	"sync"
+	"time"
 
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
 	"github.com/matrix-profile-foundation/go-matrixprofile/util"
 	"gonum.org/v1/gonum/dsp/fourier"
-	"gonum.org/v1/plot/plotter"
 )
 
 // KMP is a struct that tracks the current k-dimensional matrix profile
@@ -26,6 +28,13 @@ type KMP struct {
 	W     int            // length of a subsequence
 	MP    [][]float64    // matrix profile
 	Idx   [][]int        // matrix profile index
+	AV    av.AV          // type of annotation vector which defaults to all ones
+	Opts  *KMPOpts       // options used for the computation
+	Times []time.Time    // timestamp of each point if created from time series
+
+	// dimensions of the best subset behind each matrix profile value. Row d
+	// holds d+1 dimensions per subsequence, use Subspace to look them up
+	SubspaceIdx [][]int
 }
 
 // NewKMP creates a matrix profile struct specifically to be used with the k dimensional
@@ -33,13 +42,15 @@ type KMP struct {
 // and each row holds a series of points of equal length as each other.
 func NewKMP(t [][]float64, w int) (*KMP, error) {
 	if t == nil || len(t) == 0 {
-		return nil, fmt.Errorf("slice is nil or has a length of 0 dimensions")
+		return nil, newError(ErrEmptySeries, "slice is nil or has a length of 0 dimensions")
 	}
 
 	k := KMP{
-		T: t,
-		W: w,
-		n: len(t[0]),
+		T:    t,
+		W:    w,
+		n:    len(t[0]),
+		AV:   av.Default,
+		Opts: NewKMPOpts(),
 	}
 
 	// checks that all timeseries have the same length
@@ -50,11 +61,11 @@ func NewKMP(t [][]float64, w int) (*KMP, error) {
 	}
 
 	if k.W*2 >= k.n {
-		return nil, fmt.Errorf("subsequence length must be less than half the timeseries")
+		return nil, newError(ErrWindowTooLarge, "subsequence length must be less than half the timeseries")
 	}
 
 	if k.W < 2 {
-		return nil, fmt.Errorf("subsequence length must be at least 2")
+		return nil, newError(ErrWindowTooSmall, "subsequence length must be at least 2")
 	}
 
 	k.tMean = make([][]float64, len(t))
@@ -62,12 +73,14 @@ func NewKMP(t [][]float64, w int) (*KMP, error) {
 	k.tF = make([][]complex128, len(t))
 	k.MP = make([][]float64, len(t))
 	k.Idx = make([][]int, len(t))
+	k.SubspaceIdx = make([][]int, len(t))
 	for d := 0; d < len(t); d++ {
 		k.tMean[d] = make([]float64, k.n-k.W+1)
 		k.tStd[d] = make([]float64, k.n-k.W+1)
 		k.tF[d] = make([]complex128, k.n-k.W+1)
 		k.MP[d] = make([]float64, k.n-k.W+1)
 		k.Idx[d] = make([]int, k.n-k.W+1)
+		k.SubspaceIdx[d] = make([]int, (d+1)*(k.n-k.W+1))
 	}
 
 	for d := 0; d < len(t); d++ {
@@ -84,49 +97,57 @@ func NewKMP(t [][]float64, w int) (*KMP, error) {
 	return &k, nil
 }
 
-// Save will save the current matrix profile struct to disk
+// Save will save the current matrix profile struct to disk. Any existing file
+// at filepath is truncated.
 func (k KMP) Save(filepath, format string) error {
-	var err error
+	return saveFile(filepath, false, func(w io.Writer) error {
+		return k.Encode(w, format)
+	})
+}
+
+// SaveAtomic will save the current matrix profile struct to disk by first writing
+// to a temporary file in the same directory and then renaming it over filepath.
+// Readers will either see the previous contents or the new contents in full.
+func (k KMP) SaveAtomic(filepath, format string) error {
+	return saveFile(filepath, true, func(w io.Writer) error {
+		return k.Encode(w, format)
+	})
+}
+
+// Encode writes the current k-dimensional matrix profile struct to w in the specified format
+func (k KMP) Encode(w io.Writer, format string) error {
 	switch format {
 	case "json":
-		f, err := os.Open(filepath)
-		if err != nil {
-			f, err = os.Create(filepath)
-			if err != nil {
-				return err
-			}
-		}
-		defer f.Close()
 		out, err := json.Marshal(k)
 		if err != nil {
 			return err
 		}
-		_, err = f.Write(out)
+		_, err = w.Write(out)
+		return err
 	default:
-		return fmt.Errorf("invalid save format, %s", format)
+		return newError(ErrInvalidFormat, "invalid save format, %s", format)
 	}
-	return err
 }
 
 // Load will attempt to load a matrix profile from a file for iterative use
 func (k *KMP) Load(filepath, format string) error {
-	var err error
+	return loadFile(filepath, func(r io.Reader) error {
+		return k.Decode(r, format)
+	})
+}
+
+// Decode reads a k-dimensional matrix profile in the specified format from r into the struct
+func (k *KMP) Decode(r io.Reader, format string) error {
 	switch format {
 	case "json":
-		f, err := os.Open(filepath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		b, err := ioutil.ReadAll(f)
+		b, err := ioutil.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		err = json.Unmarshal(b, k)
+		return json.Unmarshal(b, k)
 	default:
-		return fmt.Errorf("invalid load format, %s", format)
+		return newError(ErrInvalidFormat, "invalid load format, %s", format)
 	}
-	return err
 }
 
 // initCaches initializes cached data including the timeseries a and b rolling mean
@@ -144,7 +165,8 @@ func (k *KMP) initCaches() error {
 
 	// precompute the fourier transform of the b timeseries since it will
 	// be used multiple times while computing the matrix profile
-	fft := fourier.NewFFT(k.n)
+	fft := getFFT(k.n)
+	defer putFFT(fft)
 	for d := 0; d < len(k.T); d++ {
 		k.tF[d] = fft.Coefficients(nil, k.T[d])
 	}
@@ -152,9 +174,48 @@ func (k *KMP) initCaches() error {
 	return nil
 }
 
-// Compute runs a k dimensional matrix profile calculation across all time series
-func (k *KMP) Compute() error {
-	return k.mStomp()
+// KMPOpts are parameters to vary the algorithm to compute the k-dimensional
+// matrix profile.
+type KMPOpts struct {
+	Algorithm Algo `json:"algorithm"` // AlgoSTOMP for mSTOMP or AlgoMPX for the pearson correlation kernel of MPX
+	NJobs     int  `json:"n_jobs"`    // number of goroutines the MPX kernel splits the diagonals across
+}
+
+// NewKMPOpts returns a default KMPOpts
+func NewKMPOpts() *KMPOpts {
+	p := runtime.NumCPU() * 2
+	if p < 1 {
+		p = 1
+	}
+	return &KMPOpts{
+		Algorithm: AlgoSTOMP,
+		NJobs:     p,
+	}
+}
+
+// Compute runs a k dimensional matrix profile calculation across all time series.
+// If o is not nil, its Algorithm and NJobs replace those of the KMP options and
+// its other fields are ignored. Otherwise the KMP options are used. The MPX
+// kernel computes each distance from an incrementally updated pearson
+// correlation rather than a fourier transform, which is more numerically
+// stable and faster on long time series.
+func (k *KMP) Compute(o *MPOpts) error {
+	if k.Opts == nil {
+		k.Opts = NewKMPOpts()
+	}
+	if o != nil {
+		k.Opts.Algorithm = o.Algorithm
+		k.Opts.NJobs = o.NJobs
+	}
+
+	switch k.Opts.Algorithm {
+	case AlgoSTOMP:
+		return k.mStomp()
+	case AlgoMPX:
+		return k.mpx(k.Opts.NJobs)
+	default:
+		return newError(ErrUnsupportedAlgorithm, "Unsupported algorithm for k-dimensional matrix profile, %s", k.Opts.Algorithm)
+	}
 }
 
 // MStomp computes the k dimensional matrix profile
@@ -164,7 +225,8 @@ func (k *KMP) mStomp() error {
 	// save the first dot product of the first row that will be used by all future
 	// go routines
 	cachedDots := make([][]float64, len(k.T))
-	fft := fourier.NewFFT(k.n)
+	fft := getFFT(k.n)
+	defer putFFT(fft)
 	k.crossCorrelate(0, fft, cachedDots)
 
 	var D [][]float64
@@ -179,6 +241,12 @@ func (k *KMP) mStomp() error {
 		copy(dots[d], cachedDots[d])
 	}
 
+	// order tracks the dimensions of each column from closest to farthest
+	order := make([][]int, k.n-k.W+1)
+	for i := range order {
+		order[i] = make([]int, len(k.T))
+	}
+
 	for idx := 0; idx < k.n-k.W+1; idx++ {
 		for d := 0; d < len(dots); d++ {
 			if idx > 0 {
@@ -195,7 +263,7 @@ func (k *KMP) mStomp() error {
 			util.ApplyExclusionZone(D[d], idx, k.W/2)
 		}
 
-		k.columnWiseSort(D)
+		k.columnWiseSort(D, order)
 		k.columnWiseCumSum(D)
 
 		for d := 0; d < len(D); d++ {
@@ -203,6 +271,10 @@ func (k *KMP) mStomp() error {
 				if D[d][i]/(float64(d)+1) < k.MP[d][i] {
 					k.MP[d][i] = D[d][i] / (float64(d) + 1)
 					k.Idx[d][i] = idx
+
+					sub := k.SubspaceIdx[d][i*(d+1) : (i+1)*(d+1)]
+					copy(sub, order[i][:d+1])
+					sort.Ints(sub)
 				}
 			}
 		}
@@ -211,6 +283,145 @@ func (k *KMP) mStomp() error {
 	return err
 }
 
+// kmpResult is the output of a batch of diagonals of the MPX kernel
+type kmpResult struct {
+	MP          [][]float64
+	Idx         [][]int
+	SubspaceIdx [][]int
+}
+
+// newKMPResult creates an empty result shaped like the k-dimensional matrix profile
+func (k KMP) newKMPResult() *kmpResult {
+	lenA := k.n - k.W + 1
+	r := &kmpResult{
+		MP:          make([][]float64, len(k.T)),
+		Idx:         make([][]int, len(k.T)),
+		SubspaceIdx: make([][]int, len(k.T)),
+	}
+	for d := range k.T {
+		r.MP[d] = make([]float64, lenA)
+		r.Idx[d] = make([]int, lenA)
+		r.SubspaceIdx[d] = make([]int, (d+1)*lenA)
+		for i := range r.MP[d] {
+			r.MP[d][i] = math.Inf(1)
+			r.Idx[d][i] = math.MaxInt64
+		}
+	}
+	return r
+}
+
+// update stores the distance of the subsequence at i to its neighbor at nn
+// for the best d+1 dimensions if it is closer than the current value. Ties go
+// to the earliest neighbor as in mSTOMP.
+func (r *kmpResult) update(d, i, nn int, dist float64, dims []int) bool {
+	if !(dist < r.MP[d][i] || (dist == r.MP[d][i] && nn < r.Idx[d][i])) {
+		return false
+	}
+	r.MP[d][i] = dist
+	r.Idx[d][i] = nn
+	sub := r.SubspaceIdx[d][i*(d+1) : (i+1)*(d+1)]
+	copy(sub, dims[:d+1])
+	sort.Ints(sub)
+	return true
+}
+
+// mpx computes the k dimensional matrix profile by walking the diagonals of
+// the distance matrix in the manner of MPX. The per dimension pearson
+// correlations along a diagonal are updated in constant time, converted to
+// distances and then sorted and averaged in the same manner as mSTOMP.
+func (k *KMP) mpx(nJobs int) error {
+	if nJobs < 1 {
+		nJobs = 1
+	}
+	lenA := k.n - k.W + 1
+
+	mu := make([][]float64, len(k.T))
+	sig := make([][]float64, len(k.T))
+	df := make([][]float64, len(k.T))
+	dg := make([][]float64, len(k.T))
+	for d, ts := range k.T {
+		mu[d], sig[d] = util.MuInvN(ts, k.W)
+		df[d] = make([]float64, lenA)
+		dg[d] = make([]float64, lenA)
+		for i := 0; i < lenA-1; i++ {
+			df[d][i+1] = 0.5 * (ts[k.W+i] - ts[i])
+			dg[d][i+1] = (ts[k.W+i] - mu[d][1+i]) + (ts[i] - mu[d][i])
+		}
+	}
+
+	batchScheme := util.DiagBatchingScheme(lenA, nJobs)
+	results := make([]*kmpResult, nJobs)
+	var wg sync.WaitGroup
+	wg.Add(nJobs)
+	for batch := 0; batch < nJobs; batch++ {
+		go func(batchNum int) {
+			defer wg.Done()
+			b := batchScheme[batchNum]
+			results[batchNum] = k.mpxBatch(b.Idx, b.Size, mu, sig, df, dg)
+		}(batch)
+	}
+	wg.Wait()
+
+	merged := k.newKMPResult()
+	for _, r := range results {
+		for d := range r.MP {
+			for i, dist := range r.MP[d] {
+				merged.update(d, i, r.Idx[d][i], dist, r.SubspaceIdx[d][i*(d+1):(i+1)*(d+1)])
+			}
+		}
+	}
+	k.MP, k.Idx, k.SubspaceIdx = merged.MP, merged.Idx, merged.SubspaceIdx
+
+	return nil
+}
+
+// mpxBatch processes a batch of diagonals of the k dimensional distance matrix
+func (k KMP) mpxBatch(idx, batchSize int, mu, sig, df, dg [][]float64) *kmpResult {
+	lenA := k.n - k.W + 1
+	exclZone := k.W / 2
+	r := k.newKMPResult()
+
+	c := make([]float64, len(k.T))
+	dist := make([]float64, len(k.T))
+	dims := make([]int, len(k.T))
+	for diag := idx + exclZone; diag < idx+batchSize+exclZone; diag++ {
+		if diag >= lenA {
+			break
+		}
+
+		for d, ts := range k.T {
+			c[d] = 0
+			for i := 0; i < k.W; i++ {
+				c[d] += (ts[diag+i] - mu[d][diag]) * (ts[i] - mu[d][0])
+			}
+		}
+
+		for offset := 0; offset < lenA-diag; offset++ {
+			for d := range k.T {
+				c[d] += df[d][offset]*dg[d][offset+diag] + df[d][offset+diag]*dg[d][offset]
+				dist[d] = math.Sqrt(2 * float64(k.W) * math.Abs(1-c[d]*sig[d][offset]*sig[d][offset+diag]))
+				dims[d] = d
+			}
+			sortDims(dist, dims)
+
+			var sum float64
+			for d := range dist {
+				sum += dist[d]
+				avg := sum / float64(d+1)
+
+				// the exclusion zone of mSTOMP only reaches the later
+				// subsequence at exactly the zone boundary
+				r.update(d, offset+diag, offset, avg, dims)
+				if diag > exclZone {
+					r.update(d, offset, offset+diag, avg, dims)
+				}
+			}
+		}
+	}
+
+	return r
+}
+
 // crossCorrelate computes the sliding dot product between two slices
 // given a query and time series. Uses fast fourier transforms to compute
 // the necessary values. Returns the a slice of floats for the cross-correlation
@@ -242,16 +453,38 @@ func (k KMP) crossCorrelate(idx int, fft *fourier.FFT, D [][]float64) {
 	}
 }
 
-func (k KMP) columnWiseSort(D [][]float64) {
+// columnWiseSort sorts each column of D across dimensions in ascending order.
+// If order is not nil, the dimension each sorted value came from is stored in
+// the order slice of the column.
+func (k KMP) columnWiseSort(D [][]float64, order [][]int) {
 	dist := make([]float64, len(D))
+	dims := make([]int, len(D))
 	for i := 0; i < k.n-k.W+1; i++ {
 		for d := 0; d < len(D); d++ {
 			dist[d] = D[d][i]
+			dims[d] = d
 		}
-		sort.Float64s(dist)
+		sortDims(dist, dims)
+
 		for d := 0; d < len(D); d++ {
 			D[d][i] = dist[d]
 		}
+		if order != nil {
+			copy(order[i], dims)
+		}
+	}
+}
+
+// sortDims sorts the distances of each dimension in ascending order along
+// with the dimension they belong to. This is an insertion sort since there are
+// only a few dimensions. NaNs are ordered first in the same manner as
+// sort.Float64s.
+func sortDims(dist []float64, dims []int) {
+	for a := 1; a < len(dist); a++ {
+		for b := a; b > 0 && (dist[b] < dist[b-1] || (math.IsNaN(dist[b]) && !math.IsNaN(dist[b-1]))); b-- {
+			dist[b], dist[b-1] = dist[b-1], dist[b]
+			dims[b], dims[b-1] = dims[b-1], dims[b]
+		}
 	}
 }
 
@@ -266,19 +499,70 @@ func (k KMP) columnWiseCumSum(D [][]float64) {
 	}
 }
 
+// Subspace returns the dimensions, in ascending order, that make up the best
+// subset of d+1 dimensions for the subsequence at idx. These are the
+// dimensions behind the matrix profile value MP[d][idx].
+func (k KMP) Subspace(d, idx int) ([]int, error) {
+	if d < 0 || d >= len(k.SubspaceIdx) {
+		return nil, fmt.Errorf("dimension %d is outside of the %d dimensions", d, len(k.SubspaceIdx))
+	}
+	if idx < 0 || (idx+1)*(d+1) > len(k.SubspaceIdx[d]) {
+		return nil, fmt.Errorf("index %d is outside of the matrix profile", idx)
+	}
+	if k.Idx[d][idx] == math.MaxInt64 {
+		return nil, fmt.Errorf("no subspace was found for index %d", idx)
+	}
+	return append([]int(nil), k.SubspaceIdx[d][idx*(d+1):(idx+1)*(d+1)]...), nil
+}
+
+// ApplyAV applies the annotation vector to each row of the k-dimensional matrix
+// profile and returns the corrected profiles. An annotation vector is created
+// for each dimension's timeseries and, since each row of the profile combines
+// several dimensions, the element wise mean of those vectors is applied to
+// every row. Annotation vector values must be between 0 and 1.
+func (k KMP) ApplyAV() ([][]float64, error) {
+	var avec []float64
+	for d := range k.T {
+		davec, err := av.Create(k.AV, k.T[d], k.W)
+		if err != nil {
+			return nil, fmt.Errorf("dimension %d, %v", d, err)
+		}
+		if len(davec) != len(k.MP[d]) {
+			return nil, fmt.Errorf("dimension %d annotation vector length, %d, does not match matrix profile length, %d", d, len(davec), len(k.MP[d]))
+		}
+		if avec == nil {
+			avec = make([]float64, len(davec))
+		}
+		for i, val := range davec {
+			avec[i] += val / float64(len(k.T))
+		}
+	}
+
+	out := make([][]float64, len(k.MP))
+	var err error
+	for d := range k.MP {
+		out[d], err = applyAVec(k.MP[d], avec)
+		if err != nil {
+			return nil, fmt.Errorf("dimension %d, %v", d, err)
+		}
+	}
+
+	return out, nil
+}
+
 // Analyze has not been implemented yet
-func (k KMP) Analyze(mo *MPOpts, ao *AnalyzeOpts) error {
-	return errors.New("Analyze for KMP has not been implemented yet.")
+func (k KMP) Analyze(mo *MPOpts, ao *AnalyzeOpts) (*AnalysisResult, error) {
+	return nil, newError(ErrNotImplemented, "Analyze for KMP has not been implemented yet.")
 }
 
 // DiscoverMotifs has not been implemented yet
-func (k KMP) DiscoverMotifs(kMotifs int, r float64) ([]MotifGroup, error) {
-	return nil, errors.New("Motifs for KMP has not been implemented yet.")
+func (k KMP) DiscoverMotifs(kMotifs int, r float64, neighborCount, exclusionZone int) ([]MotifGroup, error) {
+	return nil, newError(ErrNotImplemented, "Motifs for KMP has not been implemented yet.")
 }
 
 // DiscoverDiscords has not been implemented yet
 func (k KMP) DiscoverDiscords(kDiscords int, exclusionZone int) ([]int, error) {
-	return nil, errors.New("Discords for KMP has not been implemented yet.")
+	return nil, newError(ErrNotImplemented, "Discords for KMP has not been implemented yet.")
 }
 
 // DiscoverSegments has not been implemented yet
@@ -286,17 +570,21 @@ func (k KMP) DiscoverSegments() (int, float64, []float64) {
 	return 0, 0, nil
 }
 
-// Visualize creates a png of the k-dimensional matrix profile.
+// Visualize creates an image of the k-dimensional matrix profile. The image
+// format is chosen from the file extension and defaults to png.
 func (k KMP) Visualize(fn string) error {
-	sigPts := make([]plotter.XYs, len(k.T))
-	for i := 0; i < len(k.T); i++ {
-		sigPts[i] = points(k.T[i], len(k.T[0]))
-	}
+	o := newKMPVisualizeOpts()
+	o.Format = formatFromFilename(fn, o.Format)
 
-	mpPts := make([]plotter.XYs, len(k.MP))
-	for i := 0; i < len(k.MP); i++ {
-		mpPts[i] = points(k.MP[i], len(k.T[0]))
-	}
+	return saveFile(fn, false, func(w io.Writer) error {
+		return k.VisualizeTo(w, o)
+	})
+}
 
-	return plotKMP(sigPts, mpPts, fn)
+// newKMPVisualizeOpts returns the default visualization options for a k-dimensional
+// matrix profile which is drawn as a single column
+func newKMPVisualizeOpts() *VisualizeOpts {
+	o := NewVisualizeOpts()
+	o.Width = 600
+	return o
 }
@@ -37,7 +37,26 @@ func BenchmarkMStomp(b *testing.B) {
 	}
 
 	for i := 0; i < b.N; i++ {
-		err = mp.Compute()
+		err = mp.Compute(nil)
+		if err != nil {
+			b.Error(err)
+		}
+		if len(mp.MP) < 1 || len(mp.Idx) < 1 {
+			b.Error("expected at least one dimension from matrix profile and matrix profile index")
+		}
+	}
+}
+
+func BenchmarkKMPMPX(b *testing.B) {
+	sig := setupKData()
+	mp, err := NewKMP(sig, 25)
+	if err != nil {
+		b.Error(err)
+	}
+	mp.Opts.Algorithm = AlgoMPX
+
+	for i := 0; i < b.N; i++ {
+		err = mp.Compute(nil)
 		if err != nil {
 			b.Error(err)
 		}
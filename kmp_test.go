@@ -3,9 +3,14 @@ package matrixprofile
 import (
 	"math"
 	"os"
+	"reflect"
+	"sort"
 	"testing"
 
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
 	"gonum.org/v1/gonum/dsp/fourier"
+	"gonum.org/v1/gonum/floats"
 )
 
 func TestNewKMP(t *testing.T) {
@@ -95,8 +100,9 @@ func TestKCrossCorrelate(t *testing.T) {
 
 func TestColumnWiseSort(t *testing.T) {
 	testdata := []struct {
-		d         [][]float64
-		expectedD [][]float64
+		d             [][]float64
+		expectedD     [][]float64
+		expectedOrder [][]int
 	}{
 		{
 			[][]float64{
@@ -109,12 +115,24 @@ func TestColumnWiseSort(t *testing.T) {
 				{2, 2, 3},
 				{3, 4, 4},
 				{4, 6, 9}},
+			[][]int{
+				{0, 1, 2, 3},
+				{3, 2, 0, 1},
+				{3, 2, 1, 0}},
 		},
 	}
 
 	for _, d := range testdata {
 		mp := &KMP{W: 5, n: 7}
-		mp.columnWiseSort(d.d)
+		order := make([][]int, mp.n-mp.W+1)
+		for i := range order {
+			order[i] = make([]int, len(d.d))
+		}
+		mp.columnWiseSort(d.d, order)
+
+		if !reflect.DeepEqual(order, d.expectedOrder) {
+			t.Errorf("Expected order %v, but got %v", d.expectedOrder, order)
+		}
 
 		if len(d.d) != len(d.expectedD) {
 			t.Errorf("Expected %d dimensions, but got %d, %+v", len(d.expectedD), len(d.d), d)
@@ -164,7 +182,7 @@ func TestMStomp(t *testing.T) {
 			}
 		}
 
-		err = mp.Compute()
+		err = mp.Compute(nil)
 		if err != nil {
 			if d.expectedMP == nil {
 				// Got an error while z normalizing and expected an error
@@ -195,11 +213,132 @@ func TestMStomp(t *testing.T) {
 	}
 }
 
+func TestKMPSubspace(t *testing.T) {
+	ts := [][]float64{
+		{0, 0, 1, 1, 0, 0, 0, 1, 1, 0, 0, 2, 1, 3, 0},
+		{0, 0, -1, -1, 0, 0, 0, -1, -1, 0, 0, 1, 0, 2, 1},
+		{0, 0, 0, 1, 0, 1, 1, 0, 0, 1, 0, 3, 1, 0, 2},
+	}
+	w := 4
+
+	mp, err := NewKMP(ts, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for d := range mp.MP {
+		for i := range mp.MP[d] {
+			sub, err := mp.Subspace(d, i)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, for dimension %d at %d", err, d, i)
+			}
+			if len(sub) != d+1 || !sort.IntsAreSorted(sub) {
+				t.Fatalf("Expected %d sorted dimensions, but got %v", d+1, sub)
+			}
+
+			// the mean distance over the subspace to the nearest neighbor is
+			// the matrix profile value
+			nn := mp.Idx[d][i]
+			var sum float64
+			for _, dim := range sub {
+				a, _ := util.ZNormalize(ts[dim][i : i+w])
+				b, _ := util.ZNormalize(ts[dim][nn : nn+w])
+				sum += floats.Distance(a, b, 2)
+			}
+			if math.Abs(sum/float64(d+1)-mp.MP[d][i]) > 1e-6 {
+				t.Errorf("Expected subspace %v to give %.6f, but got %.6f for dimension %d at %d", sub, mp.MP[d][i], sum/float64(d+1), d, i)
+			}
+		}
+	}
+
+	if _, err = mp.Subspace(len(ts), 0); err == nil {
+		t.Errorf("Expected an error for a dimension out of range")
+	}
+	if _, err = mp.Subspace(0, len(mp.MP[0])); err == nil {
+		t.Errorf("Expected an error for an index out of range")
+	}
+}
+
+func TestKMPMPX(t *testing.T) {
+	ts := [][]float64{setupData(200), setupData(200), setupData(200)}
+	w := 12
+
+	expected, err := NewKMP(ts, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = expected.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, nJobs := range []int{1, 4} {
+		mp, err := NewKMP(ts, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mp.Opts.Algorithm = AlgoMPX
+		mp.Opts.NJobs = nJobs
+		if err = mp.Compute(nil); err != nil {
+			t.Fatalf("Did not expect an error, %v, for %d jobs", err, nJobs)
+		}
+
+		for d := range expected.MP {
+			for i := range expected.MP[d] {
+				if math.Abs(mp.MP[d][i]-expected.MP[d][i]) > 1e-6 {
+					t.Fatalf("Expected %.6f, but got %.6f for dimension %d at %d with %d jobs", expected.MP[d][i], mp.MP[d][i], d, i, nJobs)
+				}
+				if _, err = mp.Subspace(d, i); err != nil {
+					t.Fatalf("Did not expect an error, %v, for dimension %d at %d with %d jobs", err, d, i, nJobs)
+				}
+			}
+		}
+	}
+
+	// the algorithm and number of jobs of the matrix profile options replace
+	// those of the KMP options
+	mp, err := NewKMP(ts, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoMPX
+	o.NJobs = 3
+	if err = mp.Compute(o); err != nil {
+		t.Fatalf("Did not expect an error, %v, with matrix profile options", err)
+	}
+	if mp.Opts.Algorithm != AlgoMPX || mp.Opts.NJobs != 3 {
+		t.Errorf("Expected the KMP options to be MPX with 3 jobs, but got %v", *mp.Opts)
+	}
+	for d := range expected.MP {
+		for i := range expected.MP[d] {
+			if math.Abs(mp.MP[d][i]-expected.MP[d][i]) > 1e-6 {
+				t.Fatalf("Expected %.6f, but got %.6f for dimension %d at %d with matrix profile options", expected.MP[d][i], mp.MP[d][i], d, i)
+			}
+		}
+	}
+
+	mp, err = NewKMP(ts, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp.Opts.Algorithm = AlgoSTAMP
+	if err = mp.Compute(nil); err == nil {
+		t.Errorf("Expected an error for an unsupported algorithm")
+	}
+	o.Algorithm = AlgoSTAMP
+	if err = mp.Compute(o); Cause(err) != ErrUnsupportedAlgorithm {
+		t.Errorf("Expected an unsupported algorithm error from the matrix profile options, but got %v", err)
+	}
+}
+
 func TestKMPSave(t *testing.T) {
 	ts := [][]float64{{1, 2, 3, 4, 5, 6, 7, 8, 9}}
 	m := 3
 	p, err := NewKMP(ts, m)
-	p.Compute()
+	p.Compute(nil)
 	filepath := "./kmp.json"
 	err = p.Save(filepath, "json")
 	if err != nil {
@@ -214,7 +353,7 @@ func TestKMPLoad(t *testing.T) {
 	ts := [][]float64{{1, 2, 3, 4, 5, 6, 7, 8, 9}}
 	w := 3
 	p, err := NewKMP(ts, w)
-	p.Compute()
+	p.Compute(nil)
 	filepath := "./kmp.json"
 	if err = p.Save(filepath, "json"); err != nil {
 		t.Errorf("Received error while saving matrix profile, %v", err)
@@ -237,3 +376,43 @@ func TestKMPLoad(t *testing.T) {
 	}
 
 }
+
+func TestKMPApplyAV(t *testing.T) {
+	k, err := NewKMP([][]float64{
+		{0, 0, 1, 1, 0, 0, 0, 1, 1, 0, 0},
+		{0, 0, -1, -1, 0, 0, 0, -1, -1, 0, 0},
+		{0, 0, 0, 1, 0, 1, 1, 0, 0, 1, 0}}, 4)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new kmp", err)
+	}
+	if err = k.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+
+	out, err := k.ApplyAV()
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while applying the annotation vector", err)
+	}
+	for d := range k.MP {
+		for i := range k.MP[d] {
+			if math.Abs(out[d][i]-k.MP[d][i]) > 1e-9 {
+				t.Fatalf("Expected no change with the default annotation vector, but got %v for %v", out[d], k.MP[d])
+			}
+		}
+	}
+
+	k.AV = av.MeanStd
+	if out, err = k.ApplyAV(); err != nil {
+		t.Fatalf("Did not expect an error, %v, while applying the annotation vector", err)
+	}
+	for d := range k.MP {
+		if len(out[d]) != len(k.MP[d]) {
+			t.Errorf("Expected %d elements, but got %d for dimension %d", len(k.MP[d]), len(out[d]), d)
+		}
+	}
+
+	k.MP[1] = k.MP[1][1:]
+	if _, err = k.ApplyAV(); err == nil {
+		t.Errorf("Expected an error for a profile that does not match the annotation vector length")
+	}
+}
@@ -0,0 +1,104 @@
+package matrixprofile
+
+import (
+	"math"
+)
+
+// LibraryMatch is the closest subsequence of the b time series to any
+// subsequence of one pattern of a library.
+type LibraryMatch struct {
+	Pattern int     `json:"pattern"` // index of the pattern in the library
+	Offset  int     `json:"offset"`  // starting index of the matching subsequence within the pattern
+	AIdx    int     `json:"a_idx"`   // starting index of the matching subsequence in the concatenated library
+	BIdx    int     `json:"b_idx"`   // starting index of the match in b
+	Dist    float64 `json:"dist"`    // z-normalized euclidean distance of the match. +Inf if no match has a defined distance
+}
+
+// LibraryJoin is the AB join of a library of short reference patterns, a,
+// with a time series, b, where no subsequence of a straddles two patterns.
+type LibraryJoin struct {
+	A        []float64      `json:"a"`          // patterns concatenated with a NaN separator between each
+	Starts   []int          `json:"starts"`     // starting index of each pattern in a
+	W        int            `json:"w"`          // length of a subsequence
+	MPB      []float64      `json:"mp_ba"`      // euclidean distance from each subsequence of b to its nearest neighbor in the library
+	IdxB     []int          `json:"pi_ba"`      // starting index in a of the nearest neighbor of each subsequence of b
+	PatternB []int          `json:"pattern_ba"` // pattern holding the nearest neighbor of each subsequence of b. -1 if there is none
+	Matches  []LibraryMatch `json:"matches"`    // best match in b of each pattern
+}
+
+// JoinLibrary joins a library of reference patterns with the time series b
+// using a subsequence length of w in a single pass over the library. The
+// patterns are concatenated into one query time series with a NaN separator
+// between each pattern, and only subsequences lying entirely within a pattern
+// are compared against b. Every pattern must be at least w long. This is
+// suited to dictionary based detection where each pattern is a known event
+// and the best match of each one in b is wanted.
+func JoinLibrary(patterns [][]float64, b []float64, w int) (*LibraryJoin, error) {
+	if len(patterns) == 0 {
+		return nil, newError(ErrEmptySeries, "pattern library is empty")
+	}
+	if b == nil {
+		return nil, newError(ErrEmptySeries, "time series to join the pattern library with must not be nil")
+	}
+
+	lj := &LibraryJoin{W: w, Starts: make([]int, len(patterns))}
+	for p, pat := range patterns {
+		if len(pat) < w {
+			return nil, newError(ErrWindowTooLarge, "pattern %d of length %d is shorter than the subsequence length %d", p, len(pat), w)
+		}
+		if p > 0 {
+			lj.A = append(lj.A, math.NaN())
+		}
+		lj.Starts[p] = len(lj.A)
+		lj.A = append(lj.A, pat...)
+	}
+
+	mp, err := New(lj.A, b, w)
+	if err != nil {
+		return nil, err
+	}
+	if err = mp.initCaches(); err != nil {
+		return nil, err
+	}
+
+	n := len(b) - w + 1
+	lj.MPB, lj.IdxB = initProfile(nil, nil, n, false)
+	lj.PatternB = make([]int, n)
+	for j := range lj.PatternB {
+		lj.PatternB[j] = -1
+	}
+	lj.Matches = make([]LibraryMatch, len(patterns))
+
+	prof := make([]float64, n)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for p, pat := range patterns {
+		best := LibraryMatch{Pattern: p, AIdx: lj.Starts[p], BIdx: -1, Dist: math.Inf(1)}
+		for off := 0; off <= len(pat)-w; off++ {
+			i := lj.Starts[p] + off
+			err = mp.distanceProfile(i, prof, fft)
+			if Cause(err) == ErrZeroStd {
+				// constant subsequences of a pattern match nothing
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			for j, d := range prof {
+				// constant subsequences have no defined distance
+				if math.IsNaN(d) {
+					continue
+				}
+				if d < lj.MPB[j] {
+					lj.MPB[j], lj.IdxB[j], lj.PatternB[j] = d, i, p
+				}
+				if d < best.Dist {
+					best.Offset, best.AIdx, best.BIdx, best.Dist = off, i, j, d
+				}
+			}
+		}
+		lj.Matches[p] = best
+	}
+
+	return lj, nil
+}
@@ -0,0 +1,73 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestJoinLibrary(t *testing.T) {
+	patterns := [][]float64{
+		{0, 1, 3, 6, 3, 1, 0, -1},
+		{5, -5, 5, -5, 5, -5},
+	}
+	offsets := []int{40, 12}
+
+	b := make([]float64, 80)
+	for i := range b {
+		b[i] = math.Sin(float64(i)*0.37) * math.Cos(float64(i)*0.13)
+	}
+	for p, off := range offsets {
+		copy(b[off:], patterns[p])
+	}
+
+	lj, err := JoinLibrary(patterns, b, 6)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	if len(lj.A) != len(patterns[0])+len(patterns[1])+1 || !math.IsNaN(lj.A[len(patterns[0])]) {
+		t.Errorf("Expected patterns separated by a NaN, but got %v", lj.A)
+	}
+	if lj.Starts[0] != 0 || lj.Starts[1] != len(patterns[0])+1 {
+		t.Errorf("Expected pattern starts of [0 %d], but got %v", len(patterns[0])+1, lj.Starts)
+	}
+	if len(lj.MPB) != len(b)-5 || len(lj.IdxB) != len(lj.MPB) || len(lj.PatternB) != len(lj.MPB) {
+		t.Fatalf("Expected profiles of length %d, but got %d", len(b)-5, len(lj.MPB))
+	}
+
+	for p, m := range lj.Matches {
+		if m.Pattern != p || m.Dist > 1e-6 {
+			t.Errorf("Expected an exact match for pattern %d, but got %+v", p, m)
+		}
+		if m.BIdx-m.Offset != offsets[p] || m.AIdx != lj.Starts[p]+m.Offset {
+			t.Errorf("Expected pattern %d to match at %d in b, but got %+v", p, offsets[p], m)
+		}
+	}
+
+	for j, idx := range lj.IdxB {
+		p := lj.PatternB[j]
+		if p < 0 {
+			continue
+		}
+		// no nearest neighbor straddles two patterns
+		if idx < lj.Starts[p] || idx+lj.W > lj.Starts[p]+len(patterns[p]) {
+			t.Errorf("Expected the neighbor of %d within pattern %d, but got index %d", j, p, idx)
+		}
+	}
+
+	testdata := []struct {
+		patterns [][]float64
+		b        []float64
+		w        int
+	}{
+		{nil, b, 6},
+		{patterns, nil, 6},
+		{patterns, b, 7},
+		{patterns, b, 1},
+	}
+	for _, d := range testdata {
+		if _, err = JoinLibrary(d.patterns, d.b, d.w); err == nil {
+			t.Errorf("Expected an error for %d patterns with a window of %d", len(d.patterns), d.w)
+		}
+	}
+}
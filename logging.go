@@ -0,0 +1,26 @@
+package matrixprofile
+
+// Logger receives debug and progress logs from the computation and discovery
+// of a matrix profile, such as the algorithm chosen, the parallelism, the
+// batching scheme and timings. Each log is a message followed by alternating
+// keys and values, which maps onto most structured logging libraries. Set it
+// on each MatrixProfile to log from, nothing is logged if nil.
+type Logger interface {
+	Log(msg string, keyvals ...interface{})
+}
+
+// LoggerFunc adapts an ordinary function to the Logger interface
+type LoggerFunc func(msg string, keyvals ...interface{})
+
+// Log calls f(msg, keyvals...)
+func (f LoggerFunc) Log(msg string, keyvals ...interface{}) {
+	f(msg, keyvals...)
+}
+
+// log sends a message and its key value pairs to the logger of the matrix
+// profile if one is set
+func (mp MatrixProfile) log(msg string, keyvals ...interface{}) {
+	if mp.Logger != nil {
+		mp.Logger.Log(msg, keyvals...)
+	}
+}
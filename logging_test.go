@@ -0,0 +1,61 @@
+package matrixprofile
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var mu sync.Mutex
+	logged := make(map[string]int)
+
+	mp, err := New(setupData(300), nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp.Logger = LoggerFunc(func(msg string, keyvals ...interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(keyvals)%2 != 0 {
+			t.Errorf("Expected alternating keys and values for %q, but got %v", msg, keyvals)
+		}
+		logged[msg]++
+	})
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		o := NewMPOpts()
+		o.Algorithm = algo
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = mp.DiscoverMotifs(2, 2, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverDiscords(2, 8); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]int{
+		"computing matrix profile": 2,
+		"computed matrix profile":  2,
+		"batching diagonals":       1,
+		"batching rows":            1,
+		"discovered motifs":        1,
+		"discovered discords":      1,
+	}
+	for msg, n := range expected {
+		if logged[msg] != n {
+			t.Errorf("Expected %q to be logged %d times, but got %d", msg, n, logged[msg])
+		}
+	}
+
+	o := NewMPOpts()
+	o.ExclusionZone = -1
+	if err = mp.Compute(o); err == nil {
+		t.Fatal("Expected an error for a negative exclusion zone")
+	}
+	if logged["matrix profile computation failed"] != 1 {
+		t.Errorf("Expected the failed computation to be logged")
+	}
+}
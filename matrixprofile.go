@@ -3,23 +3,24 @@ package matrixprofile
 
 import (
 	"container/heap"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/rand"
-	"os"
 	"runtime"
 	"sort"
-	"strconv"
 	"sync"
+	"time"
 
 	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/preprocess"
 	"github.com/matrix-profile-foundation/go-matrixprofile/util"
 	"gonum.org/v1/gonum/dsp/fourier"
 	"gonum.org/v1/gonum/floats"
-	"gonum.org/v1/plot/plotter"
 )
 
 // MatrixProfile is a struct that tracks the current matrix profile computation
@@ -28,11 +29,11 @@ import (
 type MatrixProfile struct {
 	A        []float64    `json:"a"`                 // query time series
 	B        []float64    `json:"b"`                 // timeseries to perform full join with
-	AMean    []float64    `json:"a_mean"`            // sliding mean of a with a window of m each
-	AStd     []float64    `json:"a_std"`             // sliding standard deviation of a with a window of m each
-	BMean    []float64    `json:"b_mean"`            // sliding mean of b with a window of m each
-	BStd     []float64    `json:"b_std"`             // sliding standard deviation of b with a window of m each
-	BF       []complex128 `json:"b_fft"`             // holds an existing calculation of the FFT of b timeseries
+	AMean    []float64    `json:"a_mean,omitempty"`  // sliding mean of a with a window of m each
+	AStd     []float64    `json:"a_std,omitempty"`   // sliding standard deviation of a with a window of m each
+	BMean    []float64    `json:"b_mean,omitempty"`  // sliding mean of b with a window of m each
+	BStd     []float64    `json:"b_std,omitempty"`   // sliding standard deviation of b with a window of m each
+	BF       []complex128 `json:"b_fft,omitempty"`   // holds an existing calculation of the FFT of b timeseries
 	N        int          `json:"n"`                 // length of the timeseries
 	W        int          `json:"w"`                 // length of a subsequence
 	SelfJoin bool         `json:"self_join"`         // indicates whether a self join is performed with an exclusion zone
@@ -40,10 +41,55 @@ type MatrixProfile struct {
 	Idx      []int        `json:"pi"`                // matrix profile index
 	MPB      []float64    `json:"mp_ba"`             // matrix profile for the BA join
 	IdxB     []int        `json:"pi_ba"`             // matrix profile index for the BA join
+	LMP      []float64    `json:"lmp,omitempty"`     // left matrix profile with nearest neighbors only before each subsequence
+	LIdx     []int        `json:"lpi,omitempty"`     // left matrix profile index
+	RMP      []float64    `json:"rmp,omitempty"`     // right matrix profile with nearest neighbors only after each subsequence
+	RIdx     []int        `json:"rpi,omitempty"`     // right matrix profile index
+	Corr     []float64    `json:"corr,omitempty"`    // pearson correlation of each subsequence with its nearest neighbor, only set with the KeepCorr option
 	AV       av.AV        `json:"annotation_vector"` // type of annotation vector which defaults to all ones
 	Opts     *MPOpts      `json:"options"`           // options used for the computation
+	Times    []time.Time  `json:"times,omitempty"`   // timestamp of each point in a if created from a time series
 	Motifs   []MotifGroup
 	Discords []int
+
+	// trend and seasonal components removed from a if created from a
+	// decomposition
+	Decomposition *preprocess.Decomposition `json:"decomposition,omitempty"`
+
+	// approximate nearest neighbor index over the subsequences of b if built
+	// by BuildIndex
+	Index *SubsequenceIndex `json:"index,omitempty"`
+
+	// statistics of the naive recomputation of a sample of the matrix profile
+	// if computed with the Verify option
+	Verification *Verification `json:"verification,omitempty"`
+
+	// arc curve of the matrix profile index kept up to date by Update and
+	// UpdateWindow once tracked with TrackArcs
+	Arcs *ArcCurve `json:"-"`
+
+	// receives debug logs from computation and discovery if set
+	Logger Logger `json:"-"`
+
+	// version, algorithm and time of the last computation along with the
+	// lengths and checksums of the time series when encoded
+	Provenance *Provenance `json:"provenance,omitempty"`
+
+	// weighted sliding sums of b cached when the options set weights
+	bWSum   []float64
+	bWSqSum []float64
+
+	// sliding dot product of the last subsequence of b with a cached by UpdateB
+	bQT    []float64
+	bQTIdx int
+
+	// set while Compute runs and left set if it fails so that discovery
+	// doesn't operate on a partially computed matrix profile
+	stale bool
+
+	// set when decoded with checksums but without the time series so that
+	// updates and discovery wait for Attach
+	unverified bool
 }
 
 // New creates a matrix profile struct with a given timeseries length n and
@@ -52,11 +98,11 @@ type MatrixProfile struct {
 // assumes a self join on the first timeseries.
 func New(a, b []float64, w int) (*MatrixProfile, error) {
 	if a == nil || len(a) == 0 {
-		return nil, fmt.Errorf("first slice is nil or has a length of 0")
+		return nil, newError(ErrEmptySeries, "first slice is nil or has a length of 0")
 	}
 
 	if b != nil && len(b) == 0 {
-		return nil, fmt.Errorf("second slice must be nil for self-join operation or have a length greater than 0")
+		return nil, newError(ErrEmptySeries, "second slice must be nil for self-join operation or have a length greater than 0")
 	}
 
 	mp := MatrixProfile{
@@ -73,11 +119,11 @@ func New(a, b []float64, w int) (*MatrixProfile, error) {
 	}
 
 	if mp.W > len(mp.A) || mp.W > len(mp.B) {
-		return nil, fmt.Errorf("subsequence length must be less than the timeseries")
+		return nil, newError(ErrWindowTooLarge, "subsequence length must be less than the timeseries")
 	}
 
 	if mp.W < 2 {
-		return nil, fmt.Errorf("subsequence length must be at least 2")
+		return nil, newError(ErrWindowTooSmall, "subsequence length must be at least 2")
 	}
 
 	mp.AV = av.Default
@@ -90,15 +136,20 @@ func applySingleAV(mp, ts []float64, w int, a av.AV) ([]float64, error) {
 	if err != nil {
 		return nil, err
 	}
+	return applyAVec(mp, avec)
+}
 
+// applyAVec applies an already created annotation vector to a matrix profile
+// of euclidean distances
+func applyAVec(mp, avec []float64) ([]float64, error) {
 	if len(avec) != len(mp) {
 		return nil, fmt.Errorf("annotation vector length, %d, does not match matrix profile length, %d", len(avec), len(mp))
 	}
 
-	// find the maximum matrix profile value
+	// find the maximum finite matrix profile value
 	maxMP := 0.0
 	for _, val := range mp {
-		if val > maxMP {
+		if val > maxMP && !math.IsInf(val, 1) {
 			maxMP = val
 		}
 	}
@@ -121,8 +172,27 @@ func applySingleAV(mp, ts []float64, w int, a av.AV) ([]float64, error) {
 }
 
 // ApplyAV applies an annotation vector to the current matrix profile. Annotation vector
-// values must be between 0 and 1.
+// values must be between 0 and 1. The profiles are returned in the units of the
+// matrix profile, so as pearson correlations if it was computed without
+// euclidean distances.
 func (mp MatrixProfile) ApplyAV() ([]float64, []float64, error) {
+	abmp, bamp, err := mp.applyAVEuclidean()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !mp.Opts.Euclidean {
+		euclideanToPearson(abmp, mp.W)
+		euclideanToPearson(bamp, mp.W)
+	}
+
+	return abmp, bamp, nil
+}
+
+// applyAVEuclidean applies the annotation vector in the same manner as ApplyAV
+// but always returns euclidean distances, so that the smallest value is the
+// closest match regardless of the units of the matrix profile.
+func (mp MatrixProfile) applyAVEuclidean() ([]float64, []float64, error) {
 	var err error
 	abmp := make([]float64, len(mp.MP))
 	bamp := make([]float64, len(mp.MPB))
@@ -130,8 +200,8 @@ func (mp MatrixProfile) ApplyAV() ([]float64, []float64, error) {
 	copy(abmp, mp.MP)
 	copy(bamp, mp.MPB)
 	if !mp.Opts.Euclidean {
-		util.P2E(abmp, mp.W)
-		util.P2E(bamp, mp.W)
+		pearsonToEuclidean(abmp, mp.W)
+		pearsonToEuclidean(bamp, mp.W)
 	}
 
 	abmp, err = applySingleAV(abmp, mp.A, mp.W, mp.AV)
@@ -147,57 +217,256 @@ func (mp MatrixProfile) ApplyAV() ([]float64, []float64, error) {
 		return nil, nil, err
 	}
 
+	return abmp, bamp, nil
+}
+
+// guidedProfile returns the euclidean matrix profile corrected by an
+// annotation vector given at call time without changing mp.AV. The values of
+// avec are applied if set, otherwise the annotation vector a if set, otherwise
+// the annotation vector of the matrix profile.
+func (mp MatrixProfile) guidedProfile(a av.AV, avec []float64) ([]float64, error) {
+	if avec == nil {
+		if a != "" {
+			mp.AV = a
+		}
+		prof, _, err := mp.applyAVEuclidean()
+		return prof, err
+	}
+
+	prof := append([]float64(nil), mp.MP...)
 	if !mp.Opts.Euclidean {
-		util.E2P(abmp, mp.W)
-		util.E2P(bamp, mp.W)
+		pearsonToEuclidean(prof, mp.W)
 	}
+	return applyAVec(prof, avec)
+}
 
-	return abmp, bamp, nil
+// pearsonToEuclidean converts pearson correlations to z-normalized euclidean
+// distances in place. Unlike util.P2E, undefined entries stay at +Inf instead
+// of becoming a distance of 0.
+func pearsonToEuclidean(prof []float64, w int) {
+	for i, v := range prof {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			prof[i] = math.Inf(1)
+			continue
+		}
+		util.P2E(prof[i:i+1], w)
+	}
 }
 
-// Save will save the current matrix profile struct to disk
+// euclideanToPearson converts z-normalized euclidean distances to pearson
+// correlations in place, leaving undefined entries at +Inf.
+func euclideanToPearson(prof []float64, w int) {
+	for i, v := range prof {
+		if math.IsInf(v, 0) || math.IsNaN(v) {
+			prof[i] = math.Inf(1)
+			continue
+		}
+		util.E2P(prof[i:i+1], w)
+	}
+}
+
+// ToEuclidean converts a matrix profile computed with pearson correlations to
+// z-normalized euclidean distances in place, including the BA join and left
+// and right matrix profiles. The options are updated to match so that later
+// discovery treats the profiles as distances. Profiles that are already
+// euclidean are left unchanged.
+func (mp *MatrixProfile) ToEuclidean() error {
+	if mp.Opts == nil || mp.MP == nil {
+		return ErrNotComputed
+	}
+	if mp.Opts.Euclidean {
+		return nil
+	}
+
+	for _, prof := range [][]float64{mp.MP, mp.MPB, mp.LMP, mp.RMP} {
+		pearsonToEuclidean(prof, mp.W)
+	}
+	o := *mp.Opts
+	o.Euclidean = true
+	mp.Opts = &o
+	return nil
+}
+
+// ToPearson converts a matrix profile of z-normalized euclidean distances to
+// pearson correlations in place in the same manner as ToEuclidean. Distances
+// beyond that of uncorrelated subsequences are reported as a correlation of 0.
+// Mean-centered or weighted distances have no pearson equivalent and return an
+// error.
+func (mp *MatrixProfile) ToPearson() error {
+	if mp.Opts == nil || mp.MP == nil {
+		return ErrNotComputed
+	}
+	if !mp.Opts.Euclidean {
+		return nil
+	}
+	if mp.meanCentered() || mp.Opts.Weights != nil {
+		return errors.New("only z-normalized unweighted distances can be converted to pearson correlations")
+	}
+
+	for _, prof := range [][]float64{mp.MP, mp.MPB, mp.LMP, mp.RMP} {
+		euclideanToPearson(prof, mp.W)
+	}
+	o := *mp.Opts
+	o.Euclidean = false
+	o.RemapNegCorr = false
+	mp.Opts = &o
+	return nil
+}
+
+// Save will save the current matrix profile struct to disk. Any existing file
+// at filepath is truncated.
 func (mp MatrixProfile) Save(filepath, format string) error {
+	return saveFile(filepath, false, func(w io.Writer) error {
+		return mp.Encode(w, format)
+	})
+}
+
+// SaveAtomic will save the current matrix profile struct to disk by first writing
+// to a temporary file in the same directory and then renaming it over filepath.
+// Readers will either see the previous contents or the new contents in full.
+func (mp MatrixProfile) SaveAtomic(filepath, format string) error {
+	return saveFile(filepath, true, func(w io.Writer) error {
+		return mp.Encode(w, format)
+	})
+}
+
+// SaveOpts are parameters to vary how a matrix profile is saved or encoded.
+type SaveOpts struct {
+	Format     string `json:"format"`      // encoding format, "json" or "json_caches" as for Encode
+	Atomic     bool   `json:"atomic"`      // writes to a temporary file renamed over the destination as with SaveAtomic. Only applies to SaveWithOpts.
+	OmitSeries bool   `json:"omit_series"` // leaves out the values of the a and b time series along with the caches and decomposition derived from them, such as for sensitive data. Their lengths and checksums are kept in the provenance and Attach binds them again after decoding.
+}
+
+// NewSaveOpts returns a default SaveOpts encoding json with the time series
+func NewSaveOpts() *SaveOpts {
+	return &SaveOpts{Format: "json"}
+}
+
+// SaveWithOpts saves the matrix profile to disk in the same manner as Save
+// while choosing the format, atomicity and whether the raw time series are
+// written with the options.
+func (mp MatrixProfile) SaveWithOpts(filepath string, o *SaveOpts) error {
+	if o == nil {
+		o = NewSaveOpts()
+	}
+	return saveFile(filepath, o.Atomic, func(w io.Writer) error {
+		return mp.EncodeWithOpts(w, o)
+	})
+}
+
+// EncodeWithOpts writes the matrix profile to w in the same manner as Encode
+// while choosing the format and whether the raw time series are written with
+// the options. A profile encoded without its time series still holds their
+// lengths and checksums, so it can be decoded and bound to them again with
+// Attach before updating or discovering anything that needs the raw values.
+func (mp MatrixProfile) EncodeWithOpts(w io.Writer, o *SaveOpts) error {
+	if o == nil {
+		o = NewSaveOpts()
+	}
+	if !o.OmitSeries {
+		return mp.Encode(w, o.Format)
+	}
+	if o.Format != "json" && o.Format != "json_caches" {
+		return newError(ErrInvalidFormat, "invalid save format, %s", o.Format)
+	}
+
+	// the checksums are recorded even for a profile that was never computed
+	// so that the time series can be attached again
+	if mp.Provenance == nil {
+		mp.Provenance = &Provenance{Version: moduleVersion()}
+	}
+	mp.Provenance = mp.encodedProvenance()
+	mp.A, mp.B = nil, nil
+	mp.AMean, mp.AStd, mp.BMean, mp.BStd, mp.BF = nil, nil, nil, nil, nil
+	mp.Decomposition = nil
+	out, err := json.Marshal(mpJSON(mp))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// mpJSON has the fields of a MatrixProfile without its custom JSON encoding
+type mpJSON MatrixProfile
+
+// MarshalJSON encodes the matrix profile without the sliding means, standard
+// deviations and fourier transform cached while computing. These are several
+// times larger than the profile itself and are derived again from the time
+// series when next needed.
+func (mp MatrixProfile) MarshalJSON() ([]byte, error) {
+	mp.AMean, mp.AStd, mp.BMean, mp.BStd, mp.BF = nil, nil, nil, nil, nil
+	return json.Marshal(mpJSON(mp))
+}
+
+// Encode writes the current matrix profile struct to w in the specified format.
+// The "json" format omits cached data as in MarshalJSON while "json_caches"
+// also includes the sliding means and standard deviations. The fourier
+// transform of b is never encoded. The method is not named WriteTo to avoid
+// clashing with io.WriterTo. The provenance of a computed matrix profile is
+// encoded with the lengths and checksums of the current time series.
+func (mp MatrixProfile) Encode(w io.Writer, format string) error {
+	var out []byte
 	var err error
+	mp.Provenance = mp.encodedProvenance()
 	switch format {
 	case "json":
-		f, err := os.Open(filepath)
-		if err != nil {
-			f, err = os.Create(filepath)
-			if err != nil {
-				return err
-			}
-		}
-		defer f.Close()
-		out, err := json.Marshal(mp)
-		if err != nil {
-			return err
-		}
-		_, err = f.Write(out)
+		out, err = json.Marshal(mp)
+	case "json_caches":
+		mp.BF = nil
+		out, err = json.Marshal(mpJSON(mp))
 	default:
-		return fmt.Errorf("invalid save format, %s", format)
+		return newError(ErrInvalidFormat, "invalid save format, %s", format)
 	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
 	return err
 }
 
 // Load will attempt to load a matrix profile from a file for iterative use
 func (mp *MatrixProfile) Load(filepath, format string) error {
-	var err error
+	return loadFile(filepath, func(r io.Reader) error {
+		return mp.Decode(r, format)
+	})
+}
+
+// Decode reads a matrix profile in the specified format from r into the struct.
+// Besides the "json" formats written by Encode, "mpf" reads the json written
+// by the python matrixprofile library and "stumpy" reads a numpy npz archive
+// of a matrix profile computed by stumpy. The time series and subsequence
+// length must already be set for "stumpy", as with New, since the archive
+// only holds the matrix profile. The method is not named ReadFrom to avoid
+// clashing with io.ReaderFrom. The "json" formats check the time series
+// against the checksums of the provenance and return ErrSeriesMismatch if
+// they differ. A profile saved without its time series can't be updated until
+// they are attached with Attach.
+func (mp *MatrixProfile) Decode(r io.Reader, format string) error {
+	mp.Provenance, mp.unverified = nil, false
 	switch format {
-	case "json":
-		f, err := os.Open(filepath)
+	case "mpf":
+		return mp.decodeMPF(r)
+	case "stumpy":
+		return mp.decodeStumpy(r)
+	case "json", "json_caches":
+		b, err := ioutil.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		b, err := ioutil.ReadAll(f)
-		if err != nil {
+		// caches from a previous profile must not outlive it as they are
+		// derived again when missing
+		mp.AMean, mp.AStd, mp.BMean, mp.BStd, mp.BF = nil, nil, nil, nil, nil
+		mp.Index = nil
+		mp.Arcs = nil
+		mp.stale = false
+		if err = json.Unmarshal(b, mp); err != nil {
 			return err
 		}
-		err = json.Unmarshal(b, mp)
+		return mp.verifyDecoded()
 	default:
-		return fmt.Errorf("invalid load format, %s", format)
+		return newError(ErrInvalidFormat, "invalid load format, %s", format)
 	}
-	return err
 }
 
 type mpVals []float64
@@ -226,16 +495,32 @@ func (m *mpVals) Pop() interface{} {
 	return x
 }
 
+// MPDistOpts are parameters to vary the computation of the matrix profile distance.
 type MPDistOpts struct {
-	AV   av.AV
-	Opts *MPOpts
+	AV        av.AV
+	Opts      *MPOpts
+	Threshold float64 // fraction of the combined length of a and b used to pick the k-th smallest distance. Defaults to 0.05 if 0
 }
 
+// NewMPDistOpts returns a default MPDistOpts
 func NewMPDistOpts() *MPDistOpts {
 	return &MPDistOpts{
-		AV:   av.Default,
-		Opts: NewMPOpts(),
+		AV:        av.Default,
+		Opts:      NewMPOpts(),
+		Threshold: 0.05,
+	}
+}
+
+// threshold returns the threshold of the options, defaulting to 0.05 if it
+// is unset
+func (o MPDistOpts) threshold() (float64, error) {
+	if o.Threshold == 0 {
+		return 0.05, nil
 	}
+	if o.Threshold < 0 || o.Threshold > 1 {
+		return 0, fmt.Errorf("threshold must be greater than 0 and at most 1, threshold: %.3f", o.Threshold)
+	}
+	return o.Threshold, nil
 }
 
 // MPDist computes the matrix profile distance measure between a and b with a
@@ -245,22 +530,27 @@ func MPDist(a, b []float64, w int, o *MPDistOpts) (float64, error) {
 		o = NewMPDistOpts()
 	}
 
+	threshold, err := o.threshold()
+	if err != nil {
+		return 0, err
+	}
+
 	mp, err := New(a, b, w)
 	if err != nil {
 		return 0, err
 	}
 
 	if err = mp.Compute(o.Opts); err != nil {
-		return 0, nil
+		return 0, err
 	}
 
+	mp.AV = o.AV
 	mpab, mpba, err := mp.ApplyAV()
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
-	thresh := 0.05
-	k := int(thresh * float64(len(a)+len(b)))
+	k := int(threshold * float64(len(a)+len(b)))
 	mpABBASize := len(mpab) + len(mpba)
 
 	if k < mpABBASize {
@@ -337,6 +627,111 @@ func MPDist(a, b []float64, w int, o *MPDistOpts) (float64, error) {
 	return trackVal, nil
 }
 
+// MPDistVector computes the matrix profile distance between a and every
+// subsequence of b of length l using a subsequence window of w. The i-th
+// element of the output is equivalent to MPDist(a, b[i:i+l], w, o) but each
+// distance profile of a against b is only computed once. Only the default
+// annotation vector is supported, since the correction of any other depends
+// on the whole matrix profile of each window.
+func MPDistVector(a, b []float64, w, l int, o *MPDistOpts) ([]float64, error) {
+	if o == nil {
+		o = NewMPDistOpts()
+	}
+
+	threshold, err := o.threshold()
+	if err != nil {
+		return nil, err
+	}
+
+	if o.AV != "" && o.AV != av.Default {
+		return nil, fmt.Errorf("annotation vector %s is not supported by MPDistVector, only %s", o.AV, av.Default)
+	}
+
+	if l < w || l > len(b) {
+		return nil, fmt.Errorf("window length, %d, must be at least the subsequence length, %d, and at most the length of b, %d", l, w, len(b))
+	}
+
+	mp, err := New(a, b, w)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = mp.initCaches(); err != nil {
+		return nil, err
+	}
+
+	lenA := len(a) - w + 1
+	lenB := len(b) - w + 1
+	subLen := l - w + 1
+	nWindows := len(b) - l + 1
+
+	// colMin holds the nearest neighbor distance of each subsequence in b to a, while
+	// rowMin holds the nearest neighbor distance of each subsequence in a to every
+	// window of b
+	colMin := make([]float64, lenB)
+	for i := range colMin {
+		colMin[i] = math.Inf(1)
+	}
+	rowMin := make([][]float64, lenA)
+
+	profile := make([]float64, lenB)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for i := 0; i < lenA; i++ {
+		if err = mp.distanceProfile(i, profile, fft); err != nil {
+			return nil, err
+		}
+		for j, d := range profile {
+			if d < colMin[j] {
+				colMin[j] = d
+			}
+		}
+		rowMin[i] = movMin(profile, subLen)
+	}
+
+	k := int(threshold * float64(len(a)+l))
+	dists := make([]float64, lenA+subLen)
+	out := make([]float64, nWindows)
+	for i := 0; i < nWindows; i++ {
+		for j := 0; j < lenA; j++ {
+			dists[j] = rowMin[j][i]
+		}
+		copy(dists[lenA:], colMin[i:i+subLen])
+		sort.Float64s(dists)
+		if k < len(dists) {
+			out[i] = dists[k]
+		} else {
+			out[i] = dists[len(dists)-1]
+		}
+	}
+
+	if o.Opts != nil && !o.Opts.Euclidean {
+		util.E2P(out, w)
+	}
+
+	return out, nil
+}
+
+// movMin computes the minimum of each sliding window of length w over a
+// slice of floats in linear time using a monotonic queue of indices.
+func movMin(x []float64, w int) []float64 {
+	out := make([]float64, len(x)-w+1)
+	q := make([]int, 0, w)
+	for i, v := range x {
+		for len(q) > 0 && x[q[len(q)-1]] >= v {
+			q = q[:len(q)-1]
+		}
+		q = append(q, i)
+		if q[0] <= i-w {
+			q = q[1:]
+		}
+		if i >= w-1 {
+			out[i-w+1] = x[q[0]]
+		}
+	}
+	return out
+}
+
 type Algo string
 
 const (
@@ -348,13 +743,42 @@ const (
 
 // MPOpts are parameters to vary the algorithm to compute the matrix profile.
 type MPOpts struct {
-	Algorithm    Algo    `json:"algorithm"`  // choose which algorithm to compute the matrix profile
-	SamplePct    float64 `json:"sample_pct"` // only applicable to algorithm STAMP
-	NJobs        int     `json:"n_jobs"`
-	Euclidean    bool    `json:"euclidean"`                  // defaults to using euclidean distance instead of pearson correlation for matrix profile
-	RemapNegCorr bool    `json:"remap_negative_correlation"` // defaults to no remapping. This is used so that highly negatively correlated sequences will show a low distance as well.
+	Algorithm     Algo          `json:"algorithm"`                  // choose which algorithm to compute the matrix profile
+	SamplePct     float64       `json:"sample_pct"`                 // only applicable to algorithm STAMP
+	NJobs         int           `json:"n_jobs"`                     // number of parallel workers. Equidistant neighbors are broken toward the smaller index and STOMP rolls every row from the same re-seeded row so the results are the same for any number.
+	Euclidean     bool          `json:"euclidean"`                  // defaults to using euclidean distance instead of pearson correlation for matrix profile
+	RemapNegCorr  bool          `json:"remap_negative_correlation"` // defaults to no remapping. This is used so that highly negatively correlated sequences will show a low distance as well.
+	ReuseOutput   bool          `json:"reuse_output"`               // defaults to allocating new output. If set, the existing MP, Idx, MPB and IdxB slices are overwritten when they have enough capacity.
+	Seed          int64         `json:"seed"`                       // seeds the random ordering of STAMP so that sampled matrix profiles are reproducible. Defaults to the current time.
+	ExclusionZone int           `json:"exclusion_zone"`             // subsequences closer than this many points to each other are trivial matches in a self join. Defaults to W/4, and at least 1, if 0. Set to the effective value once computed.
+	Mask          []bool        `json:"mask,omitempty"`             // subsequences marked true are never used as nearest neighbors, such as known bad data. Only applies to self joins and must have one entry per subsequence if set.
+	Boundaries    []int         `json:"boundaries,omitempty"`       // indexes where a new session starts in a self join of concatenated recordings. Subsequences spanning a boundary are skipped and never used as nearest neighbors, while neighbors in other sessions are allowed. Merged into Mask once computed.
+	LeftRight     bool          `json:"left_right"`                 // defaults to not computing the left and right matrix profiles. Only applies to self joins.
+	Normalization Normalization `json:"normalization,omitempty"`    // defaults to z-normalizing each subsequence if empty. NormMean requires euclidean distances and no remapping of negative correlations.
+	Weights       []float64     `json:"weights,omitempty"`          // weight of each position within a subsequence when summing squared differences. Defaults to all ones if nil. Must have W non-negative entries and requires euclidean distances without remapping negative correlations.
+	KeepCorr      bool          `json:"keep_corr,omitempty"`        // keeps the pearson correlations compared by MPX in Corr even when euclidean distances are output. Requires MPX with z-normalization and no weights or sampling.
+	MaxCPUPercent float64       `json:"max_cpu_percent,omitempty"`  // approximate share of the total cpu capacity, between 0 and 100, used while computing. Workers sleep between rows or diagonals to stay within it. Defaults to no limit if 0.
+	MemoryBudget  int64         `json:"memory_budget,omitempty"`    // approximate number of bytes Compute may allocate as estimated by EstimateComputeMemory. NJobs is lowered until the estimate fits and ErrMemoryBudget is returned if it doesn't fit with a single job. Defaults to no limit if 0.
+	Stabilize     int           `json:"stabilize,omitempty"`        // recomputes the rolling dot product of STOMP and MPX exactly every this many rows or diagonal steps, bounding the floating point error accumulated on very long time series at a small cost. Defaults to every 512 rows for STOMP and never for MPX if 0.
+	Metrics       Metrics       `json:"-"`                          // receives the time taken to merge each batch and to compute so that long running computations can be monitored. Defaults to no telemetry if nil.
+	Verify        bool          `json:"verify,omitempty"`           // recomputes a random sample of the matrix profile with a naive dot product after computing, storing the statistics in Verification. Compute returns ErrInvalidProfile if any value is off by more than a small tolerance. Requires no sampling.
 }
 
+// Normalization is how each subsequence is normalized before distances are
+// computed
+type Normalization string
+
+const (
+	// NormZ subtracts the mean and divides by the standard deviation of each
+	// subsequence so that only the shape of subsequences is compared
+	NormZ Normalization = "z"
+
+	// NormMean only subtracts the mean of each subsequence so that differences
+	// in amplitude are kept while offsets are ignored. This is the mean-centered
+	// euclidean distance suited to amplitude sensitive signals.
+	NormMean Normalization = "mean"
+)
+
 // NewMPOpts returns a default MPOpts
 func NewMPOpts() *MPOpts {
 	p := runtime.NumCPU() * 2
@@ -366,31 +790,218 @@ func NewMPOpts() *MPOpts {
 		SamplePct: 1.0,
 		NJobs:     p,
 		Euclidean: true,
+		Seed:      time.Now().UnixNano(),
 	}
 }
 
-// Compute calculate the matrixprofile given a set of input options.
-func (mp *MatrixProfile) Compute(o *MPOpts) error {
+// defaultExclusionZone returns the self join exclusion zone used for a window
+// of w when none is set in the options
+func defaultExclusionZone(w int) int {
+	if w/4 > 1 {
+		return w / 4
+	}
+	return 1
+}
+
+// setOpts stores a copy of the options in the struct with the effective
+// exclusion zone filled in so that saved profiles record the zone used. The
+// caller's options are not modified since they may be reused across windows.
+func (mp *MatrixProfile) setOpts(o *MPOpts) error {
 	if o == nil {
 		o = NewMPOpts()
 	}
-	mp.Opts = o
+	if o.ExclusionZone < 0 {
+		return fmt.Errorf("exclusion zone must be at least 0, got %d", o.ExclusionZone)
+	}
+	if o.MaxCPUPercent < 0 || o.MaxCPUPercent > 100 {
+		return fmt.Errorf("max cpu percent must be between 0 and 100, got %.3f", o.MaxCPUPercent)
+	}
+	if o.Stabilize < 0 {
+		return fmt.Errorf("stabilize interval must be at least 0, got %d", o.Stabilize)
+	}
+	if o.MemoryBudget < 0 {
+		return fmt.Errorf("memory budget must be at least 0, got %d", o.MemoryBudget)
+	}
+
+	switch o.Normalization {
+	case "", NormZ:
+	case NormMean:
+		if !o.Euclidean || o.RemapNegCorr {
+			return errors.New("mean-centered normalization requires euclidean distances without remapping negative correlations")
+		}
+	default:
+		return fmt.Errorf("unsupported normalization, %s", o.Normalization)
+	}
+
+	if o.Weights != nil {
+		if len(o.Weights) != mp.W {
+			return fmt.Errorf("number of weights, %d, must match the subsequence length, %d", len(o.Weights), mp.W)
+		}
+		if floats.Min(o.Weights) < 0 || floats.Max(o.Weights) <= 0 {
+			return errors.New("weights must be non-negative with at least one positive weight")
+		}
+		if !o.Euclidean || o.RemapNegCorr {
+			return errors.New("weighted distances require euclidean distances without remapping negative correlations")
+		}
+		if o.Algorithm == AlgoMPX && !mp.SelfJoin {
+			return fmt.Errorf("weighted distances of an AB join are not supported by %s", AlgoMPX)
+		}
+	}
+
+	if o.KeepCorr && (o.Algorithm != AlgoMPX || o.SamplePct < 1 || o.Weights != nil || o.Normalization == NormMean) {
+		return fmt.Errorf("keeping correlations requires %s with z-normalization and no weights or sampling", AlgoMPX)
+	}
+
+	if o.Verify && o.SamplePct < 1 {
+		return errors.New("verification requires an exact matrix profile without sampling")
+	}
+
+	if o.LeftRight && !mp.SelfJoin {
+		return newError(ErrNotSelfJoin, "left and right matrix profiles can only be computed for a self join")
+	}
+	if o.Mask != nil {
+		if !mp.SelfJoin {
+			return newError(ErrNotSelfJoin, "an exclusion mask can only be applied to a self join")
+		}
+		if len(o.Mask) != len(mp.A)-mp.W+1 {
+			return fmt.Errorf("exclusion mask length, %d, must match the number of subsequences, %d", len(o.Mask), len(mp.A)-mp.W+1)
+		}
+	}
+
+	opts := *o
+	if o.Boundaries != nil {
+		if !mp.SelfJoin {
+			return newError(ErrNotSelfJoin, "session boundaries can only be applied to a self join")
+		}
+		mask, err := SessionMask(len(mp.A), mp.W, o.Boundaries)
+		if err != nil {
+			return err
+		}
+		for i := range o.Mask {
+			mask[i] = mask[i] || o.Mask[i]
+		}
+		opts.Mask = mask
+	}
+	if opts.ExclusionZone == 0 {
+		opts.ExclusionZone = defaultExclusionZone(mp.W)
+	}
+	if err := mp.fitMemoryBudget(&opts); err != nil {
+		return err
+	}
+	mp.Opts = &opts
+	return nil
+}
+
+// exclusionZone returns the self join exclusion zone from the options or the
+// default if the options are not set
+func (mp MatrixProfile) exclusionZone() int {
+	if mp.Opts != nil && mp.Opts.ExclusionZone > 0 {
+		return mp.Opts.ExclusionZone
+	}
+	return defaultExclusionZone(mp.W)
+}
+
+// Compute calculate the matrixprofile given a set of input options.
+func (mp *MatrixProfile) Compute(o *MPOpts) error {
+	mp.stale = true
+	mp.Verification = nil
+	start := time.Now()
+	err := mp.compute(o)
+	if o != nil && o.Metrics != nil {
+		o.Metrics.ComputeDone(o.Algorithm, len(mp.MP), time.Since(start), err)
+	}
+	if err != nil {
+		mp.log("matrix profile computation failed", "elapsed", time.Since(start), "err", err)
+		return err
+	}
+	mp.log("computed matrix profile", "algorithm", mp.Opts.Algorithm, "elapsed", time.Since(start))
+	mp.clearBoundaries()
+	if mp.Opts.Verify {
+		if err := mp.verify(); err != nil {
+			return err
+		}
+	}
+	if mp.Arcs != nil {
+		mp.Arcs = NewArcCurve(mp.Idx)
+	}
+	mp.recordProvenance()
+	mp.stale = false
+	return nil
+}
+
+// checkComputed returns an error if the matrix profile is missing, was left
+// stale by a failed Compute or no longer matches the length of the time
+// series it was computed from.
+func (mp MatrixProfile) checkComputed() error {
+	if mp.stale {
+		return newError(ErrNotComputed, "matrix profile is stale after a failed computation")
+	}
+	if err := mp.checkVerified(); err != nil {
+		return err
+	}
+	if mp.MP == nil {
+		return ErrNotComputed
+	}
+
+	n := len(mp.B)
+	if mp.indexedByA() {
+		n = len(mp.A)
+	}
+	if len(mp.MP) != n-mp.W+1 {
+		return newError(ErrNotComputed, "matrix profile length, %d, does not match the %d subsequences of the time series", len(mp.MP), n-mp.W+1)
+	}
+	return nil
+}
+
+// ensureComputed lazily computes a matrix profile that has options set but
+// was never computed. Otherwise it behaves the same as checkComputed.
+func (mp *MatrixProfile) ensureComputed() error {
+	if !mp.stale && mp.MP == nil && mp.Opts != nil && len(mp.A) > 0 && len(mp.B) > 0 {
+		return mp.Compute(mp.Opts)
+	}
+	return mp.checkComputed()
+}
 
-	if o.SamplePct < 1 {
-		return mp.stamp()
+// compute dispatches to the algorithm selected in the options
+func (mp *MatrixProfile) compute(o *MPOpts) error {
+	if err := mp.setOpts(o); err != nil {
+		return err
 	}
+	o = mp.Opts
+	mp.bQT = nil
+	mp.Corr = nil
+	mp.log("computing matrix profile", "algorithm", o.Algorithm, "n", len(mp.A), "m", len(mp.B), "w", mp.W,
+		"self_join", mp.SelfJoin, "n_jobs", o.NJobs, "sample_pct", o.SamplePct, "euclidean", o.Euclidean)
 
-	switch o.Algorithm {
-	case AlgoSTOMP:
-		return mp.stomp()
-	case AlgoSTAMP:
-		return mp.stamp()
-	case AlgoSTMP:
-		return mp.stmp()
-	case AlgoMPX:
+	var err error
+	switch {
+	case o.SamplePct < 1:
+		err = mp.stamp()
+	case o.Weights != nil && (o.Algorithm == AlgoSTOMP || o.Algorithm == AlgoMPX):
+		// the incremental dot product updates of STOMP and MPX do not hold once
+		// positions within a subsequence are weighted, so every row is computed
+		// from its own sliding dot product as in STAMP
+		err = mp.stamp()
+	case o.Algorithm == AlgoMPX:
+		// MPX tracks pearson correlations natively so needs no conversion
 		return mp.mpx()
+	case o.Algorithm == AlgoSTOMP:
+		err = mp.stomp()
+	case o.Algorithm == AlgoSTAMP:
+		err = mp.stamp()
+	case o.Algorithm == AlgoSTMP:
+		err = mp.stmp()
 	default:
-		return fmt.Errorf("Unsupported algorithm for matrix profile, %s", o.Algorithm)
+		return newError(ErrUnsupportedAlgorithm, "Unsupported algorithm for matrix profile, %s", o.Algorithm)
+	}
+	if err != nil || o.Euclidean {
+		return err
+	}
+
+	// the remaining algorithms compute distances which are reported as
+	// pearson correlations when euclidean distances were not requested
+	for _, prof := range [][]float64{mp.MP, mp.MPB, mp.LMP, mp.RMP} {
+		euclideanToPearson(prof, mp.W)
 	}
 	return nil
 }
@@ -413,18 +1024,88 @@ func (mp *MatrixProfile) initCaches() error {
 
 	// precompute the fourier transform of the b timeseries since it will
 	// be used multiple times while computing the matrix profile
-	fft := fourier.NewFFT(mp.N)
+	fft := getFFT(mp.N)
 	mp.BF = fft.Coefficients(nil, mp.B)
+	putFFT(fft)
+
+	mp.bWSum, mp.bWSqSum = nil, nil
+	if mp.Opts != nil && mp.Opts.Weights != nil {
+		mp.bWSum, mp.bWSqSum = weightedSums(mp.B, mp.Opts.Weights)
+	}
 
 	return nil
 }
 
+// weightedSums returns the weighted sum and weighted sum of squares of every
+// subsequence of ts with a length of the number of weights
+func weightedSums(ts, weights []float64) ([]float64, []float64) {
+	sum := make([]float64, len(ts)-len(weights)+1)
+	sqSum := make([]float64, len(sum))
+	for i := range sum {
+		for k, wt := range weights {
+			sum[i] += wt * ts[i+k]
+			sqSum[i] += wt * ts[i+k] * ts[i+k]
+		}
+	}
+	return sum, sqSum
+}
+
+// initProfile returns a matrix profile and matrix profile index of length n
+// with every value unset. The provided slices are reused if reuse is set and
+// they have enough capacity, otherwise new slices are allocated.
+func initProfile(prof []float64, idx []int, n int, reuse bool) ([]float64, []int) {
+	if !reuse || cap(prof) < n || cap(idx) < n {
+		prof = make([]float64, n)
+		idx = make([]int, n)
+	}
+	prof, idx = prof[:n], idx[:n]
+	for i := 0; i < n; i++ {
+		prof[i] = math.Inf(1)
+		idx[i] = math.MaxInt64
+	}
+	return prof, idx
+}
+
+// meanCentered returns whether subsequences are only mean-centered rather than
+// z-normalized before computing distances
+func (mp MatrixProfile) meanCentered() bool {
+	return mp.Opts != nil && mp.Opts.Normalization == NormMean
+}
+
+// masked returns whether the subsequence at idx is excluded from being a
+// nearest neighbor by the options mask
+func (mp MatrixProfile) masked(idx int) bool {
+	return mp.Opts != nil && idx < len(mp.Opts.Mask) && mp.Opts.Mask[idx]
+}
+
+// applyTrivialMatchZone sets the distance to +Inf for every subsequence in
+// profile closer than the exclusion zone to the subsequence at idx. This
+// matches the diagonals skipped by MPX so all algorithms agree.
+func (mp MatrixProfile) applyTrivialMatchZone(profile []float64, idx int) {
+	zone := mp.exclusionZone()
+	for i := idx - zone + 1; i < idx+zone; i++ {
+		if i >= 0 && i < len(profile) {
+			profile[i] = math.Inf(1)
+		}
+	}
+}
+
 // crossCorrelate computes the sliding dot product between two slices
-// given a query and time series. Uses fast fourier transforms to compute
-// the necessary values. Returns the a slice of floats for the cross-correlation
-// of the signal q and the mp.B signal. This makes an optimization where the query
-// length must be less than half the length of the timeseries, b.
+// given a query and time series. Returns the a slice of floats for the
+// cross-correlation of the signal q and the mp.B signal. Subsequences shorter
+// than directMaxW are computed directly, otherwise fast fourier transforms are
+// used.
 func (mp MatrixProfile) crossCorrelate(q []float64, fft *fourier.FFT) []float64 {
+	if mp.W < directMaxW {
+		return slidingDot(q[:mp.W], mp.B)
+	}
+	return mp.fftCrossCorrelate(q, fft)
+}
+
+// fftCrossCorrelate computes the sliding dot product of q and mp.B using fast
+// fourier transforms. This makes an optimization where the query length must
+// be less than half the length of the timeseries, b.
+func (mp MatrixProfile) fftCrossCorrelate(q []float64, fft *fourier.FFT) []float64 {
 	qpad := make([]float64, mp.N)
 	for i := 0; i < len(q); i++ {
 		qpad[i] = q[mp.W-i-1]
@@ -472,17 +1153,65 @@ func (mp MatrixProfile) distanceProfile(idx int, profile []float64, fft *fourier
 		return fmt.Errorf("provided index  %d is beyond the length of timeseries %d minus the subsequence length %d", idx, len(mp.A), mp.W)
 	}
 
-	if err := mp.mass(mp.A[idx:idx+mp.W], profile, fft); err != nil {
-		return err
-	}
+	if mp.Opts != nil && mp.Opts.Weights != nil {
+		q := make([]float64, mp.W)
+		floats.MulTo(q, mp.Opts.Weights, mp.A[idx:idx+mp.W])
+		mp.weightedDistanceProfile(mp.crossCorrelate(q, fft), idx, profile)
+		if mp.SelfJoin {
+			mp.applyTrivialMatchZone(profile, idx)
+		}
+		return nil
+	}
+
+	if mp.meanCentered() {
+		dot := mp.crossCorrelate(mp.A[idx:idx+mp.W], fft)
+		return mp.calculateDistanceProfile(dot, idx, profile)
+	}
+
+	if err := mp.mass(mp.A[idx:idx+mp.W], profile, fft); err != nil {
+		return err
+	}
 
 	// sets the distance in the exclusion zone to +Inf
 	if mp.SelfJoin {
-		util.ApplyExclusionZone(profile, idx, mp.W/2)
+		mp.applyTrivialMatchZone(profile, idx)
 	}
 	return nil
 }
 
+// weightedDistanceProfile converts the sliding dot product of the weighted
+// subsequence at idx in mp.A with mp.B into weighted euclidean distances,
+// sum_k weights[k]*(x[k]-y[k])^2, between the normalized subsequences. The
+// mean and standard deviation used to normalize are unweighted, so weights of
+// all ones give the same distances as calculateDistanceProfile.
+func (mp MatrixProfile) weightedDistanceProfile(wdot []float64, idx int, profile []float64) {
+	weights := mp.Opts.Weights
+	wSum := floats.Sum(weights)
+
+	var aSum, aSqSum float64
+	for k, wt := range weights {
+		aSum += wt * mp.A[idx+k]
+		aSqSum += wt * mp.A[idx+k] * mp.A[idx+k]
+	}
+	muA := mp.AMean[idx]
+	sxx := aSqSum - 2*muA*aSum + muA*muA*wSum
+
+	for i := range wdot {
+		muB := mp.BMean[i]
+		syy := mp.bWSqSum[i] - 2*muB*mp.bWSum[i] + muB*muB*wSum
+		sxy := wdot[i] - muB*aSum - muA*mp.bWSum[i] + muA*muB*wSum
+
+		var d float64
+		if mp.meanCentered() {
+			d = sxx + syy - 2*sxy
+		} else {
+			sa, sb := mp.AStd[idx], mp.BStd[i]
+			d = sxx/(sa*sa) + syy/(sb*sb) - 2*sxy/(sa*sb)
+		}
+		profile[i] = math.Sqrt(math.Max(0, d))
+	}
+}
+
 // calculateDistanceProfile converts a sliding dot product slice of floats into
 // distances and normalizes the output. Writes results back into the profile slice
 // of floats representing the distance profile.
@@ -496,13 +1225,21 @@ func (mp MatrixProfile) calculateDistanceProfile(dot []float64, idx int, profile
 	}
 
 	// converting cross correlation value to euclidian distance
-	for i := 0; i < len(dot); i++ {
-		profile[i] = math.Sqrt(2 * float64(mp.W) * math.Abs(1-(dot[i]-float64(mp.W)*mp.BMean[i]*mp.AMean[idx])/(float64(mp.W)*mp.BStd[i]*mp.AStd[idx])))
+	w := float64(mp.W)
+	if mp.meanCentered() {
+		ssA := w * mp.AStd[idx] * mp.AStd[idx]
+		for i := 0; i < len(dot); i++ {
+			profile[i] = math.Sqrt(math.Max(0, ssA+w*mp.BStd[i]*mp.BStd[i]-2*(dot[i]-w*mp.BMean[i]*mp.AMean[idx])))
+		}
+	} else {
+		for i := 0; i < len(dot); i++ {
+			profile[i] = math.Sqrt(2 * w * math.Abs(1-(dot[i]-w*mp.BMean[i]*mp.AMean[idx])/(w*mp.BStd[i]*mp.AStd[idx])))
+		}
 	}
 
 	if mp.SelfJoin {
 		// sets the distance in the exclusion zone to +Inf
-		util.ApplyExclusionZone(profile, idx, mp.W/2)
+		mp.applyTrivialMatchZone(profile, idx)
 	}
 	return nil
 }
@@ -516,39 +1253,80 @@ func (mp *MatrixProfile) stmp() error {
 		return err
 	}
 
-	mp.MP = make([]float64, mp.N-mp.W+1)
-	mp.Idx = make([]int, mp.N-mp.W+1)
-	for i := 0; i < len(mp.MP); i++ {
-		mp.MP[i] = math.Inf(1)
-		mp.Idx[i] = math.MaxInt64
-	}
+	mp.MP, mp.Idx = initProfile(mp.MP, mp.Idx, mp.N-mp.W+1, mp.Opts.ReuseOutput)
+	mp.initLeftRight()
 
 	var err error
 	profile := make([]float64, mp.N-mp.W+1)
 
-	fft := fourier.NewFFT(mp.N)
-	for i := 0; i < mp.N-mp.W+1; i++ {
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	thr := mp.newThrottle(1)
+	// rows are the subsequences of a while each distance profile spans b
+	for i := 0; i < len(mp.A)-mp.W+1; i++ {
+		thr.pause()
+		if mp.masked(i) {
+			continue
+		}
 		if err = mp.distanceProfile(i, profile, fft); err != nil {
 			return err
 		}
 
 		for j := 0; j < len(profile); j++ {
-			if profile[j] <= mp.MP[j] {
+			if closerDist(profile[j], i, mp.MP[j], mp.Idx[j]) {
 				mp.MP[j] = profile[j]
 				mp.Idx[j] = i
 			}
 		}
+		if mp.Opts.LeftRight {
+			mergeLeftRight(mp.LMP, mp.LIdx, mp.RMP, mp.RIdx, profile, i)
+		}
 	}
 
 	return nil
 }
 
+// initLeftRight initializes the left and right matrix profiles if they are
+// requested in the options and clears them otherwise
+func (mp *MatrixProfile) initLeftRight() {
+	if !mp.Opts.LeftRight {
+		mp.LMP, mp.LIdx, mp.RMP, mp.RIdx = nil, nil, nil, nil
+		return
+	}
+	mp.LMP, mp.LIdx = initProfile(mp.LMP, mp.LIdx, len(mp.MP), mp.Opts.ReuseOutput)
+	mp.RMP, mp.RIdx = initProfile(mp.RMP, mp.RIdx, len(mp.MP), mp.Opts.ReuseOutput)
+}
+
+// mergeLeftRight updates the left and right matrix profiles with the distance
+// profile of the subsequence at idx. The subsequence at idx is a left neighbor
+// of every later subsequence and a right neighbor of every earlier one.
+func mergeLeftRight(lmp []float64, lidx []int, rmp []float64, ridx []int, profile []float64, idx int) {
+	for j := 0; j < len(profile); j++ {
+		switch {
+		case j > idx && closerDist(profile[j], idx, lmp[j], lidx[j]):
+			lmp[j] = profile[j]
+			lidx[j] = idx
+		case j < idx && closerDist(profile[j], idx, rmp[j], ridx[j]):
+			rmp[j] = profile[j]
+			ridx[j] = idx
+		}
+	}
+}
+
 // Update updates a matrix profile and matrix profile index in place providing streaming
-// like behavior.
+// like behavior. Use UpdateB for an AB join with a fixed a and a growing b.
 func (mp *MatrixProfile) Update(newValues []float64) error {
+	if err := mp.checkVerified(); err != nil {
+		return err
+	}
+	if mp.Opts != nil && !mp.Opts.Euclidean {
+		return errors.New("can only update euclidean distances, convert pearson correlations with ToEuclidean first")
+	}
+
 	var err error
 
 	var profile []float64
+	arcs := mp.trackingArcs()
 	for _, val := range newValues {
 		// add to the a and b time series and increment the time series length
 		if mp.SelfJoin {
@@ -562,6 +1340,16 @@ func (mp *MatrixProfile) Update(newValues []float64) error {
 		// increase the size of the Matrix Profile and Index
 		mp.MP = append(mp.MP, math.Inf(1))
 		mp.Idx = append(mp.Idx, math.MaxInt64)
+		if arcs {
+			mp.Arcs.Append(math.MaxInt64)
+		}
+		leftRight := mp.LMP != nil && len(mp.LMP) == len(mp.MP)-1
+		if leftRight {
+			mp.LMP = append(mp.LMP, math.Inf(1))
+			mp.LIdx = append(mp.LIdx, math.MaxInt64)
+			mp.RMP = append(mp.RMP, math.Inf(1))
+			mp.RIdx = append(mp.RIdx, math.MaxInt64)
+		}
 
 		if err = mp.initCaches(); err != nil {
 			return err
@@ -569,25 +1357,48 @@ func (mp *MatrixProfile) Update(newValues []float64) error {
 
 		// only compute the last distance profile
 		profile = make([]float64, len(mp.MP))
-		fft := fourier.NewFFT(mp.N)
-		if err = mp.distanceProfile(len(mp.A)-mp.W, profile, fft); err != nil {
+		fft := getFFT(mp.N)
+		err = mp.distanceProfile(len(mp.A)-mp.W, profile, fft)
+		putFFT(fft)
+		if err != nil {
 			return err
 		}
 
 		minVal := math.Inf(1)
 		minIdx := math.MaxInt64
 		for j := 0; j < len(profile)-1; j++ {
-			if profile[j] <= mp.MP[j] {
+			if mp.crossesBoundary(j) {
+				continue
+			}
+			if closerDist(profile[j], mp.N-mp.W, mp.MP[j], mp.Idx[j]) {
 				mp.MP[j] = profile[j]
 				mp.Idx[j] = mp.N - mp.W
+				if arcs {
+					mp.Arcs.Set(j, mp.N-mp.W)
+				}
 			}
-			if profile[j] < minVal {
+			if profile[j] < minVal && !mp.masked(j) {
 				minVal = profile[j]
 				minIdx = j
 			}
 		}
 		mp.MP[mp.N-mp.W] = minVal
 		mp.Idx[mp.N-mp.W] = minIdx
+		if arcs {
+			mp.Arcs.Set(mp.N-mp.W, minIdx)
+		}
+
+		// every other subsequence is to the left of the newest one
+		if leftRight {
+			for j := 0; j < len(profile)-1; j++ {
+				if closerDist(profile[j], mp.N-mp.W, mp.RMP[j], mp.RIdx[j]) {
+					mp.RMP[j] = profile[j]
+					mp.RIdx[j] = mp.N - mp.W
+				}
+			}
+			mp.LMP[mp.N-mp.W] = minVal
+			mp.LIdx[mp.N-mp.W] = minIdx
+		}
 	}
 	return nil
 }
@@ -599,65 +1410,126 @@ type mpResult struct {
 	Idx  []int
 	MPB  []float64
 	IdxB []int
+	LMP  []float64
+	LIdx []int
+	RMP  []float64
+	RIdx []int
 	Err  error
 }
 
+// mpResultPool holds batch results so that repeated computations reuse the
+// full length per batch profiles rather than allocating new ones
+var mpResultPool = sync.Pool{
+	New: func() interface{} { return &mpResult{} },
+}
+
+// newMPResult gets a batch result from the pool with a matrix profile of length
+// lenA and a BA join matrix profile of length lenB. Profile values are set to
+// fill and indexes to math.MaxInt64.
+func newMPResult(lenA, lenB int, fill float64) *mpResult {
+	r := mpResultPool.Get().(*mpResult)
+	r.Err = nil
+	r.MP, r.Idx = resizeProfile(r.MP, r.Idx, lenA, fill)
+	r.MPB, r.IdxB = resizeProfile(r.MPB, r.IdxB, lenB, fill)
+	r.LMP, r.LIdx = resizeProfile(r.LMP, r.LIdx, 0, fill)
+	r.RMP, r.RIdx = resizeProfile(r.RMP, r.RIdx, 0, fill)
+	return r
+}
+
+// withLeftRight sizes the left and right matrix profiles of the batch result
+// to match its matrix profile if requested
+func (r *mpResult) withLeftRight(leftRight bool, fill float64) {
+	if leftRight {
+		r.LMP, r.LIdx = resizeProfile(r.LMP, r.LIdx, len(r.MP), fill)
+		r.RMP, r.RIdx = resizeProfile(r.RMP, r.RIdx, len(r.MP), fill)
+	}
+}
+
+func resizeProfile(prof []float64, idx []int, n int, fill float64) ([]float64, []int) {
+	if cap(prof) < n || cap(idx) < n {
+		prof = make([]float64, n)
+		idx = make([]int, n)
+	}
+	prof, idx = prof[:n], idx[:n]
+	for i := 0; i < n; i++ {
+		prof[i] = fill
+		idx[i] = math.MaxInt64
+	}
+	return prof, idx
+}
+
 // mergeMPResults reads from a slice of channels for Matrix Profile results and
-// updates the matrix profile in the struct
+// updates the matrix profile in the struct. Each result is returned to the pool
+// once merged.
 func (mp *MatrixProfile) mergeMPResults(results []chan *mpResult, euclidean bool) error {
 	var err error
 
-	resultSlice := make([]*mpResult, len(results))
+	metrics := mp.metrics()
 	for i := 0; i < len(results); i++ {
-		resultSlice[i] = <-results[i]
+		r := <-results[i]
+		start := time.Now()
 
 		// if an error is encountered set the variable so that it can be checked
 		// for at the end of processing. Tracks the last error emitted by any
 		// batch
-		if resultSlice[i].Err != nil {
-			err = resultSlice[i].Err
-			continue
-		}
-
-		// continues to the next loop if the result returned is empty but
-		// had no errors
-		if resultSlice[i].MP == nil || resultSlice[i].Idx == nil {
-			continue
+		if r.Err != nil {
+			err = r.Err
+		} else {
+			mergeProfile(mp.MP, mp.Idx, r.MP, r.Idx, euclidean)
+			mergeProfile(mp.MPB, mp.IdxB, r.MPB, r.IdxB, euclidean)
+			mergeProfile(mp.LMP, mp.LIdx, r.LMP, r.LIdx, euclidean)
+			mergeProfile(mp.RMP, mp.RIdx, r.RMP, r.RIdx, euclidean)
 		}
-		for j := 0; j < len(resultSlice[i].MP); j++ {
-			if euclidean {
-				if resultSlice[i].MP[j] <= mp.MP[j] {
-					mp.MP[j] = resultSlice[i].MP[j]
-					mp.Idx[j] = resultSlice[i].Idx[j]
-				}
-			} else {
-				if math.Abs(resultSlice[i].MP[j]) < math.Abs(mp.MP[j]) {
-					mp.MP[j] = resultSlice[i].MP[j]
-					mp.Idx[j] = resultSlice[i].Idx[j]
-				}
-			}
+		mpResultPool.Put(r)
+		if metrics != nil {
+			metrics.BatchMerged(mp.Opts.Algorithm, i, len(results), time.Since(start))
 		}
+	}
+	return err
+}
 
-		// check if the BA join has results and merge if so
-		if resultSlice[i].MPB == nil || resultSlice[i].IdxB == nil {
-			continue
-		}
-		for j := 0; j < len(resultSlice[i].MPB); j++ {
-			if euclidean {
-				if resultSlice[i].MPB[j] <= mp.MPB[j] {
-					mp.MPB[j] = resultSlice[i].MPB[j]
-					mp.IdxB[j] = resultSlice[i].IdxB[j]
-				}
-			} else {
-				if math.Abs(resultSlice[i].MPB[j]) < math.Abs(mp.MPB[j]) {
-					mp.MPB[j] = resultSlice[i].MPB[j]
-					mp.IdxB[j] = resultSlice[i].IdxB[j]
-				}
+// closerDist reports whether a distance d to the subsequence at i is a closer
+// match than the current distance cur to the subsequence at curIdx. Ties go to
+// the smaller index so that results don't depend on the order in which
+// subsequences are compared or how the work is split into batches. Distances
+// of +Inf are never closer.
+func closerDist(d float64, i int, cur float64, curIdx int) bool {
+	return d < cur || d == cur && i < curIdx && !math.IsInf(d, 1)
+}
+
+// closerCorr is the same as closerDist for pearson correlations where the
+// highest correlation is the closest match and unset entries are +Inf
+func closerCorr(c float64, i int, cur float64, curIdx int) bool {
+	if math.IsInf(c, 0) {
+		return false
+	}
+	return math.IsInf(cur, 1) || higher(c, i, cur, curIdx)
+}
+
+// higher reports whether c with a neighbor at i beats the current value cur
+// with a neighbor at curIdx when larger values are closer matches, as in the
+// MPX kernels. Ties go to the smaller index as in closerDist.
+func higher(c float64, i int, cur float64, curIdx int) bool {
+	return c > cur || c == cur && i < curIdx
+}
+
+// mergeProfile updates the matrix profile and index in place with any closer
+// matches from a batch profile and index. Empty batch profiles are ignored.
+func mergeProfile(prof []float64, idx []int, batchProf []float64, batchIdx []int, euclidean bool) {
+	if len(batchProf) == 0 || len(batchIdx) == 0 {
+		return
+	}
+	for j := 0; j < len(batchProf); j++ {
+		if euclidean {
+			if closerDist(batchProf[j], batchIdx[j], prof[j], idx[j]) {
+				prof[j] = batchProf[j]
+				idx[j] = batchIdx[j]
 			}
+		} else if closerCorr(batchProf[j], batchIdx[j], prof[j], idx[j]) {
+			prof[j] = batchProf[j]
+			idx[j] = batchIdx[j]
 		}
-
 	}
-	return err
 }
 
 // stamp uses random ordering to compute the matrix profile. User can specify the
@@ -674,16 +1546,13 @@ func (mp *MatrixProfile) stamp() error {
 		return err
 	}
 
-	mp.MP = make([]float64, mp.N-mp.W+1)
-	mp.Idx = make([]int, mp.N-mp.W+1)
-	for i := 0; i < len(mp.MP); i++ {
-		mp.MP[i] = math.Inf(1)
-		mp.Idx[i] = math.MaxInt64
-	}
+	mp.MP, mp.Idx = initProfile(mp.MP, mp.Idx, mp.N-mp.W+1, mp.Opts.ReuseOutput)
+	mp.initLeftRight()
 
-	randIdx := rand.Perm(len(mp.A) - mp.W + 1)
+	randIdx := rand.New(rand.NewSource(mp.Opts.Seed)).Perm(len(mp.A) - mp.W + 1)
 
 	batchSize := (len(mp.A)-mp.W+1)/mp.Opts.NJobs + 1
+	mp.log("batching rows", "batches", mp.Opts.NJobs, "batch_size", batchSize)
 	results := make([]chan *mpResult, mp.Opts.NJobs)
 	for i := 0; i < mp.Opts.NJobs; i++ {
 		results[i] = make(chan *mpResult)
@@ -696,6 +1565,8 @@ func (mp *MatrixProfile) stamp() error {
 	var err error
 	done := make(chan bool)
 	go func() {
+		// batches hold euclidean distances which compute converts to pearson
+		// correlations once merged if requested in the options
 		err = mp.mergeMPResults(results, true)
 		done <- true
 	}()
@@ -726,31 +1597,34 @@ func (mp MatrixProfile) stampBatch(idx, batchSize int, sample float64, randIdx [
 	}
 
 	// initialize this batch's matrix profile results
-	result := &mpResult{
-		MP:  make([]float64, mp.N-mp.W+1),
-		Idx: make([]int, mp.N-mp.W+1),
-	}
-	for i := 0; i < len(mp.MP); i++ {
-		result.MP[i] = math.Inf(1)
-		result.Idx[i] = math.MaxInt64
-	}
+	result := newMPResult(mp.N-mp.W+1, 0, math.Inf(1))
+	result.withLeftRight(mp.Opts.LeftRight, math.Inf(1))
 
 	var err error
 	profile := make([]float64, len(result.MP))
-	fft := fourier.NewFFT(mp.N)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	thr := mp.newThrottle(mp.Opts.NJobs)
 	for i := 0; i < int(float64(batchSize)*sample); i++ {
+		thr.pause()
 		if idx*batchSize+i >= len(randIdx) {
 			break
 		}
+		if mp.masked(randIdx[idx*batchSize+i]) {
+			continue
+		}
 		if err = mp.distanceProfile(randIdx[idx*batchSize+i], profile, fft); err != nil {
-			return &mpResult{nil, nil, nil, nil, err}
+			return &mpResult{Err: err}
 		}
 		for j := 0; j < len(profile); j++ {
-			if profile[j] <= result.MP[j] {
+			if closerDist(profile[j], randIdx[idx*batchSize+i], result.MP[j], result.Idx[j]) {
 				result.MP[j] = profile[j]
 				result.Idx[j] = randIdx[idx*batchSize+i]
 			}
 		}
+		if mp.Opts.LeftRight {
+			mergeLeftRight(result.LMP, result.LIdx, result.RMP, result.RIdx, profile, randIdx[idx*batchSize+i])
+		}
 	}
 	return result
 }
@@ -765,14 +1639,11 @@ func (mp *MatrixProfile) stomp() error {
 		return err
 	}
 
-	mp.MP = make([]float64, mp.N-mp.W+1)
-	mp.Idx = make([]int, mp.N-mp.W+1)
-	for i := 0; i < len(mp.MP); i++ {
-		mp.MP[i] = math.Inf(1)
-		mp.Idx[i] = math.MaxInt64
-	}
+	mp.MP, mp.Idx = initProfile(mp.MP, mp.Idx, mp.N-mp.W+1, mp.Opts.ReuseOutput)
+	mp.initLeftRight()
 
 	batchSize := (len(mp.A)-mp.W+1)/mp.Opts.NJobs + 1
+	mp.log("batching rows", "batches", mp.Opts.NJobs, "batch_size", batchSize)
 	results := make([]chan *mpResult, mp.Opts.NJobs)
 	for i := 0; i < mp.Opts.NJobs; i++ {
 		results[i] = make(chan *mpResult)
@@ -785,6 +1656,8 @@ func (mp *MatrixProfile) stomp() error {
 	var err error
 	done := make(chan bool)
 	go func() {
+		// batches hold euclidean distances which compute converts to pearson
+		// correlations once merged if requested in the options
 		err = mp.mergeMPResults(results, true)
 		done <- true
 	}()
@@ -806,6 +1679,37 @@ func (mp *MatrixProfile) stomp() error {
 	return err
 }
 
+// stompSeedRows returns the number of rows between exact re-seeds of the STOMP
+// sliding dot product, which is the Stabilize option if set
+func (mp MatrixProfile) stompSeedRows() int {
+	if mp.Opts.Stabilize > 0 {
+		return mp.Opts.Stabilize
+	}
+	return 512
+}
+
+// stompNextDot rolls the sliding dot product of row-1 in place into the one of
+// row, re-seeding it exactly on every stompSeedRows row so that rounding errors
+// of the recurrence don't accumulate over long batches
+func (mp MatrixProfile) stompNextDot(dot []float64, row, seed int, fft *fourier.FFT) []float64 {
+	if row%seed == 0 {
+		return mp.crossCorrelate(mp.A[row:row+mp.W], fft)
+	}
+	for j := mp.N - mp.W; j > 0; j-- {
+		dot[j] = dot[j-1] - mp.B[j-1]*mp.A[row-1] + mp.B[j+mp.W-1]*mp.A[row+mp.W-1]
+	}
+
+	// recompute the first cross correlation since the algorithm is only valid for
+	// points after it. Previous optimization of using a precomputed cache ONLY applies
+	// if we're doing a self-join and is invalidated with AB-joins of different time series
+	var nextDotZero float64
+	for k := 0; k < mp.W; k++ {
+		nextDotZero += mp.A[row+k] * mp.B[k]
+	}
+	dot[0] = nextDotZero
+	return dot
+}
+
 // stompBatch processes a batch set of rows in matrix profile calculation. Each batch
 // will compute its first row's dot product and build the subsequent matrix profile and
 // matrix profile index using the stomp iterative algorithm. This also uses the very
@@ -818,81 +1722,191 @@ func (mp MatrixProfile) stompBatch(idx, batchSize int, wg *sync.WaitGroup) *mpRe
 		return &mpResult{}
 	}
 
-	// compute for this batch the first row's sliding dot product
-	fft := fourier.NewFFT(mp.N)
-	dot := mp.crossCorrelate(mp.A[idx*batchSize:idx*batchSize+mp.W], fft)
+	// compute for this batch the first row's sliding dot product. It is rolled
+	// from the last re-seeded row so that every row's dot product is the same
+	// regardless of where the batch starts and therefore of the number of jobs.
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	seed := mp.stompSeedRows()
+	start := idx * batchSize / seed * seed
+	dot := mp.crossCorrelate(mp.A[start:start+mp.W], fft)
+	for row := start + 1; row <= idx*batchSize; row++ {
+		dot = mp.stompNextDot(dot, row, seed, fft)
+	}
 
 	profile := make([]float64, len(dot))
 	var err error
 	if err = mp.calculateDistanceProfile(dot, idx*batchSize, profile); err != nil {
-		return &mpResult{nil, nil, nil, nil, err}
+		return &mpResult{Err: err}
 	}
 
 	// initialize this batch's matrix profile results
-	result := &mpResult{
-		MP:  make([]float64, mp.N-mp.W+1),
-		Idx: make([]int, mp.N-mp.W+1),
-	}
-
-	copy(result.MP, profile)
-	for i := 0; i < len(profile); i++ {
-		result.Idx[i] = idx * batchSize
+	result := newMPResult(mp.N-mp.W+1, 0, math.Inf(1))
+	result.withLeftRight(mp.Opts.LeftRight, math.Inf(1))
+	if !mp.masked(idx * batchSize) {
+		copy(result.MP, profile)
+		for i := 0; i < len(profile); i++ {
+			result.Idx[i] = idx * batchSize
+		}
+		if mp.Opts.LeftRight {
+			mergeLeftRight(result.LMP, result.LIdx, result.RMP, result.RIdx, profile, idx*batchSize)
+		}
 	}
 
 	// iteratively update for this batch each row's matrix profile and matrix
 	// profile index
-	var nextDotZero float64
+	thr := mp.newThrottle(mp.Opts.NJobs)
 	for i := 1; i < batchSize; i++ {
+		thr.pause()
 		if idx*batchSize+i-1 >= len(mp.A) || idx*batchSize+i+mp.W-1 >= len(mp.A) {
 			// looking for an index beyond the length of mp.A so ignore and move one
 			// with the current processed matrix profile
 			break
 		}
-		for j := mp.N - mp.W; j > 0; j-- {
-			dot[j] = dot[j-1] - mp.B[j-1]*mp.A[idx*batchSize+i-1] + mp.B[j+mp.W-1]*mp.A[idx*batchSize+i+mp.W-1]
-		}
-
-		// recompute the first cross correlation since the algorithm is only valid for
-		// points after it. Previous optimization of using a precomputed cache ONLY applies
-		// if we're doing a self-join and is invalidated with AB-joins of different time series
-		nextDotZero = 0
-		for k := 0; k < mp.W; k++ {
-			nextDotZero += mp.A[idx*batchSize+i+k] * mp.B[k]
+		dot = mp.stompNextDot(dot, idx*batchSize+i, seed, fft)
+		if mp.masked(idx*batchSize + i) {
+			continue
 		}
-		dot[0] = nextDotZero
 		if err = mp.calculateDistanceProfile(dot, idx*batchSize+i, profile); err != nil {
-			return &mpResult{nil, nil, nil, nil, err}
+			return &mpResult{Err: err}
 		}
 
 		// element wise min update of the matrix profile and matrix profile index
 		for j := 0; j < len(profile); j++ {
-			if profile[j] <= result.MP[j] {
+			if closerDist(profile[j], idx*batchSize+i, result.MP[j], result.Idx[j]) {
 				result.MP[j] = profile[j]
 				result.Idx[j] = idx*batchSize + i
 			}
 		}
+		if mp.Opts.LeftRight {
+			mergeLeftRight(result.LMP, result.LIdx, result.RMP, result.RIdx, profile, idx*batchSize+i)
+		}
 	}
 	return result
 }
 
 func (mp *MatrixProfile) mpx() error {
-	lenA := len(mp.A) - mp.W + 1
-	lenB := len(mp.B) - mp.W + 1
+	return mp.mpxRange(0, mp.NumDiagonals())
+}
+
+// NumDiagonals returns the number of diagonals of the distance matrix that
+// MPX iterates over. For AB joins the diagonals of the AB join are followed by
+// the diagonals of the BA join. Used to split a computation with ComputeRange.
+func (mp MatrixProfile) NumDiagonals() int {
+	n := len(mp.A) - mp.W + 1
+	if !mp.SelfJoin {
+		n += len(mp.B) - mp.W + 1
+	}
+	return n
+}
+
+// ComputeRange computes a partial matrix profile with MPX using only the
+// diagonals of the distance matrix from start up to, but not including, end.
+// Entries without a match in the range are left as +Inf with an index of
+// math.MaxInt64. This allows a large join to be sharded across machines, with
+// each computing a range of [0, NumDiagonals()), and combined afterwards with
+// MergePartialProfiles.
+func (mp *MatrixProfile) ComputeRange(o *MPOpts, start, end int) error {
+	if o == nil {
+		o = NewMPOpts()
+	}
+	if o.Algorithm != AlgoMPX {
+		return newError(ErrUnsupportedAlgorithm, "partial computation is only supported by %s, got %s", AlgoMPX, o.Algorithm)
+	}
+	if o.Weights != nil {
+		return errors.New("partial computation does not support weighted distances")
+	}
+	if start < 0 || end > mp.NumDiagonals() || start > end {
+		return fmt.Errorf("invalid diagonal range [%d, %d) for %d diagonals", start, end, mp.NumDiagonals())
+	}
+	if err := mp.setOpts(o); err != nil {
+		return err
+	}
 
-	mp.MP = make([]float64, lenA)
-	mp.Idx = make([]int, lenA)
-	for i := 0; i < len(mp.MP); i++ {
-		mp.MP[i] = math.Inf(1)
-		mp.Idx[i] = math.MaxInt64
+	if err := mp.mpxRange(start, end); err != nil {
+		return err
 	}
+	mp.clearBoundaries()
+	return nil
+}
 
-	if !mp.SelfJoin {
-		mp.MPB = make([]float64, lenB)
-		mp.IdxB = make([]int, lenB)
-		for i := 0; i < len(mp.MPB); i++ {
-			mp.MPB[i] = math.Inf(1)
-			mp.IdxB[i] = math.MaxInt64
+// MergePartialProfiles combines matrix profiles computed over different parts
+// of the same join, such as with ComputeRange, into a new matrix profile by
+// keeping the closest match for each subsequence. All profiles must have the
+// same window, join type, profile lengths and distance metric. The inputs are
+// not modified.
+func MergePartialProfiles(profiles ...*MatrixProfile) (*MatrixProfile, error) {
+	if len(profiles) == 0 {
+		return nil, errors.New("must provide at least one matrix profile to merge")
+	}
+
+	first := profiles[0]
+	euclidean := first.Opts == nil || first.Opts.Euclidean
+	for i, p := range profiles {
+		if p.MP == nil {
+			return nil, newError(ErrNotComputed, "matrix profile %d has not been computed", i)
 		}
+		if p.W != first.W || p.SelfJoin != first.SelfJoin {
+			return nil, fmt.Errorf("matrix profile %d has a different window or join type", i)
+		}
+		if len(p.MP) != len(first.MP) || len(p.Idx) != len(first.MP) ||
+			len(p.MPB) != len(first.MPB) || len(p.IdxB) != len(first.MPB) ||
+			len(p.LMP) != len(first.LMP) || len(p.RMP) != len(first.RMP) {
+			return nil, fmt.Errorf("matrix profile %d has a different length", i)
+		}
+		if (p.Opts == nil || p.Opts.Euclidean) != euclidean {
+			return nil, fmt.Errorf("matrix profile %d uses a different distance metric", i)
+		}
+	}
+
+	out := *first
+	out.MP = append([]float64(nil), first.MP...)
+	out.Idx = append([]int(nil), first.Idx...)
+	if first.MPB != nil {
+		out.MPB = append([]float64(nil), first.MPB...)
+		out.IdxB = append([]int(nil), first.IdxB...)
+	}
+	if first.LMP != nil {
+		out.LMP = append([]float64(nil), first.LMP...)
+		out.LIdx = append([]int(nil), first.LIdx...)
+		out.RMP = append([]float64(nil), first.RMP...)
+		out.RIdx = append([]int(nil), first.RIdx...)
+	}
+	for _, p := range profiles[1:] {
+		mergeProfile(out.MP, out.Idx, p.MP, p.Idx, euclidean)
+		mergeProfile(out.MPB, out.IdxB, p.MPB, p.IdxB, euclidean)
+		mergeProfile(out.LMP, out.LIdx, p.LMP, p.LIdx, euclidean)
+		mergeProfile(out.RMP, out.RIdx, p.RMP, p.RIdx, euclidean)
+	}
+
+	// kept correlations follow the profile each closest match came from
+	out.Corr = nil
+	if first.Corr != nil {
+		out.Corr = make([]float64, len(out.MP))
+		for j := range out.Corr {
+			out.Corr[j] = math.Inf(1)
+			for _, p := range profiles {
+				if len(p.Corr) == len(out.MP) && p.Idx[j] == out.Idx[j] {
+					out.Corr[j] = p.Corr[j]
+					break
+				}
+			}
+		}
+	}
+
+	return &out, nil
+}
+
+// mpxRange computes the matrix profile with MPX over the diagonals from start
+// up to, but not including, end.
+func (mp *MatrixProfile) mpxRange(start, end int) error {
+	lenA := len(mp.A) - mp.W + 1
+	lenB := len(mp.B) - mp.W + 1
+
+	mp.MP, mp.Idx = initProfile(mp.MP, mp.Idx, lenA, mp.Opts.ReuseOutput)
+	mp.initLeftRight()
+	if !mp.SelfJoin {
+		mp.MPB, mp.IdxB = initProfile(mp.MPB, mp.IdxB, lenB, mp.Opts.ReuseOutput)
 	}
 
 	mua, siga := util.MuInvN(mp.A, mp.W)
@@ -919,7 +1933,17 @@ func (mp *MatrixProfile) mpx() error {
 	}
 
 	// setup for AB join
-	batchScheme := util.DiagBatchingScheme(lenA, mp.Opts.NJobs)
+	var batchScheme []util.Batch
+	if start == 0 && end >= lenA {
+		batchScheme = util.DiagBatchingScheme(lenA, mp.Opts.NJobs)
+	} else {
+		abEnd := end
+		if abEnd > lenA {
+			abEnd = lenA
+		}
+		batchScheme = util.DiagRangeBatchingScheme(lenA, start, abEnd, mp.Opts.NJobs)
+	}
+	mp.log("batching diagonals", "join", "ab", "self_join", mp.SelfJoin, "scheme", batchScheme)
 	results := make([]chan *mpResult, mp.Opts.NJobs)
 	for i := 0; i < mp.Opts.NJobs; i++ {
 		results[i] = make(chan *mpResult)
@@ -932,7 +1956,7 @@ func (mp *MatrixProfile) mpx() error {
 	var err error
 	done := make(chan bool)
 	go func() {
-		err = mp.mergeMPResults(results, mp.Opts.Euclidean)
+		err = mp.mergeMPResults(results, false)
 		done <- true
 	}()
 
@@ -955,23 +1979,29 @@ func (mp *MatrixProfile) mpx() error {
 	// waits for all results to be read and merged before returning success
 	<-done
 
-	if mp.SelfJoin || err != nil {
+	if mp.SelfJoin || err != nil || end <= lenA {
+		mp.mpxToDist()
 		return err
 	}
 
-	// setup for BA join
-	batchScheme = util.DiagBatchingScheme(lenB, mp.Opts.NJobs)
-	results = make([]chan *mpResult, mp.Opts.NJobs)
-	for i := 0; i < mp.Opts.NJobs; i++ {
-		results[i] = make(chan *mpResult)
+	// setup for BA join reusing the drained result channels
+	baStart := start - lenA
+	if baStart < 0 {
+		baStart = 0
+	}
+	if baStart == 0 && end-lenA >= lenB {
+		batchScheme = util.DiagBatchingScheme(lenB, mp.Opts.NJobs)
+	} else {
+		batchScheme = util.DiagRangeBatchingScheme(lenB, baStart, end-lenA, mp.Opts.NJobs)
 	}
+	mp.log("batching diagonals", "join", "ba", "scheme", batchScheme)
 
 	// go routine to continually check for results on the slice of channels
 	// for each batch kicked off. This merges the results of the batched go
 	// routines by picking the lowest value in each batch's matrix profile and
 	// updating the matrix profile index.
 	go func() {
-		err = mp.mergeMPResults(results, mp.Opts.Euclidean)
+		err = mp.mergeMPResults(results, false)
 		done <- true
 	}()
 
@@ -989,33 +2019,49 @@ func (mp *MatrixProfile) mpx() error {
 	// waits for all results to be read and merged before returning success
 	<-done
 
+	mp.mpxToDist()
 	return err
 }
 
+// mpxToDist converts the merged MPX profiles from the values compared in the
+// kernels to distances. Batches are merged before converting so that the same
+// values decide ties regardless of how the diagonals were split into batches.
+func (mp *MatrixProfile) mpxToDist() {
+	mp.Corr = nil
+	if mp.Opts.KeepCorr {
+		mp.Corr = append([]float64(nil), mp.MP...)
+	}
+	for _, prof := range [][]float64{mp.MP, mp.MPB, mp.LMP, mp.RMP} {
+		if mp.meanCentered() {
+			negSqDistToDist(prof)
+		} else if mp.Opts.Euclidean {
+			pearsonToEuclidean(prof, mp.W)
+		}
+	}
+}
+
 // mpxBatch processes a batch set of rows in matrix profile calculation.
 func (mp MatrixProfile) mpxBatch(idx int, mu, sig, df, dg []float64, batchSize int, wg *sync.WaitGroup) *mpResult {
 	defer wg.Done()
-	exclZone := 1 // for seljoin we should at least get rid of neighboring points
-	if mp.W/4 > exclZone {
-		exclZone = mp.W / 4
-	}
+	exclZone := mp.exclusionZone()
 	if idx+exclZone > len(mp.A)-mp.W+1 {
 		// got an index larger than max lag so ignore
 		return &mpResult{}
 	}
 
-	mpr := &mpResult{
-		MP:  make([]float64, len(mp.A)-mp.W+1),
-		Idx: make([]int, len(mp.A)-mp.W+1),
-	}
-	for i := 0; i < len(mpr.MP); i++ {
-		mpr.MP[i] = -1
-	}
+	mpr := newMPResult(len(mp.A)-mp.W+1, 0, math.Inf(-1))
+	mpr.withLeftRight(mp.Opts.LeftRight, math.Inf(-1))
+	leftRight := mp.Opts.LeftRight
+	mask := mp.Opts.Mask
+	meanCentered := mp.meanCentered()
 
 	var c, c_cmp float64
 	s1 := make([]float64, mp.W)
 	s2 := make([]float64, mp.W)
+	thr := mp.newThrottle(mp.Opts.NJobs)
+	stabilize := mp.Opts.Stabilize
 	for diag := idx + exclZone; diag < idx+batchSize+exclZone; diag++ {
+		thr.pause()
 		if diag >= len(mp.A)-mp.W+1 {
 			break
 		}
@@ -1030,26 +2076,42 @@ func (mp MatrixProfile) mpxBatch(idx int, mu, sig, df, dg []float64, batchSize i
 		c = floats.Dot(s1, s2)
 
 		for offset := 0; offset < len(mp.A)-mp.W-diag+1; offset++ {
-			c += df[offset]*dg[offset+diag] + df[offset+diag]*dg[offset]
-			c_cmp = c * (sig[offset] * sig[offset+diag])
+			if stabilize > 0 && offset > 0 && offset%stabilize == 0 {
+				c = covariance(mp.A, offset+diag, mu[offset+diag], mp.A, offset, mu[offset], mp.W)
+			} else {
+				c += df[offset]*dg[offset+diag] + df[offset+diag]*dg[offset]
+			}
+			if meanCentered {
+				c_cmp = negSqDist(c, sig[offset], sig[offset+diag])
+			} else {
+				c_cmp = c * (sig[offset] * sig[offset+diag])
+			}
 			if mp.Opts.RemapNegCorr && c_cmp < 0 {
 				c_cmp = -c_cmp
 			}
-			if c_cmp > mpr.MP[offset] {
+			if higher(c_cmp, offset+diag, mpr.MP[offset], mpr.Idx[offset]) && (mask == nil || !mask[offset+diag]) {
 				mpr.MP[offset] = c_cmp
 				mpr.Idx[offset] = offset + diag
 			}
-			if c_cmp > mpr.MP[offset+diag] {
+			if higher(c_cmp, offset, mpr.MP[offset+diag], mpr.Idx[offset+diag]) && (mask == nil || !mask[offset]) {
 				mpr.MP[offset+diag] = c_cmp
 				mpr.Idx[offset+diag] = offset
 			}
+			if !leftRight {
+				continue
+			}
+			// offset+diag is always to the right of offset
+			if higher(c_cmp, offset+diag, mpr.RMP[offset], mpr.RIdx[offset]) && (mask == nil || !mask[offset+diag]) {
+				mpr.RMP[offset] = c_cmp
+				mpr.RIdx[offset] = offset + diag
+			}
+			if higher(c_cmp, offset, mpr.LMP[offset+diag], mpr.LIdx[offset+diag]) && (mask == nil || !mask[offset]) {
+				mpr.LMP[offset+diag] = c_cmp
+				mpr.LIdx[offset+diag] = offset
+			}
 		}
 	}
 
-	if mp.Opts.Euclidean {
-		util.P2E(mpr.MP, mp.W)
-	}
-
 	return mpr
 }
 
@@ -1064,24 +2126,17 @@ func (mp MatrixProfile) mpxabBatch(idx int, mua, siga, dfa, dga, mub, sigb, dfb,
 		return &mpResult{}
 	}
 
-	mpr := &mpResult{
-		MP:   make([]float64, lenA),
-		Idx:  make([]int, lenA),
-		MPB:  make([]float64, lenB),
-		IdxB: make([]int, lenB),
-	}
-	for i := 0; i < len(mpr.MP); i++ {
-		mpr.MP[i] = -1
-	}
-	for i := 0; i < len(mpr.MPB); i++ {
-		mpr.MPB[i] = -1
-	}
+	mpr := newMPResult(lenA, lenB, math.Inf(-1))
+	meanCentered := mp.meanCentered()
 
 	var c, c_cmp float64
 	var offsetMax int
 	s1 := make([]float64, mp.W)
 	s2 := make([]float64, mp.W)
+	thr := mp.newThrottle(mp.Opts.NJobs)
+	stabilize := mp.Opts.Stabilize
 	for diag := idx; diag < idx+batchSize; diag++ {
+		thr.pause()
 		if diag >= lenA {
 			break
 		}
@@ -1101,27 +2156,30 @@ func (mp MatrixProfile) mpxabBatch(idx int, mua, siga, dfa, dga, mub, sigb, dfb,
 		}
 
 		for offset := 0; offset < offsetMax; offset++ {
-			c += dfb[offset]*dga[offset+diag] + dfa[offset+diag]*dgb[offset]
-			c_cmp = c * (sigb[offset] * siga[offset+diag])
+			if stabilize > 0 && offset > 0 && offset%stabilize == 0 {
+				c = covariance(mp.A, offset+diag, mua[offset+diag], mp.B, offset, mub[offset], mp.W)
+			} else {
+				c += dfb[offset]*dga[offset+diag] + dfa[offset+diag]*dgb[offset]
+			}
+			if meanCentered {
+				c_cmp = negSqDist(c, sigb[offset], siga[offset+diag])
+			} else {
+				c_cmp = c * (sigb[offset] * siga[offset+diag])
+			}
 			if mp.Opts.RemapNegCorr && c_cmp < 0 {
 				c_cmp = -c_cmp
 			}
-			if c_cmp > mpr.MP[offset+diag] {
+			if higher(c_cmp, offset, mpr.MP[offset+diag], mpr.Idx[offset+diag]) {
 				mpr.MP[offset+diag] = c_cmp
 				mpr.Idx[offset+diag] = offset
 			}
-			if c_cmp > mpr.MPB[offset] {
+			if higher(c_cmp, offset+diag, mpr.MPB[offset], mpr.IdxB[offset]) {
 				mpr.MPB[offset] = c_cmp
 				mpr.IdxB[offset] = offset + diag
 			}
 		}
 	}
 
-	if mp.Opts.Euclidean {
-		util.P2E(mpr.MP, mp.W)
-		util.P2E(mpr.MPB, mp.W)
-	}
-
 	return mpr
 }
 
@@ -1136,24 +2194,17 @@ func (mp MatrixProfile) mpxbaBatch(idx int, mua, siga, dfa, dga, mub, sigb, dfb,
 		return &mpResult{}
 	}
 
-	mpr := &mpResult{
-		MP:   make([]float64, lenA),
-		Idx:  make([]int, lenA),
-		MPB:  make([]float64, lenB),
-		IdxB: make([]int, lenB),
-	}
-	for i := 0; i < len(mpr.MP); i++ {
-		mpr.MP[i] = -1
-	}
-	for i := 0; i < len(mpr.MPB); i++ {
-		mpr.MPB[i] = -1
-	}
+	mpr := newMPResult(lenA, lenB, math.Inf(-1))
+	meanCentered := mp.meanCentered()
 
 	var c, c_cmp float64
 	var offsetMax int
 	s1 := make([]float64, mp.W)
 	s2 := make([]float64, mp.W)
+	thr := mp.newThrottle(mp.Opts.NJobs)
+	stabilize := mp.Opts.Stabilize
 	for diag := idx; diag < idx+batchSize; diag++ {
+		thr.pause()
 		if diag >= lenB {
 			break
 		}
@@ -1173,144 +2224,363 @@ func (mp MatrixProfile) mpxbaBatch(idx int, mua, siga, dfa, dga, mub, sigb, dfb,
 		}
 
 		for offset := 0; offset < offsetMax; offset++ {
-			c += dfa[offset]*dgb[offset+diag] + dfb[offset+diag]*dga[offset]
-			c_cmp = c * (siga[offset] * sigb[offset+diag])
+			if stabilize > 0 && offset > 0 && offset%stabilize == 0 {
+				c = covariance(mp.B, offset+diag, mub[offset+diag], mp.A, offset, mua[offset], mp.W)
+			} else {
+				c += dfa[offset]*dgb[offset+diag] + dfb[offset+diag]*dga[offset]
+			}
+			if meanCentered {
+				c_cmp = negSqDist(c, siga[offset], sigb[offset+diag])
+			} else {
+				c_cmp = c * (siga[offset] * sigb[offset+diag])
+			}
 			if mp.Opts.RemapNegCorr && c_cmp < 0 {
 				c_cmp = -c_cmp
 			}
-			if c_cmp > mpr.MP[offset] {
+			if higher(c_cmp, offset+diag, mpr.MP[offset], mpr.Idx[offset]) {
 				mpr.MP[offset] = c_cmp
 				mpr.Idx[offset] = offset + diag
 			}
-			if c_cmp > mpr.MPB[offset+diag] {
+			if higher(c_cmp, offset, mpr.MPB[offset+diag], mpr.IdxB[offset+diag]) {
 				mpr.MPB[offset+diag] = c_cmp
 				mpr.IdxB[offset+diag] = offset
 			}
 		}
 	}
 
-	if mp.Opts.Euclidean {
-		util.P2E(mpr.MP, mp.W)
-		util.P2E(mpr.MPB, mp.W)
-	}
-
 	return mpr
 }
 
-// Analyze performs the matrix profile computation and discovers various features
-// from the profile such as motifs, discords, and segmentation. The results are
-// visualized and saved into an output file.
-func (mp MatrixProfile) Analyze(mo *MPOpts, ao *AnalyzeOpts) error {
-	var err error
-
-	if err = mp.Compute(mo); err != nil {
-		return err
+// covariance returns the sum of the products of the deviations from their
+// means of the subsequences of a at i and b at j, the value MPX updates along
+// each diagonal. It re-seeds the update with the Stabilize option.
+func covariance(a []float64, i int, muA float64, b []float64, j int, muB float64, w int) float64 {
+	var c float64
+	for k := 0; k < w; k++ {
+		c += (a[i+k] - muA) * (b[j+k] - muB)
 	}
+	return c
+}
 
-	if ao == nil {
-		ao = NewAnalyzeOpts()
+// negSqDist returns the negated squared mean-centered euclidean distance
+// between two subsequences given their covariance, c, and the inverse square
+// roots of their sums of squared deviations from MuInvN. Larger values are
+// closer matches so that MPX keeps the same comparisons as for correlations.
+func negSqDist(c, sig1, sig2 float64) float64 {
+	var ss float64
+	if sig1 > 0 {
+		ss += 1 / (sig1 * sig1)
 	}
-
-	_, err = mp.DiscoverMotifs(ao.kMotifs, ao.rMotifs, 10, mp.W/2)
-	if err != nil {
-		return err
+	if sig2 > 0 {
+		ss += 1 / (sig2 * sig2)
 	}
+	return 2*c - ss
+}
 
-	_, err = mp.DiscoverDiscords(ao.kDiscords, mp.W/2)
-	if err != nil {
-		return err
+// negSqDistToDist converts a slice of negated squared distances from
+// negSqDist to euclidean distances in place, leaving undefined entries at +Inf
+func negSqDistToDist(mp []float64) {
+	for i := range mp {
+		if math.IsInf(mp[i], 1) {
+			continue
+		}
+		mp[i] = math.Sqrt(math.Max(0, -mp[i]))
 	}
-
-	return mp.Visualize(ao.OutputFilename)
 }
 
-// DiscoverMotifs will iteratively go through the matrix profile to find the
-// top k motifs with a given radius. Only applies to self joins.
-func (mp *MatrixProfile) DiscoverMotifs(k int, radius float64, neighborCount, exclusionZone int) ([]MotifGroup, error) {
-	if !mp.SelfJoin {
-		return nil, errors.New("can only find top motifs if a self join is performed")
+// Analyze performs the matrix profile computation and discovers various features
+// from the profile such as motifs, discords, and segmentation. Each step can be
+// toggled in the analyze options. The results are returned and, if an output
+// filename is provided, visualized and saved into an output file.
+func (mp MatrixProfile) Analyze(mo *MPOpts, ao *AnalyzeOpts) (*AnalysisResult, error) {
+	if err := mp.Compute(mo); err != nil {
+		return nil, err
 	}
+	return mp.analyze(ao)
+}
 
-	if neighborCount == 0 {
-		neighborCount = 10
+// analyze discovers the features enabled in the analyze options from the
+// computed matrix profile and visualizes them if an output filename is set
+func (mp *MatrixProfile) analyze(ao *AnalyzeOpts) (*AnalysisResult, error) {
+	var err error
+
+	if ao == nil {
+		ao = NewAnalyzeOpts()
 	}
 
-	var err error
-	var minDistIdx int
+	res := &AnalysisResult{}
 
-	motifs := make([]MotifGroup, k)
+	switch ao.AV {
+	case "":
+	case av.Auto:
+		if res.AVRecommendation, err = av.Recommend(mp.A, mp.W); err != nil {
+			return nil, err
+		}
+		mp.AV = res.AVRecommendation.AV
+		mp.log("recommended annotation vector", "annotation_vector", mp.AV, "reason", res.AVRecommendation.Reason)
+	default:
+		mp.AV = ao.AV
+	}
 
-	mpCurrent, _, err := mp.ApplyAV()
-	if err != nil {
-		return nil, err
+	exclusionZone := ao.ExclusionZone
+	if exclusionZone <= 0 {
+		exclusionZone = mp.W / 2
 	}
 
-	if mp.BF == nil {
-		if err = mp.initCaches(); err != nil {
+	if ao.Motifs {
+		res.Motifs, err = mp.DiscoverMotifs(ao.KMotifs, ao.RMotifs, 10, exclusionZone)
+		if err != nil {
+			return nil, err
+		}
+		if err = RankMotifs(res.Motifs, ao.MotifRank); err != nil {
 			return nil, err
 		}
 	}
 
-	prof := make([]float64, len(mpCurrent)) // stores minimum matrix profile distance between motif pairs
-	fft := fourier.NewFFT(mp.N)
-	var j int
+	if ao.Discords {
+		res.Discords, err = mp.DiscoverSeasonalDiscords(ao.KDiscords, exclusionZone, ao.Period)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	for j = 0; j < k; j++ {
-		// find minimum distance and index location
-		motifDistance := math.Inf(1)
-		minIdx := math.MaxInt64
-		for i, d := range mpCurrent {
-			if d < motifDistance {
-				motifDistance = d
-				minIdx = i
+	if ao.Segments {
+		if ao.SegmentOpts != nil {
+			res.SegmentIdx, res.SegmentScore, res.CAC, err = mp.DiscoverSegmentsWithOpts(ao.SegmentOpts)
+			if err != nil {
+				return nil, err
 			}
+		} else {
+			res.SegmentIdx, res.SegmentScore, res.CAC = mp.DiscoverSegments()
 		}
+	}
 
-		if minIdx == math.MaxInt64 {
-			// can't find any more motifs so returning what we currently found
-			return motifs, nil
+	if ao.Regimes > 1 {
+		if res.Regimes, err = mp.analyzeRegimes(ao, exclusionZone); err != nil {
+			return nil, err
 		}
+	}
+	res.setTimes(mp.Times)
 
-		// filter out all indexes that have a distance within r*motifDistance
-		motifSet := make(map[int]struct{})
-		initialMotif := []int{minIdx, mp.Idx[minIdx]}
-		motifSet[minIdx] = struct{}{}
-		motifSet[mp.Idx[minIdx]] = struct{}{}
-
-		if err = mp.distanceProfile(initialMotif[0], prof, fft); err != nil {
+	if ao.Subsequences {
+		if err = res.setSubsequences(*mp); err != nil {
 			return nil, err
 		}
+	}
 
-		// kill off any indices around the initial motif pair since they are
-		// trivial solutions
-		util.ApplyExclusionZone(prof, initialMotif[0], exclusionZone)
-		util.ApplyExclusionZone(prof, initialMotif[1], exclusionZone)
+	if ao.OutputFilename == "" {
+		return res, nil
+	}
+
+	vo := NewVisualizeOpts()
+	vo.Format = ao.OutputFormat
+	if vo.Format == "" {
+		vo.Format = formatFromFilename(ao.OutputFilename, "png")
+	}
+	vo.Motifs = ao.Motifs
+	vo.Discords = ao.Discords
+	vo.CAC = ao.Segments
+
+	err = saveFile(ao.OutputFilename, false, func(w io.Writer) error {
+		return mp.VisualizeTo(w, vo)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// DiscoverMotifs will iteratively go through the matrix profile to find the
+// top k motifs with a given radius. Only applies to self joins. A matrix
+// profile with options set that was never computed is computed first.
+func (mp *MatrixProfile) DiscoverMotifs(k int, radius float64, neighborCount, exclusionZone int) ([]MotifGroup, error) {
+	return mp.discoverMotifs(context.Background(), &MotifOpts{K: k, Radius: radius, NeighborCount: neighborCount, ExclusionZone: exclusionZone})
+}
+
+// DiscoverMotifsWithOpts finds motifs in the same manner as DiscoverMotifs
+// with the parameters of the options. An annotation vector set in the options
+// guides this search only, so different searches can be run over the same
+// matrix profile without changing mp.AV. With a DTW band, more candidates are
+// discovered and the top K by constrained DTW distance are returned.
+func (mp *MatrixProfile) DiscoverMotifsWithOpts(o *MotifOpts) ([]MotifGroup, error) {
+	return mp.DiscoverMotifsContext(context.Background(), o)
+}
+
+// DiscoverMotifsContext finds motifs in the same manner as
+// DiscoverMotifsWithOpts and stops with the error of the context once it is
+// cancelled, leaving mp.Motifs unchanged.
+func (mp *MatrixProfile) DiscoverMotifsContext(ctx context.Context, o *MotifOpts) ([]MotifGroup, error) {
+	if o == nil {
+		o = NewMotifOpts()
+	}
+	if o.DTWBand < 0 {
+		return nil, fmt.Errorf("dtw band must be at least 0, got %d", o.DTWBand)
+	}
+	if o.NeighborCount < 0 || o.MinSeparation < 0 {
+		return nil, fmt.Errorf("neighbor count and minimum separation must be at least 0, got %d and %d", o.NeighborCount, o.MinSeparation)
+	}
+	oc := *o
+	if oc.ExclusionZone <= 0 {
+		oc.ExclusionZone = mp.W / 2
+	}
+	if o.DTWBand == 0 {
+		return mp.discoverMotifs(ctx, &oc)
+	}
+
+	if oc.K = o.Candidates; oc.K < o.K {
+		oc.K = 2 * o.K
+	}
+	motifs, err := mp.discoverMotifs(ctx, &oc)
+	if err != nil {
+		return nil, err
+	}
+	if err = mp.rerankDTW(motifs, o.DTWBand); err != nil {
+		return nil, err
+	}
+	mp.log("reranked motifs by dtw distance", "candidates", len(motifs), "dtw_band", o.DTWBand)
+	if len(motifs) > o.K {
+		motifs = motifs[:o.K]
+	}
+	mp.Motifs = motifs
+	return motifs, nil
+}
+
+// discoverMotifs finds the top K motifs of the options over the matrix
+// profile corrected by the annotation vector from guidedProfile, using the
+// exclusion zone of the options as is. Members added to the closest pair of a
+// group start at least MinSeparation points from every other member if that
+// is wider than the exclusion zone. The profiles are scanned for the closest
+// matches by NJobs goroutines.
+func (mp *MatrixProfile) discoverMotifs(ctx context.Context, o *MotifOpts) ([]MotifGroup, error) {
+	if !mp.SelfJoin {
+		return nil, newError(ErrNotSelfJoin, "can only find top motifs if a self join is performed")
+	}
+	if err := mp.ensureComputed(); err != nil {
+		return nil, err
+	}
+
+	k, radius := o.K, o.Radius
+	exclusionZone, minSeparation := o.ExclusionZone, o.MinSeparation
+	neighborCount := o.NeighborCount
+	if neighborCount == 0 {
+		neighborCount = 10
+	}
+	jobs := o.NJobs
+	if jobs <= 0 {
+		jobs = runtime.NumCPU()
+	}
+	start := time.Now()
+
+	var err error
+	var minDistIdx int
+
+	motifs := make([]MotifGroup, k)
+
+	// motifs are found over euclidean distances so that the closest match is
+	// always the smallest value, even for pearson correlation profiles
+	mpCurrent, err := mp.guidedProfile(o.AV, o.AVec)
+	if err != nil {
+		return nil, err
+	}
+
+	if mp.BF == nil {
+		if err = mp.initCaches(); err != nil {
+			return nil, err
+		}
+	}
+
+	prof := make([]float64, len(mpCurrent)) // stores minimum matrix profile distance between motif pairs
+	centerDists := make([]float64, len(mpCurrent))
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	var j int
+
+	// exclude applies the exclusion zone around idx and always excludes idx
+	// itself so that a zone of 0 can't match the same subsequence forever
+	exclude := func(prof []float64, idx, zone int) {
+		util.ApplyExclusionZone(prof, idx, zone)
+		prof[idx] = math.Inf(1)
+	}
+
+	// separate excludes every subsequence starting less than minSeparation
+	// points from a member so that periodic signals don't flood a group with
+	// trivial repeats
+	separate := func(prof []float64, idx int) {
+		if minSeparation <= exclusionZone {
+			return
+		}
+		for i := idx - minSeparation + 1; i < idx+minSeparation; i++ {
+			if i >= 0 && i < len(prof) {
+				prof[i] = math.Inf(1)
+			}
+		}
+	}
+
+	for j = 0; j < k; j++ {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// find minimum distance and index location
+		minIdx := scanMinIdx(mpCurrent, jobs)
+		if minIdx < 0 || !(mpCurrent[minIdx] < math.Inf(1)) {
+			// can't find any more motifs so returning what we currently found
+			mp.log("discovered motifs", "k", k, "found", j, "n_jobs", jobs, "elapsed", time.Since(start))
+			return motifs, nil
+		}
+		motifDistance := mpCurrent[minIdx]
+
+		// filter out all indexes that have a distance within r*motifDistance
+		motifSet := make(map[int]struct{})
+		initialMotif := []int{minIdx, mp.Idx[minIdx]}
+		motifSet[minIdx] = struct{}{}
+		motifSet[mp.Idx[minIdx]] = struct{}{}
+
+		if err = mp.distanceProfile(initialMotif[0], prof, fft); err != nil {
+			return nil, err
+		}
+		copy(centerDists, prof)
+
+		// kill off any indices around the initial motif pair since they are
+		// trivial solutions
+		exclude(prof, initialMotif[0], exclusionZone)
+		exclude(prof, initialMotif[1], exclusionZone)
+		separate(prof, initialMotif[0])
+		separate(prof, initialMotif[1])
 		if j > 0 {
 			for k := j; k >= 0; k-- {
 				for _, idx := range motifs[k].Idx {
-					util.ApplyExclusionZone(prof, idx, exclusionZone)
+					exclude(prof, idx, exclusionZone)
 				}
 			}
 		}
+		if mp.Opts != nil {
+			// masked subsequences can never be motif members
+			util.ApplyExclusionMask(prof, mp.Opts.Mask)
+		}
 		// keep looking for the closest index to the current motif. Each
 		// index found will have an exclusion zone applied as to remove
 		// trivial solutions. This eventually exits when there's nothing
 		// found within the radius distance.
 		for {
-			minDistIdx = floats.MinIdx(prof)
+			// we hit our limit of neighborCount so stop searching
+			if len(motifSet) >= neighborCount {
+				break
+			}
+			if err = ctx.Err(); err != nil {
+				return nil, err
+			}
+			minDistIdx = scanMinIdx(prof, jobs)
 
 			if prof[minDistIdx] < motifDistance*radius {
 				motifSet[minDistIdx] = struct{}{}
-				util.ApplyExclusionZone(prof, minDistIdx, exclusionZone)
+				exclude(prof, minDistIdx, exclusionZone)
+				separate(prof, minDistIdx)
 			} else {
 				// the closest distance in the profile is greater than the desired
 				// distance so break
 				break
 			}
-			// we hit our limit of neighborCount so stop searching
-			if len(motifSet) == neighborCount {
-				break
-			}
 		}
 
 		// store the found motif indexes and create an exclusion zone around
@@ -1321,29 +2591,61 @@ func (mp *MatrixProfile) DiscoverMotifs(k int, radius float64, neighborCount, ex
 		}
 		for idx := range motifSet {
 			motifs[j].Idx = append(motifs[j].Idx, idx)
-			util.ApplyExclusionZone(mpCurrent, idx, exclusionZone)
+			exclude(mpCurrent, idx, exclusionZone)
 		}
 
 		// sorts the indices in ascending order
 		sort.IntSlice(motifs[j].Idx).Sort()
+		mp.refineMotif(&motifs[j], initialMotif[0], centerDists)
 	}
 	mp.Motifs = motifs[:j]
+	mp.log("discovered motifs", "k", k, "found", j, "n_jobs", jobs, "elapsed", time.Since(start))
 
 	return motifs[:j], nil
 }
 
 // DiscoverDiscords finds the top k time series discords starting indexes from a computed
 // matrix profile. Each discovery of a discord will apply an exclusion zone around
-// the found index so that new discords can be discovered.
+// the found index so that new discords can be discovered. A matrix profile with
+// options set that was never computed is computed first.
 func (mp *MatrixProfile) DiscoverDiscords(k int, exclusionZone int) ([]int, error) {
-	mpCurrent, _, err := mp.ApplyAV()
+	return mp.DiscoverSeasonalDiscords(k, exclusionZone, 0)
+}
+
+// DiscoverSeasonalDiscords finds the top k time series discords in the same
+// manner as DiscoverDiscords for a time series with a known seasonality of
+// period points. Each discovery applies the exclusion zone around every index
+// in the same phase of the period as the found index, so that a pattern
+// recurring every period is only reported once. A period of 0 or less behaves
+// the same as DiscoverDiscords.
+func (mp *MatrixProfile) DiscoverSeasonalDiscords(k, exclusionZone, period int) ([]int, error) {
+	if err := mp.ensureComputed(); err != nil {
+		return nil, err
+	}
+
+	// discords are the largest euclidean distances, the lowest correlations
+	// for pearson correlation profiles
+	mpCurrent, _, err := mp.applyAVEuclidean()
 	if err != nil {
 		return nil, err
 	}
 
+	discords := topDiscords(mpCurrent, k, func(idx int) {
+		util.ApplyPeriodicExclusionZone(mpCurrent, idx, exclusionZone, period)
+	})
+	mp.Discords = discords
+	mp.log("discovered discords", "k", k, "found", len(discords), "period", period)
+
+	return discords, nil
+}
+
+// topDiscords finds up to k indexes of the largest finite values of prof,
+// calling exclude after each one is found so that it and any indexes around
+// it are set to +Inf before the next search.
+func topDiscords(prof []float64, k int, exclude func(idx int)) []int {
 	// if requested k is larger than length of the matrix profile, cap it
-	if k > len(mpCurrent) {
-		k = len(mpCurrent)
+	if k > len(prof) {
+		k = len(prof)
 	}
 
 	discords := make([]int, k)
@@ -1354,7 +2656,7 @@ func (mp *MatrixProfile) DiscoverDiscords(k int, exclusionZone int) ([]int, erro
 	for i = 0; i < k; i++ {
 		maxVal = 0
 		maxIdx = math.MaxInt64
-		for j, val := range mpCurrent {
+		for j, val := range prof {
 			if !math.IsInf(val, 1) && val > maxVal {
 				maxVal = val
 				maxIdx = j
@@ -1366,11 +2668,563 @@ func (mp *MatrixProfile) DiscoverDiscords(k int, exclusionZone int) ([]int, erro
 		}
 
 		discords[i] = maxIdx
-		util.ApplyExclusionZone(mpCurrent, maxIdx, exclusionZone)
+		exclude(maxIdx)
+	}
+
+	return discords[:i]
+}
+
+// kNeighborProfile computes the euclidean distance from each subsequence of a
+// self join to its k-th nearest neighbor. Each neighbor found is excluded
+// along with the subsequences within the exclusion zone around it before the
+// next one is searched, so that trivial matches of a neighbor are not counted
+// again. The mask and session boundaries of the options apply. Subsequences
+// with fewer than k neighbors are +Inf.
+func (mp MatrixProfile) kNeighborProfile(k int) ([]float64, error) {
+	if err := mp.initCaches(); err != nil {
+		return nil, err
 	}
-	mp.Discords = discords[:i]
 
-	return discords[:i], nil
+	zone := mp.exclusionZone()
+	kprof := make([]float64, len(mp.A)-mp.W+1)
+	prof := make([]float64, len(kprof))
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for i := range kprof {
+		kprof[i] = math.Inf(1)
+		if mp.crossesBoundary(i) {
+			continue
+		}
+		err := mp.distanceProfile(i, prof, fft)
+		if Cause(err) == ErrZeroStd {
+			// constant subsequences have no defined distance
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if mp.Opts.Mask != nil {
+			util.ApplyExclusionMask(prof, mp.Opts.Mask)
+		}
+		kprof[i] = kthNeighbor(prof, k, zone)
+	}
+	return kprof, nil
+}
+
+// kthNeighbor returns the k-th smallest distance of a distance profile after
+// excluding the zone around each smaller one found, or +Inf if there are
+// fewer than k. The profile is modified.
+func kthNeighbor(prof []float64, k, zone int) float64 {
+	for n := 0; ; n++ {
+		best, idx := math.Inf(1), -1
+		for j, d := range prof {
+			if d < best {
+				best, idx = d, j
+			}
+		}
+		if idx < 0 || n == k-1 {
+			return best
+		}
+		util.ApplyExclusionZone(prof, idx, zone)
+	}
+}
+
+// DiscoverScoredDiscords finds the top k time series discords in the same manner as
+// DiscoverDiscords and scores each one by its matrix profile value, standard score
+// and percentile relative to the rest of the matrix profile.
+func (mp *MatrixProfile) DiscoverScoredDiscords(k int, exclusionZone int) ([]Discord, error) {
+	idxs, err := mp.DiscoverDiscords(k, exclusionZone)
+	if err != nil {
+		return nil, err
+	}
+
+	discords := scoreDiscords(mp.MP, idxs, mp.W, mp.Opts.Euclidean)
+	mp.setDiscordNeighbors(discords)
+	return discords, nil
+}
+
+// DiscoverDiscordsWithOpts finds the top k time series discords in the same
+// manner as DiscoverScoredDiscords while choosing how found discords are
+// excluded from the rest of the search with the options. Each discord also
+// holds the index of and euclidean distance to its nearest neighbor. A matrix
+// profile with options set that was never computed is computed first. With
+// the KNeighbor option, discords are ranked and scored by the distance to
+// their k-th nearest neighbor, which is robust to twin freaks, anomalies that
+// occur twice and so are each other's close nearest neighbor.
+func (mp *MatrixProfile) DiscoverDiscordsWithOpts(o *DiscordOpts) ([]Discord, error) {
+	if o == nil {
+		o = NewDiscordOpts()
+	}
+	if o.KNeighbor < 0 {
+		return nil, fmt.Errorf("k-th nearest neighbor must be at least 0, got %d", o.KNeighbor)
+	}
+	if err := mp.ensureComputed(); err != nil {
+		return nil, err
+	}
+
+	// ranking by a farther neighbor replaces the matrix profile searched and
+	// scored with the k-th nearest neighbor profile
+	ranked := *mp
+	if o.KNeighbor > 1 {
+		if !mp.SelfJoin {
+			return nil, newError(ErrNotSelfJoin, "discords by k-th nearest neighbor require a self join")
+		}
+		kprof, err := mp.kNeighborProfile(o.KNeighbor)
+		if err != nil {
+			return nil, err
+		}
+		if !mp.Opts.Euclidean {
+			euclideanToPearson(kprof, mp.W)
+		}
+		ranked.MP = kprof
+	}
+
+	mpCurrent, err := ranked.guidedProfile(o.AV, o.AVec)
+	if err != nil {
+		return nil, err
+	}
+
+	var exclude func(idx int)
+	switch o.Policy {
+	case ExcludeNone:
+		exclude = func(idx int) { mpCurrent[idx] = math.Inf(1) }
+	case "", ExcludeZone:
+		zone := o.ExclusionZone
+		if zone <= 0 {
+			zone = mp.exclusionZone()
+		}
+		exclude = func(idx int) {
+			util.ApplyPeriodicExclusionZone(mpCurrent, idx, zone, o.Period)
+		}
+	case ExcludeMask:
+		if len(o.Mask) != len(mpCurrent) {
+			return nil, fmt.Errorf("mask length, %d, must match the matrix profile length, %d", len(o.Mask), len(mpCurrent))
+		}
+		util.ApplyExclusionMask(mpCurrent, o.Mask)
+		exclude = func(idx int) { mpCurrent[idx] = math.Inf(1) }
+	default:
+		return nil, fmt.Errorf("unsupported exclusion policy, %s", o.Policy)
+	}
+
+	idxs := topDiscords(mpCurrent, o.K, exclude)
+	mp.Discords = idxs
+	mp.log("discovered discords", "k", o.K, "found", len(idxs), "policy", o.Policy, "k_neighbor", o.KNeighbor)
+
+	discords := scoreDiscords(ranked.MP, idxs, mp.W, mp.Opts.Euclidean)
+	mp.setDiscordNeighbors(discords)
+	return discords, nil
+}
+
+// setDiscordNeighbors sets the nearest neighbor index and euclidean distance
+// of each discord from the matrix profile and its index
+func (mp MatrixProfile) setDiscordNeighbors(discords []Discord) {
+	d := make([]float64, 1)
+	for i := range discords {
+		idx := discords[i].Idx
+		d[0] = mp.MP[idx]
+		if mp.Opts != nil && !mp.Opts.Euclidean {
+			pearsonToEuclidean(d, mp.W)
+		}
+		discords[i].NeighborDist = d[0]
+		discords[i].NeighborIdx = math.MaxInt64
+		if idx < len(mp.Idx) {
+			discords[i].NeighborIdx = mp.Idx[idx]
+		}
+	}
+}
+
+// DiscoverNovelties finds the top k subsequences where a new behavior emerges.
+// These are subsequences whose nearest neighbor before them is far away while
+// their nearest neighbor after them is close, ranked by the difference between
+// the left and right matrix profiles. Subsequences near the start of the time
+// series have few earlier neighbors to match and tend to score highly as well.
+// Requires the left and right matrix profiles to be computed with the
+// LeftRight option. Each discovery applies an
+// exclusion zone around the found index so that new novelties can be
+// discovered.
+func (mp *MatrixProfile) DiscoverNovelties(k int, exclusionZone int) ([]Novelty, error) {
+	if mp.LMP == nil || mp.RMP == nil {
+		return nil, newError(ErrNotComputed, "left and right matrix profiles must be computed to find novelties")
+	}
+
+	lmp := append([]float64(nil), mp.LMP...)
+	rmp := append([]float64(nil), mp.RMP...)
+	if mp.Opts != nil && !mp.Opts.Euclidean {
+		pearsonToEuclidean(lmp, mp.W)
+		pearsonToEuclidean(rmp, mp.W)
+	}
+
+	// subsequences without a neighbor on either side can't be scored
+	score := make([]float64, len(lmp))
+	for i := range score {
+		score[i] = lmp[i] - rmp[i]
+		if math.IsInf(lmp[i], 0) || math.IsInf(rmp[i], 0) || math.IsNaN(score[i]) {
+			score[i] = math.Inf(1)
+		}
+	}
+
+	var novelties []Novelty
+	for len(novelties) < k {
+		maxIdx, maxVal := -1, 0.0
+		for i, v := range score {
+			if !math.IsInf(v, 1) && v > maxVal {
+				maxIdx, maxVal = i, v
+			}
+		}
+		if maxIdx < 0 {
+			break
+		}
+
+		novelties = append(novelties, Novelty{Idx: maxIdx, LeftDist: lmp[maxIdx], RightDist: rmp[maxIdx]})
+		util.ApplyExclusionZone(score, maxIdx, exclusionZone)
+	}
+
+	return novelties, nil
+}
+
+// DiscoverChains finds the time series chains of the matrix profile, the
+// sequences of subsequences where the right nearest neighbor of each link is
+// the next link and the left nearest neighbor of the next link points back.
+// Chains show a pattern that drifts over time. Only chains that aren't part of
+// a longer chain are returned, longest first with ties in order of the first
+// link, so the first chain is the unanchored chain. Requires the left and
+// right matrix profiles to be computed with the LeftRight option.
+func (mp MatrixProfile) DiscoverChains() ([][]int, error) {
+	if mp.LIdx == nil || mp.RIdx == nil {
+		return nil, newError(ErrNotComputed, "left and right matrix profiles must be computed to find chains")
+	}
+
+	// a link from i to j requires both to be each other's nearest neighbor in
+	// their direction of time
+	next := func(i int) int {
+		j := mp.RIdx[i]
+		if j < 0 || j >= len(mp.LIdx) || mp.LIdx[j] != i {
+			return -1
+		}
+		return j
+	}
+
+	linked := make([]bool, len(mp.RIdx))
+	for i := range mp.RIdx {
+		if j := next(i); j >= 0 {
+			linked[j] = true
+		}
+	}
+
+	var chains [][]int
+	for i := range mp.RIdx {
+		if linked[i] || next(i) < 0 {
+			continue
+		}
+		chain := []int{i}
+		for j := next(i); j >= 0; j = next(j) {
+			chain = append(chain, j)
+		}
+		chains = append(chains, chain)
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		return len(chains[i]) > len(chains[j])
+	})
+	return chains, nil
+}
+
+// indexedByA returns whether the matrix profile of an AB join is indexed by
+// the subsequences of a with the BA join profile indexed by b, as computed by
+// MPX. The other algorithms, including sampled STAMP, index the matrix profile
+// by the subsequences of b and leave the BA join profile unset.
+func (mp MatrixProfile) indexedByA() bool {
+	return mp.Opts != nil && mp.Opts.Algorithm == AlgoMPX && mp.Opts.SamplePct >= 1 && mp.Opts.Weights == nil
+}
+
+// profileOfB returns the euclidean distance from every subsequence of b to
+// its nearest neighbor in a along with the index of that neighbor. MPX stores
+// these in the BA join profile while the other algorithms, including sampled
+// STAMP, store them in the matrix profile.
+func (mp MatrixProfile) profileOfB() ([]float64, []int, error) {
+	if mp.SelfJoin {
+		return nil, nil, newError(ErrNotABJoin, "can only find matches of b in a if an AB join is performed")
+	}
+	if mp.Opts == nil || mp.MP == nil {
+		return nil, nil, ErrNotComputed
+	}
+
+	mpx := mp.indexedByA()
+	prof, idx := mp.MP, mp.Idx
+	if mpx {
+		prof, idx = mp.MPB, mp.IdxB
+	}
+	if len(prof) != len(mp.B)-mp.W+1 || len(idx) != len(prof) {
+		return nil, nil, fmt.Errorf("expected a profile of length %d for b, got %d", len(mp.B)-mp.W+1, len(prof))
+	}
+
+	out := append([]float64(nil), prof...)
+	if !mp.Opts.Euclidean {
+		pearsonToEuclidean(out, mp.W)
+	}
+	return out, idx, nil
+}
+
+// discoverJoin finds the top k subsequences of b by their distance to the
+// nearest neighbor in a, largest first if discords is set and smallest first
+// otherwise. Subsequences without a defined distance are skipped.
+func (mp MatrixProfile) discoverJoin(k, exclusionZone int, discords bool) ([]JoinMatch, error) {
+	prof, idx, err := mp.profileOfB()
+	if err != nil {
+		return nil, err
+	}
+	cur, err := applySingleAV(prof, mp.B, mp.W, mp.AV)
+	if err != nil {
+		return nil, err
+	}
+	if exclusionZone <= 0 {
+		exclusionZone = mp.W / 2
+	}
+
+	var matches []JoinMatch
+	for len(matches) < k {
+		bestIdx := -1
+		for i, v := range cur {
+			if math.IsInf(v, 0) || math.IsNaN(v) {
+				continue
+			}
+			if bestIdx < 0 || (discords && v > cur[bestIdx]) || (!discords && v < cur[bestIdx]) {
+				bestIdx = i
+			}
+		}
+		if bestIdx < 0 {
+			break
+		}
+
+		matches = append(matches, JoinMatch{BIdx: bestIdx, AIdx: idx[bestIdx], Dist: prof[bestIdx]})
+		util.ApplyExclusionZone(cur, bestIdx, exclusionZone)
+	}
+	return matches, nil
+}
+
+// DiscoverJoinDiscords finds the top k subsequences of b with no close match
+// in a from an AB join, such as anomalies of a test time series relative to a
+// reference time series a. This works for every algorithm regardless of
+// whether the distances from b are stored in the matrix profile or the BA join
+// matrix profile. Each discovery applies an exclusion zone around the found
+// index so that trivially overlapping subsequences are not returned. Defaults
+// to half the subsequence length if the exclusion zone is 0.
+func (mp MatrixProfile) DiscoverJoinDiscords(k, exclusionZone int) ([]JoinMatch, error) {
+	return mp.discoverJoin(k, exclusionZone, true)
+}
+
+// DiscoverJoinMotifs finds the top k subsequences of b with the closest match
+// in a from an AB join, the patterns conserved between both time series. The
+// exclusion zone is applied in the same manner as DiscoverJoinDiscords.
+func (mp MatrixProfile) DiscoverJoinMotifs(k, exclusionZone int) ([]JoinMatch, error) {
+	return mp.discoverJoin(k, exclusionZone, false)
+}
+
+// DiscoverJoinMotifGroups finds the top k patterns of a occurring in b from an
+// AB join, such as known patterns of a reference library a within new data b.
+// Each group starts from the subsequence of b with the closest match in a that
+// is not yet part of a group, and adds every other subsequence of b within
+// radius times that closest distance of the matching pattern of a, up to
+// neighborCount occurrences. Groups are returned closest first. The exclusion
+// zone is applied around every occurrence in the same manner as
+// DiscoverJoinMotifs. Requires z-normalized unweighted distances.
+func (mp MatrixProfile) DiscoverJoinMotifGroups(k int, radius float64, neighborCount, exclusionZone int) ([]JoinMotifGroup, error) {
+	prof, idx, err := mp.profileOfB()
+	if err != nil {
+		return nil, err
+	}
+	if mp.Opts.Weights != nil || mp.meanCentered() {
+		return nil, errors.New("join motif groups require z-normalized unweighted distances")
+	}
+	cur, err := applySingleAV(prof, mp.B, mp.W, mp.AV)
+	if err != nil {
+		return nil, err
+	}
+	if exclusionZone <= 0 {
+		exclusionZone = mp.W / 2
+	}
+	if neighborCount <= 0 {
+		neighborCount = 10
+	}
+
+	if mp.BF == nil {
+		if err = mp.initCaches(); err != nil {
+			return nil, err
+		}
+	}
+	dists := make([]float64, len(prof))
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+
+	var groups []JoinMotifGroup
+	for len(groups) < k {
+		best := -1
+		for i, v := range cur {
+			if !math.IsInf(v, 0) && !math.IsNaN(v) && (best < 0 || v < cur[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+
+		g := JoinMotifGroup{AIdx: idx[best], MinDist: prof[best]}
+		if err = mp.mass(mp.A[g.AIdx:g.AIdx+mp.W], dists, fft); err != nil {
+			return nil, err
+		}
+		for i, d := range dists {
+			// constant subsequences in b have no defined distance
+			if math.IsNaN(d) {
+				dists[i] = math.Inf(1)
+			}
+		}
+		// keeps the distance of every occurrence to the pattern before any
+		// exclusion zones are applied
+		patternDists := append([]float64(nil), dists...)
+		// occurrences already grouped with another pattern are not reused
+		for _, prev := range groups {
+			for _, i := range prev.BIdx {
+				util.ApplyExclusionZone(dists, i, exclusionZone)
+			}
+		}
+
+		members := []int{best}
+		util.ApplyExclusionZone(dists, best, exclusionZone)
+		for len(members) < neighborCount {
+			i := floats.MinIdx(dists)
+			if !(dists[i] < g.MinDist*radius) {
+				break
+			}
+			members = append(members, i)
+			util.ApplyExclusionZone(dists, i, exclusionZone)
+		}
+
+		sort.Ints(members)
+		for _, i := range members {
+			g.BIdx = append(g.BIdx, i)
+			g.Dists = append(g.Dists, patternDists[i])
+			util.ApplyExclusionZone(cur, i, exclusionZone)
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// RangeQuery finds every occurrence of the query subsequence in the b time
+// series with a z-normalized euclidean distance within radius. The query must
+// be of length W. Matches are found closest first and an exclusion zone is
+// applied around each one so that trivially overlapping subsequences are not
+// reported as separate occurrences. An exclusion zone of 0 returns every index
+// within the radius. The matches are returned in ascending order of index.
+func (mp *MatrixProfile) RangeQuery(q []float64, radius float64, exclusionZone int) ([]RangeMatch, error) {
+	if len(q) != mp.W {
+		return nil, fmt.Errorf("query length %d must match the subsequence length %d", len(q), mp.W)
+	}
+	if radius < 0 {
+		return nil, fmt.Errorf("radius must be non-negative, got %.3f", radius)
+	}
+
+	if mp.BF == nil {
+		if err := mp.initCaches(); err != nil {
+			return nil, err
+		}
+	}
+
+	prof := make([]float64, mp.N-mp.W+1)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	if err := mp.mass(q, prof, fft); err != nil {
+		return nil, err
+	}
+	for i, d := range prof {
+		// constant subsequences in b have no defined distance
+		if math.IsNaN(d) {
+			prof[i] = math.Inf(1)
+		}
+	}
+
+	var matches []RangeMatch
+	for {
+		minIdx := floats.MinIdx(prof)
+		if prof[minIdx] > radius || math.IsInf(prof[minIdx], 1) {
+			break
+		}
+		matches = append(matches, RangeMatch{Idx: minIdx, Dist: prof[minIdx]})
+		if exclusionZone > 0 {
+			util.ApplyExclusionZone(prof, minIdx, exclusionZone)
+		} else {
+			prof[minIdx] = math.Inf(1)
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Idx < matches[j].Idx })
+	return matches, nil
+}
+
+// BatchDistanceProfiles computes the z-normalized euclidean distance profile
+// of each query against every subsequence of the b time series. Every query
+// must be of length W. The fourier transform of b is computed once and reused
+// for all queries, which are spread across mp.Opts.NJobs workers, or one per
+// cpu if the options are not set. Constant subsequences in b have no defined
+// distance and are set to +Inf. The profiles are returned in query order.
+func (mp *MatrixProfile) BatchDistanceProfiles(queries [][]float64) ([][]float64, error) {
+	for i, q := range queries {
+		if len(q) != mp.W {
+			return nil, fmt.Errorf("query %d length %d must match the subsequence length %d", i, len(q), mp.W)
+		}
+	}
+
+	if mp.BF == nil {
+		if err := mp.initCaches(); err != nil {
+			return nil, err
+		}
+	}
+
+	workers := runtime.NumCPU()
+	if mp.Opts != nil && mp.Opts.NJobs > 0 {
+		workers = mp.Opts.NJobs
+	}
+	if workers > len(queries) {
+		workers = len(queries)
+	}
+
+	profs := make([][]float64, len(queries))
+	errs := make([]error, len(queries))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			fft := getFFT(mp.N)
+			defer putFFT(fft)
+			for idx := range jobs {
+				prof := make([]float64, mp.N-mp.W+1)
+				if errs[idx] = mp.mass(queries[idx], prof, fft); errs[idx] != nil {
+					continue
+				}
+				for i, d := range prof {
+					if math.IsNaN(d) {
+						prof[i] = math.Inf(1)
+					}
+				}
+				profs[idx] = prof
+			}
+		}()
+	}
+	for i := range queries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute the distance profile of query %d, %v", i, err)
+		}
+	}
+	return profs, nil
 }
 
 // DiscoverSegments finds the the index where there may be a potential timeseries
@@ -1379,17 +3233,14 @@ func (mp *MatrixProfile) DiscoverDiscords(k int, exclusionZone int) ([]int, erro
 // the matrix profile index. This approach is based on the UCR paper on
 // segmentation of timeseries using matrix profiles which can be found
 // https://www.cs.ucr.edu/%7Eeamonn/Segmentation_ICDM.pdf
+// No segment is found if the last Compute failed.
 func (mp MatrixProfile) DiscoverSegments() (int, float64, []float64) {
-	histo := arcCurve(mp.Idx)
-
-	for i := 0; i < len(histo); i++ {
-		if i == 0 || i == len(histo)-1 {
-			histo[i] = math.Min(1.0, float64(len(histo)))
-		} else {
-			histo[i] = math.Min(1.0, histo[i]/iac(float64(i), len(histo)))
-		}
+	if mp.stale {
+		return math.MaxInt64, math.Inf(1), nil
 	}
 
+	histo := mp.correctedArcCurve(nil)
+
 	minIdx := math.MaxInt64
 	minVal := math.Inf(1)
 	for i := 0; i < len(histo); i++ {
@@ -1402,28 +3253,102 @@ func (mp MatrixProfile) DiscoverSegments() (int, float64, []float64) {
 	return minIdx, float64(minVal), histo
 }
 
-// Visualize creates a png of the matrix profile given a matrix profile.
-func (mp MatrixProfile) Visualize(fn string) error {
-	sigPts := points(mp.A, len(mp.A))
-	mpPts := points(mp.MP, len(mp.A))
-	motifPts := make([][]plotter.XYs, len(mp.Motifs))
-	discordPts := make([]plotter.XYs, len(mp.Discords))
-	discordLabels := make([]string, len(mp.Discords))
+// correctedArcCurve computes the arc curve of the matrix profile index, read
+// from mp.Arcs if tracked, corrected by the ideal arc curve
+func (mp MatrixProfile) correctedArcCurve(ideal []float64) []float64 {
+	if mp.trackingArcs() {
+		return mp.Arcs.Corrected(ideal)
+	}
+	return correctArcCurve(arcCurve(mp.Idx), ideal)
+}
 
-	for i := 0; i < len(mp.Motifs); i++ {
-		motifPts[i] = make([]plotter.XYs, len(mp.Motifs[i].Idx))
+// correctArcCurve divides an arc curve in place by the ideal arc curve and
+// caps it at 1. The parabola from iac is used if ideal is nil. Points where no
+// arcs are expected are set to 1.
+func correctArcCurve(histo, ideal []float64) []float64 {
+	for i := 0; i < len(histo); i++ {
+		switch {
+		case i == 0 || i == len(histo)-1:
+			histo[i] = math.Min(1.0, float64(len(histo)))
+		case ideal == nil:
+			histo[i] = math.Min(1.0, histo[i]/iac(float64(i), len(histo)))
+		case ideal[i] <= 0:
+			histo[i] = 1
+		default:
+			histo[i] = math.Min(1.0, histo[i]/ideal[i])
+		}
 	}
+	return histo
+}
 
-	for i := 0; i < len(mp.Motifs); i++ {
-		for j, idx := range mp.Motifs[i].Idx {
-			motifPts[i][j] = points(mp.A[idx:idx+mp.W], mp.W)
+// DiscoverRegimes finds the boundaries between numRegimes regimes of the time
+// series from the corrected arc curve. The lowest point of the curve is picked
+// as a boundary and an exclusion zone is applied around it before picking the
+// next one, until numRegimes-1 boundaries are found or no candidates remain.
+// Points within the exclusion zone of either end of the curve are never
+// picked since few arcs can cross them. Defaults to 5 subsequence lengths if
+// the exclusion zone is 0. The boundaries are returned in ascending order of
+// index. This is the regime extraction of the UCR paper, Matrix Profile VIII:
+// Domain Agnostic Online Semantic Segmentation at Superhuman Performance
+// Levels, by Gharghabi et al.
+func (mp MatrixProfile) DiscoverRegimes(numRegimes, exclusionZone int) ([]Regime, error) {
+	if numRegimes < 1 {
+		return nil, fmt.Errorf("number of regimes must be at least 1, got %d", numRegimes)
+	}
+	if mp.stale || mp.Idx == nil {
+		return nil, ErrNotComputed
+	}
+	if exclusionZone <= 0 {
+		exclusionZone = 5 * mp.W
+	}
+
+	cac := mp.correctedArcCurve(nil)
+	for i := range cac {
+		if i < exclusionZone || i >= len(cac)-exclusionZone {
+			cac[i] = math.Inf(1)
 		}
 	}
 
-	for i, idx := range mp.Discords {
-		discordPts[i] = points(mp.A[idx:idx+mp.W], mp.W)
-		discordLabels[i] = strconv.Itoa(idx)
+	regimes := make([]Regime, 0, numRegimes-1)
+	for len(regimes) < numRegimes-1 {
+		minIdx, minVal := -1, math.Inf(1)
+		for i, v := range cac {
+			if v < minVal {
+				minIdx, minVal = i, v
+			}
+		}
+		if minIdx < 0 {
+			break
+		}
+
+		regimes = append(regimes, Regime{Idx: minIdx, Score: minVal})
+		util.ApplyExclusionZone(cac, minIdx, exclusionZone)
 	}
 
-	return plotMP(sigPts, mpPts, motifPts, discordPts, discordLabels, fn)
+	sort.Slice(regimes, func(i, j int) bool {
+		return regimes[i].Idx < regimes[j].Idx
+	})
+	return regimes, nil
+}
+
+// Visualize creates an image of the matrix profile given a matrix profile. The
+// image format is chosen from the file extension and defaults to png.
+func (mp MatrixProfile) Visualize(fn string) error {
+	o := NewVisualizeOpts()
+	o.Format = formatFromFilename(fn, o.Format)
+
+	return saveFile(fn, false, func(w io.Writer) error {
+		return mp.VisualizeTo(w, o)
+	})
+}
+
+// VisualizeChain creates an image of a time series chain from DiscoverChains.
+// The image format is chosen from the file extension and defaults to png.
+func (mp MatrixProfile) VisualizeChain(fn string, chain []int) error {
+	o := NewVisualizeOpts()
+	o.Format = formatFromFilename(fn, o.Format)
+
+	return saveFile(fn, false, func(w io.Writer) error {
+		return mp.VisualizeChainTo(w, chain, o)
+	})
 }
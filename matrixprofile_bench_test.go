@@ -1,6 +1,7 @@
 package matrixprofile
 
 import (
+	"fmt"
 	"math/rand"
 	"testing"
 
@@ -80,6 +81,34 @@ func BenchmarkCrossCorrelate(b *testing.B) {
 	}
 }
 
+func BenchmarkCrossCorrelateKernels(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		sig := setupData(n)
+		for _, w := range []int{8, 16, 32, 64, 128} {
+			mp, err := New(sig[:w], sig, w)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err = mp.initCaches(); err != nil {
+				b.Fatal(err)
+			}
+			q := sig[:w]
+			fft := fourier.NewFFT(mp.N)
+
+			b.Run(fmt.Sprintf("fft_n%d_w%d", n, w), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					mp.fftCrossCorrelate(q, fft)
+				}
+			})
+			b.Run(fmt.Sprintf("direct_n%d_w%d", n, w), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					slidingDot(q, mp.B)
+				}
+			})
+		}
+	}
+}
+
 func BenchmarkMass(b *testing.B) {
 	sig := setupData(1000)
 	var err error
@@ -301,6 +330,30 @@ func BenchmarkMpx(b *testing.B) {
 	}
 }
 
+func BenchmarkMpxReuseOutput(b *testing.B) {
+	sig := setupData(4096)
+	mp, err := New(sig, nil, 128)
+	if err != nil {
+		b.Error(err)
+	}
+
+	o := NewMPOpts()
+	o.Algorithm = AlgoMPX
+	o.NJobs = 2
+	o.ReuseOutput = true
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		err = mp.Compute(o)
+		if err != nil {
+			b.Error(err)
+		}
+		if len(mp.MP) < 1 || len(mp.Idx) < 1 {
+			b.Error("expected at least one value from matrix profile and matrix profile index")
+		}
+	}
+}
+
 func BenchmarkUpdate(b *testing.B) {
 	sig := setupData(5000)
 	mp, err := New(sig, nil, 32)
@@ -1,13 +1,21 @@
 package matrixprofile
 
 import (
+	"bytes"
+	"context"
 	"math"
+	"math/rand"
 	"os"
+	"reflect"
 	"sort"
 	"testing"
 
 	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
 	"gonum.org/v1/gonum/dsp/fourier"
+	"gonum.org/v1/gonum/floats"
+	"gonum.org/v1/gonum/stat"
 )
 
 func TestNew(t *testing.T) {
@@ -125,6 +133,97 @@ func TestLoad(t *testing.T) {
 
 }
 
+func TestEncodeDecode(t *testing.T) {
+	ts := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	w := 3
+	p, err := New(ts, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = p.Encode(&buf, "json"); err != nil {
+		t.Errorf("Received error while encoding matrix profile, %v", err)
+	}
+
+	newP := &MatrixProfile{}
+	if err = newP.Decode(&buf, "json"); err != nil {
+		t.Errorf("Failed to decode matrix profile, %v", err)
+	}
+
+	if newP.W != w {
+		t.Errorf("Expected window of %d, but got %d", w, newP.W)
+	}
+	if len(newP.MP) != len(p.MP) {
+		t.Errorf("Expected matrix profile length of %d, but got %d", len(p.MP), len(newP.MP))
+	}
+
+	if err = p.Encode(&buf, "csv"); err == nil {
+		t.Errorf("Expected an error for an invalid encoding format")
+	}
+}
+
+func TestEncodeCaches(t *testing.T) {
+	p, err := New(setupData(100), nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	if err = p.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		format      string
+		expectCache bool
+	}{
+		{"json", false},
+		{"json_caches", true},
+	}
+
+	for _, d := range testdata {
+		var buf bytes.Buffer
+		if err = p.Encode(&buf, d.format); err != nil {
+			t.Fatalf("Received error while encoding matrix profile as %s, %v", d.format, err)
+		}
+		if hasCache := bytes.Contains(buf.Bytes(), []byte(`"a_mean"`)); hasCache != d.expectCache {
+			t.Errorf("Expected caches encoded to be %t, but got %t for %s", d.expectCache, hasCache, d.format)
+		}
+		if bytes.Contains(buf.Bytes(), []byte(`"b_fft"`)) {
+			t.Errorf("Expected the fourier transform to never be encoded for %s", d.format)
+		}
+
+		// decoding over an existing profile drops its stale caches
+		newP, err := New([]float64{1, 2, 3, 4, 5}, nil, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = newP.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+		if err = newP.Decode(&buf, d.format); err != nil {
+			t.Fatalf("Failed to decode matrix profile as %s, %v", d.format, err)
+		}
+		if newP.BF != nil || (len(newP.AMean) > 0) != d.expectCache {
+			t.Errorf("Expected only the encoded caches after decoding %s", d.format)
+		}
+
+		// caches are derived again when needed
+		motifs, err := newP.DiscoverMotifs(1, 2, 0, 4)
+		if err != nil {
+			t.Fatalf("Failed to discover motifs after decoding %s, %v", d.format, err)
+		}
+		expected, _ := p.DiscoverMotifs(1, 2, 0, 4)
+		if len(motifs) != len(expected) || motifs[0].Idx[0] != expected[0].Idx[0] {
+			t.Errorf("Expected motifs %v, but got %v after decoding %s", expected, motifs, d.format)
+		}
+	}
+}
+
 func TestMPDist(t *testing.T) {
 	testData := []struct {
 		a        []float64
@@ -156,6 +255,85 @@ func TestMPDist(t *testing.T) {
 	}
 }
 
+func TestMPDistThreshold(t *testing.T) {
+	a := []float64{1, 2, 3, 4, 3, 2, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	b := []float64{0, 0, 0, 0, 0, 0, 0, 0, -1, -2, -3, -2, -1, 0, 1, 2, 1, 0}
+
+	o := NewMPDistOpts()
+	o.Threshold = -0.1
+	if _, err := MPDist(a, b, 5, o); err == nil {
+		t.Errorf("Expected an error for a negative threshold")
+	}
+
+	o.Threshold = 1
+	res, err := MPDist(a, b, 5, o)
+	if err != nil {
+		t.Errorf("Did not expect to get an error, %v", err)
+	}
+	low, err := MPDist(a, b, 5, nil)
+	if err != nil {
+		t.Errorf("Did not expect to get an error, %v", err)
+	}
+	if res < low {
+		t.Errorf("Expected a higher threshold to produce a distance of at least %.6f, but got %.6f", low, res)
+	}
+
+	// options built without a threshold use the default one
+	unset, err := MPDist(a, b, 5, &MPDistOpts{AV: av.Default, Opts: NewMPOpts()})
+	if err != nil {
+		t.Errorf("Did not expect to get an error, %v", err)
+	}
+	if unset != low {
+		t.Errorf("Expected an unset threshold to default to %.6f, but got %.6f", low, unset)
+	}
+}
+
+func TestMPDistVector(t *testing.T) {
+	a := []float64{0, 1, 2, 3, 2, 1, 0, -1, -2, -1, 0, 1, 2, 1}
+	b := make([]float64, 60)
+	for i := range b {
+		b[i] = math.Sin(float64(i)/3) + 0.3*math.Cos(float64(i*i)/7)
+	}
+	w, l := 4, 16
+
+	o := NewMPDistOpts()
+	out, err := MPDistVector(a, b, w, l, o)
+	if err != nil {
+		t.Fatalf("Did not expect to get an error, %v", err)
+	}
+	if len(out) != len(b)-l+1 {
+		t.Fatalf("Expected %d elements, but got %d", len(b)-l+1, len(out))
+	}
+
+	for i := range out {
+		expected, err := MPDist(a, b[i:i+l], w, o)
+		if err != nil {
+			t.Fatalf("Did not expect to get an error, %v", err)
+		}
+		if math.Abs(out[i]-expected) > 1e-4 {
+			t.Errorf("Expected %.6f at index %d, but got %.6f", expected, i, out[i])
+		}
+	}
+
+	if _, err = MPDistVector(a, b, w, w-1, o); err == nil {
+		t.Errorf("Expected an error for a window length smaller than the subsequence length")
+	}
+
+	// options without a threshold or matrix profile options use the defaults
+	unset, err := MPDistVector(a, b, w, l, &MPDistOpts{AV: av.Default})
+	if err != nil {
+		t.Fatalf("Did not expect to get an error, %v", err)
+	}
+	if !reflect.DeepEqual(unset, out) {
+		t.Errorf("Expected the default options to give %v, but got %v", out, unset)
+	}
+
+	o.AV = av.Complexity
+	if _, err = MPDistVector(a, b, w, l, o); err == nil {
+		t.Errorf("Expected an error for an annotation vector other than the default")
+	}
+}
+
 func TestCrossCorrelate(t *testing.T) {
 	var err error
 	var out []float64
@@ -292,7 +470,7 @@ func TestDistanceProfile(t *testing.T) {
 		{[]float64{}, []float64{}, 2, 0, nil},
 		{[]float64{1, 1, 1, 1, 1}, []float64{}, 2, 0, nil},
 		{[]float64{}, []float64{1, 1, 1, 1, 1}, 2, 0, nil},
-		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 0, []float64{math.Inf(1), math.Inf(1), 4, 2.8284271247461903, 0, 2.8284271247461903, 4, 2.8284271247461903, 0}},
+		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 0, []float64{math.Inf(1), 2.8284271247461903, 4, 2.8284271247461903, 0, 2.8284271247461903, 4, 2.8284271247461903, 0}},
 		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 9, nil},
 	}
 
@@ -350,7 +528,7 @@ func TestCalculateDistanceProfile(t *testing.T) {
 		{[]float64{}, []float64{}, 2, 0, nil},
 		{[]float64{1, 1, 1, 1, 1}, []float64{}, 2, 0, nil},
 		{[]float64{}, []float64{1, 1, 1, 1, 1}, 2, 0, nil},
-		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 0, []float64{math.Inf(1), math.Inf(1), 4, 2.8284271247461903, 0, 2.8284271247461903, 4, 2.8284271247461903, 0}},
+		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 0, []float64{math.Inf(1), 2.8284271247461903, 4, 2.8284271247461903, 0, 2.8284271247461903, 4, 2.8284271247461903, 0}},
 		{[]float64{0, 1, 1, 0, 0, 1, 1, 0, 0, 1, 1, 0}, nil, 4, 9, nil},
 	}
 
@@ -530,6 +708,41 @@ func TestComputeStamp(t *testing.T) {
 	}
 }
 
+func TestComputeStampSeed(t *testing.T) {
+	sig := setupData(300)
+
+	compute := func(seed int64) *MatrixProfile {
+		mp, err := New(sig, nil, 16)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = AlgoSTAMP
+		o.SamplePct = 0.1
+		o.NJobs = 2
+		o.Seed = seed
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating", err)
+		}
+		return mp
+	}
+
+	mp1, mp2, mp3 := compute(1), compute(1), compute(2)
+	var diff bool
+	for i := range mp1.MP {
+		if mp1.MP[i] != mp2.MP[i] || mp1.Idx[i] != mp2.Idx[i] {
+			t.Errorf("Expected the same matrix profile for the same seed, but got a difference at index %d", i)
+			break
+		}
+		if mp1.Idx[i] != mp3.Idx[i] {
+			diff = true
+		}
+	}
+	if !diff {
+		t.Errorf("Expected a different matrix profile index for a different seed")
+	}
+}
+
 func TestComputeStomp(t *testing.T) {
 	var err error
 	var mp *MatrixProfile
@@ -628,14 +841,14 @@ func TestComputeMpx(t *testing.T) {
 		{[]float64{}, []float64{}, 2, 1, false, nil, nil},
 		{[]float64{1, 1, 1, 1, 1}, []float64{}, 2, 1, false, nil, nil},
 		{[]float64{}, []float64{1, 1, 1, 1, 1}, 2, 1, false, nil, nil},
-		{[]float64{1, 2, 1, 3, 1}, []float64{2, 1, 1, 2, 1, 3, 1, -1, -2}, 2, 1, false, []float64{0, 0, 0, 0}, []int{2, 3, 2, 3}},
-		{[]float64{1, 1, 1, 1, 1}, []float64{1, 1, 1, 1, 1, 2, 2, 3, 4, 5}, 2, 1, false, []float64{2, 2, 2, 2}, []int{0, 1, 2, 3}},
+		{[]float64{1, 2, 1, 3, 1}, []float64{2, 1, 1, 2, 1, 3, 1, -1, -2}, 2, 1, false, []float64{0, 0, 0, 0}, []int{2, 0, 2, 0}},
+		{[]float64{1, 1, 1, 1, 1}, []float64{1, 1, 1, 1, 1, 2, 2, 3, 4, 5}, 2, 1, false, []float64{2, 2, 2, 2}, []int{0, 0, 0, 0}},
 		{[]float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, 4, 1, false,
 			[]float64{0, 0, 0, 0, 0, 0, 0, 0, 0},
 			[]int{0, 1, 2, 3, 4, 5, 6, 7, 8}},
 		{[]float64{0, 1, 1, 1, 0, 0, 2, 1, 0, 0, 2, 1}, nil, 4, 1, false,
 			[]float64{1.9550, 1.8388, 0.8739, 0, 0, 1.9550, 0.8739, 0, 0},
-			[]int{4, 2, 6, 7, 8, 1, 2, 3, 4}},
+			[]int{4, 2, 6, 7, 8, 0, 2, 3, 4}},
 		{[]float64{0, 1, 1, 1, 0, 0, 2, 1, 0, 0, 2, 1}, nil, 4, 1, true,
 			[]float64{1.0183, 1.0183, 0.8739, 0, 0, 1.2060, 0.8739, 0, 0},
 			[]int{6, 3, 4, 7, 8, 3, 2, 3, 4}},
@@ -703,142 +916,1435 @@ func TestComputeMpx(t *testing.T) {
 	}
 }
 
-func TestUpdate(t *testing.T) {
-	var err error
-	var outMP []float64
-	var outIdx []int
-	var mp *MatrixProfile
+func TestComputeTieBreaking(t *testing.T) {
+	// a repeated pattern has exact ties between every repetition so the
+	// nearest neighbor should always be the earliest one outside of the
+	// exclusion zone
+	var a []float64
+	for i := 0; i < 8; i++ {
+		a = append(a, 0, 1, 3, 2, 1)
+	}
+	a = append(a, 4, 0, 2)
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP, AlgoSTAMP, AlgoSTMP} {
+		var expected []int
+		for _, njobs := range []int{1, 2, 3, 7} {
+			mp, err := New(a, nil, 4)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := NewMPOpts()
+			o.Algorithm = algo
+			o.NJobs = njobs
+			if err = mp.Compute(o); err != nil {
+				t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+			}
+			if expected == nil {
+				expected = mp.Idx
+				if expected[5] != 0 || expected[0] != 5 {
+					t.Errorf("Expected ties to go to the smaller index for %s, but got %v", algo, expected)
+				}
+				continue
+			}
+			if !reflect.DeepEqual(mp.Idx, expected) {
+				t.Errorf("Expected %v with %d jobs for %s, but got %v", expected, njobs, algo, mp.Idx)
+			}
+		}
+	}
 
-	a := []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}
+	// MPX compares the same values regardless of batching and STOMP rolls
+	// every row from the same seed, so the results are reproducible for
+	// floating point data as well. The series spans several STOMP batches.
+	sig := siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 12), siggen.Noise(0.1, 1200))
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		var expected *MatrixProfile
+		for _, njobs := range []int{1, 2, 3, 7} {
+			mp, err := New(sig, nil, 16)
+			if err != nil {
+				t.Fatal(err)
+			}
+			o := NewMPOpts()
+			o.Algorithm = algo
+			o.NJobs = njobs
+			if err = mp.Compute(o); err != nil {
+				t.Fatal(err)
+			}
+			if expected == nil {
+				expected = mp
+			} else if !reflect.DeepEqual(mp.Idx, expected.Idx) || !reflect.DeepEqual(mp.MP, expected.MP) {
+				t.Errorf("Expected the same matrix profile with %d jobs for %s", njobs, algo)
+			}
+		}
+	}
+}
 
-	testdata := []struct {
-		vals []float64
-	}{
-		{[]float64{}},
-		{[]float64{0.5}},
-		{[]float64{0.2, 0.3, 0.4, 0.9}},
+func TestComputeKeepCorr(t *testing.T) {
+	a := setupData(200)
+	b := setupData(150)
+
+	for _, bb := range [][]float64{nil, b} {
+		mp, err := New(a, bb, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+		if mp.Corr != nil {
+			t.Errorf("Expected no correlations unless kept")
+		}
+
+		o.KeepCorr = true
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v", err)
+		}
+		if len(mp.Corr) != len(mp.MP) {
+			t.Fatalf("Expected %d correlations, but got %d", len(mp.MP), len(mp.Corr))
+		}
+		corr := append([]float64(nil), mp.MP...)
+		euclideanToPearson(corr, mp.W)
+		for i := range corr {
+			if math.Abs(mp.Corr[i]-corr[i]) > 1e-6 && !(math.IsInf(mp.Corr[i], 1) && math.IsInf(corr[i], 1)) {
+				t.Errorf("Expected a correlation of %.6f at %d, but got %.6f", corr[i], i, mp.Corr[i])
+				break
+			}
+		}
 	}
 
-	mp, err = New(a, nil, 4)
+	// correlations are kept for partial computations and follow the merge
+	full, _ := New(a, nil, 16)
+	o := NewMPOpts()
+	o.KeepCorr = true
+	if err := full.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	p1, _ := New(a, nil, 16)
+	p2, _ := New(a, nil, 16)
+	half := p1.NumDiagonals() / 2
+	if err := p1.ComputeRange(o, 0, half); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2.ComputeRange(o, half, p2.NumDiagonals()); err != nil {
+		t.Fatal(err)
+	}
+	merged, err := MergePartialProfiles(p1, p2)
 	if err != nil {
-		t.Error(err)
-		return
+		t.Fatal(err)
 	}
-	o := NewMPOpts()
-	o.Algorithm = AlgoSTOMP
-	if err = mp.Compute(o); err != nil {
-		t.Error(err)
-		return
+	if !reflect.DeepEqual(merged.Idx, full.Idx) || !reflect.DeepEqual(merged.Corr, full.Corr) {
+		t.Errorf("Expected merged correlations to match the full computation")
 	}
 
-	for _, d := range testdata {
-		if err = mp.Update(d.vals); err != nil {
-			t.Error(err)
-			return
+	for _, bad := range []*MPOpts{
+		{Algorithm: AlgoSTOMP, SamplePct: 1, NJobs: 1, Euclidean: true, KeepCorr: true},
+		{Algorithm: AlgoMPX, SamplePct: 0.5, NJobs: 1, Euclidean: true, KeepCorr: true},
+		{Algorithm: AlgoMPX, SamplePct: 1, NJobs: 1, Euclidean: true, Normalization: NormMean, KeepCorr: true},
+	} {
+		if err = full.Compute(bad); err == nil {
+			t.Errorf("Expected an error keeping correlations with %+v", bad)
 		}
-		outMP = make([]float64, len(mp.MP))
-		outIdx = make([]int, len(mp.Idx))
-		copy(outMP, mp.MP)
-		copy(outIdx, mp.Idx)
+	}
+}
 
-		if err = mp.stomp(); err != nil {
-			t.Error(err)
-			return
+func TestComputeReuseOutput(t *testing.T) {
+	a := []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}
+	b := []float64{0, 1, 1, 1, 0, 0, 2, 1, 0, 0, 2, 1, 0.5}
+
+	testdata := []struct {
+		algo Algo
+		b    []float64
+	}{
+		{AlgoMPX, nil},
+		{AlgoMPX, b},
+		{AlgoSTOMP, nil},
+		{AlgoSTAMP, nil},
+		{AlgoSTMP, nil},
+	}
+
+	for _, d := range testdata {
+		expected, err := New(a, d.b, 4)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = d.algo
+		o.NJobs = 2
+		if err = expected.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating for %s", err, d.algo)
 		}
 
-		for i := 0; i < len(mp.MP); i++ {
-			if math.Abs(mp.MP[i]-outMP[i]) > 1e-7 {
-				t.Errorf("Expected\n%.4f, but got\n%.4f for\n%+v", mp.MP, outMP, d)
-				break
+		mp, err := New(a, d.b, 4)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		mp.MP = make([]float64, 0, 32)
+		mp.Idx = make([]int, 0, 32)
+		mp.MPB = make([]float64, 0, 32)
+		mp.IdxB = make([]int, 0, 32)
+		outMP := mp.MP[:1]
+		outMPB := mp.MPB[:1]
+
+		o.ReuseOutput = true
+		for i := 0; i < 2; i++ {
+			if err = mp.Compute(o); err != nil {
+				t.Fatalf("Did not expect an error, %v, while calculating for %s", err, d.algo)
 			}
 		}
-		for i := 0; i < len(mp.Idx); i++ {
-			if math.Abs(float64(mp.Idx[i]-outIdx[i])) > 1e-7 {
-				t.Errorf("Expected %d,\nbut got\n%v for\n%+v", mp.Idx, outIdx, d)
+
+		if &mp.MP[0] != &outMP[0] {
+			t.Errorf("Expected the provided matrix profile slice to be reused for %s", d.algo)
+		}
+		if d.b != nil && &mp.MPB[0] != &outMPB[0] {
+			t.Errorf("Expected the provided BA matrix profile slice to be reused for %s", d.algo)
+		}
+		if len(mp.MP) != len(expected.MP) {
+			t.Fatalf("Expected %d elements, but got %d for %s", len(expected.MP), len(mp.MP), d.algo)
+		}
+		for i := range mp.MP {
+			if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-7 || mp.Idx[i] != expected.Idx[i] {
+				t.Errorf("Expected\n%.4f, %v, but got\n%.4f, %v for %s", expected.MP, expected.Idx, mp.MP, mp.Idx, d.algo)
 				break
 			}
 		}
 	}
 }
 
-func TestDiscoverDiscords(t *testing.T) {
-	mprof := []float64{1, 2, 3, 4}
-	a := []float64{1, 2, 3, 4, 5, 6}
-	w := 3
+func TestComputeStabilize(t *testing.T) {
+	a := setupData(300)
+	b := setupData(200)
 
 	testdata := []struct {
-		mp               []float64
-		k                int
-		exzone           int
-		expectedDiscords []int
+		algo Algo
+		b    []float64
 	}{
-		{mprof, 4, 0, []int{3, 3, 3, 3}},
-		{mprof, 4, 1, []int{3, 1}},
-		{mprof, 10, 1, []int{3, 1}},
-		{mprof, 0, 1, []int{}},
+		{AlgoMPX, nil},
+		{AlgoMPX, b},
+		{AlgoSTOMP, nil},
+		{AlgoSTOMP, b},
 	}
 
 	for _, d := range testdata {
-		mp := MatrixProfile{A: a, B: a, W: w, MP: d.mp, AV: av.Default, Opts: NewMPOpts()}
-		discords, err := mp.DiscoverDiscords(d.k, d.exzone)
+		expected, err := New(a, d.b, 16)
 		if err != nil {
-			t.Errorf("Got error %v on %v", err, d)
-			return
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
 		}
-		if len(discords) != len(d.expectedDiscords) {
-			t.Errorf("Got a length of %d discords, but expected %d, for %v", len(discords), len(d.expectedDiscords), d)
-			return
+		o := NewMPOpts()
+		o.Algorithm = d.algo
+		o.NJobs = 2
+		if err = expected.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating for %s", err, d.algo)
 		}
-		for i, idx := range discords {
-			if idx != d.expectedDiscords[i] {
-				t.Errorf("expected index, %d, but got %d, for %v", d.expectedDiscords[i], idx, d)
-				return
+
+		for _, stabilize := range []int{1, 7} {
+			mp, err := New(a, d.b, 16)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+			}
+			o.Stabilize = stabilize
+			if err = mp.Compute(o); err != nil {
+				t.Fatalf("Did not expect an error, %v, while calculating for %s", err, d.algo)
+			}
+			if len(mp.MP) != len(expected.MP) {
+				t.Fatalf("Expected %d elements, but got %d for %s", len(expected.MP), len(mp.MP), d.algo)
+			}
+			for i := range mp.MP {
+				if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-7 {
+					t.Errorf("Expected %.6f at %d, but got %.6f for %s stabilized every %d", expected.MP[i], i, mp.MP[i], d.algo, stabilize)
+					break
+				}
 			}
 		}
+		o.Stabilize = 0
+	}
+
+	mp, err := New(a, nil, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	o := NewMPOpts()
+	o.Stabilize = -1
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error for a negative stabilize interval")
 	}
 }
 
-func TestDiscoverMotifs(t *testing.T) {
-	a := []float64{0, 0, 0.56, 0.99, 0.97, 0.75, 0, 0, 0, 0.43, 0.98, 0.99, 0.65, 0, 0, 0, 0.6, 0.97, 0.965, 0.8, 0, 0, 0}
+func TestComputeRange(t *testing.T) {
+	a := setupData(300)
+	b := setupData(200)
 
 	testdata := []struct {
-		a               []float64
-		b               []float64
-		m               int
-		k               int
-		expectedMotifs  [][]int
-		expectedMinDist []float64
+		b      []float64
+		shards int
 	}{
-		{
-			a, nil, 7, 3,
-			[][]int{{0, 14}, {0, 7}, {3, 10}},
-			[]float64{0.1459619228330262, 0.3352336136782056, 0.46369664551715467},
-		},
-		{
-			a, a, 7, 3,
-			nil,
-			nil,
-		},
-		{
-			a, nil, 7, 5,
-			[][]int{{0, 14}, {0, 7}, {3, 10}, {}, {}},
-			[]float64{0.1459619228330262, 0.3352336136782056, 0.46369664551715467, 0, 0},
-		},
-		{
-			[]float64{0, 1, 0, 0, 1, 0, 0}, nil, 3, 2,
-			[][]int{{0, 3}, {1, 4}},
-			[]float64{5.1619136559035694e-08, 0},
-		},
+		{nil, 1},
+		{nil, 3},
+		{b, 2},
+		{b, 5},
 	}
 
 	for _, d := range testdata {
-		mp, err := New(d.a, d.b, d.m)
+		expected, err := New(a, d.b, 16)
 		if err != nil {
-			t.Error(err)
-			return
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		if err = expected.Compute(NewMPOpts()); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating", err)
 		}
 
-		o := NewMPOpts()
+		n := expected.NumDiagonals()
+		partials := make([]*MatrixProfile, d.shards)
+		for i := range partials {
+			partials[i], err = New(a, d.b, 16)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+			}
+			o := NewMPOpts()
+			o.NJobs = 2
+			if err = partials[i].ComputeRange(o, i*n/d.shards, (i+1)*n/d.shards); err != nil {
+				t.Fatalf("Did not expect an error, %v, while calculating shard %d", err, i)
+			}
+		}
+
+		mp, err := MergePartialProfiles(partials...)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while merging", err)
+		}
+		for i := range expected.MP {
+			if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-7 {
+				t.Errorf("Expected %.4f at index %d, but got %.4f with %d shards", expected.MP[i], i, mp.MP[i], d.shards)
+				break
+			}
+		}
+		for i := range expected.MPB {
+			if math.Abs(mp.MPB[i]-expected.MPB[i]) > 1e-7 {
+				t.Errorf("Expected %.4f at BA index %d, but got %.4f with %d shards", expected.MPB[i], i, mp.MPB[i], d.shards)
+				break
+			}
+		}
+	}
+
+	mp, err := New(a, nil, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	if err = mp.ComputeRange(nil, 10, mp.NumDiagonals()+1); err == nil {
+		t.Errorf("Expected an error for a range beyond the number of diagonals")
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	if err = mp.ComputeRange(o, 0, 10); err == nil {
+		t.Errorf("Expected an error for an algorithm other than mpx")
+	}
+}
+
+func TestMergePartialProfiles(t *testing.T) {
+	p1 := &MatrixProfile{W: 2, SelfJoin: true, MP: []float64{1, math.Inf(1), 3}, Idx: []int{2, math.MaxInt64, 0}}
+	p2 := &MatrixProfile{W: 2, SelfJoin: true, MP: []float64{2, 0.5, math.Inf(1)}, Idx: []int{1, 2, math.MaxInt64}}
+
+	mp, err := MergePartialProfiles(p1, p2)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while merging", err)
+	}
+	expectedMP := []float64{1, 0.5, 3}
+	expectedIdx := []int{2, 2, 0}
+	for i := range expectedMP {
+		if mp.MP[i] != expectedMP[i] || mp.Idx[i] != expectedIdx[i] {
+			t.Errorf("Expected %v, %v, but got %v, %v", expectedMP, expectedIdx, mp.MP, mp.Idx)
+			break
+		}
+	}
+	if p1.MP[1] != math.Inf(1) {
+		t.Errorf("Expected the input matrix profile to be unmodified, but got %v", p1.MP)
+	}
+	if mp.Corr != nil {
+		t.Errorf("Expected no correlations without kept correlations, but got %v", mp.Corr)
+	}
+
+	p1.Corr = []float64{0.9, math.Inf(1), 0.1}
+	p2.Corr = []float64{0.8, 0.95, math.Inf(1)}
+	if mp, err = MergePartialProfiles(p1, p2); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []float64{0.9, 0.95, 0.1}; !reflect.DeepEqual(mp.Corr, expected) {
+		t.Errorf("Expected correlations %v, but got %v", expected, mp.Corr)
+	}
+	p1.Corr, p2.Corr = nil, nil
+
+	testdata := [][]*MatrixProfile{
+		{},
+		{p1, {W: 3, SelfJoin: true, MP: []float64{1, 2, 3}, Idx: []int{0, 1, 2}}},
+		{p1, {W: 2, SelfJoin: true, MP: []float64{1, 2}, Idx: []int{0, 1}}},
+		{p1, {W: 2, SelfJoin: true}},
+		{p1, {W: 2, SelfJoin: true, MP: []float64{1, 2, 3}, Idx: []int{0, 1, 2}, Opts: &MPOpts{Euclidean: false}}},
+	}
+	for _, d := range testdata {
+		if _, err = MergePartialProfiles(d...); err == nil {
+			t.Errorf("Expected an error merging %v", d)
+		}
+	}
+}
+
+func TestComputeExclusionZone(t *testing.T) {
+	sig := setupData(200)
+
+	testdata := []struct {
+		zone         int
+		expectedZone int
+	}{
+		{0, 4},
+		{1, 1},
+		{8, 8},
+	}
+
+	for _, d := range testdata {
+		var expected *MatrixProfile
+		for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+			mp, err := New(sig, nil, 16)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+			}
+			o := NewMPOpts()
+			o.Algorithm = algo
+			o.ExclusionZone = d.zone
+			if err = mp.Compute(o); err != nil {
+				t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+			}
+			if o.ExclusionZone != d.zone {
+				t.Errorf("Expected the provided options to be unmodified, but got a zone of %d", o.ExclusionZone)
+			}
+			if mp.Opts.ExclusionZone != d.expectedZone {
+				t.Errorf("Expected an effective exclusion zone of %d, but got %d for %s", d.expectedZone, mp.Opts.ExclusionZone, algo)
+			}
+
+			for i := range mp.Idx {
+				if i-mp.Idx[i] < d.expectedZone && mp.Idx[i]-i < d.expectedZone {
+					t.Errorf("Expected no match within the exclusion zone, but got %d for %d with %s", mp.Idx[i], i, algo)
+					break
+				}
+			}
+
+			if expected == nil {
+				expected = mp
+				continue
+			}
+			for i := range mp.MP {
+				if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-6 {
+					t.Errorf("Expected %s to match mpx with a zone of %d, but got %.6f instead of %.6f at %d", algo, d.zone, mp.MP[i], expected.MP[i], i)
+					break
+				}
+			}
+		}
+	}
+
+	mp, err := New(sig, nil, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	o := NewMPOpts()
+	o.ExclusionZone = -1
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error for a negative exclusion zone")
+	}
+}
+
+func TestComputeMask(t *testing.T) {
+	sig := setupData(200)
+	w := 16
+	mask := make([]bool, len(sig)-w+1)
+	for i := 40; i < 90; i++ {
+		mask[i] = true
+	}
+
+	var expected *MatrixProfile
+	for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+		mp, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.Mask = mask
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+		}
+
+		for i, idx := range mp.Idx {
+			if idx < len(mask) && mask[idx] {
+				t.Errorf("Expected no masked nearest neighbors, but got %d for %d with %s", idx, i, algo)
+				break
+			}
+		}
+
+		if expected == nil {
+			expected = mp
+			continue
+		}
+		for i := range mp.MP {
+			if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-6 {
+				t.Errorf("Expected %s to match mpx with a mask, but got %.6f instead of %.6f at %d", algo, mp.MP[i], expected.MP[i], i)
+				break
+			}
+		}
+	}
+
+	// masked subsequences still have their own nearest neighbor
+	if math.IsInf(expected.MP[50], 1) {
+		t.Errorf("Expected a masked subsequence to have a nearest neighbor")
+	}
+
+	testdata := []struct {
+		b    []float64
+		mask []bool
+	}{
+		{nil, mask[1:]},
+		{sig, mask},
+	}
+	for _, d := range testdata {
+		mp, err := New(sig, d.b, w)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Mask = d.mask
+		if err = mp.Compute(o); err == nil {
+			t.Errorf("Expected an error for a mask of length %d with a self join of %v", len(d.mask), mp.SelfJoin)
+		}
+	}
+}
+
+func TestComputeLeftRight(t *testing.T) {
+	sig := setupData(200)
+
+	var expected *MatrixProfile
+	for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+		mp, err := New(sig, nil, 16)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.LeftRight = true
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+		}
+		if len(mp.LMP) != len(mp.MP) || len(mp.RMP) != len(mp.MP) {
+			t.Fatalf("Expected left and right profiles of length %d, but got %d and %d for %s", len(mp.MP), len(mp.LMP), len(mp.RMP), algo)
+		}
+
+		for i := range mp.MP {
+			if mp.LIdx[i] != math.MaxInt64 && mp.LIdx[i] >= i {
+				t.Errorf("Expected a left neighbor before %d, but got %d for %s", i, mp.LIdx[i], algo)
+				break
+			}
+			if mp.RIdx[i] != math.MaxInt64 && mp.RIdx[i] <= i {
+				t.Errorf("Expected a right neighbor after %d, but got %d for %s", i, mp.RIdx[i], algo)
+				break
+			}
+			if math.Abs(math.Min(mp.LMP[i], mp.RMP[i])-mp.MP[i]) > 1e-6 {
+				t.Errorf("Expected the matrix profile to be the smaller of the left and right profiles at %d for %s", i, algo)
+				break
+			}
+		}
+		if !math.IsInf(mp.LMP[0], 1) || !math.IsInf(mp.RMP[len(mp.RMP)-1], 1) {
+			t.Errorf("Expected no left neighbor for the first and no right neighbor for the last subsequence for %s", algo)
+		}
+
+		if expected == nil {
+			expected = mp
+			continue
+		}
+		for i := range mp.LMP {
+			if math.Abs(mp.LMP[i]-expected.LMP[i]) > 1e-6 || math.Abs(mp.RMP[i]-expected.RMP[i]) > 1e-6 {
+				t.Errorf("Expected %s to match the left and right profiles of mpx at %d", algo, i)
+				break
+			}
+		}
+	}
+
+	// left and right profiles are cleared when not requested
+	if err := expected.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if expected.LMP != nil || expected.RMP != nil {
+		t.Errorf("Expected no left and right profiles without the option")
+	}
+
+	mp, err := New(sig, sig, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error computing left and right profiles of an AB join")
+	}
+}
+
+func TestComputeMeanCentered(t *testing.T) {
+	sig := setupData(200)
+	// scale the second half so that amplitude differences matter
+	for i := 100; i < len(sig); i++ {
+		sig[i] *= 3
+	}
+	w := 16
+
+	// brute force mean-centered euclidean nearest neighbor distances
+	centered := make([][]float64, len(sig)-w+1)
+	for i := range centered {
+		centered[i] = make([]float64, w)
+		copy(centered[i], sig[i:i+w])
+		floats.AddConst(-stat.Mean(centered[i], nil), centered[i])
+	}
+	zone := defaultExclusionZone(w)
+	expected := make([]float64, len(centered))
+	for i := range centered {
+		expected[i] = math.Inf(1)
+		for j := range centered {
+			if j > i-zone && j < i+zone {
+				continue
+			}
+			expected[i] = math.Min(expected[i], floats.Distance(centered[i], centered[j], 2))
+		}
+	}
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+		mp, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.Normalization = NormMean
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+		}
+		for i := range expected {
+			if math.Abs(mp.MP[i]-expected[i]) > 1e-6 {
+				t.Errorf("Expected %.6f, but got %.6f at %d for %s", expected[i], mp.MP[i], i, algo)
+				break
+			}
+		}
+	}
+
+	testdata := []struct {
+		opts *MPOpts
+	}{
+		{&MPOpts{Normalization: NormMean}},
+		{&MPOpts{Normalization: NormMean, Euclidean: true, RemapNegCorr: true}},
+		{&MPOpts{Normalization: "minmax", Euclidean: true}},
+	}
+	for _, d := range testdata {
+		mp, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		if err = mp.Compute(d.opts); err == nil {
+			t.Errorf("Expected an error for %+v", d.opts)
+		}
+	}
+}
+
+func TestComputeWeights(t *testing.T) {
+	sig := setupData(200)
+	w := 16
+	weights := make([]float64, w)
+	for k := range weights {
+		// emphasize the center of each subsequence
+		weights[k] = math.Exp(-math.Pow(float64(k-w/2), 2) / 32)
+	}
+
+	// brute force weighted nearest neighbor distances of the normalized subsequences
+	zone := defaultExclusionZone(w)
+	bruteForce := func(norm Normalization) []float64 {
+		subs := make([][]float64, len(sig)-w+1)
+		for i := range subs {
+			subs[i] = make([]float64, w)
+			copy(subs[i], sig[i:i+w])
+			floats.AddConst(-stat.Mean(subs[i], nil), subs[i])
+			if norm != NormMean {
+				floats.Scale(1/math.Sqrt(floats.Dot(subs[i], subs[i])/float64(w)), subs[i])
+			}
+		}
+		out := make([]float64, len(subs))
+		for i := range subs {
+			out[i] = math.Inf(1)
+			for j := range subs {
+				if j > i-zone && j < i+zone {
+					continue
+				}
+				var d float64
+				for k, wt := range weights {
+					d += wt * (subs[i][k] - subs[j][k]) * (subs[i][k] - subs[j][k])
+				}
+				out[i] = math.Min(out[i], math.Sqrt(d))
+			}
+		}
+		return out
+	}
+
+	for _, norm := range []Normalization{NormZ, NormMean} {
+		expected := bruteForce(norm)
+		for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+			mp, err := New(sig, nil, w)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+			}
+			o := NewMPOpts()
+			o.Algorithm = algo
+			o.Normalization = norm
+			o.Weights = weights
+			if err = mp.Compute(o); err != nil {
+				t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+			}
+			for i := range expected {
+				if math.Abs(mp.MP[i]-expected[i]) > 1e-6 {
+					t.Errorf("Expected %.6f, but got %.6f at %d for %s with %s normalization", expected[i], mp.MP[i], i, algo, norm)
+					break
+				}
+			}
+		}
+	}
+
+	// weights of all ones match the unweighted matrix profile
+	unweighted, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	if err = unweighted.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	mp, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+	}
+	o := NewMPOpts()
+	o.Weights = make([]float64, w)
+	floats.AddConst(1, o.Weights)
+	if err = mp.Compute(o); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	for i := range mp.MP {
+		if math.Abs(mp.MP[i]-unweighted.MP[i]) > 1e-6 {
+			t.Errorf("Expected %.6f, but got %.6f at %d with weights of ones", unweighted.MP[i], mp.MP[i], i)
+			break
+		}
+	}
+
+	testdata := []struct {
+		b    []float64
+		opts *MPOpts
+	}{
+		{nil, &MPOpts{Euclidean: true, Weights: weights[1:]}},
+		{nil, &MPOpts{Euclidean: true, Weights: make([]float64, w)}},
+		{nil, &MPOpts{Weights: weights}},
+		{sig, &MPOpts{Algorithm: AlgoMPX, Euclidean: true, Weights: weights}},
+	}
+	for _, d := range testdata {
+		mp, err := New(sig, d.b, w)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new mp", err)
+		}
+		if err = mp.Compute(d.opts); err == nil {
+			t.Errorf("Expected an error for %+v", d.opts)
+		}
+	}
+}
+
+func TestUpdateLeftRight(t *testing.T) {
+	sig := setupData(200)
+
+	mp, err := New(sig[:150], nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Update(sig[150:]); err != nil {
+		t.Fatal(err)
+	}
+
+	full, err := New(sig, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = full.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range full.LMP {
+		if math.Abs(mp.LMP[i]-full.LMP[i]) > 1e-6 || math.Abs(mp.RMP[i]-full.RMP[i]) > 1e-6 {
+			t.Errorf("Expected left and right profiles %.4f, %.4f at %d, but got %.4f, %.4f", full.LMP[i], full.RMP[i], i, mp.LMP[i], mp.RMP[i])
+			break
+		}
+	}
+}
+
+func TestDiscoverNovelties(t *testing.T) {
+	pattern := []float64{0, 2, 5, 1, -3, -1, 4, 0, -2, 1, 3, 0}
+	sig := make([]float64, 300)
+	for i := range sig {
+		sig[i] = math.Sin(2 * math.Pi * float64(i) / 20)
+	}
+	// a new behavior emerges at 180 and repeats afterwards
+	for _, idx := range []int{180, 230, 270} {
+		copy(sig[idx:], pattern)
+	}
+
+	mp, err := New(sig, nil, len(pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverNovelties(1, len(pattern)/2); err == nil {
+		t.Errorf("Expected an error without left and right profiles")
+	}
+
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	novelties, err := mp.DiscoverNovelties(3, len(pattern)/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first period of the sine wave has no earlier match so is novel too
+	found := false
+	for i, n := range novelties {
+		switch {
+		case n.Idx == 180:
+			found = true
+			if n.LeftDist <= n.RightDist {
+				t.Errorf("Expected the left distance to exceed the right distance, but got %v", n)
+			}
+		case n.Idx >= 20+len(pattern):
+			t.Errorf("Expected only the emerging pattern or the start of the series, but got %v", n)
+		}
+		if i > 0 && n.LeftDist-n.RightDist > novelties[i-1].LeftDist-novelties[i-1].RightDist {
+			t.Errorf("Expected novelties in descending order of score, but got %v", novelties)
+		}
+	}
+	if !found {
+		t.Errorf("Expected a novelty at 180, but got %v", novelties)
+	}
+}
+
+func TestDiscoverChains(t *testing.T) {
+	mp := MatrixProfile{W: 2}
+	if _, err := mp.DiscoverChains(); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed without left and right profiles, but got %v", err)
+	}
+
+	// 1 points right to 3 but 3 points left to 0 so the link is one sided
+	none := math.MaxInt64
+	mp.RIdx = []int{2, 3, 4, none, 5, none, 7, none}
+	mp.LIdx = []int{none, 0, 0, 1, 2, 4, 0, 6}
+	chains, err := mp.DiscoverChains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := [][]int{{0, 2, 4, 5}, {1, 3}, {6, 7}}
+	if !reflect.DeepEqual(chains, expected) {
+		t.Errorf("Expected %v, but got %v", expected, chains)
+	}
+
+	// a pattern that gradually changes shape forms a chain across its
+	// occurrences
+	var sig []float64
+	for k := 0; k < 6; k++ {
+		for i := 0; i < 40; i++ {
+			x := 2 * math.Pi * float64(i) / 40
+			sig = append(sig, math.Sin(x)+0.3*float64(k)*math.Sin(3*x))
+		}
+	}
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+	mpc, err := New(sig, nil, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = mpc.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	chains, err = mpc.DiscoverChains()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chains) == 0 || len(chains[0]) < 4 {
+		t.Fatalf("Expected a chain through the occurrences of the pattern, but got %v", chains)
+	}
+	for i := 1; i < len(chains[0]); i++ {
+		if gap := chains[0][i] - chains[0][i-1]; gap < 30 || gap > 50 {
+			t.Errorf("Expected links about one pattern apart, but got %v", chains[0])
+			break
+		}
+	}
+}
+
+func TestDiscoverJoinMotifGroups(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	pattern := make([]float64, 40)
+	for i := range pattern {
+		pattern[i] = 3 * math.Sin(2*math.Pi*float64(i*i)/400)
+	}
+	a := make([]float64, 300)
+	b := make([]float64, 500)
+	for i := range a {
+		a[i] = 0.05 * r.NormFloat64()
+	}
+	for i := range b {
+		b[i] = 0.05 * r.NormFloat64()
+	}
+	// a library with a single known pattern occurring three times in b
+	planted := []int{50, 250, 400}
+	for i, v := range pattern {
+		a[100+i] += v
+		for _, p := range planted {
+			b[p+i] += v
+		}
+	}
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		mp, err := New(a, b, 32)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		groups, err := mp.DiscoverJoinMotifGroups(2, 3, 10, 0)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+		}
+		if len(groups) == 0 {
+			t.Fatalf("Expected motif groups for %s", algo)
+		}
+		g := groups[0]
+		if g.AIdx < 100 || g.AIdx > 108 {
+			t.Errorf("Expected the pattern of a near 100, but got %d for %s", g.AIdx, algo)
+		}
+		if len(g.BIdx) != len(planted) || len(g.Dists) != len(g.BIdx) {
+			t.Fatalf("Expected %d occurrences in b, but got %+v for %s", len(planted), g, algo)
+		}
+		for i, p := range planted {
+			if g.BIdx[i] < p || g.BIdx[i] > p+8 {
+				t.Errorf("Expected an occurrence near %d, but got %d for %s", p, g.BIdx[i], algo)
+			}
+		}
+		if g.MinDist > 1 || math.Abs(floats.Min(g.Dists)-g.MinDist) > 1e-6 {
+			t.Errorf("Expected a close match with the minimum distance, but got %+v for %s", g, algo)
+		}
+		for _, other := range groups[1:] {
+			if other.MinDist < g.MinDist {
+				t.Errorf("Expected groups in ascending order of distance, but got %+v for %s", groups, algo)
+			}
+		}
+
+	}
+
+	// each occurrence's distance is to the pattern of its group rather than
+	// to its nearest neighbor in a, which differ for loosely matching noise
+	noiseA, noiseB := make([]float64, 200), make([]float64, 300)
+	for i := range noiseA {
+		noiseA[i] = r.NormFloat64()
+	}
+	for i := range noiseB {
+		noiseB[i] = r.NormFloat64()
+	}
+	mp, err := New(noiseA, noiseB, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := mp.DiscoverJoinMotifGroups(3, 1.5, 10, 0)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	var members int
+	for _, g := range groups {
+		qa, err := util.ZNormalize(noiseA[g.AIdx : g.AIdx+16])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j, bIdx := range g.BIdx {
+			qb, err := util.ZNormalize(noiseB[bIdx : bIdx+16])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := floats.Distance(qa, qb, 2); math.Abs(g.Dists[j]-expected) > 1e-6 {
+				t.Errorf("Expected a distance of %.6f from %d in b to the pattern %d in a, but got %.6f", expected, bIdx, g.AIdx, g.Dists[j])
+			}
+			members++
+		}
+	}
+	if members <= len(groups) {
+		t.Errorf("Expected groups with more than one occurrence, but got %+v", groups)
+	}
+
+	mp, err = New(a, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverJoinMotifGroups(1, 3, 10, 0); Cause(err) != ErrNotABJoin {
+		t.Errorf("Expected ErrNotABJoin for a self join, but got %v", err)
+	}
+}
+
+func TestDiscoverJoin(t *testing.T) {
+	a := make([]float64, 300)
+	b := make([]float64, 250)
+	for i := range a {
+		a[i] = math.Sin(2 * math.Pi * float64(i) / 25)
+	}
+	for i := range b {
+		b[i] = math.Sin(2*math.Pi*float64(i)/25) + 0.01*math.Cos(float64(i))
+	}
+	// anomaly in b with no counterpart in a
+	for i := 150; i < 170; i++ {
+		b[i] = math.Sin(2 * math.Pi * float64(i) / 7)
+	}
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP, AlgoSTAMP} {
+		mp, err := New(a, b, 20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		discords, err := mp.DiscoverJoinDiscords(2, 0)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+		}
+		if len(discords) != 2 || discords[0].BIdx < 130 || discords[0].BIdx > 170 {
+			t.Errorf("Expected the top discord of b near 150, but got %+v for %s", discords, algo)
+		}
+		if len(discords) == 2 && discords[0].Dist < discords[1].Dist {
+			t.Errorf("Expected discords in descending order of distance, but got %+v for %s", discords, algo)
+		}
+
+		motifs, err := mp.DiscoverJoinMotifs(3, 0)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+		}
+		if len(motifs) != 3 || motifs[0].Dist > 0.5 || motifs[0].Dist > motifs[2].Dist {
+			t.Errorf("Expected close matches in ascending order, but got %+v for %s", motifs, algo)
+		}
+		for _, m := range motifs {
+			if m.BIdx >= 130 && m.BIdx <= 170 {
+				t.Errorf("Expected no motif at the anomaly, but got %+v for %s", m, algo)
+			}
+			if m.AIdx < 0 || m.AIdx > len(a)-20 {
+				t.Errorf("Expected a neighbor index in a, but got %d for %s", m.AIdx, algo)
+			}
+		}
+	}
+
+	mp, err := New(a, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverJoinDiscords(1, 0); err == nil {
+		t.Errorf("Expected an error for a self join")
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	var err error
+	var outMP []float64
+	var outIdx []int
+	var mp *MatrixProfile
+
+	a := []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}
+
+	testdata := []struct {
+		vals []float64
+	}{
+		{[]float64{}},
+		{[]float64{0.5}},
+		{[]float64{0.2, 0.3, 0.4, 0.9}},
+	}
+
+	mp, err = New(a, nil, 4)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	if err = mp.Compute(o); err != nil {
+		t.Error(err)
+		return
+	}
+
+	for _, d := range testdata {
+		if err = mp.Update(d.vals); err != nil {
+			t.Error(err)
+			return
+		}
+		outMP = make([]float64, len(mp.MP))
+		outIdx = make([]int, len(mp.Idx))
+		copy(outMP, mp.MP)
+		copy(outIdx, mp.Idx)
+
+		if err = mp.stomp(); err != nil {
+			t.Error(err)
+			return
+		}
+
+		for i := 0; i < len(mp.MP); i++ {
+			if math.Abs(mp.MP[i]-outMP[i]) > 1e-7 {
+				t.Errorf("Expected\n%.4f, but got\n%.4f for\n%+v", mp.MP, outMP, d)
+				break
+			}
+		}
+		for i := 0; i < len(mp.Idx); i++ {
+			if math.Abs(float64(mp.Idx[i]-outIdx[i])) > 1e-7 {
+				t.Errorf("Expected %d,\nbut got\n%v for\n%+v", mp.Idx, outIdx, d)
+				break
+			}
+		}
+	}
+}
+
+func TestDiscoverDiscords(t *testing.T) {
+	mprof := []float64{1, 2, 3, 4}
+	a := []float64{1, 2, 3, 4, 5, 6}
+	w := 3
+
+	testdata := []struct {
+		mp               []float64
+		k                int
+		exzone           int
+		expectedDiscords []int
+	}{
+		{mprof, 4, 0, []int{3, 3, 3, 3}},
+		{mprof, 4, 1, []int{3, 1}},
+		{mprof, 10, 1, []int{3, 1}},
+		{mprof, 0, 1, []int{}},
+	}
+
+	for _, d := range testdata {
+		mp := MatrixProfile{A: a, B: a, W: w, MP: d.mp, AV: av.Default, Opts: NewMPOpts()}
+		discords, err := mp.DiscoverDiscords(d.k, d.exzone)
+		if err != nil {
+			t.Errorf("Got error %v on %v", err, d)
+			return
+		}
+		if len(discords) != len(d.expectedDiscords) {
+			t.Errorf("Got a length of %d discords, but expected %d, for %v", len(discords), len(d.expectedDiscords), d)
+			return
+		}
+		for i, idx := range discords {
+			if idx != d.expectedDiscords[i] {
+				t.Errorf("expected index, %d, but got %d, for %v", d.expectedDiscords[i], idx, d)
+				return
+			}
+		}
+	}
+}
+
+func TestDiscoverSeasonalDiscords(t *testing.T) {
+	// a daily pattern at index 2 of a period of 5 recurs at 7 and 12
+	mprof := []float64{1, 1, 9, 1, 4, 1, 1, 8, 1, 1, 1, 1, 7, 1, 1, 1}
+	a := make([]float64, len(mprof)+2)
+	w := 3
+
+	testdata := []struct {
+		period           int
+		expectedDiscords []int
+	}{
+		{0, []int{2, 7, 12}},
+		{5, []int{2, 4, 0}},
+		{100, []int{2, 7, 12}},
+	}
+
+	for _, d := range testdata {
+		mp := MatrixProfile{A: a, B: a, W: w, MP: mprof, AV: av.Default, Opts: NewMPOpts()}
+		discords, err := mp.DiscoverSeasonalDiscords(3, 1, d.period)
+		if err != nil {
+			t.Errorf("Got error %v on %v", err, d)
+			continue
+		}
+		if len(discords) != len(d.expectedDiscords) {
+			t.Errorf("Got %v discords, but expected %v, for a period of %d", discords, d.expectedDiscords, d.period)
+			continue
+		}
+		for i, idx := range discords {
+			if idx != d.expectedDiscords[i] {
+				t.Errorf("Got %v discords, but expected %v, for a period of %d", discords, d.expectedDiscords, d.period)
+				break
+			}
+		}
+	}
+}
+
+func TestDiscoverScoredDiscords(t *testing.T) {
+	a := []float64{1, 2, 3, 4, 5, 6}
+	mp := MatrixProfile{A: a, B: a, W: 3, MP: []float64{1, 2, 3, 4}, AV: av.Default, Opts: NewMPOpts()}
+
+	discords, err := mp.DiscoverScoredDiscords(2, 1)
+	if err != nil {
+		t.Fatalf("Got error %v", err)
+	}
+
+	expected := []Discord{
+		{Idx: 3, Dist: 4, ZScore: 1.5 / math.Sqrt(5.0/3), Percentile: 1},
+		{Idx: 1, Dist: 2, ZScore: -0.5 / math.Sqrt(5.0/3), Percentile: 0.5},
+	}
+	if len(discords) != len(expected) {
+		t.Fatalf("Got a length of %d discords, but expected %d", len(discords), len(expected))
+	}
+	for i, d := range discords {
+		if d.Idx != expected[i].Idx || d.Dist != expected[i].Dist ||
+			math.Abs(d.ZScore-expected[i].ZScore) > 1e-7 || math.Abs(d.Percentile-expected[i].Percentile) > 1e-7 {
+			t.Errorf("Expected %+v, but got %+v", expected[i], d)
+		}
+	}
+}
+
+func TestDiscoverDiscordsWithOpts(t *testing.T) {
+	a := []float64{1, 2, 3, 4, 5, 6}
+	mp := MatrixProfile{A: a, B: a, W: 3, MP: []float64{1, 2, 3, 4}, Idx: []int{2, 3, 0, 1}, AV: av.Default, Opts: NewMPOpts()}
+
+	testdata := []struct {
+		o                *DiscordOpts
+		expectedDiscords []int
+	}{
+		{nil, []int{3, 1}},
+		{&DiscordOpts{K: 3, Policy: ExcludeNone}, []int{3, 2, 1}},
+		{&DiscordOpts{K: 3, Policy: ExcludeZone, ExclusionZone: 1, Period: 2}, []int{3}},
+		{&DiscordOpts{K: 2, Policy: ExcludeMask, Mask: []bool{false, false, false, true}}, []int{2, 1}},
+		{&DiscordOpts{K: 2, Policy: ExcludeMask, Mask: []bool{true}}, nil},
+		{&DiscordOpts{K: 2, Policy: "bogus"}, nil},
+		{&DiscordOpts{K: 1, Policy: ExcludeNone, AVec: []float64{0, 1, 1, 1}}, []int{0}},
+		{&DiscordOpts{K: 1, Policy: ExcludeNone, AVec: []float64{0, 1}}, nil},
+		{&DiscordOpts{K: 1, Policy: ExcludeNone, AV: "bogus"}, nil},
+	}
+
+	for _, d := range testdata {
+		discords, err := mp.DiscoverDiscordsWithOpts(d.o)
+		if d.expectedDiscords == nil {
+			if err == nil {
+				t.Errorf("Expected an error for %+v", d.o)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Got error %v for %+v", err, d.o)
+			continue
+		}
+		if len(discords) != len(d.expectedDiscords) {
+			t.Errorf("Got %+v discords, but expected %v, for %+v", discords, d.expectedDiscords, d.o)
+			continue
+		}
+		for i, disc := range discords {
+			idx := d.expectedDiscords[i]
+			if disc.Idx != idx || disc.Dist != mp.MP[idx] || disc.NeighborIdx != mp.Idx[idx] || disc.NeighborDist != mp.MP[idx] {
+				t.Errorf("Got %+v, but expected the discord at %d, for %+v", disc, idx, d.o)
+			}
+		}
+	}
+
+	if mp.AV != av.Default {
+		t.Errorf("Expected the annotation vector of the matrix profile to be unchanged, but got %s", mp.AV)
+	}
+}
+
+func TestDiscoverDiscordsKNeighbor(t *testing.T) {
+	sig := siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 8), siggen.Noise(0.02, 800))
+	w := 20
+	// a twin freak, the same anomaly occurring twice
+	for _, start := range []int{200, 600} {
+		for i := 0; i < w; i++ {
+			sig[start+i] = 3 * float64(i%7) / 7
+		}
+	}
+
+	mp, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Euclidean = false
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+
+	discords, err := mp.DiscoverDiscordsWithOpts(&DiscordOpts{K: 2, ExclusionZone: 100, KNeighbor: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(discords) != 2 {
+		t.Fatalf("Expected 2 discords, but got %+v", discords)
+	}
+	var twins []int
+	for _, d := range discords {
+		twins = append(twins, d.Idx)
+	}
+	sort.Ints(twins)
+	if twins[0] <= 200-w || twins[0] >= 200+w || twins[1] <= 600-w || twins[1] >= 600+w {
+		t.Errorf("Expected discords at both twins near 200 and 600, but got %v", twins)
+	}
+
+	zone := mp.exclusionZone()
+	for _, d := range discords {
+		prof, err := util.MassV2(sig[d.Idx:d.Idx+w], sig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mp.applyTrivialMatchZone(prof, d.Idx)
+		expected := []float64{kthNeighbor(prof, 2, zone)}
+		euclideanToPearson(expected, w)
+		if math.Abs(d.Dist-expected[0]) > 1e-6 {
+			t.Errorf("Expected the correlation with the second nearest neighbor, %.6f, for the discord at %d, but got %.6f", expected[0], d.Idx, d.Dist)
+		}
+		if d.NeighborIdx != mp.Idx[d.Idx] {
+			t.Errorf("Expected the nearest neighbor of the discord at %d, %d, but got %d", d.Idx, mp.Idx[d.Idx], d.NeighborIdx)
+		}
+	}
+
+	expected, err := mp.DiscoverDiscordsWithOpts(&DiscordOpts{K: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	discords, err = mp.DiscoverDiscordsWithOpts(&DiscordOpts{K: 2, KNeighbor: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(discords, expected) {
+		t.Errorf("Expected the first nearest neighbor to match the matrix profile, %+v, but got %+v", expected, discords)
+	}
+
+	if _, err = mp.DiscoverDiscordsWithOpts(&DiscordOpts{K: 2, KNeighbor: -1}); err == nil {
+		t.Errorf("Expected an error for a negative k-th nearest neighbor")
+	}
+
+	mp, err = New(sig[:400], sig[400:], w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverDiscordsWithOpts(&DiscordOpts{K: 2, KNeighbor: 2}); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin, but got %v", err)
+	}
+}
+
+func TestDiscoverNotComputed(t *testing.T) {
+	a := []float64{0, 0, 0.56, 0.99, 0.97, 0.75, 0, 0, 0, 0.43, 0.98, 0.99, 0.65, 0, 0, 0, 0.6, 0.97, 0.965, 0.8, 0, 0, 0}
+
+	mp, err := New(a, nil, 4)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if _, err = mp.DiscoverDiscords(1, 2); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed without options, but got %v", err)
+	}
+
+	// options set without a computation are used to compute lazily
+	mp.Opts = NewMPOpts()
+	if _, err = mp.DiscoverMotifs(1, 2, 0, 2); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(mp.MP) != len(a)-mp.W+1 {
+		t.Errorf("Expected a matrix profile of length %d, but got %d", len(a)-mp.W+1, len(mp.MP))
+	}
+
+	// a failed computation leaves the previous matrix profile stale
+	o := NewMPOpts()
+	o.ExclusionZone = -1
+	if err = mp.Compute(o); err == nil {
+		t.Fatalf("Expected an error for a negative exclusion zone")
+	}
+	if _, err = mp.DiscoverDiscords(1, 2); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed after a failed computation, but got %v", err)
+	}
+	if _, err = mp.DiscoverMotifs(1, 2, 0, 2); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed after a failed computation, but got %v", err)
+	}
+	if _, _, histo := mp.DiscoverSegments(); histo != nil {
+		t.Errorf("Expected no arc curve after a failed computation, but got %v", histo)
+	}
+	if _, err = mp.DiscoverRegimes(2, 0); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed after a failed computation, but got %v", err)
+	}
+
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if _, err = mp.DiscoverDiscords(1, 2); err != nil {
+		t.Errorf("Did not expect an error after computing again, %v", err)
+	}
+
+	// a time series that outgrew its matrix profile is not reused
+	mp.A = append(mp.A, 0)
+	mp.B = mp.A
+	if _, err = mp.DiscoverDiscords(1, 2); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed for a mismatched matrix profile, but got %v", err)
+	}
+}
+
+func TestDiscoverMotifs(t *testing.T) {
+	a := []float64{0, 0, 0.56, 0.99, 0.97, 0.75, 0, 0, 0, 0.43, 0.98, 0.99, 0.65, 0, 0, 0, 0.6, 0.97, 0.965, 0.8, 0, 0, 0}
+
+	testdata := []struct {
+		a               []float64
+		b               []float64
+		m               int
+		k               int
+		expectedMotifs  [][]int
+		expectedMinDist []float64
+	}{
+		{
+			a, nil, 7, 3,
+			[][]int{{0, 14}, {0, 7}, {3, 10}},
+			[]float64{0.1459619228330262, 0.3352336136782056, 0.46369664551715467},
+		},
+		{
+			a, a, 7, 3,
+			nil,
+			nil,
+		},
+		{
+			a, nil, 7, 5,
+			[][]int{{0, 14}, {0, 7}, {3, 10}, {}, {}},
+			[]float64{0.1459619228330262, 0.3352336136782056, 0.46369664551715467, 0, 0},
+		},
+		{
+			[]float64{0, 1, 0, 0, 1, 0, 0}, nil, 3, 2,
+			[][]int{{0, 3}, {1, 4}},
+			[]float64{5.1619136559035694e-08, 0},
+		},
+	}
+
+	for _, d := range testdata {
+		mp, err := New(d.a, d.b, d.m)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+
+		o := NewMPOpts()
 		o.Algorithm = AlgoSTOMP
 		if err = mp.Compute(o); err != nil {
 			t.Error(err)
@@ -877,6 +2383,389 @@ func TestDiscoverMotifs(t *testing.T) {
 	}
 }
 
+func TestDiscoverMotifsWithOpts(t *testing.T) {
+	a := []float64{0, 0, 0.56, 0.99, 0.97, 0.75, 0, 0, 0, 0.43, 0.98, 0.99, 0.65, 0, 0, 0, 0.6, 0.97, 0.965, 0.8, 0, 0, 0}
+
+	mp, err := New(a, nil, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := mp.DiscoverMotifs(3, 2, 10, mp.W/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an annotation vector of all ones leaves the search unchanged
+	o := NewMotifOpts()
+	o.AVec = make([]float64, len(mp.MP))
+	for i := range o.AVec {
+		o.AVec[i] = 1
+	}
+	motifs, err := mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(motifs) != len(expected) {
+		t.Fatalf("Expected %d motif groups, but got %d", len(expected), len(motifs))
+	}
+	for i := range motifs {
+		if motifs[i].MinDist != expected[i].MinDist || len(motifs[i].Idx) != len(expected[i].Idx) {
+			t.Errorf("Expected motif group %+v, but got %+v", expected[i], motifs[i])
+		}
+	}
+
+	// lifting the closest pair and its trivial matches moves the top motif
+	// elsewhere since the neighboring subsequences tie with it
+	for _, idx := range expected[0].Idx {
+		for i := idx - mp.W/2; i <= idx+mp.W/2; i++ {
+			if i >= 0 && i < len(o.AVec) {
+				o.AVec[i] = 0
+			}
+		}
+	}
+	motifs, err = mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if motifs[0].MinDist == expected[0].MinDist {
+		t.Errorf("Expected the annotation vector to change the top motif, but got %+v", motifs[0])
+	}
+	if mp.AV != av.Default {
+		t.Errorf("Expected the annotation vector of the matrix profile to be unchanged, but got %s", mp.AV)
+	}
+
+	o.AVec = []float64{1}
+	if _, err = mp.DiscoverMotifsWithOpts(o); err == nil {
+		t.Errorf("Expected an error for a mismatched annotation vector length")
+	}
+}
+
+func TestDiscoverMotifsSeparation(t *testing.T) {
+	sig := siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 6), siggen.Noise(0.01, 600))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewMotifOpts()
+	o.K = 1
+	o.Radius = 100
+	o.NeighborCount = 100
+	motifs, err := mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flooded := len(motifs[0].Idx)
+
+	o.MinSeparation = 100
+	motifs, err = mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx, c := motifs[0].Idx, motifs[0].Center
+	if len(idx) >= flooded {
+		t.Errorf("Expected fewer than %d members with a minimum separation, but got %d", flooded, len(idx))
+	}
+	for i := range idx {
+		for j := i + 1; j < len(idx); j++ {
+			// the closest pair always forms the group
+			pair := idx[i] == c && idx[j] == mp.Idx[c] || idx[j] == c && idx[i] == mp.Idx[c]
+			if !pair && idx[j]-idx[i] < o.MinSeparation {
+				t.Errorf("Expected members at least %d apart, but got %d and %d", o.MinSeparation, idx[i], idx[j])
+			}
+		}
+	}
+
+	o.MinSeparation = 0
+	o.NeighborCount = 2
+	motifs, err = mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(motifs[0].Idx) != 2 {
+		t.Errorf("Expected only the closest pair in the group, but got %v", motifs[0].Idx)
+	}
+
+	o.MinSeparation = -1
+	if _, err = mp.DiscoverMotifsWithOpts(o); err == nil {
+		t.Errorf("Expected an error for a negative minimum separation")
+	}
+}
+
+func TestDiscoverMotifsZeroExclusionZone(t *testing.T) {
+	sig := siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 6), siggen.Noise(0.01, 600))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	// without an exclusion zone members are still only found once, so the
+	// search stops once the group is full
+	motifs, err := mp.DiscoverMotifs(2, 100, 5, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range motifs {
+		if len(m.Idx) != 5 {
+			t.Errorf("Expected 5 distinct members in motif %d, but got %v", i, m.Idx)
+		}
+		for j := 1; j < len(m.Idx); j++ {
+			if m.Idx[j] == m.Idx[j-1] {
+				t.Errorf("Expected distinct members in motif %d, but got %v", i, m.Idx)
+			}
+		}
+	}
+}
+
+func TestDiscoverMotifsContext(t *testing.T) {
+	mp, err := New(setupData(200), nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	expected, err := mp.DiscoverMotifs(3, 2, 10, mp.W/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMotifOpts()
+	o.NJobs = 4
+	motifs, err := mp.DiscoverMotifsContext(context.Background(), o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(motifs, expected) {
+		t.Errorf("Expected motifs %+v, but got %+v", expected, motifs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = mp.DiscoverMotifsContext(ctx, o); err != context.Canceled {
+		t.Errorf("Expected a cancelled context error, but got %v", err)
+	}
+	if !reflect.DeepEqual(mp.Motifs, expected) {
+		t.Errorf("Expected the motifs to be unchanged after cancelling, but got %+v", mp.Motifs)
+	}
+}
+
+func TestDiscoverMotifsDTW(t *testing.T) {
+	mp, err := New(setupData(200), nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewMotifOpts()
+	o.DTWBand = 2
+	motifs, err := mp.DiscoverMotifsWithOpts(o)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(motifs) == 0 || len(motifs) > o.K {
+		t.Fatalf("Expected between 1 and %d motif groups, but got %d", o.K, len(motifs))
+	}
+	if len(mp.Motifs) != len(motifs) {
+		t.Errorf("Expected the re-ranked motifs to be stored, but got %d groups", len(mp.Motifs))
+	}
+	for i, mg := range motifs {
+		if i > 0 && mg.DTWDist < motifs[i-1].DTWDist {
+			t.Errorf("Expected motif groups sorted by dtw distance, but got %.4f after %.4f", mg.DTWDist, motifs[i-1].DTWDist)
+		}
+
+		// warping within the band can only shorten the euclidean distance
+		closest := math.Inf(1)
+		for j, idx := range mg.Idx {
+			if idx != mg.Center && mg.Dists[j] < closest {
+				closest = mg.Dists[j]
+			}
+		}
+		if mg.DTWDist > closest+1e-6 {
+			t.Errorf("Expected a dtw distance of at most %.4f, but got %.4f for group %d", closest, mg.DTWDist, i)
+		}
+	}
+
+	o.DTWBand = -1
+	if _, err = mp.DiscoverMotifsWithOpts(o); err == nil {
+		t.Errorf("Expected an error for a negative dtw band")
+	}
+}
+
+func TestDiscoverMotifsRefinement(t *testing.T) {
+	mp, err := New(setupData(200), nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	radius := 3.0
+	motifs, err := mp.DiscoverMotifs(3, radius, 10, mp.W/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, mg := range motifs {
+		if len(mg.Dists) != len(mg.Idx) {
+			t.Fatalf("expected %d member distances for group %d, but got %d", len(mg.Idx), i, len(mg.Dists))
+		}
+		centered := false
+		for j, idx := range mg.Idx {
+			if idx == mg.Center {
+				centered = true
+				if mg.Dists[j] != 0 {
+					t.Errorf("expected a zero distance for the center of group %d, but got %v", i, mg.Dists[j])
+				}
+				continue
+			}
+			if mg.Dists[j] > mg.MinDist*radius+1e-7 {
+				t.Errorf("expected member %d of group %d within %v, but got %v", idx, i, mg.MinDist*radius, mg.Dists[j])
+			}
+		}
+		if !centered {
+			t.Errorf("expected center %d to be a member of group %d, %v", mg.Center, i, mg.Idx)
+		}
+
+		if len(mg.Consensus) != mp.W {
+			t.Fatalf("expected a consensus of length %d for group %d, but got %d", mp.W, i, len(mg.Consensus))
+		}
+		mean, std := stat.MeanStdDev(mg.Consensus, nil)
+		std *= math.Sqrt(float64(mp.W-1) / float64(mp.W))
+		if math.Abs(mean) > 1e-7 || math.Abs(std-1) > 1e-7 {
+			t.Errorf("expected a z-normalized consensus for group %d, but got mean %v and std %v", i, mean, std)
+		}
+	}
+}
+
+func TestRangeQuery(t *testing.T) {
+	pattern := []float64{0, 1, 3, 6, 3, 1, 0, -1}
+	b := make([]float64, 160)
+	for i := range b {
+		b[i] = math.Sin(float64(i)*0.37) * math.Cos(float64(i)*0.11) * 0.2
+	}
+	for _, idx := range []int{20, 70, 130} {
+		copy(b[idx:], pattern)
+	}
+
+	mp, err := New(setupData(100), b, len(pattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		q             []float64
+		radius        float64
+		exclusionZone int
+		expectedIdx   []int
+		expectedErr   bool
+	}{
+		{pattern, 0.5, len(pattern) / 2, []int{20, 70, 130}, false},
+		{[]float64{0, -1, -3, -6, -3, -1, 0, 1}, 0.5, len(pattern) / 2, nil, false},
+		{pattern[:4], 0.5, 4, nil, true},
+		{pattern, -1, 4, nil, true},
+	}
+
+	for _, d := range testdata {
+		out, err := mp.RangeQuery(d.q, d.radius, d.exclusionZone)
+		if d.expectedErr {
+			if err == nil {
+				t.Errorf("Expected an error for %v", d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %v", err, d)
+			continue
+		}
+		if len(out) != len(d.expectedIdx) {
+			t.Errorf("Expected %d matches, but got %v", len(d.expectedIdx), out)
+			continue
+		}
+		for i, m := range out {
+			if m.Idx != d.expectedIdx[i] {
+				t.Errorf("Expected match %d at index %d, but got %d", i, d.expectedIdx[i], m.Idx)
+			}
+			if m.Dist > d.radius {
+				t.Errorf("Expected match %d within %.3f, but got %.3f", i, d.radius, m.Dist)
+			}
+		}
+	}
+
+	// without an exclusion zone overlapping subsequences are returned as well
+	out, err := mp.RangeQuery(pattern, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) <= 3 {
+		t.Errorf("Expected more than 3 matches without an exclusion zone, but got %v", out)
+	}
+}
+
+func TestBatchDistanceProfiles(t *testing.T) {
+	b := setupData(120)
+	mp, err := New(setupData(100), b, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp.Opts = NewMPOpts()
+	mp.Opts.NJobs = 3
+
+	queries := make([][]float64, 7)
+	for i := range queries {
+		queries[i] = b[i*11 : i*11+mp.W]
+	}
+
+	profs, err := mp.BatchDistanceProfiles(queries)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(profs) != len(queries) {
+		t.Fatalf("Expected %d distance profiles, but got %d", len(queries), len(profs))
+	}
+
+	expected := make([]float64, mp.N-mp.W+1)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for i, q := range queries {
+		if err = mp.mass(q, expected, fft); err != nil {
+			t.Fatal(err)
+		}
+		if len(profs[i]) != len(expected) {
+			t.Fatalf("Expected a distance profile of length %d, but got %d", len(expected), len(profs[i]))
+		}
+		for j := range expected {
+			if math.Abs(profs[i][j]-expected[j]) > 1e-7 {
+				t.Errorf("Expected %.6f at index %d of query %d, but got %.6f", expected[j], j, i, profs[i][j])
+				break
+			}
+		}
+		if profs[i][i*11] > 1e-4 {
+			t.Errorf("Expected query %d to match itself at %d, but got %.6f", i, i*11, profs[i][i*11])
+		}
+	}
+
+	if _, err = mp.BatchDistanceProfiles([][]float64{queries[0], queries[1][:4]}); err == nil {
+		t.Errorf("Expected an error for a query of the wrong length")
+	}
+	if _, err = mp.BatchDistanceProfiles([][]float64{queries[0], {1, 1, 1, 1, 1, 1, 1, 1}}); err == nil {
+		t.Errorf("Expected an error for a constant query")
+	}
+}
+
 func TestDiscoverSegments(t *testing.T) {
 	testdata := []struct {
 		mpIdx         []int
@@ -920,3 +2809,219 @@ func TestDiscoverSegments(t *testing.T) {
 		}
 	}
 }
+
+func TestDiscoverRegimes(t *testing.T) {
+	// three regimes of different periodic shapes
+	ts := make([]float64, 900)
+	for i := range ts {
+		switch {
+		case i < 300:
+			ts[i] = math.Sin(2 * math.Pi * float64(i) / 20)
+		case i < 600:
+			ts[i] = math.Abs(math.Mod(float64(i), 30)-15) / 15
+		default:
+			ts[i] = math.Sin(2*math.Pi*float64(i)/40) + 0.5*math.Sin(2*math.Pi*float64(i)/13)
+		}
+		ts[i] += 0.01 * math.Cos(float64(i)*1.7)
+	}
+
+	mp, err := New(ts, nil, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	regimes, err := mp.DiscoverRegimes(3, 0)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(regimes) != 2 {
+		t.Fatalf("Expected 2 regime boundaries, but got %+v", regimes)
+	}
+	for i, expected := range []int{300, 600} {
+		if math.Abs(float64(regimes[i].Idx-expected)) > 40 {
+			t.Errorf("Expected a boundary near %d, but got %+v", expected, regimes)
+		}
+		if regimes[i].Score < 0 || regimes[i].Score > 1 {
+			t.Errorf("Expected a score between 0 and 1, but got %+v", regimes[i])
+		}
+	}
+
+	// the single lowest boundary is the same as the segment found
+	regimes, err = mp.DiscoverRegimes(2, 0)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	segIdx, _, _ := mp.DiscoverSegments()
+	if len(regimes) != 1 || regimes[0].Idx != segIdx {
+		t.Errorf("Expected a single boundary at %d, but got %+v", segIdx, regimes)
+	}
+
+	if regimes, err = mp.DiscoverRegimes(1, 0); err != nil || len(regimes) != 0 {
+		t.Errorf("Expected no boundaries for a single regime, but got %+v, %v", regimes, err)
+	}
+	if _, err = mp.DiscoverRegimes(0, 0); err == nil {
+		t.Errorf("Expected an error for 0 regimes")
+	}
+}
+
+func TestPearsonMode(t *testing.T) {
+	sig := siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 3), siggen.Noise(0.05, 300))
+	for i := 150; i < 160; i++ {
+		sig[i] += 2
+	}
+	w := 20
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP, AlgoSTAMP, AlgoSTMP} {
+		eo := NewMPOpts()
+		eo.Algorithm = algo
+		eo.NJobs = 4
+		euc, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = euc.Compute(eo); err != nil {
+			t.Fatal(err)
+		}
+
+		po := NewMPOpts()
+		po.Algorithm = algo
+		po.NJobs = 4
+		po.Euclidean = false
+		pea, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = pea.Compute(po); err != nil {
+			t.Fatal(err)
+		}
+
+		want := append([]float64(nil), euc.MP...)
+		util.E2P(want, w)
+		for i := range want {
+			if math.Abs(pea.MP[i]-want[i]) > 1e-6 {
+				t.Fatalf("Expected correlation %.6f, but got %.6f at %d for %s", want[i], pea.MP[i], i, algo)
+			}
+		}
+
+		eDiscords, err := euc.DiscoverDiscords(3, w/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pDiscords, err := pea.DiscoverDiscords(3, w/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(eDiscords, pDiscords) {
+			t.Errorf("Expected pearson discords %v to match euclidean discords %v for %s", pDiscords, eDiscords, algo)
+		}
+
+		eMotifs, err := euc.DiscoverMotifs(2, 2, 10, w/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pMotifs, err := pea.DiscoverMotifs(2, 2, 10, w/2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range eMotifs {
+			if !reflect.DeepEqual(eMotifs[i].Idx, pMotifs[i].Idx) || math.Abs(eMotifs[i].MinDist-pMotifs[i].MinDist) > 1e-6 {
+				t.Errorf("Expected pearson motif %+v to match euclidean motif %+v for %s", pMotifs[i], eMotifs[i], algo)
+			}
+		}
+
+		if err = pea.Update([]float64{0}); err == nil {
+			t.Errorf("Expected an error updating pearson correlations for %s", algo)
+		}
+
+		if err = pea.ToEuclidean(); err != nil {
+			t.Fatal(err)
+		}
+		if !pea.Opts.Euclidean || po.Euclidean {
+			t.Errorf("Expected only the matrix profile options to become euclidean for %s", algo)
+		}
+		for i := range euc.MP {
+			if math.Abs(pea.MP[i]-euc.MP[i]) > 1e-6 {
+				t.Fatalf("Expected distance %.6f, but got %.6f at %d for %s", euc.MP[i], pea.MP[i], i, algo)
+			}
+		}
+		if err = pea.ToPearson(); err != nil {
+			t.Fatal(err)
+		}
+		if pea.Opts.Euclidean {
+			t.Errorf("Expected pearson options for %s", algo)
+		}
+	}
+
+	mp, err := New(sig, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.ToEuclidean(); err == nil {
+		t.Errorf("Expected an error converting a matrix profile that was not computed")
+	}
+	o := NewMPOpts()
+	o.Normalization = NormMean
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.ToPearson(); err == nil {
+		t.Errorf("Expected an error converting mean-centered distances")
+	}
+}
+
+func TestPearsonModeDistanceEngines(t *testing.T) {
+	a := setupData(150)
+	b := setupData(200)
+	w := 12
+
+	for _, algo := range []Algo{AlgoSTOMP, AlgoSTAMP, AlgoSTMP} {
+		for _, d := range []struct {
+			b         []float64
+			leftRight bool
+		}{{b, false}, {nil, true}} {
+			compute := func(euclidean bool) *MatrixProfile {
+				mp, err := New(a, d.b, w)
+				if err != nil {
+					t.Fatal(err)
+				}
+				o := NewMPOpts()
+				o.Algorithm = algo
+				o.NJobs = 3
+				o.Euclidean = euclidean
+				o.LeftRight = d.leftRight
+				if err = mp.Compute(o); err != nil {
+					t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+				}
+				return mp
+			}
+			euc, pea := compute(true), compute(false)
+
+			for _, prof := range []struct {
+				name    string
+				got     []float64
+				want    []float64
+				gotIdx  []int
+				wantIdx []int
+			}{
+				{"matrix profile", pea.MP, euc.MP, pea.Idx, euc.Idx},
+				{"left matrix profile", pea.LMP, euc.LMP, pea.LIdx, euc.LIdx},
+				{"right matrix profile", pea.RMP, euc.RMP, pea.RIdx, euc.RIdx},
+			} {
+				want := append([]float64(nil), prof.want...)
+				euclideanToPearson(want, w)
+				if len(prof.got) != len(want) {
+					t.Fatalf("Expected %s of length %d, but got %d for %s", prof.name, len(want), len(prof.got), algo)
+				}
+				for i := range want {
+					if math.Abs(prof.got[i]-want[i]) > 1e-9 || prof.gotIdx[i] != prof.wantIdx[i] {
+						t.Errorf("Expected %s correlation %.6f at %d, but got %.6f at %d for %s", prof.name, want[i], prof.wantIdx[i], prof.got[i], prof.gotIdx[i], algo)
+						break
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package matrixprofile
+
+// bytes taken by a float64 or int value
+const wordSize = 8
+
+// EstimateComputeMemory returns the approximate number of bytes allocated by
+// Compute for the self join of a time series of n points with a subsequence
+// length of w and the given options, or the default options if nil. This
+// covers the output profiles, the cached sliding statistics and fourier
+// transform, and the full length profile each of the NJobs workers keeps
+// while computing, which dominates for long time series with many workers.
+// Slices reused with the ReuseOutput option are still counted.
+func EstimateComputeMemory(n, w int, opts *MPOpts) int64 {
+	return estimateMemory(n, n, w, true, opts)
+}
+
+// estimateMemory returns the approximate number of bytes allocated by
+// Compute for time series a and b of lenA and lenB points
+func estimateMemory(lenA, lenB, w int, selfJoin bool, o *MPOpts) int64 {
+	if o == nil {
+		o = NewMPOpts()
+	}
+	profA := int64(lenA - w + 1)
+	profB := int64(lenB - w + 1)
+	if profA < 1 || profB < 1 {
+		return 0
+	}
+	jobs := int64(o.NJobs)
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	// left and right matrix profiles double the values kept per profile
+	perProf := int64(2 * wordSize)
+	if o.LeftRight {
+		perProf *= 3
+	}
+
+	var total int64
+	switch {
+	case o.Algorithm == AlgoMPX && o.SamplePct >= 1 && o.Weights == nil:
+		// sliding statistics and update differences of each time series
+		total = 4 * wordSize * profA
+		batch := perProf * profA
+		total += perProf * profA
+		if !selfJoin {
+			total += 4 * wordSize * profB
+			batch += 2 * wordSize * profB
+			total += 2 * wordSize * profB
+		}
+		total += jobs * batch
+		if o.KeepCorr {
+			total += wordSize * profA
+		}
+	default:
+		// sliding statistics of both time series and the fourier transform
+		// of b stored as complex values
+		total = 2*wordSize*(profA+profB) + 2*wordSize*(int64(lenB)/2+1)
+		if o.Weights != nil {
+			total += 2 * wordSize * profB
+		}
+		total += perProf * profB
+
+		// each worker holds its batch profile along with the padded query,
+		// its fourier transform and the sliding dot product
+		batch := perProf*profB + 4*wordSize*int64(lenB) + wordSize*profB
+		if o.Algorithm == AlgoSTMP {
+			jobs = 1
+		}
+		total += jobs * batch
+		if o.SamplePct < 1 || o.Algorithm == AlgoSTAMP {
+			// random ordering of the rows
+			total += wordSize * profA
+		}
+	}
+	return total
+}
+
+// fitMemoryBudget lowers the number of jobs in the options until the
+// estimated memory of the computation fits the memory budget of the options.
+// Returns an error if it does not fit with a single job.
+func (mp MatrixProfile) fitMemoryBudget(o *MPOpts) error {
+	if o.MemoryBudget <= 0 {
+		return nil
+	}
+
+	est := estimateMemory(len(mp.A), len(mp.B), mp.W, mp.SelfJoin, o)
+	jobs := o.NJobs
+	for est > o.MemoryBudget && o.NJobs > 1 {
+		o.NJobs--
+		est = estimateMemory(len(mp.A), len(mp.B), mp.W, mp.SelfJoin, o)
+	}
+	if o.NJobs < jobs {
+		mp.log("lowered jobs to fit the memory budget", "n_jobs", o.NJobs, "requested_n_jobs", jobs, "estimated_bytes", est, "memory_budget", o.MemoryBudget)
+	}
+	if est > o.MemoryBudget {
+		return newError(ErrMemoryBudget, "computing needs about %d bytes with a single job, over the budget of %d bytes", est, o.MemoryBudget)
+	}
+	return nil
+}
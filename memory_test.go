@@ -0,0 +1,62 @@
+package matrixprofile
+
+import "testing"
+
+func TestEstimateComputeMemory(t *testing.T) {
+	o := NewMPOpts()
+	o.NJobs = 1
+	one := EstimateComputeMemory(10000, 100, o)
+	o.NJobs = 8
+	eight := EstimateComputeMemory(10000, 100, o)
+	if one <= 0 || eight <= one {
+		t.Errorf("Expected the estimate to grow with the number of jobs, but got %d for 1 job and %d for 8 jobs", one, eight)
+	}
+
+	// the batch profiles alone take 16 bytes per subsequence for every job
+	if eight < 8*16*(10000-100+1) {
+		t.Errorf("Expected at least %d bytes for 8 jobs, but got %d", 8*16*(10000-100+1), eight)
+	}
+
+	for _, algo := range []Algo{AlgoSTOMP, AlgoSTAMP, AlgoSTMP} {
+		o.Algorithm = algo
+		if est := EstimateComputeMemory(10000, 100, o); est <= 0 {
+			t.Errorf("Expected a positive estimate for %s, but got %d", algo, est)
+		}
+	}
+
+	if est := EstimateComputeMemory(50, 100, nil); est != 0 {
+		t.Errorf("Expected no memory for a window longer than the time series, but got %d", est)
+	}
+}
+
+func TestComputeMemoryBudget(t *testing.T) {
+	a := setupData(1000)
+
+	o := NewMPOpts()
+	o.NJobs = 8
+	o.MemoryBudget = EstimateComputeMemory(len(a), 32, &MPOpts{Algorithm: AlgoMPX, SamplePct: 1, NJobs: 2})
+
+	mp, err := New(a, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(o); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if mp.Opts.NJobs != 2 {
+		t.Errorf("Expected the number of jobs to be lowered to 2, but got %d", mp.Opts.NJobs)
+	}
+	if o.NJobs != 8 {
+		t.Errorf("Expected the caller's options to be unchanged, but got %d jobs", o.NJobs)
+	}
+
+	o.MemoryBudget = 1000
+	if err = mp.Compute(o); Cause(err) != ErrMemoryBudget {
+		t.Errorf("Expected ErrMemoryBudget, but got %v", err)
+	}
+
+	o.MemoryBudget = -1
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error for a negative memory budget")
+	}
+}
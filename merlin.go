@@ -0,0 +1,172 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// minSubseqStd is the smallest standard deviation of a subsequence to be
+// considered by MERLIN. Flatter subsequences have no z-normalized shape.
+const minSubseqStd = 1e-8
+
+// minDragRange is the smallest range searched by DRAG before falling back to
+// refining every candidate
+const minDragRange = 1e-3
+
+// LengthDiscord is the top discord for a single subsequence length.
+type LengthDiscord struct {
+	Idx    int     `json:"idx"`    // starting index of the discord
+	Length int     `json:"length"` // subsequence length of the discord
+	Dist   float64 `json:"dist"`   // z-normalized euclidean distance to the nearest non-overlapping neighbor
+}
+
+// Merlin finds the top discord of every subsequence length between minL and
+// maxL inclusive. A discord is the subsequence with the largest distance to
+// its nearest non-overlapping neighbor. Each length is searched with the DRAG
+// algorithm which only refines candidates that have no neighbor within a range
+// r. The range for each length is predicted from the discord distances of the
+// previous lengths so that few candidates survive. Lengths where every
+// subsequence is constant are skipped. This is based on the UCR paper, MERLIN:
+// Parameter-Free Discovery of Arbitrary Length Anomalies in Massive Time
+// Series Archives, by Nakamura et al.
+func Merlin(ts []float64, minL, maxL int) ([]LengthDiscord, error) {
+	if minL < 4 {
+		return nil, fmt.Errorf("minimum length must be at least 4, got %d", minL)
+	}
+	if maxL < minL {
+		return nil, fmt.Errorf("maximum length %d must be at least the minimum length %d", maxL, minL)
+	}
+	if 2*maxL > len(ts) {
+		return nil, fmt.Errorf("maximum length %d must be at most half the length of the time series %d", maxL, len(ts))
+	}
+
+	discords := make([]LengthDiscord, 0, maxL-minL+1)
+	dists := make([]float64, 0, maxL-minL+1)
+	for l := minL; l <= maxL; l++ {
+		mp, err := New(ts, nil, l)
+		if err != nil {
+			return nil, err
+		}
+		if err = mp.initCaches(); err != nil {
+			return nil, err
+		}
+
+		var r, step float64
+		switch {
+		case len(dists) == 0:
+			r = 2 * math.Sqrt(float64(l))
+		case len(dists) < 5:
+			r = 0.99 * dists[len(dists)-1]
+		default:
+			m, s := stat.MeanStdDev(dists[len(dists)-5:], nil)
+			r, step = m-2*s, s
+		}
+
+		var d LengthDiscord
+		found := false
+		for !found {
+			d, found, err = mp.drag(r)
+			if err != nil {
+				return nil, err
+			}
+			if r <= 0 {
+				// every candidate was refined so nothing is left to find
+				break
+			}
+
+			switch {
+			case len(dists) == 0:
+				r *= 0.5
+			case len(dists) < 5 || step == 0:
+				r *= 0.99
+			default:
+				r -= step
+			}
+			if r < minDragRange {
+				// refine every remaining candidate on the last pass
+				r = 0
+			}
+		}
+		if !found {
+			continue
+		}
+
+		discords = append(discords, d)
+		dists = append(dists, d.Dist)
+	}
+
+	return discords, nil
+}
+
+// drag searches for the subsequence of length W with the largest distance to
+// its nearest non-overlapping neighbor among those with no neighbor closer
+// than r. Returns false if every subsequence has a neighbor within r.
+func (mp MatrixProfile) drag(r float64) (LengthDiscord, bool, error) {
+	n := len(mp.AMean)
+	w := float64(mp.W)
+
+	dist := func(i, j int) float64 {
+		var dot float64
+		for k := 0; k < mp.W; k++ {
+			dot += mp.A[i+k] * mp.A[j+k]
+		}
+		corr := (dot - w*mp.AMean[i]*mp.AMean[j]) / (w * mp.AStd[i] * mp.AStd[j])
+		return math.Sqrt(math.Max(0, 2*w*(1-corr)))
+	}
+
+	// phase 1 selects candidates that have no earlier neighbor within r and
+	// removes earlier candidates that have a neighbor within r
+	var cands []int
+	for i := 0; i < n; i++ {
+		if !(mp.AStd[i] > minSubseqStd) {
+			continue
+		}
+
+		isCand := true
+		kept := cands[:0]
+		for _, c := range cands {
+			if i-c < mp.W {
+				kept = append(kept, c)
+				continue
+			}
+			if dist(i, c) < r {
+				isCand = false
+				continue
+			}
+			kept = append(kept, c)
+		}
+		cands = kept
+		if isCand {
+			cands = append(cands, i)
+		}
+	}
+
+	// phase 2 computes the exact nearest neighbor distance of the remaining
+	// candidates and discards those with a neighbor within r
+	best := LengthDiscord{Idx: -1, Length: mp.W, Dist: math.Inf(-1)}
+	prof := make([]float64, n)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for _, c := range cands {
+		if err := mp.mass(mp.A[c:c+mp.W], prof, fft); err != nil {
+			return best, false, err
+		}
+
+		nn := math.Inf(1)
+		for j, d := range prof {
+			if j > c-mp.W && j < c+mp.W {
+				continue
+			}
+			if mp.AStd[j] > minSubseqStd && d < nn {
+				nn = d
+			}
+		}
+		if nn >= r && !math.IsInf(nn, 1) && nn > best.Dist {
+			best.Idx, best.Dist = c, nn
+		}
+	}
+
+	return best, best.Idx >= 0, nil
+}
@@ -0,0 +1,93 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// bruteForceDiscord finds the subsequence with the largest distance to its
+// nearest non-overlapping neighbor by comparing every pair of subsequences
+func bruteForceDiscord(ts []float64, w int) (int, float64) {
+	n := len(ts) - w + 1
+	z := make([][]float64, n)
+	for i := range z {
+		z[i], _ = util.ZNormalize(ts[i : i+w])
+	}
+
+	bestIdx, bestDist := -1, math.Inf(-1)
+	for i := 0; i < n; i++ {
+		nn := math.Inf(1)
+		for j := 0; j < n; j++ {
+			if j > i-w && j < i+w {
+				continue
+			}
+			var d float64
+			for k := range z[i] {
+				d += (z[i][k] - z[j][k]) * (z[i][k] - z[j][k])
+			}
+			nn = math.Min(nn, math.Sqrt(d))
+		}
+		if nn > bestDist {
+			bestIdx, bestDist = i, nn
+		}
+	}
+	return bestIdx, bestDist
+}
+
+func TestMerlin(t *testing.T) {
+	ts := make([]float64, 240)
+	for i := range ts {
+		ts[i] = math.Sin(float64(i)*2*math.Pi/20) + 0.05*math.Sin(float64(i)*1.3)
+	}
+	for i := 150; i < 160; i++ {
+		ts[i] += 0.8 * math.Sin(float64(i)*1.1)
+	}
+
+	minL, maxL := 8, 20
+	out, err := Merlin(ts, minL, maxL)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(out) != maxL-minL+1 {
+		t.Fatalf("Expected %d discords, but got %d", maxL-minL+1, len(out))
+	}
+
+	for i, d := range out {
+		if d.Length != minL+i {
+			t.Errorf("Expected discord %d with length %d, but got %d", i, minL+i, d.Length)
+		}
+		idx, dist := bruteForceDiscord(ts, d.Length)
+		if d.Idx != idx || math.Abs(d.Dist-dist) > 1e-6 {
+			t.Errorf("Expected discord at %d with distance %.6f for length %d, but got %d with %.6f", idx, dist, d.Length, d.Idx, d.Dist)
+		}
+	}
+}
+
+func TestMerlinInvalid(t *testing.T) {
+	ts := make([]float64, 50)
+	testdata := []struct {
+		minL int
+		maxL int
+	}{
+		{3, 10},
+		{10, 8},
+		{10, 26},
+	}
+
+	for _, d := range testdata {
+		if _, err := Merlin(ts, d.minL, d.maxL); err == nil {
+			t.Errorf("Expected an error for lengths %d to %d", d.minL, d.maxL)
+		}
+	}
+
+	// a constant time series has no discords of any length
+	out, err := Merlin(ts, 4, 10)
+	if err != nil {
+		t.Errorf("Did not expect an error, %v", err)
+	}
+	if len(out) != 0 {
+		t.Errorf("Expected no discords for a constant time series, but got %v", out)
+	}
+}
@@ -0,0 +1,32 @@
+package matrixprofile
+
+import (
+	"time"
+)
+
+// Metrics receives telemetry from matrix profile computations so that long
+// running jobs can be monitored, such as by exporting counters and timers to
+// Prometheus or OpenTelemetry. Set it with the Metrics option. Methods may be
+// called from other goroutines than the one computing and should return
+// quickly since the computation waits on them.
+type Metrics interface {
+	// BatchMerged is called after the results of one of the batches of
+	// parallel workers are merged into the matrix profile, with the number of
+	// batches of the computation and the time the merge took. Batches are
+	// merged in order, so batch+1 out of batches is the progress made.
+	BatchMerged(algo Algo, batch, batches int, merge time.Duration)
+
+	// ComputeDone is called once Compute finishes with the length of the
+	// matrix profile, the total time taken and any error returned. The rate
+	// of subsequences computed per second follows from the length and time
+	// taken, scaled by the sample of rows for a sampled STAMP.
+	ComputeDone(algo Algo, length int, elapsed time.Duration, err error)
+}
+
+// metrics returns the metrics of the options or nil if none are set
+func (mp MatrixProfile) metrics() Metrics {
+	if mp.Opts == nil {
+		return nil
+	}
+	return mp.Opts.Metrics
+}
@@ -0,0 +1,73 @@
+package matrixprofile
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordedMetrics struct {
+	mu       sync.Mutex
+	batches  []int
+	total    int
+	computed int
+	length   int
+	err      error
+}
+
+func (r *recordedMetrics) BatchMerged(algo Algo, batch, batches int, merge time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, batch)
+	r.total = batches
+}
+
+func (r *recordedMetrics) ComputeDone(algo Algo, length int, elapsed time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.computed++
+	r.length = length
+	r.err = err
+}
+
+func TestComputeMetrics(t *testing.T) {
+	mp, err := New(setupData(300), nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP, AlgoSTAMP} {
+		m := &recordedMetrics{}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.NJobs = 3
+		o.Metrics = m
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		if m.total == 0 || len(m.batches) != m.total {
+			t.Errorf("Expected every batch merged with %s, but got %v of %d", algo, m.batches, m.total)
+		}
+		for i, b := range m.batches {
+			if b != i {
+				t.Errorf("Expected batches merged in order with %s, but got %v", algo, m.batches)
+				break
+			}
+		}
+		if m.computed != 1 || m.length != len(mp.MP) || m.err != nil {
+			t.Errorf("Expected one computation of %d subsequences with %s, but got %d of %d with error %v", len(mp.MP), algo, m.computed, m.length, m.err)
+		}
+	}
+
+	m := &recordedMetrics{}
+	o := NewMPOpts()
+	o.Metrics = m
+	o.ExclusionZone = -1
+	if err = mp.Compute(o); err == nil {
+		t.Fatal("Expected an error for a negative exclusion zone")
+	}
+	if m.computed != 1 || m.err != err {
+		t.Errorf("Expected the failed computation to be reported with %v, but got %v", err, m.err)
+	}
+}
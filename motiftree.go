@@ -0,0 +1,109 @@
+package matrixprofile
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// MotifTreeOpts are parameters to vary how a hierarchy of motifs is built
+// with DiscoverMotifTree.
+type MotifTreeOpts struct {
+	K             int       // the top k motifs to find at each radius
+	Radii         []float64 // radii to discover motifs with as multiples of the closest pair distance of each motif
+	NeighborCount int       // maximum number of members in a motif group. Defaults to 10 if 0
+	ExclusionZone int       // exclusion zone around found motifs. Defaults to half the subsequence length if 0
+}
+
+// NewMotifTreeOpts returns a default MotifTreeOpts which doubles the radius
+// at each level of the hierarchy.
+func NewMotifTreeOpts() *MotifTreeOpts {
+	return &MotifTreeOpts{
+		K:     3,
+		Radii: []float64{1, 2, 4},
+	}
+}
+
+// MotifNode is a motif group within a hierarchy of motifs discovered with
+// growing radii.
+type MotifNode struct {
+	Group    MotifGroup   `json:"group"`    // motif group discovered at this level
+	Radius   float64      `json:"radius"`   // radius the group was discovered with
+	Children []*MotifNode `json:"children"` // groups of the next smaller radius nested within this group
+}
+
+// DiscoverMotifTree discovers motifs at each of the radii of the options and
+// nests every group within the group of the next larger radius sharing the
+// most members, where members closer than the exclusion zone are shared. This
+// shows how tight motifs merge into looser ones as the radius grows. The roots
+// are the groups of the largest radius followed by any smaller groups not
+// nested in a larger one. Only applies to self joins and leaves the motifs
+// stored on the matrix profile unchanged.
+func (mp *MatrixProfile) DiscoverMotifTree(o *MotifTreeOpts) ([]*MotifNode, error) {
+	if o == nil {
+		o = NewMotifTreeOpts()
+	}
+	if len(o.Radii) == 0 {
+		return nil, errors.New("must provide at least one radius to build a motif tree")
+	}
+	radii := append([]float64(nil), o.Radii...)
+	sort.Float64s(radii)
+	if radii[0] <= 0 {
+		return nil, fmt.Errorf("radii must be positive, got %.3f", radii[0])
+	}
+	zone := o.ExclusionZone
+	if zone <= 0 {
+		zone = mp.W / 2
+	}
+
+	saved := mp.Motifs
+	defer func() { mp.Motifs = saved }()
+
+	levels := make([][]*MotifNode, len(radii))
+	for l, r := range radii {
+		groups, err := mp.DiscoverMotifs(o.K, r, o.NeighborCount, zone)
+		if err != nil {
+			return nil, err
+		}
+		for _, g := range groups {
+			if len(g.Idx) > 0 {
+				levels[l] = append(levels[l], &MotifNode{Group: g, Radius: r})
+			}
+		}
+	}
+
+	roots := levels[len(levels)-1]
+	for l := len(levels) - 2; l >= 0; l-- {
+		for _, n := range levels[l] {
+			if parent := nestedIn(n, levels[l+1], zone); parent != nil {
+				parent.Children = append(parent.Children, n)
+			} else {
+				roots = append(roots, n)
+			}
+		}
+	}
+	return roots, nil
+}
+
+// nestedIn returns the candidate sharing the most members with the node or
+// nil if no members are shared. Members closer than the exclusion zone are
+// treated as the same occurrence.
+func nestedIn(n *MotifNode, candidates []*MotifNode, zone int) *MotifNode {
+	var best *MotifNode
+	var bestShared int
+	for _, c := range candidates {
+		var shared int
+		for _, i := range n.Group.Idx {
+			for _, j := range c.Group.Idx {
+				if i-j < zone && j-i < zone {
+					shared++
+					break
+				}
+			}
+		}
+		if shared > bestShared {
+			best, bestShared = c, shared
+		}
+	}
+	return best
+}
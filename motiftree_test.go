@@ -0,0 +1,85 @@
+package matrixprofile
+
+import (
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestDiscoverMotifTree(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.3, 100))
+	sig = siggen.Add(sig, siggen.Noise(0.05, len(sig)))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	mp.Motifs = []MotifGroup{{Idx: []int{1, 2}}}
+
+	o := NewMotifTreeOpts()
+	o.Radii = []float64{4, 1, 2}
+	roots, err := mp.DiscoverMotifTree(o)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(roots) == 0 || roots[0].Radius != 4 {
+		t.Fatalf("Expected the largest radius at the roots, but got %+v", roots)
+	}
+	if len(mp.Motifs) != 1 || mp.Motifs[0].Idx[0] != 1 {
+		t.Errorf("Expected the stored motifs to be unchanged, but got %+v", mp.Motifs)
+	}
+
+	var nodes int
+	var walk func(n *MotifNode)
+	walk = func(n *MotifNode) {
+		nodes++
+		for _, c := range n.Children {
+			if c.Radius >= n.Radius {
+				t.Errorf("Expected a child radius below %.1f, but got %.1f", n.Radius, c.Radius)
+			}
+			if nestedIn(c, []*MotifNode{n}, mp.W/2) != n {
+				t.Errorf("Expected child %v to share members with %v", c.Group.Idx, n.Group.Idx)
+			}
+			walk(c)
+		}
+	}
+	for _, r := range roots {
+		walk(r)
+	}
+	if nodes < len(roots)+1 {
+		t.Errorf("Expected nested motif groups, but got %d nodes for %d roots", nodes, len(roots))
+	}
+
+	for _, radii := range [][]float64{nil, {0, 1}, {-1}} {
+		if _, err = mp.DiscoverMotifTree(&MotifTreeOpts{K: 2, Radii: radii}); err == nil {
+			t.Errorf("Expected an error for radii %v", radii)
+		}
+	}
+
+	ab, err := New(sig, sig[:100], 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ab.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ab.DiscoverMotifTree(nil); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin, but got %v", err)
+	}
+}
+
+func TestNestedIn(t *testing.T) {
+	a := &MotifNode{Group: MotifGroup{Idx: []int{0, 50}}}
+	b := &MotifNode{Group: MotifGroup{Idx: []int{2, 49, 100}}}
+	c := &MotifNode{Group: MotifGroup{Idx: []int{51, 200}}}
+	n := &MotifNode{Group: MotifGroup{Idx: []int{1, 52}}}
+
+	if p := nestedIn(n, []*MotifNode{c, b, a}, 5); p != b {
+		t.Errorf("Expected the group sharing the most members, but got %+v", p)
+	}
+	if p := nestedIn(n, []*MotifNode{c, b, a}, 1); p != nil {
+		t.Errorf("Expected no parent without shared members, but got %+v", p)
+	}
+}
@@ -0,0 +1,382 @@
+package mpio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+// Arrow IPC constants from the Arrow columnar format specification
+const (
+	arrowContinuation   = 0xFFFFFFFF
+	arrowMetadataV5     = 4
+	arrowHeaderSchema   = 1
+	arrowHeaderRecord   = 3
+	arrowTypeInt        = 2
+	arrowTypeFloat      = 3
+	arrowPrecisionFloat = 2 // DOUBLE
+)
+
+// WriteArrow writes the matrix profile and matrix profile index to w as an Arrow
+// IPC stream containing a single record batch. The schema has a float64 "mp"
+// and int64 "pi" column along with "mp_ba" and "pi_ba" for AB joins. The BA
+// join columns are padded with nulls if they are shorter than the AB join.
+// The window, time series length, and whether a self join was performed are
+// stored as schema metadata under "w", "n", and "self_join". The stream can be
+// read with pyarrow.ipc.open_stream.
+func WriteArrow(w io.Writer, p *mp.MatrixProfile) error {
+	cols := []column{
+		{name: "mp", f64: p.MP},
+		{name: "pi", i64: p.Idx},
+	}
+	if len(p.MPB) > 0 {
+		cols = append(cols,
+			column{name: "mp_ba", f64: p.MPB},
+			column{name: "pi_ba", i64: p.IdxB},
+		)
+	}
+
+	meta := [][2]string{
+		{"w", strconv.Itoa(p.W)},
+		{"n", strconv.Itoa(p.N)},
+		{"self_join", strconv.FormatBool(p.SelfJoin)},
+	}
+	return writeArrowStream(w, cols, meta)
+}
+
+// ReadArrow reads a matrix profile written by WriteArrow from r. Only the
+// profiles, indexes and window metadata are restored.
+func ReadArrow(r io.Reader) (*mp.MatrixProfile, error) {
+	cols, meta, err := readArrowStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &mp.MatrixProfile{}
+	if p.W, err = strconv.Atoi(meta["w"]); err != nil {
+		return nil, fmt.Errorf("invalid window metadata, %v", err)
+	}
+	if p.N, err = strconv.Atoi(meta["n"]); err != nil {
+		return nil, fmt.Errorf("invalid length metadata, %v", err)
+	}
+	if p.SelfJoin, err = strconv.ParseBool(meta["self_join"]); err != nil {
+		return nil, fmt.Errorf("invalid self join metadata, %v", err)
+	}
+
+	for _, c := range cols {
+		switch c.name {
+		case "mp":
+			p.MP = c.f64
+		case "pi":
+			p.Idx = c.i64
+		case "mp_ba":
+			p.MPB = c.f64
+		case "pi_ba":
+			p.IdxB = c.i64
+		}
+	}
+	if p.MP == nil || p.Idx == nil {
+		return nil, fmt.Errorf("arrow stream is missing the mp or pi column")
+	}
+
+	return p, nil
+}
+
+// WriteKMPArrow writes the k-dimensional matrix profile and matrix profile index
+// to w as an Arrow IPC stream containing a single record batch with a float64
+// "mp_<d>" and int64 "pi_<d>" column for each dimension d. The window and the
+// number of dimensions are stored as schema metadata under "w" and "dims".
+func WriteKMPArrow(w io.Writer, k *mp.KMP) error {
+	cols := make([]column, 0, 2*len(k.MP))
+	for d := range k.MP {
+		cols = append(cols, column{name: "mp_" + strconv.Itoa(d), f64: k.MP[d]})
+	}
+	for d := range k.Idx {
+		cols = append(cols, column{name: "pi_" + strconv.Itoa(d), i64: k.Idx[d]})
+	}
+
+	meta := [][2]string{
+		{"w", strconv.Itoa(k.W)},
+		{"dims", strconv.Itoa(len(k.MP))},
+	}
+	return writeArrowStream(w, cols, meta)
+}
+
+// ReadKMPArrow reads a k-dimensional matrix profile written by WriteKMPArrow
+// from r. Only the profiles, indexes and window are restored.
+func ReadKMPArrow(r io.Reader) (*mp.KMP, error) {
+	cols, meta, err := readArrowStream(r)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &mp.KMP{}
+	if k.W, err = strconv.Atoi(meta["w"]); err != nil {
+		return nil, fmt.Errorf("invalid window metadata, %v", err)
+	}
+	dims, err := strconv.Atoi(meta["dims"])
+	if err != nil {
+		return nil, fmt.Errorf("invalid dimension metadata, %v", err)
+	}
+
+	byName := make(map[string]column, len(cols))
+	for _, c := range cols {
+		byName[c.name] = c
+	}
+
+	k.MP = make([][]float64, dims)
+	k.Idx = make([][]int, dims)
+	for d := 0; d < dims; d++ {
+		mpCol, ok1 := byName["mp_"+strconv.Itoa(d)]
+		idxCol, ok2 := byName["pi_"+strconv.Itoa(d)]
+		if !ok1 || !ok2 || mpCol.f64 == nil || idxCol.i64 == nil {
+			return nil, fmt.Errorf("arrow stream is missing columns for dimension %d", d)
+		}
+		k.MP[d] = mpCol.f64
+		k.Idx[d] = idxCol.i64
+	}
+
+	return k, nil
+}
+
+// writeArrowStream writes a schema message, a single record batch and the end
+// of stream marker.
+func writeArrowStream(w io.Writer, cols []column, meta [][2]string) error {
+	fields := make(fbTables, len(cols))
+	for i, c := range cols {
+		var typ fbTable
+		var typeID uint64
+		if c.f64 != nil {
+			typeID = arrowTypeFloat
+			typ = fbTable{fbScalar(0, 2, arrowPrecisionFloat)}
+		} else {
+			typeID = arrowTypeInt
+			typ = fbTable{fbScalar(0, 4, 64), fbScalar(1, 1, 1)}
+		}
+		fields[i] = fbTable{
+			fbRef(0, fbString(c.name)),
+			fbScalar(1, 1, 1),
+			fbScalar(2, 1, typeID),
+			fbRef(3, typ),
+			fbRef(5, fbTables{}),
+		}
+	}
+
+	kvs := make(fbTables, len(meta))
+	for i, kv := range meta {
+		kvs[i] = fbTable{fbRef(0, fbString(kv[0])), fbRef(1, fbString(kv[1]))}
+	}
+
+	schema := fbTable{fbRef(1, fields), fbRef(2, kvs)}
+	if err := writeArrowMessage(w, arrowHeaderSchema, schema, nil); err != nil {
+		return err
+	}
+
+	var length int
+	for _, c := range cols {
+		if c.len() > length {
+			length = c.len()
+		}
+	}
+
+	var body []byte
+	nodes := fbStructs{n: len(cols)}
+	buffers := fbStructs{n: 2 * len(cols)}
+	for _, c := range cols {
+		nulls := length - c.len()
+		nodes.fields = append(nodes.fields, int64(length), int64(nulls))
+
+		// validity bitmap which can be omitted if there are no nulls
+		start := len(body)
+		if nulls > 0 {
+			bitmap := make([]byte, (length+7)/8)
+			for i := 0; i < c.len(); i++ {
+				bitmap[i/8] |= 1 << uint(i%8)
+			}
+			body = append(body, bitmap...)
+			body = padTo8(body)
+		}
+		buffers.fields = append(buffers.fields, int64(start), int64(len(body)-start))
+
+		start = len(body)
+		data := make([]byte, 8*length)
+		for i := 0; i < c.len(); i++ {
+			if c.f64 != nil {
+				binary.LittleEndian.PutUint64(data[8*i:], math.Float64bits(c.f64[i]))
+			} else {
+				binary.LittleEndian.PutUint64(data[8*i:], uint64(c.i64[i]))
+			}
+		}
+		body = append(body, data...)
+		buffers.fields = append(buffers.fields, int64(start), int64(len(body)-start))
+	}
+
+	batch := fbTable{
+		fbScalar(0, 8, uint64(length)),
+		fbRef(1, nodes),
+		fbRef(2, buffers),
+	}
+	if err := writeArrowMessage(w, arrowHeaderRecord, batch, body); err != nil {
+		return err
+	}
+
+	eos := make([]byte, 8)
+	binary.LittleEndian.PutUint32(eos, arrowContinuation)
+	_, err := w.Write(eos)
+	return err
+}
+
+// writeArrowMessage writes an encapsulated IPC message made of the continuation
+// marker, metadata length, padded flatbuffer metadata and the message body.
+func writeArrowMessage(w io.Writer, headerType uint64, header fbTable, body []byte) error {
+	msg := fbTable{
+		fbScalar(0, 2, arrowMetadataV5),
+		fbScalar(1, 1, headerType),
+		fbRef(2, header),
+		fbScalar(3, 8, uint64(len(body))),
+	}
+	meta := padTo8(finishFlatbuffer(msg))
+
+	prefix := make([]byte, 8)
+	binary.LittleEndian.PutUint32(prefix, arrowContinuation)
+	binary.LittleEndian.PutUint32(prefix[4:], uint32(len(meta)))
+	for _, b := range [][]byte{prefix, meta, body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func padTo8(b []byte) []byte {
+	for len(b)%8 != 0 {
+		b = append(b, 0)
+	}
+	return b
+}
+
+// readArrowStream reads an IPC stream with a schema and a single record batch of
+// non-null float64 and int64 columns. Trailing nulls are trimmed from each column.
+func readArrowStream(r io.Reader) (cols []column, meta map[string]string, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			cols, meta, err = nil, nil, fmt.Errorf("invalid arrow stream, %v", errMalformed)
+		}
+	}()
+
+	schema, _, err := readArrowMessage(r, arrowHeaderSchema)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	meta = make(map[string]string)
+	start, n := schema.vector(2)
+	for i := 0; i < n; i++ {
+		kv := schema.tableAt(start, i)
+		meta[kv.str(0)] = kv.str(1)
+	}
+
+	start, n = schema.vector(1)
+	cols = make([]column, n)
+	isFloat := make([]bool, n)
+	for i := 0; i < n; i++ {
+		f := schema.tableAt(start, i)
+		cols[i].name = f.str(0)
+		typ, _ := f.table(3)
+		switch f.uint(2, 1, 0) {
+		case arrowTypeFloat:
+			if typ.uint(0, 2, 0) != arrowPrecisionFloat {
+				return nil, nil, fmt.Errorf("column %s is not a float64", cols[i].name)
+			}
+			isFloat[i] = true
+		case arrowTypeInt:
+			if typ.uint(0, 4, 0) != 64 {
+				return nil, nil, fmt.Errorf("column %s is not an int64", cols[i].name)
+			}
+		default:
+			return nil, nil, fmt.Errorf("column %s has an unsupported type", cols[i].name)
+		}
+	}
+
+	batch, body, err := readArrowMessage(r, arrowHeaderRecord)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	length := int(batch.uint(0, 8, 0))
+	nodeStart, numNodes := batch.vector(1)
+	bufStart, numBufs := batch.vector(2)
+	if numNodes != len(cols) || numBufs != 2*len(cols) {
+		return nil, nil, fmt.Errorf("record batch does not match the schema")
+	}
+
+	for i := range cols {
+		nulls := int(batch.int64At(nodeStart + 16*i + 8))
+		validOff, validLen := int(batch.int64At(bufStart+32*i)), int(batch.int64At(bufStart+32*i+8))
+		dataOff := int(batch.int64At(bufStart + 32*i + 16))
+
+		// only trailing nulls are supported so count the leading valid values
+		valid := length
+		if nulls > 0 && validLen > 0 {
+			valid = 0
+			for valid < length && body[validOff+valid/8]&(1<<uint(valid%8)) != 0 {
+				valid++
+			}
+			if length-valid != nulls {
+				return nil, nil, fmt.Errorf("column %s has nulls that are not trailing", cols[i].name)
+			}
+		}
+
+		data := body[dataOff : dataOff+8*length]
+		if isFloat[i] {
+			cols[i].f64 = make([]float64, valid)
+			for j := range cols[i].f64 {
+				cols[i].f64[j] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*j:]))
+			}
+		} else {
+			cols[i].i64 = make([]int, valid)
+			for j := range cols[i].i64 {
+				cols[i].i64[j] = int(int64(binary.LittleEndian.Uint64(data[8*j:])))
+			}
+		}
+	}
+
+	return cols, meta, nil
+}
+
+// readArrowMessage reads the next encapsulated IPC message and checks that its
+// header has the expected type. Returns the header table and the message body.
+func readArrowMessage(r io.Reader, headerType uint64) (fbReader, []byte, error) {
+	prefix := make([]byte, 8)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return fbReader{}, nil, err
+	}
+	size := binary.LittleEndian.Uint32(prefix[4:])
+	if binary.LittleEndian.Uint32(prefix) != arrowContinuation || size == 0 {
+		return fbReader{}, nil, fmt.Errorf("unexpected end of arrow stream")
+	}
+
+	meta := make([]byte, size)
+	if _, err := io.ReadFull(r, meta); err != nil {
+		return fbReader{}, nil, err
+	}
+
+	msg := rootTable(meta)
+	if got := msg.uint(1, 1, 0); got != headerType {
+		return fbReader{}, nil, fmt.Errorf("expected arrow message type %d, but got %d", headerType, got)
+	}
+	header, ok := msg.table(2)
+	if !ok {
+		return fbReader{}, nil, fmt.Errorf("arrow message is missing a header")
+	}
+
+	body := make([]byte, msg.uint(3, 8, 0))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return fbReader{}, nil, err
+	}
+	return header, body, nil
+}
@@ -0,0 +1,138 @@
+package mpio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+func TestArrowRoundTrip(t *testing.T) {
+	testdata := []*mp.MatrixProfile{
+		{W: 4, N: 8, SelfJoin: true, MP: []float64{0.5, 1, math.Inf(1), 2, 0.25}, Idx: []int{3, 4, math.MaxInt64, 0, 1}},
+		{W: 2, N: 5, MP: []float64{1, 2, 3, 4}, Idx: []int{0, 1, 2, 3}, MPB: []float64{5, 6}, IdxB: []int{1, 0}},
+		{W: 3, N: 10, MP: []float64{1}, Idx: []int{0}, MPB: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}, IdxB: []int{0, 0, 0, 0, 0, 0, 0, 0, 0}},
+	}
+
+	for _, p := range testdata {
+		var buf bytes.Buffer
+		if err := WriteArrow(&buf, p); err != nil {
+			t.Fatalf("Did not expect an error writing arrow, %v", err)
+		}
+		if buf.Len()%8 != 0 {
+			t.Errorf("Expected an 8 byte aligned stream, but got %d bytes", buf.Len())
+		}
+
+		out, err := ReadArrow(&buf)
+		if err != nil {
+			t.Fatalf("Did not expect an error reading arrow, %v", err)
+		}
+		if out.W != p.W || out.N != p.N || out.SelfJoin != p.SelfJoin {
+			t.Errorf("Expected metadata %d, %d, %v, but got %d, %d, %v", p.W, p.N, p.SelfJoin, out.W, out.N, out.SelfJoin)
+		}
+		if !equalFloats(out.MP, p.MP) || !equalInts(out.Idx, p.Idx) {
+			t.Errorf("Expected %v, %v, but got %v, %v", p.MP, p.Idx, out.MP, out.Idx)
+		}
+		if !equalFloats(out.MPB, p.MPB) || !equalInts(out.IdxB, p.IdxB) {
+			t.Errorf("Expected %v, %v, but got %v, %v", p.MPB, p.IdxB, out.MPB, out.IdxB)
+		}
+	}
+}
+
+func TestKMPArrowRoundTrip(t *testing.T) {
+	k := &mp.KMP{
+		W:   3,
+		MP:  [][]float64{{1, 2, 3}, {0.5, 0.25, 0.125}},
+		Idx: [][]int{{2, 0, 1}, {1, 2, 0}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteKMPArrow(&buf, k); err != nil {
+		t.Fatalf("Did not expect an error writing arrow, %v", err)
+	}
+
+	out, err := ReadKMPArrow(&buf)
+	if err != nil {
+		t.Fatalf("Did not expect an error reading arrow, %v", err)
+	}
+	if out.W != k.W || len(out.MP) != len(k.MP) {
+		t.Fatalf("Expected window %d and %d dimensions, but got %d and %d", k.W, len(k.MP), out.W, len(out.MP))
+	}
+	for d := range k.MP {
+		if !equalFloats(out.MP[d], k.MP[d]) || !equalInts(out.Idx[d], k.Idx[d]) {
+			t.Errorf("Expected %v, %v, but got %v, %v for dimension %d", k.MP[d], k.Idx[d], out.MP[d], out.Idx[d], d)
+		}
+	}
+}
+
+func TestArrowStreamLayout(t *testing.T) {
+	var buf bytes.Buffer
+	p := &mp.MatrixProfile{W: 2, N: 3, SelfJoin: true, MP: []float64{1, 2}, Idx: []int{1, 0}}
+	if err := WriteArrow(&buf, p); err != nil {
+		t.Fatalf("Did not expect an error writing arrow, %v", err)
+	}
+
+	b := buf.Bytes()
+	if binary.LittleEndian.Uint32(b) != arrowContinuation {
+		t.Errorf("Expected stream to start with a continuation marker")
+	}
+	eos := b[len(b)-8:]
+	if binary.LittleEndian.Uint32(eos) != arrowContinuation || binary.LittleEndian.Uint32(eos[4:]) != 0 {
+		t.Errorf("Expected stream to end with an end of stream marker, but got %v", eos)
+	}
+}
+
+func TestReadArrowInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	p := &mp.MatrixProfile{W: 2, N: 3, SelfJoin: true, MP: []float64{1, 2}, Idx: []int{1, 0}}
+	if err := WriteArrow(&buf, p); err != nil {
+		t.Fatalf("Did not expect an error writing arrow, %v", err)
+	}
+	b := buf.Bytes()
+
+	testdata := [][]byte{
+		nil,
+		b[:4],
+		b[:40],
+		append([]byte{1, 2, 3, 4}, b[4:]...),
+	}
+
+	for _, d := range testdata {
+		if _, err := ReadArrow(bytes.NewReader(d)); err == nil {
+			t.Errorf("Expected an error reading %d bytes, but got none", len(d))
+		}
+	}
+
+	// corrupt the root offset of the schema message
+	corrupt := append([]byte{}, b...)
+	binary.LittleEndian.PutUint32(corrupt[8:], 1<<30)
+	if _, err := ReadArrow(bytes.NewReader(corrupt)); err == nil {
+		t.Errorf("Expected an error reading a corrupt schema, but got none")
+	}
+}
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package mpio
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// fbObject is a flatbuffer object that can be appended to a buffer. Objects are
+// written front to back so every child is placed after the offset referencing
+// it, which keeps all unsigned offsets pointing forward as the format requires.
+type fbObject interface {
+	writeTo(b *fbBuilder) int
+}
+
+// fbBuilder accumulates an encoded flatbuffer
+type fbBuilder struct {
+	buf []byte
+}
+
+// finish encodes root as the root table of a new flatbuffer
+func finishFlatbuffer(root fbObject) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	pos := root.writeTo(b)
+	binary.LittleEndian.PutUint32(b.buf, uint32(pos))
+	return b.buf
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) grow(n int) int {
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, n)...)
+	return pos
+}
+
+// patch stores the forward offset from pos to target at pos
+func (b *fbBuilder) patch(pos, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+// fbField is a single field of a table stored in the given vtable slot. Either
+// a scalar of the given byte size or a reference to a child object is stored.
+type fbField struct {
+	slot  int
+	size  int
+	val   uint64
+	child fbObject
+}
+
+func fbScalar(slot, size int, val uint64) fbField {
+	return fbField{slot: slot, size: size, val: val}
+}
+
+func fbRef(slot int, child fbObject) fbField {
+	return fbField{slot: slot, size: 4, child: child}
+}
+
+// fbTable is a flatbuffer table made of fields
+type fbTable []fbField
+
+func (t fbTable) writeTo(b *fbBuilder) int {
+	numSlots := 0
+	inline := make([]int, len(t))
+	off := 4 // leading soffset to the vtable
+	for i, f := range t {
+		if f.slot+1 > numSlots {
+			numSlots = f.slot + 1
+		}
+		for off%f.size != 0 {
+			off++
+		}
+		inline[i] = off
+		off += f.size
+	}
+
+	b.align(2)
+	vt := b.grow(4 + 2*numSlots)
+	binary.LittleEndian.PutUint16(b.buf[vt:], uint16(4+2*numSlots))
+	binary.LittleEndian.PutUint16(b.buf[vt+2:], uint16(off))
+	for i, f := range t {
+		binary.LittleEndian.PutUint16(b.buf[vt+4+2*f.slot:], uint16(inline[i]))
+	}
+
+	b.align(8)
+	tp := b.grow(off)
+	binary.LittleEndian.PutUint32(b.buf[tp:], uint32(int32(tp-vt)))
+	for i, f := range t {
+		p := tp + inline[i]
+		switch f.size {
+		case 1:
+			b.buf[p] = byte(f.val)
+		case 2:
+			binary.LittleEndian.PutUint16(b.buf[p:], uint16(f.val))
+		case 4:
+			binary.LittleEndian.PutUint32(b.buf[p:], uint32(f.val))
+		case 8:
+			binary.LittleEndian.PutUint64(b.buf[p:], f.val)
+		}
+	}
+
+	for i, f := range t {
+		if f.child != nil {
+			b.patch(tp+inline[i], f.child.writeTo(b))
+		}
+	}
+	return tp
+}
+
+// fbTables is a vector of tables
+type fbTables []fbObject
+
+func (v fbTables) writeTo(b *fbBuilder) int {
+	b.align(4)
+	pos := b.grow(4 + 4*len(v))
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(v)))
+	for i, elem := range v {
+		b.patch(pos+4+4*i, elem.writeTo(b))
+	}
+	return pos
+}
+
+// fbStructs is a vector of structs made of 8 byte little endian integers
+type fbStructs struct {
+	n      int     // number of structs
+	fields []int64 // all struct fields flattened
+}
+
+func (v fbStructs) writeTo(b *fbBuilder) int {
+	b.align(8)
+	b.grow(4)
+	pos := b.grow(4 + 8*len(v.fields))
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(v.n))
+	for i, f := range v.fields {
+		binary.LittleEndian.PutUint64(b.buf[pos+4+8*i:], uint64(f))
+	}
+	return pos
+}
+
+// fbString is a null terminated string
+type fbString string
+
+func (s fbString) writeTo(b *fbBuilder) int {
+	b.align(4)
+	pos := b.grow(4 + len(s) + 1)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(len(s)))
+	copy(b.buf[pos+4:], s)
+	return pos
+}
+
+var errMalformed = errors.New("malformed flatbuffer")
+
+// fbReader reads tables from an encoded flatbuffer. Out of range reads panic
+// and are expected to be recovered by the caller.
+type fbReader struct {
+	buf []byte
+	pos int
+}
+
+func rootTable(buf []byte) fbReader {
+	return fbReader{buf, int(binary.LittleEndian.Uint32(buf))}
+}
+
+// field returns the absolute position of the field in slot or 0 if absent
+func (t fbReader) field(slot int) int {
+	vt := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	vtSize := int(binary.LittleEndian.Uint16(t.buf[vt:]))
+	if 4+2*slot >= vtSize {
+		return 0
+	}
+	off := int(binary.LittleEndian.Uint16(t.buf[vt+4+2*slot:]))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t fbReader) uint(slot, size int, def uint64) uint64 {
+	p := t.field(slot)
+	if p == 0 {
+		return def
+	}
+	switch size {
+	case 1:
+		return uint64(t.buf[p])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(t.buf[p:]))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(t.buf[p:]))
+	default:
+		return binary.LittleEndian.Uint64(t.buf[p:])
+	}
+}
+
+// deref follows the offset stored in slot returning 0 if the field is absent
+func (t fbReader) deref(slot int) int {
+	p := t.field(slot)
+	if p == 0 {
+		return 0
+	}
+	return p + int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+func (t fbReader) table(slot int) (fbReader, bool) {
+	p := t.deref(slot)
+	return fbReader{t.buf, p}, p != 0
+}
+
+func (t fbReader) str(slot int) string {
+	p := t.deref(slot)
+	if p == 0 {
+		return ""
+	}
+	n := int(binary.LittleEndian.Uint32(t.buf[p:]))
+	return string(t.buf[p+4 : p+4+n])
+}
+
+// vector returns the position of the first element of the vector in slot and
+// its length
+func (t fbReader) vector(slot int) (int, int) {
+	p := t.deref(slot)
+	if p == 0 {
+		return 0, 0
+	}
+	return p + 4, int(binary.LittleEndian.Uint32(t.buf[p:]))
+}
+
+// tableAt returns the i-th table of a vector of tables starting at start
+func (t fbReader) tableAt(start, i int) fbReader {
+	p := start + 4*i
+	return fbReader{t.buf, p + int(binary.LittleEndian.Uint32(t.buf[p:]))}
+}
+
+func (t fbReader) int64At(p int) int64 {
+	return int64(binary.LittleEndian.Uint64(t.buf[p:]))
+}
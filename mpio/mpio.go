@@ -0,0 +1,237 @@
+// Package mpio loads time series from and exports matrix profile results to common file formats.
+package mpio
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+// CSVOpts are parameters to vary how a time series is read from a csv.
+type CSVOpts struct {
+	Comma       rune     // field delimiter
+	Header      bool     // indicates whether the first record is a header
+	Columns     []int    // zero based indexes of the columns to load as time series
+	ColumnNames []string // names of the columns to load which requires a header. Takes precedence over Columns
+	TimeColumn  int      // zero based index of the timestamp column. Set to -1 if there are no timestamps
+	TimeLayout  string   // layout used to parse timestamps, or "unix" and "unixms" for epoch seconds and milliseconds
+}
+
+// NewCSVOpts returns a default CSVOpts which loads the first column of a
+// comma delimited csv with no header or timestamps.
+func NewCSVOpts() *CSVOpts {
+	return &CSVOpts{
+		Comma:      ',',
+		Columns:    []int{0},
+		TimeColumn: -1,
+		TimeLayout: time.RFC3339,
+	}
+}
+
+// LoadCSV reads one or more time series and optional timestamps from a csv file.
+func LoadCSV(filepath string, o *CSVOpts) ([][]float64, []time.Time, error) {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	return ReadCSV(f, o)
+}
+
+// ReadCSV reads one or more time series and optional timestamps from r. Each
+// selected column is returned as its own time series in the order requested.
+// Timestamps are nil if no time column is set.
+func ReadCSV(r io.Reader, o *CSVOpts) ([][]float64, []time.Time, error) {
+	if o == nil {
+		o = NewCSVOpts()
+	}
+
+	cr := csv.NewReader(r)
+	if o.Comma != 0 {
+		cr.Comma = o.Comma
+	}
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cols := o.Columns
+	if o.Header {
+		if len(records) == 0 {
+			return nil, nil, fmt.Errorf("csv is missing a header")
+		}
+		header := records[0]
+		records = records[1:]
+
+		if len(o.ColumnNames) > 0 {
+			cols = make([]int, len(o.ColumnNames))
+			for i, name := range o.ColumnNames {
+				cols[i] = -1
+				for j, h := range header {
+					if strings.TrimSpace(h) == name {
+						cols[i] = j
+						break
+					}
+				}
+				if cols[i] < 0 {
+					return nil, nil, fmt.Errorf("column %s not found in header", name)
+				}
+			}
+		}
+	} else if len(o.ColumnNames) > 0 {
+		return nil, nil, fmt.Errorf("column names can only be used with a header")
+	}
+
+	if len(cols) == 0 {
+		return nil, nil, fmt.Errorf("no columns selected to load")
+	}
+
+	out := make([][]float64, len(cols))
+	for i := range out {
+		out[i] = make([]float64, len(records))
+	}
+
+	var ts []time.Time
+	if o.TimeColumn >= 0 {
+		ts = make([]time.Time, len(records))
+	}
+
+	for row, rec := range records {
+		for i, c := range cols {
+			if c < 0 || c >= len(rec) {
+				return nil, nil, fmt.Errorf("column %d is out of range on row %d", c, row)
+			}
+			out[i][row], err = strconv.ParseFloat(strings.TrimSpace(rec[c]), 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid value on row %d, column %d, %v", row, c, err)
+			}
+		}
+
+		if ts == nil {
+			continue
+		}
+		if o.TimeColumn >= len(rec) {
+			return nil, nil, fmt.Errorf("time column %d is out of range on row %d", o.TimeColumn, row)
+		}
+		ts[row], err = parseTime(strings.TrimSpace(rec[o.TimeColumn]), o.TimeLayout)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid timestamp on row %d, %v", row, err)
+		}
+	}
+
+	return out, ts, nil
+}
+
+// parseTime parses a timestamp using a time layout or epoch seconds or milliseconds
+func parseTime(val, layout string) (time.Time, error) {
+	switch layout {
+	case "unix", "unixms":
+		f, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if layout == "unixms" {
+			f /= 1000
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC(), nil
+	default:
+		return time.Parse(layout, val)
+	}
+}
+
+// WriteProfileCSV writes the matrix profile and matrix profile index to w with a
+// header. The BA join profile and index are included for AB joins.
+func WriteProfileCSV(w io.Writer, p *mp.MatrixProfile) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"index", "mp", "pi"}
+	ab := len(p.MPB) > 0
+	if ab {
+		header = append(header, "mp_ba", "pi_ba")
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	n := len(p.MP)
+	if len(p.MPB) > n {
+		n = len(p.MPB)
+	}
+
+	rec := make([]string, len(header))
+	for i := 0; i < n; i++ {
+		rec[0] = strconv.Itoa(i)
+		rec[1], rec[2] = profileFields(p.MP, p.Idx, i)
+		if ab {
+			rec[3], rec[4] = profileFields(p.MPB, p.IdxB, i)
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// profileFields formats the i-th element of a profile and index, leaving the
+// fields empty if i is beyond either slice
+func profileFields(prof []float64, idx []int, i int) (string, string) {
+	if i >= len(prof) || i >= len(idx) {
+		return "", ""
+	}
+	return strconv.FormatFloat(prof[i], 'g', -1, 64), strconv.Itoa(idx[i])
+}
+
+// WriteMotifsCSV writes one row per motif member to w with the motif group
+// number, starting index of the member, and the minimum distance of the group.
+func WriteMotifsCSV(w io.Writer, motifs []mp.MotifGroup) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"motif", "index", "min_dist"}); err != nil {
+		return err
+	}
+
+	for i, m := range motifs {
+		for _, idx := range m.Idx {
+			rec := []string{strconv.Itoa(i), strconv.Itoa(idx), strconv.FormatFloat(m.MinDist, 'g', -1, 64)}
+			if err := cw.Write(rec); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteDiscordsCSV writes one row per discord to w with its rank, starting
+// index and matrix profile value.
+func WriteDiscordsCSV(w io.Writer, discords []int, p *mp.MatrixProfile) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"rank", "index", "dist"}); err != nil {
+		return err
+	}
+
+	for i, idx := range discords {
+		if idx < 0 || idx >= len(p.MP) {
+			return fmt.Errorf("discord index %d is out of range of the matrix profile", idx)
+		}
+		rec := []string{strconv.Itoa(i), strconv.Itoa(idx), strconv.FormatFloat(p.MP[idx], 'g', -1, 64)}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
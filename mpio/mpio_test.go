@@ -0,0 +1,105 @@
+package mpio
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+func TestReadCSV(t *testing.T) {
+	data := "time,a,b\n2019-01-01T00:00:00Z,1,10\n2019-01-01T00:00:01Z,2,20\n2019-01-01T00:00:02Z,3,30\n"
+
+	testdata := []struct {
+		o           *CSVOpts
+		expected    [][]float64
+		expectedTS  bool
+		expectedErr bool
+	}{
+		{&CSVOpts{Header: true, Columns: []int{1}, TimeColumn: -1}, [][]float64{{1, 2, 3}}, false, false},
+		{&CSVOpts{Header: true, ColumnNames: []string{"b", "a"}, TimeColumn: 0, TimeLayout: time.RFC3339}, [][]float64{{10, 20, 30}, {1, 2, 3}}, true, false},
+		{&CSVOpts{Header: true, ColumnNames: []string{"c"}, TimeColumn: -1}, nil, false, true},
+		{&CSVOpts{Header: false, Columns: []int{1}, TimeColumn: -1}, nil, false, true},
+		{&CSVOpts{Header: true, Columns: []int{5}, TimeColumn: -1}, nil, false, true},
+	}
+
+	for _, d := range testdata {
+		out, ts, err := ReadCSV(strings.NewReader(data), d.o)
+		if d.expectedErr {
+			if err == nil {
+				t.Errorf("Expected an error, but got none for %+v", d.o)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %+v", err, d.o)
+			continue
+		}
+		if len(out) != len(d.expected) {
+			t.Errorf("Expected %d columns, but got %d", len(d.expected), len(out))
+			continue
+		}
+		for i := range out {
+			for j := range out[i] {
+				if out[i][j] != d.expected[i][j] {
+					t.Errorf("Expected %v, but got %v", d.expected, out)
+				}
+			}
+		}
+		if d.expectedTS && (len(ts) != 3 || ts[1].Sub(ts[0]) != time.Second) {
+			t.Errorf("Expected timestamps one second apart, but got %v", ts)
+		}
+		if !d.expectedTS && ts != nil {
+			t.Errorf("Expected no timestamps, but got %v", ts)
+		}
+	}
+
+	_, ts, err := ReadCSV(strings.NewReader("1500000000500;1\n1500000001500;2\n"), &CSVOpts{Comma: ';', Columns: []int{1}, TimeLayout: "unixms"})
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if ts[1].Sub(ts[0]) != time.Second {
+		t.Errorf("Expected timestamps one second apart, but got %v", ts)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	p, err := mp.New([]float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteProfileCSV(&buf, p); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(p.MP)+1 || lines[0] != "index,mp,pi" {
+		t.Errorf("Expected a header and %d rows, but got %v", len(p.MP), lines)
+	}
+
+	buf.Reset()
+	motifs := []mp.MotifGroup{{Idx: []int{0, 4}, MinDist: 0.5}}
+	if err = WriteMotifsCSV(&buf, motifs); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if buf.String() != "motif,index,min_dist\n0,0,0.5\n0,4,0.5\n" {
+		t.Errorf("Unexpected motif output, %q", buf.String())
+	}
+
+	buf.Reset()
+	if err = WriteDiscordsCSV(&buf, []int{2}, p); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "rank,index,dist\n0,2,") {
+		t.Errorf("Unexpected discord output, %q", buf.String())
+	}
+	if err = WriteDiscordsCSV(&buf, []int{100}, p); err == nil {
+		t.Errorf("Expected an error for an out of range discord")
+	}
+}
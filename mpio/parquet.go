@@ -0,0 +1,271 @@
+package mpio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+// Parquet constants from the Apache Parquet format specification
+const (
+	parquetMagic        = "PAR1"
+	parquetVersion      = 1
+	parquetTypeInt64    = 2
+	parquetTypeDouble   = 5
+	parquetOptional     = 1
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetUncompressed = 0
+	parquetDataPage     = 0
+	parquetCreatedBy    = "go-matrixprofile"
+)
+
+// column is a single column of an Arrow record batch or Parquet row group.
+// Exactly one of f64 and i64 is set. Columns shorter than the record batch or
+// row group are padded with nulls.
+type column struct {
+	name string
+	f64  []float64
+	i64  []int
+}
+
+func (c column) len() int {
+	if c.f64 != nil {
+		return len(c.f64)
+	}
+	return len(c.i64)
+}
+
+// WriteProfileParquet writes the matrix profile and matrix profile index to w
+// as an uncompressed Parquet file with the same columns as WriteProfileCSV.
+// The "index" and "pi" columns are int64 while "mp" is a double. The BA join
+// columns are null beyond their length if they are shorter than the AB join.
+// The window, time series length, and whether a self join was performed are
+// stored as key value metadata under "w", "n", and "self_join".
+func WriteProfileParquet(w io.Writer, p *mp.MatrixProfile) error {
+	n := len(p.MP)
+	if len(p.MPB) > n {
+		n = len(p.MPB)
+	}
+	index := make([]int, n)
+	for i := range index {
+		index[i] = i
+	}
+
+	cols := []column{
+		{name: "index", i64: index},
+		{name: "mp", f64: nonNilFloats(p.MP)},
+		{name: "pi", i64: nonNilInts(p.Idx)},
+	}
+	if len(p.MPB) > 0 {
+		cols = append(cols,
+			column{name: "mp_ba", f64: p.MPB},
+			column{name: "pi_ba", i64: nonNilInts(p.IdxB)},
+		)
+	}
+
+	meta := [][2]string{
+		{"w", strconv.Itoa(p.W)},
+		{"n", strconv.Itoa(p.N)},
+		{"self_join", strconv.FormatBool(p.SelfJoin)},
+	}
+	return writeParquetFile(w, cols, meta)
+}
+
+// WriteMotifsParquet writes one row per motif member to w as an uncompressed
+// Parquet file with the same columns as WriteMotifsCSV.
+func WriteMotifsParquet(w io.Writer, motifs []mp.MotifGroup) error {
+	motif, index, minDist := []int{}, []int{}, []float64{}
+	for i, m := range motifs {
+		for _, idx := range m.Idx {
+			motif = append(motif, i)
+			index = append(index, idx)
+			minDist = append(minDist, m.MinDist)
+		}
+	}
+
+	cols := []column{
+		{name: "motif", i64: motif},
+		{name: "index", i64: index},
+		{name: "min_dist", f64: minDist},
+	}
+	return writeParquetFile(w, cols, nil)
+}
+
+// WriteDiscordsParquet writes one row per discord to w as an uncompressed
+// Parquet file with the same columns as WriteDiscordsCSV.
+func WriteDiscordsParquet(w io.Writer, discords []int, p *mp.MatrixProfile) error {
+	rank, index, dist := []int{}, []int{}, []float64{}
+	for i, idx := range discords {
+		if idx < 0 || idx >= len(p.MP) {
+			return fmt.Errorf("discord index %d is out of range of the matrix profile", idx)
+		}
+		rank = append(rank, i)
+		index = append(index, idx)
+		dist = append(dist, p.MP[idx])
+	}
+
+	cols := []column{
+		{name: "rank", i64: rank},
+		{name: "index", i64: index},
+		{name: "dist", f64: dist},
+	}
+	return writeParquetFile(w, cols, nil)
+}
+
+// nonNilFloats returns an empty slice in place of nil so that the column type
+// is known
+func nonNilFloats(a []float64) []float64 {
+	if a == nil {
+		return []float64{}
+	}
+	return a
+}
+
+// nonNilInts returns an empty slice in place of nil so that the column type is
+// known
+func nonNilInts(a []int) []int {
+	if a == nil {
+		return []int{}
+	}
+	return a
+}
+
+// writeParquetFile writes the columns as a single row group of optional
+// columns, each stored in one plain encoded data page, followed by the file
+// metadata. The file is built in memory since the metadata records the offset
+// of every page.
+func writeParquetFile(w io.Writer, cols []column, meta [][2]string) error {
+	var rows int
+	for _, c := range cols {
+		if c.len() > rows {
+			rows = c.len()
+		}
+	}
+
+	schema := thriftList{elem: thriftTypeStruct}
+	schema.items = append(schema.items, thriftStruct{
+		{4, thriftString("schema")},
+		{5, thriftI32(len(cols))},
+	})
+
+	buf := []byte(parquetMagic)
+	chunks := thriftList{elem: thriftTypeStruct}
+	for _, c := range cols {
+		typ := parquetTypeInt64
+		if c.f64 != nil {
+			typ = parquetTypeDouble
+		}
+		schema.items = append(schema.items, thriftStruct{
+			{1, thriftI32(typ)},
+			{3, thriftI32(parquetOptional)},
+			{4, thriftString(c.name)},
+		})
+		if rows == 0 {
+			continue
+		}
+
+		data := parquetPageData(c, rows)
+		header := thriftStruct{
+			{1, thriftI32(parquetDataPage)},
+			{2, thriftI32(len(data))},
+			{3, thriftI32(len(data))},
+			{5, thriftStruct{
+				{1, thriftI32(rows)},
+				{2, thriftI32(parquetPlain)},
+				{3, thriftI32(parquetRLE)},
+				{4, thriftI32(parquetRLE)},
+			}},
+		}.appendTo(nil)
+
+		offset := len(buf)
+		buf = append(buf, header...)
+		buf = append(buf, data...)
+		size := len(header) + len(data)
+
+		chunks.items = append(chunks.items, thriftStruct{
+			{2, thriftI64(offset)},
+			{3, thriftStruct{
+				{1, thriftI32(typ)},
+				{2, thriftList{elem: thriftTypeI32, items: []thriftValue{thriftI32(parquetPlain), thriftI32(parquetRLE)}}},
+				{3, thriftList{elem: thriftTypeBinary, items: []thriftValue{thriftString(c.name)}}},
+				{4, thriftI32(parquetUncompressed)},
+				{5, thriftI64(rows)},
+				{6, thriftI64(size)},
+				{7, thriftI64(size)},
+				{9, thriftI64(offset)},
+			}},
+		})
+	}
+
+	// an empty file has no row groups rather than a row group without pages
+	groups := thriftList{elem: thriftTypeStruct}
+	if rows > 0 {
+		groups.items = append(groups.items, thriftStruct{
+			{1, chunks},
+			{2, thriftI64(len(buf) - len(parquetMagic))},
+			{3, thriftI64(rows)},
+		})
+	}
+
+	kvs := thriftList{elem: thriftTypeStruct}
+	for _, kv := range meta {
+		kvs.items = append(kvs.items, thriftStruct{
+			{1, thriftString(kv[0])},
+			{2, thriftString(kv[1])},
+		})
+	}
+
+	fileMeta := thriftStruct{
+		{1, thriftI32(parquetVersion)},
+		{2, schema},
+		{3, thriftI64(rows)},
+		{4, groups},
+	}
+	if len(meta) > 0 {
+		fileMeta = append(fileMeta, thriftField{5, kvs})
+	}
+	fileMeta = append(fileMeta, thriftField{6, thriftString(parquetCreatedBy)})
+
+	footer := fileMeta.appendTo(nil)
+	buf = append(buf, footer...)
+	buf = append(buf, make([]byte, 4)...)
+	binary.LittleEndian.PutUint32(buf[len(buf)-4:], uint32(len(footer)))
+	buf = append(buf, parquetMagic...)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// parquetPageData encodes the definition levels and plain values of a data
+// page for an optional column of rows values. The values of the column are
+// present and the rows past its length are null, so the levels are a run of
+// ones followed by a run of zeros in the RLE hybrid encoding.
+func parquetPageData(c column, rows int) []byte {
+	var levels []byte
+	for _, run := range []struct{ n, level int }{{c.len(), 1}, {rows - c.len(), 0}} {
+		if run.n > 0 {
+			levels = appendUvarint(levels, uint64(run.n)<<1)
+			levels = append(levels, byte(run.level))
+		}
+	}
+
+	data := make([]byte, 4, 4+len(levels)+8*c.len())
+	binary.LittleEndian.PutUint32(data, uint32(len(levels)))
+	data = append(data, levels...)
+
+	val := make([]byte, 8)
+	for i := 0; i < c.len(); i++ {
+		if c.f64 != nil {
+			binary.LittleEndian.PutUint64(val, math.Float64bits(c.f64[i]))
+		} else {
+			binary.LittleEndian.PutUint64(val, uint64(c.i64[i]))
+		}
+		data = append(data, val...)
+	}
+	return data
+}
@@ -0,0 +1,221 @@
+package mpio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"testing"
+
+	mp "github.com/matrix-profile-foundation/go-matrixprofile"
+)
+
+// thriftDecoder decodes the thrift compact protocol into maps of field id to
+// value so that the written Parquet metadata can be checked
+type thriftDecoder struct {
+	buf []byte
+	pos int
+}
+
+func (d *thriftDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf[d.pos:])
+	d.pos += n
+	return v
+}
+
+func (d *thriftDecoder) value(typ byte) interface{} {
+	switch typ {
+	case thriftTypeI32, thriftTypeI64:
+		v := d.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftTypeBinary:
+		n := int(d.uvarint())
+		d.pos += n
+		return string(d.buf[d.pos-n : d.pos])
+	case thriftTypeList:
+		h := d.buf[d.pos]
+		d.pos++
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(d.uvarint())
+		}
+		out := make([]interface{}, n)
+		for i := range out {
+			out[i] = d.value(h & 0xF)
+		}
+		return out
+	case thriftTypeStruct:
+		out := make(map[int]interface{})
+		last := 0
+		for {
+			h := d.buf[d.pos]
+			d.pos++
+			if h == 0 {
+				return out
+			}
+			id := last + int(h>>4)
+			if h>>4 == 0 {
+				v := d.uvarint()
+				id = int(int64(v>>1) ^ -int64(v&1))
+			}
+			out[id] = d.value(h & 0xF)
+			last = id
+		}
+	}
+	panic("unexpected thrift type")
+}
+
+// readParquetColumns reads the columns of a Parquet file written by
+// writeParquetFile, with nil in place of nulls, along with its metadata
+func readParquetColumns(t *testing.T, buf []byte) (map[string][]interface{}, map[int]interface{}) {
+	if string(buf[:4]) != parquetMagic || string(buf[len(buf)-4:]) != parquetMagic {
+		t.Fatalf("Expected the file to start and end with %s", parquetMagic)
+	}
+	n := int(binary.LittleEndian.Uint32(buf[len(buf)-8:]))
+	d := &thriftDecoder{buf: buf, pos: len(buf) - 8 - n}
+	meta := d.value(thriftTypeStruct).(map[int]interface{})
+	if d.pos != len(buf)-8 {
+		t.Fatalf("Expected the file metadata to end at %d, but got %d", len(buf)-8, d.pos)
+	}
+
+	rows := int(meta[3].(int64))
+	schema := meta[2].([]interface{})
+	out := make(map[string][]interface{})
+	for _, rg := range meta[4].([]interface{}) {
+		for i, cc := range rg.(map[int]interface{})[1].([]interface{}) {
+			se := schema[i+1].(map[int]interface{})
+			md := cc.(map[int]interface{})[3].(map[int]interface{})
+			d = &thriftDecoder{buf: buf, pos: int(md[9].(int64))}
+			header := d.value(thriftTypeStruct).(map[int]interface{})
+			page := header[5].(map[int]interface{})
+			if int(page[1].(int64)) != rows {
+				t.Fatalf("Expected %d values in the page of %s, but got %v", rows, se[4], page[1])
+			}
+
+			// definition levels are a length followed by RLE runs of 1 bit values
+			levelsLen := int(binary.LittleEndian.Uint32(buf[d.pos:]))
+			d.pos += 4
+			end := d.pos + levelsLen
+			var levels []byte
+			for d.pos < end {
+				run := int(d.uvarint() >> 1)
+				for k := 0; k < run; k++ {
+					levels = append(levels, d.buf[d.pos])
+				}
+				d.pos++
+			}
+
+			var vals []interface{}
+			for _, l := range levels {
+				if l == 0 {
+					vals = append(vals, nil)
+					continue
+				}
+				bits := binary.LittleEndian.Uint64(buf[d.pos:])
+				d.pos += 8
+				if se[1].(int64) == parquetTypeDouble {
+					vals = append(vals, math.Float64frombits(bits))
+				} else {
+					vals = append(vals, int64(bits))
+				}
+			}
+			out[se[4].(string)] = vals
+		}
+	}
+	return out, meta
+}
+
+func TestWriteProfileParquet(t *testing.T) {
+	p := &mp.MatrixProfile{W: 2, N: 5, MP: []float64{1, 2, math.Inf(1)}, Idx: []int{1, 0, math.MaxInt64}, MPB: []float64{5}, IdxB: []int{2}}
+
+	var buf bytes.Buffer
+	if err := WriteProfileParquet(&buf, p); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	cols, meta := readParquetColumns(t, buf.Bytes())
+
+	expected := map[string][]interface{}{
+		"index": {int64(0), int64(1), int64(2)},
+		"mp":    {1.0, 2.0, math.Inf(1)},
+		"pi":    {int64(1), int64(0), int64(math.MaxInt64)},
+		"mp_ba": {5.0, nil, nil},
+		"pi_ba": {int64(2), nil, nil},
+	}
+	if !reflect.DeepEqual(cols, expected) {
+		t.Errorf("Expected columns %v, but got %v", expected, cols)
+	}
+	if meta[3].(int64) != 3 {
+		t.Errorf("Expected 3 rows, but got %v", meta[3])
+	}
+
+	kvs := make(map[string]string)
+	for _, kv := range meta[5].([]interface{}) {
+		kv := kv.(map[int]interface{})
+		kvs[kv[1].(string)] = kv[2].(string)
+	}
+	if !reflect.DeepEqual(kvs, map[string]string{"w": "2", "n": "5", "self_join": "false"}) {
+		t.Errorf("Unexpected key value metadata, %v", kvs)
+	}
+}
+
+func TestWriteMotifsDiscordsParquet(t *testing.T) {
+	var buf bytes.Buffer
+	motifs := []mp.MotifGroup{{Idx: []int{0, 4}, MinDist: 0.5}, {Idx: []int{7}, MinDist: 0.75}}
+	if err := WriteMotifsParquet(&buf, motifs); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	cols, _ := readParquetColumns(t, buf.Bytes())
+	expected := map[string][]interface{}{
+		"motif":    {int64(0), int64(0), int64(1)},
+		"index":    {int64(0), int64(4), int64(7)},
+		"min_dist": {0.5, 0.5, 0.75},
+	}
+	if !reflect.DeepEqual(cols, expected) {
+		t.Errorf("Expected columns %v, but got %v", expected, cols)
+	}
+
+	p := &mp.MatrixProfile{MP: []float64{1, 3, 2}}
+	buf.Reset()
+	if err := WriteDiscordsParquet(&buf, []int{1, 2}, p); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	cols, _ = readParquetColumns(t, buf.Bytes())
+	expected = map[string][]interface{}{
+		"rank":  {int64(0), int64(1)},
+		"index": {int64(1), int64(2)},
+		"dist":  {3.0, 2.0},
+	}
+	if !reflect.DeepEqual(cols, expected) {
+		t.Errorf("Expected columns %v, but got %v", expected, cols)
+	}
+
+	// an empty file keeps the schema without any row groups
+	buf.Reset()
+	if err := WriteDiscordsParquet(&buf, nil, p); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	cols, meta := readParquetColumns(t, buf.Bytes())
+	if len(cols) != 0 || meta[3].(int64) != 0 || len(meta[2].([]interface{})) != 4 {
+		t.Errorf("Expected an empty file with 3 columns, but got %v", meta)
+	}
+
+	if err := WriteDiscordsParquet(&buf, []int{3}, p); err == nil {
+		t.Errorf("Expected an error for an out of range discord")
+	}
+}
+
+func TestThriftList(t *testing.T) {
+	// lists of 15 or more items store their size after the header
+	l := thriftList{elem: thriftTypeI64}
+	for i := 0; i < 20; i++ {
+		l.items = append(l.items, thriftI64(i-10))
+	}
+	d := &thriftDecoder{buf: l.appendTo(nil)}
+	out := d.value(thriftTypeList).([]interface{})
+	if len(out) != 20 || out[0].(int64) != -10 || out[19].(int64) != 9 {
+		t.Errorf("Unexpected list, %v", out)
+	}
+	if d.pos != len(d.buf) {
+		t.Errorf("Expected to decode %d bytes, but decoded %d", len(d.buf), d.pos)
+	}
+}
@@ -0,0 +1,102 @@
+package mpio
+
+// Thrift compact protocol type identifiers used by the Parquet metadata
+const (
+	thriftTypeI32    = 5
+	thriftTypeI64    = 6
+	thriftTypeBinary = 8
+	thriftTypeList   = 9
+	thriftTypeStruct = 12
+)
+
+// thriftValue is a value that can be appended to a buffer using the thrift
+// compact protocol
+type thriftValue interface {
+	thriftType() byte
+	appendTo(buf []byte) []byte
+}
+
+type thriftI32 int32
+
+func (thriftI32) thriftType() byte { return thriftTypeI32 }
+
+func (v thriftI32) appendTo(buf []byte) []byte {
+	return appendUvarint(buf, zigzag(int64(v)))
+}
+
+type thriftI64 int64
+
+func (thriftI64) thriftType() byte { return thriftTypeI64 }
+
+func (v thriftI64) appendTo(buf []byte) []byte {
+	return appendUvarint(buf, zigzag(int64(v)))
+}
+
+type thriftString string
+
+func (thriftString) thriftType() byte { return thriftTypeBinary }
+
+func (s thriftString) appendTo(buf []byte) []byte {
+	buf = appendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+// thriftList is a list of values which must all have the thrift type elem
+type thriftList struct {
+	elem  byte
+	items []thriftValue
+}
+
+func (thriftList) thriftType() byte { return thriftTypeList }
+
+func (l thriftList) appendTo(buf []byte) []byte {
+	if len(l.items) < 15 {
+		buf = append(buf, byte(len(l.items))<<4|l.elem)
+	} else {
+		buf = append(buf, 0xF0|l.elem)
+		buf = appendUvarint(buf, uint64(len(l.items)))
+	}
+	for _, v := range l.items {
+		buf = v.appendTo(buf)
+	}
+	return buf
+}
+
+// thriftField is a single field of a struct with its field id
+type thriftField struct {
+	id  int
+	val thriftValue
+}
+
+// thriftStruct is a thrift struct made of fields in ascending order of id
+type thriftStruct []thriftField
+
+func (thriftStruct) thriftType() byte { return thriftTypeStruct }
+
+func (s thriftStruct) appendTo(buf []byte) []byte {
+	last := 0
+	for _, f := range s {
+		// field ids are stored as a delta from the previous field when small
+		if delta := f.id - last; delta > 0 && delta <= 15 {
+			buf = append(buf, byte(delta)<<4|f.val.thriftType())
+		} else {
+			buf = append(buf, f.val.thriftType())
+			buf = appendUvarint(buf, zigzag(int64(f.id)))
+		}
+		buf = f.val.appendTo(buf)
+		last = f.id
+	}
+	return append(buf, 0)
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	for v >= 0x80 {
+		buf = append(buf, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(buf, byte(v))
+}
@@ -0,0 +1,169 @@
+package matrixprofile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/preprocess"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+)
+
+// minMultiscaleWindow is the smallest subsequence length of the downsampled
+// time series used by ComputeMultiscale
+const minMultiscaleWindow = 4
+
+// ComputeMultiscale approximates the self join matrix profile of a long time
+// series. The time series is first downsampled by factor with a piecewise
+// aggregate approximation and its matrix profile is computed with the provided
+// options and a subsequence length of W/factor. Every entry is then estimated
+// from the downsampled profile, scaled by sqrt(factor), with its nearest
+// neighbor mapped back to the original resolution. The refinePct fraction of
+// downsampled subsequences with the lowest and highest distances, the likely
+// motifs and discords, are refined by computing exact distances at full
+// resolution against the neighborhood of the downsampled nearest neighbor.
+// The matrix profile and index are stored in the original index space and
+// distances are always euclidean.
+func (mp *MatrixProfile) ComputeMultiscale(o *MPOpts, factor int, refinePct float64) error {
+	if !mp.SelfJoin {
+		return newError(ErrNotSelfJoin, "multiscale computation is only supported for a self join")
+	}
+	if factor < 1 {
+		return fmt.Errorf("downsampling factor must be at least 1, got %d", factor)
+	}
+	if mp.W/factor < minMultiscaleWindow {
+		return fmt.Errorf("downsampled subsequence length, %d, must be at least %d", mp.W/factor, minMultiscaleWindow)
+	}
+	if refinePct < 0 || refinePct > 1 {
+		return fmt.Errorf("refine percentage must be between 0 and 1, got %.3f", refinePct)
+	}
+
+	var opts MPOpts
+	if o == nil {
+		opts = *NewMPOpts()
+	} else {
+		opts = *o
+	}
+	if opts.Mask != nil || opts.Boundaries != nil || opts.LeftRight {
+		return errors.New("exclusion masks, session boundaries and left and right matrix profiles are not supported by multiscale computation")
+	}
+	if opts.Normalization == NormMean || opts.Weights != nil {
+		return errors.New("multiscale computation only supports unweighted z-normalized distances")
+	}
+	opts.Euclidean = true
+	if err := mp.setOpts(&opts); err != nil {
+		return err
+	}
+
+	ds, err := preprocess.PAA(mp.A, factor)
+	if err != nil {
+		return err
+	}
+	coarse, err := New(ds.Values, nil, mp.W/factor)
+	if err != nil {
+		return err
+	}
+	co := *mp.Opts
+	co.ReuseOutput = false
+	co.ExclusionZone = (mp.exclusionZone() + factor - 1) / factor
+	if err = coarse.Compute(&co); err != nil {
+		return err
+	}
+
+	mp.AMean, mp.AStd, err = util.MovMeanStd(mp.A, mp.W)
+	if err != nil {
+		return err
+	}
+
+	n := len(mp.A) - mp.W + 1
+	mp.MP, mp.Idx = initProfile(mp.MP, mp.Idx, n, mp.Opts.ReuseOutput)
+
+	// coarseOf returns the downsampled subsequence covering the subsequence at i
+	coarseOf := func(i int) int {
+		if i/factor < len(coarse.MP) {
+			return i / factor
+		}
+		return len(coarse.MP) - 1
+	}
+
+	scale := math.Sqrt(float64(factor))
+	for i := range mp.MP {
+		ci := coarseOf(i)
+		d := coarse.MP[ci]
+		if math.IsInf(d, 0) || math.IsNaN(d) || coarse.Idx[ci] >= len(coarse.MP) {
+			continue
+		}
+		mp.MP[i] = d * scale
+		mp.Idx[i] = clampIdx(ds.Index(coarse.Idx[ci])+i%factor, n)
+	}
+
+	for _, ci := range coarse.refineCandidates(refinePct) {
+		for i := ci * factor; i < (ci+1)*factor && i < n; i++ {
+			mp.refineMultiscale(i, ds.Index(coarse.Idx[coarseOf(i)]), factor)
+		}
+	}
+
+	return nil
+}
+
+// refineCandidates returns the pct fraction of subsequences with a defined
+// distance, split between the lowest and highest distances
+func (mp MatrixProfile) refineCandidates(pct float64) []int {
+	var order []int
+	for i, d := range mp.MP {
+		if !math.IsInf(d, 0) && !math.IsNaN(d) && mp.Idx[i] < len(mp.MP) {
+			order = append(order, i)
+		}
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return mp.MP[order[i]] < mp.MP[order[j]]
+	})
+
+	k := int(math.Ceil(pct * float64(len(order))))
+	if k >= len(order) {
+		return order
+	}
+	return append(order[:k/2:k/2], order[len(order)-(k-k/2):]...)
+}
+
+// refineMultiscale sets the matrix profile at i to the exact distance to its
+// nearest neighbor among the subsequences within factor points of the
+// estimated neighbor at nn, excluding trivial matches
+func (mp *MatrixProfile) refineMultiscale(i, nn, factor int) {
+	if !(mp.AStd[i] > minSubseqStd) {
+		return
+	}
+
+	w := float64(mp.W)
+	zone := mp.exclusionZone()
+	best, bestIdx := math.Inf(1), math.MaxInt64
+	for j := nn - factor; j < nn+2*factor; j++ {
+		if j < 0 || j >= len(mp.MP) || (j > i-zone && j < i+zone) || !(mp.AStd[j] > minSubseqStd) {
+			continue
+		}
+		var dot float64
+		for k := 0; k < mp.W; k++ {
+			dot += mp.A[i+k] * mp.A[j+k]
+		}
+		corr := (dot - w*mp.AMean[i]*mp.AMean[j]) / (w * mp.AStd[i] * mp.AStd[j])
+		if d := math.Sqrt(math.Max(0, 2*w*(1-corr))); d < best {
+			best, bestIdx = d, j
+		}
+	}
+
+	if bestIdx != math.MaxInt64 {
+		mp.MP[i], mp.Idx[i] = best, bestIdx
+	}
+}
+
+// clampIdx limits an index to the range [0, n)
+func clampIdx(idx, n int) int {
+	if idx < 0 {
+		return 0
+	}
+	if idx >= n {
+		return n - 1
+	}
+	return idx
+}
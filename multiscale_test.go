@@ -0,0 +1,99 @@
+package matrixprofile
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestComputeMultiscale(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	ts := make([]float64, 2000)
+	for i := range ts {
+		ts[i] = math.Sin(2*math.Pi*float64(i)/50) + 0.05*r.NormFloat64()
+	}
+	// plant a discord that breaks the periodic pattern
+	for i := 1200; i < 1240; i++ {
+		ts[i] = 2 * math.Sin(2*math.Pi*float64(i)/13)
+	}
+
+	testdata := []struct {
+		factor    int
+		refinePct float64
+		w         int
+		selfJoin  bool
+		opts      *MPOpts
+		expectErr bool
+	}{
+		{4, 0.2, 40, true, nil, false},
+		{8, 0, 40, true, nil, false},
+		{0, 0.2, 40, true, nil, true},
+		{20, 0.2, 40, true, nil, true},
+		{4, 1.5, 40, true, nil, true},
+		{4, 0.2, 40, false, nil, true},
+		{4, 0.2, 40, true, &MPOpts{LeftRight: true}, true},
+	}
+
+	for _, d := range testdata {
+		var b []float64
+		if !d.selfJoin {
+			b = ts
+		}
+		mp, err := New(ts, b, d.w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = mp.ComputeMultiscale(d.opts, d.factor, d.refinePct)
+		if d.expectErr {
+			if err == nil {
+				t.Errorf("Expected an error, but got none for %+v", d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error, but got %v for %+v", err, d)
+			continue
+		}
+
+		if len(mp.MP) != len(ts)-d.w+1 || len(mp.Idx) != len(mp.MP) {
+			t.Errorf("Expected profile of length %d, but got %d and %d", len(ts)-d.w+1, len(mp.MP), len(mp.Idx))
+			continue
+		}
+		discords, err := mp.DiscoverDiscords(1, d.w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if discords[0] < 1200-d.w || discords[0] > 1240 {
+			t.Errorf("Expected the discord near 1200, but got %d for %+v", discords[0], d)
+		}
+	}
+}
+
+func TestComputeMultiscaleExact(t *testing.T) {
+	ts := []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0, 0.5, -0.3, 0.2, 0.1}
+	w := 4
+
+	exact, err := New(ts, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = exact.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// without downsampling and refining every subsequence the result is exact
+	mp, err := New(ts, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.ComputeMultiscale(nil, 1, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range exact.MP {
+		if math.Abs(mp.MP[i]-exact.MP[i]) > 1e-7 {
+			t.Errorf("Expected %v, but got %v", exact.MP, mp.MP)
+			break
+		}
+	}
+}
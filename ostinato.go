@@ -0,0 +1,88 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+)
+
+// ConsensusMotif is the subsequence that is conserved across a set of time
+// series along with the location of its nearest neighbor in every series.
+type ConsensusMotif struct {
+	Series    int     `json:"series"`    // index of the time series containing the consensus motif
+	Idx       int     `json:"idx"`       // starting index of the consensus motif in its time series
+	Radius    float64 `json:"radius"`    // largest distance from the consensus motif to its nearest neighbor in any other series
+	Neighbors []int   `json:"neighbors"` // starting index of the nearest neighbor in each series. The consensus motif itself for its own series
+}
+
+// Ostinato finds the consensus motif of a set of time series. The consensus
+// motif is the subsequence of length w in one of the time series that
+// minimizes the radius, the largest distance to its nearest neighbor in each
+// of the other time series. The AB joins between the time series are computed
+// with the provided options, always using euclidean distances. This is based
+// on the UCR paper, Matrix Profile XV: Exploiting Time Series Consensus Motifs
+// to Find Structure in Time Series Sets, by Kamgar et al.
+func Ostinato(ts [][]float64, w int, o *MPOpts) (*ConsensusMotif, error) {
+	if len(ts) < 2 {
+		return nil, fmt.Errorf("need at least 2 time series to find a consensus motif, got %d", len(ts))
+	}
+
+	var opts MPOpts
+	if o == nil {
+		opts = *NewMPOpts()
+	} else {
+		opts = *o
+	}
+	opts.Euclidean = true
+	opts.ReuseOutput = false
+
+	best := &ConsensusMotif{Radius: math.Inf(1)}
+	for i := range ts {
+		var radii []float64
+		nnIdx := make([][]int, len(ts))
+		for l := range ts {
+			if l == i {
+				continue
+			}
+			mp, err := New(ts[i], ts[l], w)
+			if err != nil {
+				return nil, fmt.Errorf("failed to join series %d with %d, %v", i, l, err)
+			}
+			if err = mp.Compute(&opts); err != nil {
+				return nil, fmt.Errorf("failed to join series %d with %d, %v", i, l, err)
+			}
+
+			if len(radii) == 0 {
+				radii = make([]float64, len(mp.MP))
+			}
+			for j, d := range mp.MP {
+				// constant subsequences have no defined distance
+				if math.IsNaN(d) {
+					d = math.Inf(1)
+				}
+				if d > radii[j] {
+					radii[j] = d
+				}
+			}
+			nnIdx[l] = mp.Idx
+		}
+
+		for j, r := range radii {
+			if r < best.Radius {
+				best.Series, best.Idx, best.Radius = i, j, r
+				best.Neighbors = make([]int, len(ts))
+				for l := range ts {
+					if l == i {
+						best.Neighbors[l] = j
+					} else {
+						best.Neighbors[l] = nnIdx[l][j]
+					}
+				}
+			}
+		}
+	}
+
+	if best.Neighbors == nil {
+		return nil, fmt.Errorf("no consensus motif of length %d found", w)
+	}
+	return best, nil
+}
@@ -0,0 +1,49 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOstinato(t *testing.T) {
+	pattern := []float64{0, 1, 3, 6, 3, 1, 0, -1, -2, -1}
+	offsets := []int{15, 60, 32}
+	ts := make([][]float64, len(offsets))
+	for s, off := range offsets {
+		ts[s] = make([]float64, 100)
+		for i := range ts[s] {
+			ts[s][i] = math.Sin(float64(i*(s+2))*0.37) * math.Cos(float64(i)*0.13*float64(s+1))
+		}
+		copy(ts[s][off:], pattern)
+	}
+
+	out, err := Ostinato(ts, len(pattern), nil)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if out.Radius > 1e-6 {
+		t.Errorf("Expected a radius of 0, but got %v", out.Radius)
+	}
+	if out.Idx != offsets[out.Series] {
+		t.Errorf("Expected consensus motif at %d in series %d, but got %d", offsets[out.Series], out.Series, out.Idx)
+	}
+	for s, idx := range out.Neighbors {
+		if idx != offsets[s] {
+			t.Errorf("Expected neighbor at %d in series %d, but got %d", offsets[s], s, idx)
+		}
+	}
+
+	testdata := []struct {
+		ts [][]float64
+		w  int
+	}{
+		{nil, 4},
+		{ts[:1], 4},
+		{ts, 200},
+	}
+	for _, d := range testdata {
+		if _, err = Ostinato(d.ts, d.w, nil); err == nil {
+			t.Errorf("Expected an error for %d series with a window of %d", len(d.ts), d.w)
+		}
+	}
+}
@@ -4,35 +4,40 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
-	"os"
+	"time"
 
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
 	"github.com/matrix-profile-foundation/go-matrixprofile/util"
 )
 
 // PMP represents the pan matrix profile
 type PMP struct {
-	A        []float64   `json:"a"`         // query time series
-	B        []float64   `json:"b"`         // timeseries to perform full join with
-	SelfJoin bool        `json:"self_join"` // indicates whether a self join is performed with an exclusion zone
-	PMP      [][]float64 `json:"pmp"`       // pan matrix profile
-	PIdx     [][]int     `json:"ppi"`       // pan matrix profile index
-	PWindows []int       `json:"windows"`   // pan matrix windows used and is aligned with PMP and PIdx
-	Opts     *PMPOpts    `json:"options"`   // options used for the computation
+	A        []float64   `json:"a"`                 // query time series
+	B        []float64   `json:"b"`                 // timeseries to perform full join with
+	SelfJoin bool        `json:"self_join"`         // indicates whether a self join is performed with an exclusion zone
+	PMP      [][]float64 `json:"pmp"`               // pan matrix profile
+	PIdx     [][]int     `json:"ppi"`               // pan matrix profile index
+	PWindows []int       `json:"windows"`           // pan matrix windows used and is aligned with PMP and PIdx
+	AV       av.AV       `json:"annotation_vector"` // type of annotation vector which defaults to all ones
+	Opts     *PMPOpts    `json:"options"`           // options used for the computation
+	Times    []time.Time `json:"times,omitempty"`   // timestamp of each point in a if created from a time series
 }
 
-// NewPMP creates a new Pan matrix profile
-func NewPMP(a, b []float64) (*PMP, error) {
+// NewPMP creates a new Pan matrix profile over the windows from lowerM to
+// upperM. If b is nil, then a self join on a is performed.
+func NewPMP(a, b []float64, lowerM, upperM int) (*PMP, error) {
 	if a == nil || len(a) == 0 {
-		return nil, fmt.Errorf("first slice is nil or has a length of 0")
+		return nil, newError(ErrEmptySeries, "first slice is nil or has a length of 0")
 	}
 
 	if b != nil && len(b) == 0 {
-		return nil, fmt.Errorf("second slice must be nil for self-join operation or have a length greater than 0")
+		return nil, newError(ErrEmptySeries, "second slice must be nil for self-join operation or have a length greater than 0")
 	}
 
-	p := PMP{A: a}
+	p := PMP{A: a, AV: av.Default, Opts: NewPMPOpts(lowerM, upperM)}
 	if b == nil {
 		p.B = a
 		p.SelfJoin = true
@@ -43,56 +48,84 @@ func NewPMP(a, b []float64) (*PMP, error) {
 	return &p, nil
 }
 
-// Save will save the current matrix profile struct to disk
+// Save will save the current matrix profile struct to disk. Any existing file
+// at filepath is truncated.
 func (p PMP) Save(filepath, format string) error {
-	var err error
+	return saveFile(filepath, false, func(w io.Writer) error {
+		return p.Encode(w, format)
+	})
+}
+
+// SaveAtomic will save the current matrix profile struct to disk by first writing
+// to a temporary file in the same directory and then renaming it over filepath.
+// Readers will either see the previous contents or the new contents in full.
+func (p PMP) SaveAtomic(filepath, format string) error {
+	return saveFile(filepath, true, func(w io.Writer) error {
+		return p.Encode(w, format)
+	})
+}
+
+// Encode writes the current pan matrix profile struct to w in the specified format
+func (p PMP) Encode(w io.Writer, format string) error {
 	switch format {
 	case "json":
-		f, err := os.Open(filepath)
-		if err != nil {
-			f, err = os.Create(filepath)
-			if err != nil {
-				return err
-			}
-		}
-		defer f.Close()
 		out, err := json.Marshal(p)
 		if err != nil {
 			return err
 		}
-		_, err = f.Write(out)
+		_, err = w.Write(out)
+		return err
 	default:
-		return fmt.Errorf("invalid save format, %s", format)
+		return newError(ErrInvalidFormat, "invalid save format, %s", format)
 	}
-	return err
 }
 
 // Load will attempt to load a matrix profile from a file for iterative use
 func (p *PMP) Load(filepath, format string) error {
-	var err error
+	return loadFile(filepath, func(r io.Reader) error {
+		return p.Decode(r, format)
+	})
+}
+
+// Decode reads a pan matrix profile in the specified format from r into the struct
+func (p *PMP) Decode(r io.Reader, format string) error {
 	switch format {
 	case "json":
-		f, err := os.Open(filepath)
+		b, err := ioutil.ReadAll(r)
 		if err != nil {
 			return err
 		}
-		defer f.Close()
-		b, err := ioutil.ReadAll(f)
-		if err != nil {
-			return err
-		}
-		err = json.Unmarshal(b, p)
+		return json.Unmarshal(b, p)
 	default:
-		return fmt.Errorf("invalid load format, %s", format)
+		return newError(ErrInvalidFormat, "invalid load format, %s", format)
 	}
-	return err
 }
 
 // PMPOpts are parameters to vary the algorithm to compute the pan matrix profile.
 type PMPOpts struct {
-	LowerM int     `json:"lower_m"` // used for pan matrix profile
-	UpperM int     `json:"upper_m"` // used for pan matrix profile
-	MPOpts *MPOpts `json:"mp_options"`
+	LowerM int     `json:"lower_m"`    // used for pan matrix profile
+	UpperM int     `json:"upper_m"`    // used for pan matrix profile
+	MPOpts *MPOpts `json:"mp_options"` // options for each matrix profile set by Compute. SamplePct also samples the windows and Seed makes sampling reproducible
+
+	// MinImprovement stops the computation early once adding a window changes the
+	// pan matrix profile by less than this amount. Defaults to 0 which computes
+	// all windows.
+	MinImprovement float64 `json:"min_improvement"`
+
+	// Progress is called after each window is computed. Returning false stops
+	// the computation early.
+	Progress func(PMPProgress) bool `json:"-"`
+}
+
+// PMPProgress reports the state of a pan matrix profile computation after a
+// window has been computed. Windows are computed in binary split order so that
+// the range of windows is covered evenly as early as possible.
+type PMPProgress struct {
+	Window      int     // window that was just computed
+	Computed    int     // number of windows computed so far
+	Total       int     // number of windows that will be computed if not stopped early
+	Coverage    float64 // fraction of windows from LowerM to UpperM computed so far
+	Improvement float64 // mean absolute change in the normalized profile of the window compared to the estimate from the nearest computed window. +Inf for the first window
 }
 
 // NewPMPOpts returns a default PMPOpts
@@ -107,12 +140,42 @@ func NewPMPOpts(l, u int) *PMPOpts {
 	}
 }
 
-// Compute calculate the pan matrixprofile given a set of input options.
+// Compute calculate the pan matrixprofile given a set of input options. If o is
+// nil, the options created by NewPMP with the window range are used.
 func (p *PMP) Compute(o *PMPOpts) error {
-	if o == nil {
+	if o != nil {
+		p.Opts = o
+	}
+	if p.Opts == nil {
 		return errors.New("Must provide PMP compute options")
 	}
-	p.Opts = o
+	if p.Opts.MPOpts == nil {
+		p.Opts.MPOpts = NewMPOpts()
+	}
+	return p.pmp()
+}
+
+// pmpProfile adapts a pan matrix profile to the Profile interface, whose
+// Compute takes the options used for the matrix profile of each window
+type pmpProfile struct {
+	*PMP
+}
+
+// AsProfile returns the pan matrix profile as a Profile. Its Compute sets the
+// matrix profile options of each window and keeps the window range and early
+// stopping options of p.
+func (p *PMP) AsProfile() Profile {
+	return pmpProfile{p}
+}
+
+func (p pmpProfile) Compute(o *MPOpts) error {
+	if p.Opts == nil {
+		return errors.New("Must provide PMP options with the window range")
+	}
+	if o == nil {
+		o = NewMPOpts()
+	}
+	p.Opts.MPOpts = o
 	return p.pmp()
 }
 
@@ -124,9 +187,11 @@ func (p *PMP) pmp() error {
 	}
 	p.PWindows = windows
 
-	p.PMP = make([][]float64, len(windows))
-	p.PIdx = make([][]int, len(windows))
-	for i := 0; i < len(windows); i++ {
+	// rows are indexed by window size so windows that are not sampled are left unset
+	numRows := p.Opts.UpperM - p.Opts.LowerM + 1
+	p.PMP = make([][]float64, numRows)
+	p.PIdx = make([][]int, numRows)
+	for i := 0; i < numRows; i++ {
 		lenA := len(p.A) - (i + p.Opts.LowerM) + 1
 		p.PMP[i] = make([]float64, lenA)
 		p.PIdx[i] = make([]int, lenA)
@@ -148,31 +213,181 @@ func (p *PMP) pmp() error {
 		return err
 	}
 
-	for _, w := range windows {
+	for i, w := range windows {
 		mp.W = w
 		if err := mp.Compute(p.Opts.MPOpts); err != nil {
 			return err
 		}
 		copy(p.PMP[w-p.Opts.LowerM], mp.MP)
 		copy(p.PIdx[w-p.Opts.LowerM], mp.Idx)
+
+		prog := PMPProgress{
+			Window:      w,
+			Computed:    i + 1,
+			Total:       len(windows),
+			Coverage:    float64(i+1) / float64(numRows),
+			Improvement: p.improvement(w, windows[:i]),
+		}
+
+		stop := prog.Improvement < p.Opts.MinImprovement
+		if p.Opts.Progress != nil && !p.Opts.Progress(prog) {
+			stop = true
+		}
+		if stop {
+			p.PWindows = windows[:i+1]
+			break
+		}
+	}
+
+	return nil
+}
+
+// improvement computes the mean absolute difference between the normalized
+// profile of window w and the normalized profile of the nearest previously
+// computed window, which is the best estimate of w before it was computed.
+// Distances are normalized by the maximum z-normalized euclidean distance
+// and correlations are mapped to the same range of 0 to 1.
+func (p PMP) improvement(w int, computed []int) float64 {
+	if len(computed) == 0 {
+		return math.Inf(1)
+	}
+
+	nearest := computed[0]
+	for _, c := range computed[1:] {
+		if math.Abs(float64(c-w)) < math.Abs(float64(nearest-w)) {
+			nearest = c
+		}
+	}
+
+	norm := func(v float64, w int) float64 {
+		if p.Opts.MPOpts.Euclidean {
+			return v / (2 * math.Sqrt(float64(w)))
+		}
+		return (1 - v) / 2
+	}
+
+	cur := p.PMP[w-p.Opts.LowerM]
+	est := p.PMP[nearest-p.Opts.LowerM]
+	n := len(cur)
+	if len(est) < n {
+		n = len(est)
+	}
+
+	var sum float64
+	var count int
+	for i := 0; i < n; i++ {
+		if math.IsInf(cur[i], 0) || math.IsInf(est[i], 0) || math.IsNaN(cur[i]) || math.IsNaN(est[i]) {
+			continue
+		}
+		sum += math.Abs(norm(cur[i], w) - norm(est[i], nearest))
+		count++
+	}
+	if count == 0 {
+		return math.Inf(1)
+	}
+	return sum / float64(count)
+}
+
+// ApplyAV applies the annotation vector to the profile of each computed window
+// and returns the corrected pan matrix profile. Rows of windows that were not
+// computed are returned unchanged. Annotation vector values must be between 0
+// and 1.
+func (p PMP) ApplyAV() ([][]float64, error) {
+	if p.PMP == nil || p.Opts == nil {
+		return nil, newError(ErrNotComputed, "pan matrix profile has not been computed")
+	}
+	euclidean := p.Opts.MPOpts == nil || p.Opts.MPOpts.Euclidean
+
+	out := make([][]float64, len(p.PMP))
+	for i := range p.PMP {
+		out[i] = make([]float64, len(p.PMP[i]))
+		copy(out[i], p.PMP[i])
+	}
+
+	var err error
+	for _, w := range p.PWindows {
+		row := w - p.Opts.LowerM
+		if !euclidean {
+			util.P2E(out[row], w)
+		}
+		out[row], err = applySingleAV(out[row], p.A, w, p.AV)
+		if err != nil {
+			return nil, fmt.Errorf("window %d, %v", w, err)
+		}
+		if !euclidean {
+			util.E2P(out[row], w)
+		}
+	}
+
+	return out, nil
+}
+
+// Update appends new values to the time series and updates the pan matrix
+// profile in place providing streaming like behavior. The profile of each
+// computed window is updated with the matrix profile Update while windows that
+// were not computed are extended with unset values. Only self joins using
+// euclidean distances are supported.
+func (p *PMP) Update(newValues []float64) error {
+	if p.PMP == nil || p.Opts == nil {
+		return newError(ErrNotComputed, "pan matrix profile has not been computed")
+	}
+	if !p.SelfJoin {
+		return newError(ErrNotSelfJoin, "can only update the pan matrix profile of a self join")
+	}
+	if p.Opts.MPOpts != nil && !p.Opts.MPOpts.Euclidean {
+		return errors.New("can only update a pan matrix profile of euclidean distances")
+	}
+
+	computed := make(map[int]bool, len(p.PWindows))
+	for _, w := range p.PWindows {
+		row := w - p.Opts.LowerM
+		computed[row] = true
+
+		// cap the time series so each window appends to its own copy
+		a := p.A[:len(p.A):len(p.A)]
+		mp := &MatrixProfile{
+			A:        a,
+			B:        a,
+			N:        len(a),
+			W:        w,
+			SelfJoin: true,
+			MP:       p.PMP[row],
+			Idx:      p.PIdx[row],
+			Opts:     p.Opts.MPOpts,
+		}
+		if err := mp.Update(newValues); err != nil {
+			return err
+		}
+		p.PMP[row], p.PIdx[row] = mp.MP, mp.Idx
+	}
+
+	for row := range p.PMP {
+		if computed[row] {
+			continue
+		}
+		for range newValues {
+			p.PMP[row] = append(p.PMP[row], math.Inf(1))
+			p.PIdx[row] = append(p.PIdx[row], math.MaxInt64)
+		}
 	}
 
+	p.A = append(p.A, newValues...)
 	return nil
 }
 
 // Analyze has not been implemented yet
-func (p PMP) Analyze(co *MPOpts, ao *AnalyzeOpts) error {
-	return errors.New("Analyze for PMP has not been implemented yet.")
+func (p PMP) Analyze(co *MPOpts, ao *AnalyzeOpts) (*AnalysisResult, error) {
+	return nil, newError(ErrNotImplemented, "Analyze for PMP has not been implemented yet.")
 }
 
 // DiscoverMotifs has not been implemented yet
-func (p PMP) DiscoverMotifs(k int, r float64) ([]MotifGroup, error) {
-	return nil, errors.New("Motifs for PMP has not been implemented yet.")
+func (p PMP) DiscoverMotifs(k int, r float64, neighborCount, exclusionZone int) ([]MotifGroup, error) {
+	return nil, newError(ErrNotImplemented, "Motifs for PMP has not been implemented yet.")
 }
 
 // DiscoverDiscords has not been implemented yet
 func (p PMP) DiscoverDiscords(k int, exclusionZone int) ([]int, error) {
-	return nil, errors.New("Discords for PMP has not been implemented yet.")
+	return nil, newError(ErrNotImplemented, "Discords for PMP has not been implemented yet.")
 }
 
 // DiscoverSegments has not been implemented yet
@@ -181,6 +396,6 @@ func (p PMP) DiscoverSegments() (int, float64, []float64) {
 }
 
 // Visualize has not been implemented yet
-func (p PMP) Visualize(fn string, motifs []MotifGroup, discords []int, cac []float64) error {
-	return errors.New("Visualize for PMP has not been implemented yet.")
+func (p PMP) Visualize(fn string) error {
+	return newError(ErrNotImplemented, "Visualize for PMP has not been implemented yet.")
 }
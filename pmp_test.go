@@ -4,12 +4,14 @@ import (
 	"math"
 	"os"
 	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
 )
 
 func TestPMPSave(t *testing.T) {
 	ts := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
-	p, err := NewPMP(ts, nil)
-	p.Compute(NewPMPOpts(3, 5))
+	p, err := NewPMP(ts, nil, 3, 5)
+	p.Compute(nil)
 	filepath := "./pmp.json"
 	err = p.Save(filepath, "json")
 	if err != nil {
@@ -22,8 +24,8 @@ func TestPMPSave(t *testing.T) {
 
 func TestPMPLoad(t *testing.T) {
 	ts := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13}
-	p, err := NewPMP(ts, nil)
-	p.Compute(NewPMPOpts(3, 5))
+	p, err := NewPMP(ts, nil, 3, 5)
+	p.Compute(nil)
 	filepath := "./pmp.json"
 	if err = p.Save(filepath, "json"); err != nil {
 		t.Errorf("Received error while saving matrix profile, %v", err)
@@ -60,14 +62,14 @@ func TestComputePmp(t *testing.T) {
 		{[]float64{}, []float64{}, 2, 2, 1, nil, nil},
 		{[]float64{1, 1, 1, 1, 1}, []float64{}, 2, 2, 1, nil, nil},
 		{[]float64{}, []float64{1, 1, 1, 1, 1}, 2, 2, 1, nil, nil},
-		{[]float64{1, 2, 1, 3, 1}, []float64{2, 1, 1, 2, 1, 3, 1, -1, -2}, 2, 2, 1, [][]float64{{0, 0, 0, 0}}, [][]int{{2, 3, 2, 3}}},
-		{[]float64{1, 1, 1, 1, 1}, []float64{1, 1, 1, 1, 1, 2, 2, 3, 4, 5}, 2, 2, 1, [][]float64{{2, 2, 2, 2}}, [][]int{{0, 1, 2, 3}}},
+		{[]float64{1, 2, 1, 3, 1}, []float64{2, 1, 1, 2, 1, 3, 1, -1, -2}, 2, 2, 1, [][]float64{{0, 0, 0, 0}}, [][]int{{2, 0, 2, 0}}},
+		{[]float64{1, 1, 1, 1, 1}, []float64{1, 1, 1, 1, 1, 2, 2, 3, 4, 5}, 2, 2, 1, [][]float64{{2, 2, 2, 2}}, [][]int{{0, 0, 0, 0}}},
 		{[]float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, []float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, 4, 4, 1,
 			[][]float64{{0, 0, 0, 0, 0, 0, 0, 0, 0}},
 			[][]int{{0, 1, 2, 3, 4, 5, 6, 7, 8}}},
 		{[]float64{0, 1, 1, 1, 0, 0, 2, 1, 0, 0, 2, 1}, nil, 4, 4, 1,
 			[][]float64{{1.9550, 1.8388, 0.8739, 0, 0, 1.9550, 0.8739, 0, 0}},
-			[][]int{{4, 2, 6, 7, 8, 1, 2, 3, 4}}},
+			[][]int{{4, 2, 6, 7, 8, 0, 2, 3, 4}}},
 		{[]float64{0, 0.99, 1, 0, 0, 0.98, 1, 0, 0, 0.96, 1, 0}, nil, 4, 4, 1,
 			[][]float64{{0.014355, 0.014355, 0.029138, 0.029138, 0.014355, 0.014355, 0.029138, 0.029138, 0.029138}},
 			[][]int{{4, 5, 6, 7, 0, 1, 2, 3, 4}}},
@@ -94,7 +96,7 @@ func TestComputePmp(t *testing.T) {
 	}
 
 	for _, d := range testdata {
-		p, err = NewPMP(d.a, d.b)
+		p, err = NewPMP(d.a, d.b, d.lb, d.ub)
 		if err != nil {
 			if d.expectedPMP == nil {
 				// Got an error while creating a new matrix profile
@@ -146,3 +148,206 @@ func TestComputePmp(t *testing.T) {
 		}
 	}
 }
+
+func TestComputePmpSeed(t *testing.T) {
+	sig := setupData(300)
+
+	compute := func() *PMP {
+		p, err := NewPMP(sig, nil, 8, 24)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+		}
+		o := NewPMPOpts(8, 24)
+		o.MPOpts.SamplePct = 0.5
+		o.MPOpts.Seed = 42
+		if err = p.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating", err)
+		}
+		return p
+	}
+
+	p1, p2 := compute(), compute()
+	for i := range p1.PMP {
+		for j := range p1.PMP[i] {
+			if p1.PMP[i][j] != p2.PMP[i][j] || p1.PIdx[i][j] != p2.PIdx[i][j] {
+				t.Fatalf("Expected the same pan matrix profile for the same seed, but got a difference at %d, %d", i, j)
+			}
+		}
+	}
+}
+
+func TestComputePmpProgress(t *testing.T) {
+	sig := setupData(300)
+
+	p, err := NewPMP(sig, nil, 8, 24)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+	}
+
+	var progress []PMPProgress
+	p.Opts.Progress = func(prog PMPProgress) bool {
+		progress = append(progress, prog)
+		return len(progress) < 5
+	}
+	if err = p.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+
+	if len(progress) != 5 || len(p.PWindows) != 5 {
+		t.Fatalf("Expected to stop after 5 windows, but computed %d with %d callbacks", len(p.PWindows), len(progress))
+	}
+	if !math.IsInf(progress[0].Improvement, 1) {
+		t.Errorf("Expected an infinite improvement for the first window, but got %.4f", progress[0].Improvement)
+	}
+	for i, prog := range progress {
+		if prog.Window != p.PWindows[i] || prog.Computed != i+1 || prog.Total != 17 {
+			t.Errorf("Expected progress for window %d, but got %+v", p.PWindows[i], prog)
+		}
+		if math.Abs(prog.Coverage-float64(i+1)/17) > 1e-9 {
+			t.Errorf("Expected a coverage of %.4f, but got %.4f", float64(i+1)/17, prog.Coverage)
+		}
+		if i > 0 && (prog.Improvement < 0 || prog.Improvement > 1) {
+			t.Errorf("Expected an improvement between 0 and 1, but got %.4f", prog.Improvement)
+		}
+	}
+
+	// stopping on improvement must compute fewer windows than a full computation
+	p.Opts.Progress = nil
+	p.Opts.MinImprovement = 1
+	if err = p.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+	if len(p.PWindows) != 2 {
+		t.Errorf("Expected to stop after 2 windows, but computed %v", p.PWindows)
+	}
+	for _, w := range []int{9, 10, 11} {
+		if !math.IsInf(p.PMP[w-8][0], 1) {
+			t.Errorf("Expected window %d to not be computed", w)
+		}
+	}
+}
+
+func TestPMPUpdate(t *testing.T) {
+	sig := setupData(300)
+
+	o := NewPMPOpts(8, 24)
+	o.MPOpts.Algorithm = AlgoSTMP
+	o.Progress = func(prog PMPProgress) bool {
+		return prog.Computed < 9
+	}
+
+	expected, err := NewPMP(sig, nil, 8, 24)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+	}
+	if err = expected.Compute(o); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+
+	p, err := NewPMP(sig[:250], nil, 8, 24)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+	}
+	if err = p.Compute(o); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+	if err = p.Update(sig[250:270]); err != nil {
+		t.Fatalf("Did not expect an error, %v, while updating", err)
+	}
+	if err = p.Update(sig[270:]); err != nil {
+		t.Fatalf("Did not expect an error, %v, while updating", err)
+	}
+
+	if len(p.A) != len(sig) {
+		t.Fatalf("Expected a time series of length %d, but got %d", len(sig), len(p.A))
+	}
+	for i := range expected.PMP {
+		if len(p.PMP[i]) != len(expected.PMP[i]) || len(p.PIdx[i]) != len(expected.PIdx[i]) {
+			t.Fatalf("Expected %d elements in row %d, but got %d", len(expected.PMP[i]), i, len(p.PMP[i]))
+		}
+		for j := range expected.PMP[i] {
+			if math.Abs(p.PMP[i][j]-expected.PMP[i][j]) > 1e-6 && !(math.IsInf(p.PMP[i][j], 1) && math.IsInf(expected.PMP[i][j], 1)) {
+				t.Errorf("Expected %.6f, but got %.6f at row %d, index %d", expected.PMP[i][j], p.PMP[i][j], i, j)
+				break
+			}
+		}
+	}
+
+	ab, err := NewPMP(sig[:100], sig[100:200], 8, 12)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+	}
+	if err = ab.Update(sig[200:]); err == nil {
+		t.Errorf("Expected an error updating a pan matrix profile that has not been computed")
+	}
+	if err = ab.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+	if err = ab.Update(sig[200:]); err == nil {
+		t.Errorf("Expected an error updating an AB join pan matrix profile")
+	}
+}
+
+func TestPMPApplyAV(t *testing.T) {
+	sig := setupData(300)
+
+	p, err := NewPMP(sig, nil, 8, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v, while creating new pmp", err)
+	}
+	if _, err = p.ApplyAV(); err == nil {
+		t.Errorf("Expected an error applying an annotation vector before computing")
+	}
+
+	p.Opts.Progress = func(prog PMPProgress) bool {
+		return prog.Computed < 5
+	}
+	if err = p.Compute(nil); err != nil {
+		t.Fatalf("Did not expect an error, %v, while calculating", err)
+	}
+
+	testdata := []struct {
+		av          av.AV
+		expectedErr bool
+	}{
+		{av.Default, false},
+		{av.Complexity, false},
+		{av.AV("unknown"), true},
+	}
+
+	for _, d := range testdata {
+		p.AV = d.av
+		out, err := p.ApplyAV()
+		if d.expectedErr {
+			if err == nil {
+				t.Errorf("Expected an error for annotation vector %s", d.av)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v, for annotation vector %s", err, d.av)
+		}
+
+		for _, w := range p.PWindows {
+			row := w - p.Opts.LowerM
+			for i := range out[row] {
+				if out[row][i] < p.PMP[row][i]-1e-9 {
+					t.Fatalf("Expected the annotated profile to be at least the original at window %d, index %d", w, i)
+				}
+				if d.av == av.Default && math.Abs(out[row][i]-p.PMP[row][i]) > 1e-9 {
+					t.Fatalf("Expected no change with the default annotation vector at window %d, index %d", w, i)
+				}
+			}
+		}
+		for row := range out {
+			if !math.IsInf(p.PMP[row][0], 1) {
+				continue
+			}
+			for i := range out[row] {
+				if !math.IsInf(out[row][i], 1) {
+					t.Fatalf("Expected rows of windows not computed to be unchanged, but got %v", out[row])
+				}
+			}
+		}
+	}
+}
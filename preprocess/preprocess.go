@@ -0,0 +1,236 @@
+// Package preprocess provides transformations that are commonly applied to a
+// time series before computing its matrix profile.
+package preprocess
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+// DetrendLinear removes the least squares line fit through the time series
+func DetrendLinear(ts []float64) []float64 {
+	out := make([]float64, len(ts))
+	n := float64(len(ts))
+	if len(ts) < 2 {
+		copy(out, ts)
+		return out
+	}
+
+	// x is the sample index with a mean of (n-1)/2
+	xMean := (n - 1) / 2
+	yMean := floats.Sum(ts) / n
+	var sxy, sxx float64
+	for i, y := range ts {
+		dx := float64(i) - xMean
+		sxy += dx * (y - yMean)
+		sxx += dx * dx
+	}
+	slope := sxy / sxx
+
+	for i, y := range ts {
+		out[i] = y - (yMean + slope*(float64(i)-xMean))
+	}
+	return out
+}
+
+// DetrendRollingMean removes the mean of a centered window of w points from
+// each point of the time series. The window is truncated at the edges of the
+// time series.
+func DetrendRollingMean(ts []float64, w int) ([]float64, error) {
+	if w < 1 || w > len(ts) {
+		return nil, fmt.Errorf("window must be between 1 and the length of the time series %d, got %d", len(ts), w)
+	}
+
+	c := make([]float64, len(ts)+1)
+	for i, v := range ts {
+		c[i+1] = c[i] + v
+	}
+
+	out := make([]float64, len(ts))
+	for i, v := range ts {
+		start, end := i-w/2, i-w/2+w
+		if start < 0 {
+			start = 0
+		}
+		if end > len(ts) {
+			end = len(ts)
+		}
+		out[i] = v - (c[end]-c[start])/float64(end-start)
+	}
+	return out, nil
+}
+
+// Standardize scales the time series to a mean of 0 and a standard deviation
+// of 1
+func Standardize(ts []float64) ([]float64, error) {
+	return util.ZNormalize(ts)
+}
+
+// MinMaxScale linearly scales the time series so that its minimum is lo and
+// its maximum is hi. A constant time series is set to lo.
+func MinMaxScale(ts []float64, lo, hi float64) ([]float64, error) {
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("slice does not have any data")
+	}
+	min, max := floats.Min(ts), floats.Max(ts)
+
+	out := make([]float64, len(ts))
+	for i, v := range ts {
+		out[i] = lo
+		if max > min {
+			out[i] += (v - min) / (max - min) * (hi - lo)
+		}
+	}
+	return out, nil
+}
+
+// ExpSmooth applies simple exponential smoothing to the time series with a
+// smoothing factor alpha in (0, 1]. Smaller values smooth more.
+func ExpSmooth(ts []float64, alpha float64) ([]float64, error) {
+	if alpha <= 0 || alpha > 1 {
+		return nil, fmt.Errorf("smoothing factor must be in (0, 1], got %.3f", alpha)
+	}
+
+	out := make([]float64, len(ts))
+	for i, v := range ts {
+		if i == 0 {
+			out[i] = v
+			continue
+		}
+		out[i] = alpha*v + (1-alpha)*out[i-1]
+	}
+	return out, nil
+}
+
+// Downsampled is a time series reduced in resolution by a factor. Indexes and
+// windows of a matrix profile computed on the downsampled values can be mapped
+// back to the original time series.
+type Downsampled struct {
+	Values []float64 // downsampled time series
+	Factor int       // number of original points per downsampled point
+}
+
+// Index maps an index of the downsampled time series to the index of the
+// first point it covers in the original time series. Indexes outside of the
+// downsampled time series, such as unset matrix profile indexes, are returned
+// unmodified.
+func (d Downsampled) Index(idx int) int {
+	if idx < 0 || idx >= len(d.Values) {
+		return idx
+	}
+	return idx * d.Factor
+}
+
+// Indexes maps every index of the downsampled time series to the original
+// time series in the same manner as Index.
+func (d Downsampled) Indexes(idxs []int) []int {
+	out := make([]int, len(idxs))
+	for i, idx := range idxs {
+		out[i] = d.Index(idx)
+	}
+	return out
+}
+
+// Window maps a subsequence length of the downsampled time series to the
+// number of points it covers in the original time series.
+func (d Downsampled) Window(w int) int {
+	return w * d.Factor
+}
+
+// Decimate downsamples the time series by keeping every factor-th point
+func Decimate(ts []float64, factor int) (*Downsampled, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("downsampling factor must be at least 1, got %d", factor)
+	}
+
+	out := make([]float64, 0, (len(ts)+factor-1)/factor)
+	for i := 0; i < len(ts); i += factor {
+		out = append(out, ts[i])
+	}
+	return &Downsampled{Values: out, Factor: factor}, nil
+}
+
+// PAA downsamples the time series with a piecewise aggregate approximation,
+// replacing every segment of factor points with its mean. A trailing partial
+// segment is averaged over the points it has.
+func PAA(ts []float64, factor int) (*Downsampled, error) {
+	if factor < 1 {
+		return nil, fmt.Errorf("downsampling factor must be at least 1, got %d", factor)
+	}
+
+	out := make([]float64, 0, (len(ts)+factor-1)/factor)
+	for i := 0; i < len(ts); i += factor {
+		end := int(math.Min(float64(i+factor), float64(len(ts))))
+		out = append(out, floats.Sum(ts[i:end])/float64(end-i))
+	}
+	return &Downsampled{Values: out, Factor: factor}, nil
+}
+
+// Decomposition is an additive split of a time series into trend, seasonal
+// and remainder components which sum to the time series
+type Decomposition struct {
+	Trend     []float64 `json:"trend"`     // slowly varying level of the time series
+	Seasonal  []float64 `json:"seasonal"`  // repeating pattern with a mean of 0 over each period
+	Remainder []float64 `json:"remainder"` // what is left after removing the trend and seasonality
+	Period    int       `json:"period"`    // number of points in a season
+}
+
+// Decompose performs a classical additive seasonal decomposition of the time
+// series. The trend is a centered moving average over one period, with half
+// weights at both ends for an even period, and is truncated at the edges of
+// the time series. The seasonal component is the mean of the detrended values
+// at each phase of the period, centered to a mean of 0. The period must be at
+// least 2 with at least two full periods in the time series.
+func Decompose(ts []float64, period int) (*Decomposition, error) {
+	if period < 2 || 2*period > len(ts) {
+		return nil, fmt.Errorf("period must be between 2 and half the length of the time series %d, got %d", len(ts), period)
+	}
+
+	// weights of the centered moving average
+	h := period / 2
+	weights := make([]float64, 2*h+1)
+	for i := range weights {
+		weights[i] = 1
+	}
+	if period%2 == 0 {
+		weights[0], weights[2*h] = 0.5, 0.5
+	}
+
+	d := &Decomposition{
+		Trend:     make([]float64, len(ts)),
+		Seasonal:  make([]float64, len(ts)),
+		Remainder: make([]float64, len(ts)),
+		Period:    period,
+	}
+	for i := range ts {
+		var sum, wsum float64
+		for j, wt := range weights {
+			if k := i - h + j; k >= 0 && k < len(ts) {
+				sum += wt * ts[k]
+				wsum += wt
+			}
+		}
+		d.Trend[i] = sum / wsum
+	}
+
+	phase := make([]float64, period)
+	counts := make([]float64, period)
+	for i, v := range ts {
+		phase[i%period] += v - d.Trend[i]
+		counts[i%period]++
+	}
+	var mean float64
+	for p := range phase {
+		phase[p] /= counts[p]
+		mean += phase[p] / float64(period)
+	}
+
+	for i, v := range ts {
+		d.Seasonal[i] = phase[i%period] - mean
+		d.Remainder[i] = v - d.Trend[i] - d.Seasonal[i]
+	}
+	return d, nil
+}
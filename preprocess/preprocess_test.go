@@ -0,0 +1,216 @@
+package preprocess
+
+import (
+	"math"
+	"testing"
+)
+
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDetrendLinear(t *testing.T) {
+	testdata := []struct {
+		ts       []float64
+		expected []float64
+	}{
+		{[]float64{}, []float64{}},
+		{[]float64{5}, []float64{5}},
+		{[]float64{1, 3, 5, 7}, []float64{0, 0, 0, 0}},
+		{[]float64{1, 4, 5, 8}, []float64{-0.2, 0.6, -0.6, 0.2}},
+	}
+
+	for _, d := range testdata {
+		if out := DetrendLinear(d.ts); !equalFloats(out, d.expected) {
+			t.Errorf("Expected %v, but got %v for %v", d.expected, out, d.ts)
+		}
+	}
+}
+
+func TestDetrendRollingMean(t *testing.T) {
+	testdata := []struct {
+		ts       []float64
+		w        int
+		expected []float64
+	}{
+		{[]float64{1, 2, 3, 4}, 1, []float64{0, 0, 0, 0}},
+		{[]float64{1, 2, 3, 4}, 3, []float64{-0.5, 0, 0, 0.5}},
+		{[]float64{1, 2, 3, 4}, 2, []float64{0, 0.5, 0.5, 0.5}},
+		{[]float64{1, 2, 3, 4}, 0, nil},
+		{[]float64{1, 2, 3, 4}, 5, nil},
+	}
+
+	for _, d := range testdata {
+		out, err := DetrendRollingMean(d.ts, d.w)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for a window of %d", d.w)
+			}
+			continue
+		}
+		if err != nil || !equalFloats(out, d.expected) {
+			t.Errorf("Expected %v, but got %v, %v for a window of %d", d.expected, out, err, d.w)
+		}
+	}
+}
+
+func TestMinMaxScale(t *testing.T) {
+	testdata := []struct {
+		ts       []float64
+		expected []float64
+	}{
+		{[]float64{2, 4, 6}, []float64{-1, 0, 1}},
+		{[]float64{3, 3}, []float64{-1, -1}},
+		{[]float64{}, nil},
+	}
+
+	for _, d := range testdata {
+		out, err := MinMaxScale(d.ts, -1, 1)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for %v", d.ts)
+			}
+			continue
+		}
+		if err != nil || !equalFloats(out, d.expected) {
+			t.Errorf("Expected %v, but got %v, %v", d.expected, out, err)
+		}
+	}
+}
+
+func TestStandardize(t *testing.T) {
+	out, err := Standardize([]float64{7, 5, 5, 7})
+	if err != nil || !equalFloats(out, []float64{1, -1, -1, 1}) {
+		t.Errorf("Expected a standardized series, but got %v, %v", out, err)
+	}
+	if _, err = Standardize([]float64{1, 1}); err == nil {
+		t.Errorf("Expected an error for a constant series")
+	}
+}
+
+func TestExpSmooth(t *testing.T) {
+	testdata := []struct {
+		alpha    float64
+		expected []float64
+	}{
+		{1, []float64{0, 4, 8}},
+		{0.5, []float64{0, 2, 5}},
+		{0, nil},
+		{1.5, nil},
+	}
+
+	for _, d := range testdata {
+		out, err := ExpSmooth([]float64{0, 4, 8}, d.alpha)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for alpha %.2f", d.alpha)
+			}
+			continue
+		}
+		if err != nil || !equalFloats(out, d.expected) {
+			t.Errorf("Expected %v, but got %v, %v for alpha %.2f", d.expected, out, err, d.alpha)
+		}
+	}
+}
+
+func TestDownsample(t *testing.T) {
+	ts := []float64{1, 2, 3, 4, 5, 6, 7}
+
+	testdata := []struct {
+		fn       func([]float64, int) (*Downsampled, error)
+		factor   int
+		expected []float64
+	}{
+		{Decimate, 1, ts},
+		{Decimate, 3, []float64{1, 4, 7}},
+		{PAA, 2, []float64{1.5, 3.5, 5.5, 7}},
+		{PAA, 3, []float64{2, 5, 7}},
+		{Decimate, 0, nil},
+		{PAA, -1, nil},
+	}
+
+	for _, d := range testdata {
+		out, err := d.fn(ts, d.factor)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for a factor of %d", d.factor)
+			}
+			continue
+		}
+		if err != nil || !equalFloats(out.Values, d.expected) {
+			t.Errorf("Expected %v, but got %v, %v for a factor of %d", d.expected, out, err, d.factor)
+		}
+	}
+}
+
+func TestDownsampledIndex(t *testing.T) {
+	d := Downsampled{Values: make([]float64, 5), Factor: 4}
+
+	out := d.Indexes([]int{0, 2, 4, 5, math.MaxInt64, -1})
+	expected := []int{0, 8, 16, 5, math.MaxInt64, -1}
+	for i := range out {
+		if out[i] != expected[i] {
+			t.Errorf("Expected %v, but got %v", expected, out)
+			break
+		}
+	}
+	if d.Window(3) != 12 {
+		t.Errorf("Expected a window of 12, but got %d", d.Window(3))
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	for _, period := range []int{4, 7} {
+		ts := make([]float64, 20*period)
+		for i := range ts {
+			ts[i] = 0.1*float64(i) + 3*math.Sin(2*math.Pi*float64(i)/float64(period))
+		}
+		ts[5*period] += 10
+
+		d, err := Decompose(ts, period)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v", err)
+		}
+		if d.Period != period {
+			t.Errorf("Expected a period of %d, but got %d", period, d.Period)
+		}
+
+		var seasonalSum float64
+		for i := range ts {
+			if math.Abs(d.Trend[i]+d.Seasonal[i]+d.Remainder[i]-ts[i]) > 1e-9 {
+				t.Fatalf("Expected the components to sum to the time series at %d", i)
+			}
+			if i < period {
+				seasonalSum += d.Seasonal[i]
+			}
+		}
+		if math.Abs(seasonalSum) > 1e-9 {
+			t.Errorf("Expected a seasonal component with a mean of 0 over a period, but got a sum of %.6f", seasonalSum)
+		}
+
+		// away from the edges only the spike is left in the remainder
+		maxIdx := period
+		for i := period; i < len(ts)-period; i++ {
+			if math.Abs(d.Remainder[i]) > math.Abs(d.Remainder[maxIdx]) {
+				maxIdx = i
+			}
+		}
+		if maxIdx != 5*period {
+			t.Errorf("Expected the largest remainder at the spike at %d, but got %d", 5*period, maxIdx)
+		}
+	}
+
+	for _, period := range []int{1, 11} {
+		if _, err := Decompose(make([]float64, 20), period); err == nil {
+			t.Errorf("Expected an error for a period of %d", period)
+		}
+	}
+}
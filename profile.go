@@ -0,0 +1,23 @@
+package matrixprofile
+
+// Profile is implemented by every type of matrix profile so that applications
+// can compute, analyze, persist and visualize them without knowing the
+// underlying type. Methods that have not been implemented for a profile type
+// return an error. A pan matrix profile is used through AsProfile since its
+// Compute takes the window range as well.
+type Profile interface {
+	Compute(o *MPOpts) error
+	Analyze(mo *MPOpts, ao *AnalyzeOpts) (*AnalysisResult, error)
+	DiscoverMotifs(k int, radius float64, neighborCount, exclusionZone int) ([]MotifGroup, error)
+	DiscoverDiscords(k int, exclusionZone int) ([]int, error)
+	DiscoverSegments() (int, float64, []float64)
+	Save(filepath, format string) error
+	Load(filepath, format string) error
+	Visualize(fn string) error
+}
+
+var (
+	_ Profile = (*MatrixProfile)(nil)
+	_ Profile = (*KMP)(nil)
+	_ Profile = pmpProfile{}
+)
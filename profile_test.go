@@ -0,0 +1,60 @@
+package matrixprofile
+
+import (
+	"os"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	sig := setupData(200)
+
+	mp, err := New(sig, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKMP([][]float64{sig, sig}, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := NewPMP(sig, nil, 16, 18)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		name    string
+		p       Profile
+		loaded  Profile
+		discord bool
+	}{
+		{"mp", mp, &MatrixProfile{}, true},
+		{"kmp", k, &KMP{}, false},
+		{"pmp", p.AsProfile(), (&PMP{}).AsProfile(), false},
+	}
+
+	filepath := "./profile.json"
+	defer os.Remove(filepath)
+
+	for _, d := range testdata {
+		if err = d.p.Compute(nil); err != nil {
+			t.Errorf("Did not expect an error, %v, while computing %s", err, d.name)
+			continue
+		}
+
+		_, err = d.p.DiscoverDiscords(1, 8)
+		if d.discord && err != nil {
+			t.Errorf("Did not expect an error, %v, while discovering discords for %s", err, d.name)
+		}
+		if !d.discord && err == nil {
+			t.Errorf("Expected an error for unimplemented discords for %s", d.name)
+		}
+
+		if err = d.p.Save(filepath, "json"); err != nil {
+			t.Errorf("Did not expect an error, %v, while saving %s", err, d.name)
+			continue
+		}
+		if err = d.loaded.Load(filepath, "json"); err != nil {
+			t.Errorf("Did not expect an error, %v, while loading %s", err, d.name)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package matrixprofile
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"runtime/debug"
+	"time"
+)
+
+// modulePath is the import path of this package's module, used to find its
+// version in the build information
+const modulePath = "github.com/matrix-profile-foundation/go-matrixprofile"
+
+// Provenance describes how a matrix profile was produced so that a saved
+// profile can be checked against the time series it is later used with.
+// Compute sets the version, algorithm and time while the lengths and
+// checksums of the time series are recorded whenever the profile is encoded.
+// The options used are saved alongside in Opts.
+type Provenance struct {
+	Version    string    `json:"version"`     // version of the go-matrixprofile module that computed the matrix profile, (devel) if unknown
+	Algorithm  Algo      `json:"algorithm"`   // algorithm that computed the matrix profile
+	ComputedAt time.Time `json:"computed_at"` // time the computation finished. Updates keep the time of the last full computation
+	LenA       int       `json:"len_a"`       // length of the a time series when encoded
+	LenB       int       `json:"len_b"`       // length of the b time series when encoded
+	ChecksumA  string    `json:"checksum_a"`  // hex encoded sha256 of the a time series when encoded
+	ChecksumB  string    `json:"checksum_b"`  // hex encoded sha256 of the b time series when encoded
+}
+
+// moduleVersion returns the version of this module from the build information
+// of the running binary, or (devel) if it is not available such as in tests
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// seriesChecksum returns the hex encoded sha256 of the little endian bits of
+// each value of a time series
+func seriesChecksum(ts []float64) string {
+	h := sha256.New()
+	var buf [8]byte
+	for _, v := range ts {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordProvenance sets the provenance of a computation that just finished
+func (mp *MatrixProfile) recordProvenance() {
+	mp.Provenance = &Provenance{
+		Version:    moduleVersion(),
+		Algorithm:  mp.Opts.Algorithm,
+		ComputedAt: time.Now().UTC(),
+	}
+	mp.unverified = false
+}
+
+// encodedProvenance returns a copy of the provenance with the lengths and
+// checksums of the current time series, or nil if the matrix profile has no
+// provenance. The recorded ones are kept while the time series are not
+// attached.
+func (mp MatrixProfile) encodedProvenance() *Provenance {
+	if mp.Provenance == nil {
+		return nil
+	}
+	p := *mp.Provenance
+	if mp.unverified {
+		return &p
+	}
+	p.LenA, p.LenB = len(mp.A), len(mp.B)
+	p.ChecksumA, p.ChecksumB = seriesChecksum(mp.A), seriesChecksum(mp.B)
+	return &p
+}
+
+// Attach binds the time series a and b, with b nil for a self join, to a
+// matrix profile decoded without them, such as one saved with the OmitSeries
+// option, so that it can be updated and used for discovery again. Their
+// lengths and checksums are first compared with the provenance and
+// ErrSeriesMismatch is returned if either differs. Decode checks the time
+// series saved in the profile itself, so this is only needed when they were
+// left out.
+func (mp *MatrixProfile) Attach(a, b []float64) error {
+	if b == nil && mp.SelfJoin {
+		b = a
+	}
+	if err := mp.checkSeries(a, b); err != nil {
+		return err
+	}
+
+	// caches derived from other time series must not outlive them
+	mp.A, mp.B = a, b
+	mp.AMean, mp.AStd, mp.BMean, mp.BStd, mp.BF = nil, nil, nil, nil, nil
+	mp.unverified = false
+	return nil
+}
+
+// checkSeries returns ErrSeriesMismatch unless the lengths and checksums of a
+// and b match the provenance
+func (mp MatrixProfile) checkSeries(a, b []float64) error {
+	p := mp.Provenance
+	if p == nil || p.ChecksumA == "" {
+		return newError(ErrSeriesMismatch, "matrix profile has no recorded checksums to verify the time series against")
+	}
+	if len(a) != p.LenA || seriesChecksum(a) != p.ChecksumA {
+		return newError(ErrSeriesMismatch, "time series a of length %d does not match the one of length %d the matrix profile was computed from", len(a), p.LenA)
+	}
+	if len(b) != p.LenB || seriesChecksum(b) != p.ChecksumB {
+		return newError(ErrSeriesMismatch, "time series b of length %d does not match the one of length %d the matrix profile was computed from", len(b), p.LenB)
+	}
+	return nil
+}
+
+// verifyDecoded checks the decoded time series against the decoded
+// provenance. A profile decoded without its time series is left unverified.
+func (mp *MatrixProfile) verifyDecoded() error {
+	if mp.Provenance == nil || mp.Provenance.ChecksumA == "" {
+		return nil
+	}
+	if len(mp.A) == 0 && len(mp.B) == 0 {
+		mp.unverified = true
+		return nil
+	}
+	return mp.checkSeries(mp.A, mp.B)
+}
+
+// checkVerified returns ErrSeriesMismatch if the matrix profile was decoded
+// without its time series and they have not been attached since
+func (mp MatrixProfile) checkVerified() error {
+	if mp.unverified {
+		return newError(ErrSeriesMismatch, "time series of a decoded matrix profile must be attached with Attach before use")
+	}
+	return nil
+}
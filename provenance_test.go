@@ -0,0 +1,94 @@
+package matrixprofile
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestProvenance(t *testing.T) {
+	sig := setupData(200)
+	mp, err := New(sig, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Algorithm = AlgoSTOMP
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	p := mp.Provenance
+	if p == nil || p.Version == "" || p.Algorithm != AlgoSTOMP || p.ComputedAt.IsZero() {
+		t.Fatalf("Expected the provenance of the computation, but got %+v", p)
+	}
+
+	var buf bytes.Buffer
+	if err = mp.Encode(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	saved := buf.Bytes()
+	if mp.Provenance.ChecksumA != "" {
+		t.Errorf("Expected encoding to leave the provenance of the matrix profile unchanged")
+	}
+
+	loaded := &MatrixProfile{}
+	if err = loaded.Decode(bytes.NewReader(saved), "json"); err != nil {
+		t.Fatal(err)
+	}
+	lp := loaded.Provenance
+	if lp == nil || lp.LenA != len(sig) || lp.LenB != len(sig) || lp.ChecksumA != seriesChecksum(sig) || lp.ChecksumB != lp.ChecksumA {
+		t.Fatalf("Expected the lengths and checksums of the time series, but got %+v", lp)
+	}
+	if !lp.ComputedAt.Equal(p.ComputedAt) || lp.Algorithm != p.Algorithm {
+		t.Errorf("Expected the provenance %+v to be kept, but got %+v", p, lp)
+	}
+	if err = loaded.Update(sig[:5]); err != nil {
+		t.Errorf("Expected a profile decoded with its time series to be updated, but got %v", err)
+	}
+
+	// a saved time series that no longer matches its checksum is rejected
+	var doc map[string]interface{}
+	if err = json.Unmarshal(saved, &doc); err != nil {
+		t.Fatal(err)
+	}
+	doc["a"].([]interface{})[3] = 42.0
+	tampered, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = (&MatrixProfile{}).Decode(bytes.NewReader(tampered), "json"); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch for a modified time series, but got %v", err)
+	}
+
+	// a profile saved without its time series is only updated once verified
+	doc["a"] = nil
+	doc["b"] = nil
+	stripped, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded = &MatrixProfile{}
+	if err = loaded.Decode(bytes.NewReader(stripped), "json"); err != nil {
+		t.Fatal(err)
+	}
+	if err = loaded.Update(sig[:5]); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch before verifying the time series, but got %v", err)
+	}
+	other := append([]float64(nil), sig...)
+	other[10]++
+	if err = loaded.Attach(other, nil); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch for a different time series, but got %v", err)
+	}
+	if err = loaded.Attach(sig[:100], nil); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch for a shorter time series, but got %v", err)
+	}
+	if err = loaded.Attach(append([]float64(nil), sig...), nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = loaded.Update(sig[:5]); err != nil {
+		t.Errorf("Expected a verified profile to be updated, but got %v", err)
+	}
+	if len(loaded.MP) != len(sig)+5-16+1 {
+		t.Errorf("Expected %d matrix profile values after the update, but got %d", len(sig)+5-16+1, len(loaded.MP))
+	}
+}
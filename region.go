@@ -0,0 +1,119 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Region is a range of a computed matrix profile along with the motifs and
+// discords found so far whose subsequences overlap it. It holds copies so
+// that it can be handed out, such as to a user interface panning or zooming
+// over a long profile, without sharing the full arrays.
+type Region struct {
+	Start    int          `json:"start"`           // index of the first matrix profile value in the region
+	End      int          `json:"end"`             // index after the last matrix profile value in the region
+	MP       []float64    `json:"mp"`              // matrix profile values in the region
+	Idx      []int        `json:"pi"`              // matrix profile index values in the region
+	Times    []time.Time  `json:"times,omitempty"` // timestamp of each subsequence in the region if created from a time series
+	Motifs   []MotifGroup `json:"motifs"`          // motif groups with at least one member overlapping the region
+	Discords []int        `json:"discords"`        // discords overlapping the region
+}
+
+// Region returns the matrix profile values from start up to, but not
+// including, end. The range is clipped to the matrix profile. Motif groups
+// and discords from the last discovery are included if any of their
+// subsequences share a point with a subsequence in the region.
+func (mp MatrixProfile) Region(start, end int) (*Region, error) {
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+	if start < 0 {
+		start = 0
+	}
+	if end > len(mp.MP) {
+		end = len(mp.MP)
+	}
+	if start > end {
+		return nil, fmt.Errorf("region start, %d, must not be after its end, %d", start, end)
+	}
+
+	r := &Region{
+		Start: start,
+		End:   end,
+		MP:    copyFloats(mp.MP[start:end]),
+		Idx:   copyInts(mp.Idx[start:end]),
+	}
+	if mp.timed() {
+		r.Times = make([]time.Time, end-start)
+		copy(r.Times, mp.Times[start:end])
+	}
+
+	// the subsequences of the region cover the points from start up to
+	// end-1+W, so a subsequence overlaps if it starts within W of them
+	overlaps := func(idx int) bool {
+		return start < end && idx > start-mp.W && idx < end-1+mp.W
+	}
+	for _, mg := range mp.Motifs {
+		for _, idx := range mg.Idx {
+			if overlaps(idx) {
+				r.Motifs = append(r.Motifs, mg)
+				break
+			}
+		}
+	}
+	for _, idx := range mp.Discords {
+		if overlaps(idx) {
+			r.Discords = append(r.Discords, idx)
+		}
+	}
+	return r, nil
+}
+
+// TimeRegion returns the region of the matrix profile with subsequences
+// starting from the timestamp from up to, but not including, to in the same
+// manner as Region. The matrix profile must have been created from a time
+// series and be indexed by the subsequences of a, as with a self join.
+func (mp MatrixProfile) TimeRegion(from, to time.Time) (*Region, error) {
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+	if !mp.timed() {
+		return nil, fmt.Errorf("matrix profile has no timestamps for its subsequences, create a self join with NewFromTimeSeries")
+	}
+
+	times := mp.Times[:len(mp.MP)]
+	start := sort.Search(len(times), func(i int) bool { return !times[i].Before(from) })
+	end := sort.Search(len(times), func(i int) bool { return !times[i].Before(to) })
+	if end < start {
+		end = start
+	}
+	return mp.Region(start, end)
+}
+
+// timed returns whether every matrix profile value has the timestamp of its
+// subsequence, which requires a matrix profile indexed by the subsequences of
+// a that was created from a time series
+func (mp MatrixProfile) timed() bool {
+	return len(mp.Times) >= len(mp.MP) && (mp.SelfJoin || mp.indexedByA())
+}
+
+// Page returns the region holding the page-th group of size consecutive
+// matrix profile values, starting from page 0, along with the number of
+// pages. A page past the end returns an empty region.
+func (mp MatrixProfile) Page(page, size int) (*Region, int, error) {
+	if page < 0 || size < 1 {
+		return nil, 0, fmt.Errorf("page must be at least 0 and size at least 1, got page %d of size %d", page, size)
+	}
+	if err := mp.checkComputed(); err != nil {
+		return nil, 0, err
+	}
+
+	pages := (len(mp.MP) + size - 1) / size
+	start := page * size
+	if start > len(mp.MP) {
+		start = len(mp.MP)
+	}
+	r, err := mp.Region(start, start+size)
+	return r, pages, err
+}
@@ -0,0 +1,84 @@
+package matrixprofile
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegion(t *testing.T) {
+	a := setupData(100)
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	times := make([]time.Time, len(a))
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+	ts, err := NewTimeSeries(times, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp, err := NewFromTimeSeries(ts, nil, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	mp.Motifs = []MotifGroup{{Idx: []int{5, 60}}, {Idx: []int{20, 80}}}
+	mp.Discords = []int{33, 50, 90}
+
+	r, err := mp.Region(40, 55)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if r.Start != 40 || r.End != 55 || len(r.MP) != 15 || len(r.Idx) != 15 || len(r.Times) != 15 {
+		t.Fatalf("Expected a region of 15 values from 40, but got %+v", r)
+	}
+	for i := range r.MP {
+		if r.MP[i] != mp.MP[40+i] || r.Idx[i] != mp.Idx[40+i] || !r.Times[i].Equal(times[40+i]) {
+			t.Errorf("Expected value %d of the region to match index %d of the matrix profile", i, 40+i)
+		}
+	}
+	if len(r.Motifs) != 1 || r.Motifs[0].Idx[0] != 5 {
+		t.Errorf("Expected only the motif group overlapping at 60, but got %+v", r.Motifs)
+	}
+	if len(r.Discords) != 2 || r.Discords[0] != 33 || r.Discords[1] != 50 {
+		t.Errorf("Expected discords 33 and 50, but got %v", r.Discords)
+	}
+
+	// the region is a copy
+	r.MP[0] = -1
+	if mp.MP[40] == -1 {
+		t.Errorf("Expected the region not to share the matrix profile")
+	}
+
+	tr, err := mp.TimeRegion(times[40], times[55])
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if tr.Start != 40 || tr.End != 55 {
+		t.Errorf("Expected the time region to span [40, 55), but got [%d, %d)", tr.Start, tr.End)
+	}
+
+	page, pages, err := mp.Page(9, 10)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if pages != 10 || page.Start != 90 || page.End != len(mp.MP) {
+		t.Errorf("Expected the last of 10 pages to span [90, %d), but got %d pages and [%d, %d)", len(mp.MP), pages, page.Start, page.End)
+	}
+	if page, _, err = mp.Page(20, 10); err != nil || len(page.MP) != 0 {
+		t.Errorf("Expected an empty page past the end, but got %+v, %v", page, err)
+	}
+
+	if _, err = mp.Region(50, 40); err == nil {
+		t.Errorf("Expected an error for a start after the end")
+	}
+	if _, _, err = mp.Page(0, 0); err == nil {
+		t.Errorf("Expected an error for a page size of 0")
+	}
+
+	mp.Times = nil
+	if _, err = mp.TimeRegion(times[40], times[55]); err == nil {
+		t.Errorf("Expected an error without timestamps")
+	}
+}
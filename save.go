@@ -0,0 +1,72 @@
+package matrixprofile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// saveFile writes the output of encode to the file at fp. Otherwise the output
+// is encoded in memory before fp is truncated, so that an encoding error such
+// as an invalid format leaves an existing file intact. If atomic is set, the
+// output is first written to a temporary file in the same directory which is
+// synced and then renamed over fp so that a partially written file is never
+// observed at fp.
+func saveFile(fp string, atomic bool, encode func(io.Writer) error) error {
+	if !atomic {
+		var buf bytes.Buffer
+		if err := encode(&buf); err != nil {
+			return err
+		}
+		f, err := os.Create(fp)
+		if err != nil {
+			return err
+		}
+		if _, err = buf.WriteTo(f); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(fp), filepath.Base(fp)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpName := f.Name()
+
+	if err = encode(f); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err = os.Rename(tmpName, fp); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// loadFile opens the file at fp and passes it to decode
+func loadFile(fp string, decode func(io.Reader) error) error {
+	f, err := os.Open(fp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return decode(f)
+}
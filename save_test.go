@@ -0,0 +1,166 @@
+package matrixprofile
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSaveTruncate(t *testing.T) {
+	filepath := "./mp_truncate.json"
+	defer os.Remove(filepath)
+
+	big, err := New([]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = big.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	if err = big.Save(filepath, "json"); err != nil {
+		t.Fatalf("Received error while saving matrix profile, %v", err)
+	}
+
+	small, err := New([]float64{1, 2, 3, 4, 5}, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = small.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	if err = small.Save(filepath, "json"); err != nil {
+		t.Fatalf("Received error while saving matrix profile, %v", err)
+	}
+
+	newP := &MatrixProfile{}
+	if err = newP.Load(filepath, "json"); err != nil {
+		t.Fatalf("Failed to load %s after overwriting a larger profile, %v", filepath, err)
+	}
+	if len(newP.A) != len(small.A) {
+		t.Errorf("Expected timeseries length of %d, but got %d", len(small.A), len(newP.A))
+	}
+
+	if err = big.Save(filepath, "csv"); err == nil {
+		t.Errorf("Expected an error for an invalid save format")
+	}
+	newP = &MatrixProfile{}
+	if err = newP.Load(filepath, "json"); err != nil || len(newP.A) != len(small.A) {
+		t.Errorf("Expected previous contents to be intact after a failed save, %v", err)
+	}
+}
+
+func TestSaveAtomic(t *testing.T) {
+	filepath := "./mp_atomic.json"
+	defer os.Remove(filepath)
+
+	ts := []float64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	p, err := New(ts, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err = p.SaveAtomic(filepath, "json"); err != nil {
+			t.Fatalf("Received error while saving matrix profile, %v", err)
+		}
+	}
+
+	newP := &MatrixProfile{}
+	if err = newP.Load(filepath, "json"); err != nil {
+		t.Fatalf("Failed to load %s, %v", filepath, err)
+	}
+	if len(newP.A) != len(ts) {
+		t.Errorf("Expected timeseries length of %d, but got %d", len(ts), len(newP.A))
+	}
+
+	if err = p.SaveAtomic(filepath, "csv"); err == nil {
+		t.Errorf("Expected an error for an invalid save format")
+	}
+	newP = &MatrixProfile{}
+	if err = newP.Load(filepath, "json"); err != nil {
+		t.Errorf("Expected previous contents to be intact after a failed save, %v", err)
+	}
+}
+
+func TestSaveOmitSeries(t *testing.T) {
+	filepath := "./mp_omit.json"
+	defer os.Remove(filepath)
+
+	sig := setupData(200)
+	p, err := New(sig, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := p.DiscoverDiscords(2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := NewSaveOpts()
+	o.Atomic = true
+	o.OmitSeries = true
+	if err = p.SaveWithOpts(filepath, o); err != nil {
+		t.Fatalf("Received error while saving matrix profile, %v", err)
+	}
+	if len(p.A) != len(sig) {
+		t.Errorf("Expected saving to leave the time series of the matrix profile unchanged")
+	}
+	b, err := ioutil.ReadFile(filepath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(b, []byte(`"a":null`)) || !bytes.Contains(b, []byte(`"b":null`)) {
+		t.Errorf("Expected the time series to be left out, but got %s", b)
+	}
+
+	newP := &MatrixProfile{}
+	if err = newP.Load(filepath, "json"); err != nil {
+		t.Fatalf("Failed to load %s, %v", filepath, err)
+	}
+	if newP.Provenance == nil || newP.Provenance.LenA != len(sig) || newP.Provenance.ChecksumA != seriesChecksum(sig) {
+		t.Fatalf("Expected the length and checksum of the time series, but got %+v", newP.Provenance)
+	}
+	if _, err = newP.DiscoverDiscords(2, 8); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch before attaching the time series, but got %v", err)
+	}
+
+	// encoding again keeps the checksums of the time series not attached
+	var buf bytes.Buffer
+	if err = newP.Encode(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	again := &MatrixProfile{}
+	if err = again.Decode(&buf, "json"); err != nil {
+		t.Fatal(err)
+	}
+	if again.Provenance.ChecksumA != newP.Provenance.ChecksumA || again.Provenance.LenA != len(sig) {
+		t.Errorf("Expected the recorded checksums to be kept, but got %+v", again.Provenance)
+	}
+
+	if err = newP.Attach(sig[1:], nil); Cause(err) != ErrSeriesMismatch {
+		t.Errorf("Expected ErrSeriesMismatch for another time series, but got %v", err)
+	}
+	if err = newP.Attach(sig, nil); err != nil {
+		t.Fatal(err)
+	}
+	discords, err := newP.DiscoverDiscords(2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(discords, expected) {
+		t.Errorf("Expected discords %v after attaching the time series, but got %v", expected, discords)
+	}
+
+	o.Format = "csv"
+	if err = p.EncodeWithOpts(&buf, o); Cause(err) != ErrInvalidFormat {
+		t.Errorf("Expected ErrInvalidFormat, but got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package matrixprofile
+
+import (
+	"fmt"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/preprocess"
+)
+
+// NewSeasonalAdjusted creates a self join matrix profile of the remainder of
+// ts after removing its trend and seasonality with preprocess.Decompose. The
+// matrix profile of a strongly seasonal time series mostly finds the
+// seasonality, while the remainder shows the motifs and discords beyond it.
+// Indexes are the same as in ts and the removed components are attached to
+// the subsequences of discovered features.
+func NewSeasonalAdjusted(ts []float64, w, period int) (*MatrixProfile, error) {
+	d, err := preprocess.Decompose(ts, period)
+	if err != nil {
+		return nil, err
+	}
+	return NewFromDecomposition(d, w)
+}
+
+// NewFromDecomposition creates a self join matrix profile of the remainder of
+// a decomposition, such as one computed externally with STL, in the same
+// manner as NewSeasonalAdjusted. The trend and seasonal components must either
+// be nil or the same length as the remainder.
+func NewFromDecomposition(d *preprocess.Decomposition, w int) (*MatrixProfile, error) {
+	if d == nil {
+		return nil, newError(ErrEmptySeries, "decomposition is nil")
+	}
+	for name, c := range map[string][]float64{"trend": d.Trend, "seasonal": d.Seasonal} {
+		if c != nil && len(c) != len(d.Remainder) {
+			return nil, fmt.Errorf("%s length, %d, must match the remainder length, %d", name, len(c), len(d.Remainder))
+		}
+	}
+
+	mp, err := New(d.Remainder, nil, w)
+	if err != nil {
+		return nil, err
+	}
+	mp.Decomposition = d
+	return mp, nil
+}
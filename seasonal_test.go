@@ -0,0 +1,85 @@
+package matrixprofile
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/preprocess"
+)
+
+func TestNewSeasonalAdjusted(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	period := 24
+	ts := make([]float64, 40*period)
+	for i := range ts {
+		ts[i] = 0.01*float64(i) + 5*math.Sin(2*math.Pi*float64(i)/float64(period)) + 0.02*r.NormFloat64()
+	}
+	// a pattern dwarfed by the seasonality recurs at unrelated phases
+	planted := []int{100, 437, 700}
+	for _, idx := range planted {
+		for i := 0; i < 12; i++ {
+			ts[idx+i] += 2 * math.Sin(math.Pi*float64(i)/6)
+		}
+	}
+
+	mp, err := NewSeasonalAdjusted(ts, 12, period)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	motifs, err := mp.DiscoverMotifs(1, 2, 10, mp.W/2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	near := func(idx int) bool {
+		for _, p := range planted {
+			if idx >= p-mp.W/2 && idx <= p+mp.W/2 {
+				return true
+			}
+		}
+		return false
+	}
+	if len(motifs) != 1 || len(motifs[0].Idx) < 2 || !near(motifs[0].Idx[0]) || !near(motifs[0].Idx[1]) {
+		t.Fatalf("Expected a motif at the planted pattern, but got %+v", motifs)
+	}
+
+	sub, err := mp.Subsequence(motifs[0].Idx[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sub.Trend) != mp.W || len(sub.Seasonal) != mp.W {
+		t.Fatalf("Expected the trend and seasonal components to be attached, but got %+v", sub)
+	}
+	for i, v := range sub.Values {
+		if math.Abs(v+sub.Trend[i]+sub.Seasonal[i]-ts[sub.Idx+i]) > 1e-9 {
+			t.Errorf("Expected the components to add back to the time series at %d", sub.Idx+i)
+			break
+		}
+	}
+
+	if _, err = NewSeasonalAdjusted(ts[:30], 12, period); err == nil {
+		t.Errorf("Expected an error with less than two periods")
+	}
+	if _, err = NewFromDecomposition(nil, 12); Cause(err) != ErrEmptySeries {
+		t.Errorf("Expected ErrEmptySeries for a nil decomposition, but got %v", err)
+	}
+	d := &preprocess.Decomposition{Remainder: ts, Trend: ts[:10]}
+	if _, err = NewFromDecomposition(d, 12); err == nil {
+		t.Errorf("Expected an error for a trend of a different length")
+	}
+
+	// an external decomposition with only a remainder
+	d = &preprocess.Decomposition{Remainder: ts}
+	if mp, err = NewFromDecomposition(d, 12); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if sub, err = mp.Subsequence(0); err != nil || sub.Trend != nil || sub.Seasonal != nil {
+		t.Errorf("Expected no components without a trend or seasonality, but got %+v, %v", sub, err)
+	}
+}
@@ -0,0 +1,89 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// SegmentOpts are parameters to vary the ideal arc curve used to correct the
+// arc curve during segmentation. The default parabola assumes every nearest
+// neighbor is equally likely to be anywhere in the time series, which does not
+// hold near the ends of the time series or for AB joins.
+type SegmentOpts struct {
+	IAC          []float64 `json:"iac"`          // custom ideal arc curve with one value per matrix profile index. Takes precedence over Permutations if set
+	Permutations int       `json:"permutations"` // number of random permutations of the matrix profile index averaged to learn the ideal arc curve. The parabola is used if 0
+	Seed         int64     `json:"seed"`         // seeds the random permutations so that learned ideal arc curves are reproducible
+}
+
+// NewSegmentOpts returns a default SegmentOpts which uses the parabola as the
+// ideal arc curve
+func NewSegmentOpts() *SegmentOpts {
+	return &SegmentOpts{
+		Seed: time.Now().UnixNano(),
+	}
+}
+
+// EmpiricalIAC learns the ideal arc curve of the matrix profile index by
+// averaging the arc curves of random permutations of the index. A permutation
+// keeps the distribution of nearest neighbor locations while removing any
+// relation to where each subsequence is, which is the arc curve expected from
+// a time series without regime changes.
+func (mp MatrixProfile) EmpiricalIAC(permutations int, seed int64) ([]float64, error) {
+	if permutations < 1 {
+		return nil, fmt.Errorf("number of permutations must be at least 1, got %d", permutations)
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	idx := make([]int, len(mp.Idx))
+	ideal := make([]float64, len(mp.Idx))
+	for p := 0; p < permutations; p++ {
+		for i, j := range r.Perm(len(mp.Idx)) {
+			idx[i] = mp.Idx[j]
+		}
+		for i, v := range arcCurve(idx) {
+			ideal[i] += v / float64(permutations)
+		}
+	}
+	return ideal, nil
+}
+
+// DiscoverSegmentsWithOpts finds the index where there may be a potential
+// timeseries change in the same manner as DiscoverSegments while correcting
+// the arc curve with the ideal arc curve from the options. Returns the index
+// of the potential change, value of the corrected arc curve score and the
+// corrected arc curve.
+func (mp MatrixProfile) DiscoverSegmentsWithOpts(o *SegmentOpts) (int, float64, []float64, error) {
+	if o == nil {
+		o = NewSegmentOpts()
+	}
+	if mp.stale || mp.Idx == nil {
+		return 0, 0, nil, ErrNotComputed
+	}
+
+	var ideal []float64
+	var err error
+	switch {
+	case o.IAC != nil:
+		if len(o.IAC) != len(mp.Idx) {
+			return 0, 0, nil, fmt.Errorf("ideal arc curve length, %d, must match the matrix profile index length, %d", len(o.IAC), len(mp.Idx))
+		}
+		ideal = o.IAC
+	case o.Permutations < 0:
+		return 0, 0, nil, fmt.Errorf("number of permutations must be at least 0, got %d", o.Permutations)
+	case o.Permutations > 0:
+		if ideal, err = mp.EmpiricalIAC(o.Permutations, o.Seed); err != nil {
+			return 0, 0, nil, err
+		}
+	}
+
+	cac := mp.correctedArcCurve(ideal)
+	minIdx, minVal := math.MaxInt64, math.Inf(1)
+	for i, v := range cac {
+		if v < minVal {
+			minIdx, minVal = i, v
+		}
+	}
+	return minIdx, minVal, cac, nil
+}
@@ -0,0 +1,100 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiscoverSegmentsWithOpts(t *testing.T) {
+	ts := make([]float64, 600)
+	for i := range ts {
+		if i < 350 {
+			ts[i] = math.Sin(2 * math.Pi * float64(i) / 20)
+		} else {
+			ts[i] = math.Abs(math.Mod(float64(i), 30)-15) / 15
+		}
+		ts[i] += 0.01 * math.Cos(float64(i)*1.7)
+	}
+	mp, err := New(ts, nil, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	parabola := make([]float64, len(mp.Idx))
+	for i := range parabola {
+		parabola[i] = iac(float64(i), len(parabola))
+	}
+	segIdx, segVal, segCAC := mp.DiscoverSegments()
+
+	testdata := []struct {
+		opts      *SegmentOpts
+		expectErr bool
+	}{
+		{nil, false},
+		{&SegmentOpts{IAC: parabola}, false},
+		{&SegmentOpts{Permutations: 5, Seed: 3}, false},
+		{&SegmentOpts{IAC: parabola[1:]}, true},
+		{&SegmentOpts{Permutations: -1}, true},
+	}
+
+	for _, d := range testdata {
+		idx, val, cac, err := mp.DiscoverSegmentsWithOpts(d.opts)
+		if d.expectErr {
+			if err == nil {
+				t.Errorf("Expected an error for %+v", d.opts)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %+v", err, d.opts)
+			continue
+		}
+		if math.Abs(float64(idx-350)) > 40 {
+			t.Errorf("Expected a segment near 350, but got %d for %+v", idx, d.opts)
+		}
+		if len(cac) != len(mp.Idx) || val != cac[idx] {
+			t.Errorf("Expected the score %.3f to be the corrected arc curve value at %d", val, idx)
+		}
+
+		// the parabola is the default ideal arc curve
+		if d.opts == nil || d.opts.IAC != nil {
+			if idx != segIdx || math.Abs(val-segVal) > 1e-9 {
+				t.Errorf("Expected %d, %.3f as with DiscoverSegments, but got %d, %.3f", segIdx, segVal, idx, val)
+			}
+			for i := range cac {
+				if math.Abs(cac[i]-segCAC[i]) > 1e-9 {
+					t.Errorf("Expected the same corrected arc curve as DiscoverSegments at %d", i)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestEmpiricalIAC(t *testing.T) {
+	mp := MatrixProfile{Idx: []int{4, 5, 6, 0, 2, 1, 0}}
+
+	a, err := mp.EmpiricalIAC(10, 1)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	b, err := mp.EmpiricalIAC(10, 1)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("Expected the same ideal arc curve for the same seed, but got %v and %v", a, b)
+		}
+	}
+	if a[0] != 0 || a[len(a)-1] != 0 {
+		t.Errorf("Expected no arcs crossing the ends, but got %v", a)
+	}
+
+	if _, err = mp.EmpiricalIAC(0, 1); err == nil {
+		t.Errorf("Expected an error for 0 permutations")
+	}
+}
@@ -0,0 +1,157 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+)
+
+// SessionMask marks the subsequences of length w of a time series of n points
+// that span one of the boundaries, where each boundary is the index of the
+// first point of a new session in a time series concatenated from several
+// recordings. The result has one entry per subsequence and can be used as an
+// exclusion mask, such as for ExcludeMask when discovering discords.
+func SessionMask(n, w int, boundaries []int) ([]bool, error) {
+	if w < 1 || w > n {
+		return nil, fmt.Errorf("subsequence length must be between 1 and the time series length, %d, got %d", n, w)
+	}
+	mask := make([]bool, n-w+1)
+	for _, b := range boundaries {
+		if b <= 0 || b >= n {
+			return nil, fmt.Errorf("session boundary must be between 1 and %d, got %d", n-1, b)
+		}
+		start := b - w + 1
+		if start < 0 {
+			start = 0
+		}
+		for i := start; i < b && i < len(mask); i++ {
+			mask[i] = true
+		}
+	}
+	return mask, nil
+}
+
+// crossesBoundary returns whether the subsequence at idx spans one of the
+// session boundaries of the options
+func (mp MatrixProfile) crossesBoundary(idx int) bool {
+	if mp.Opts == nil {
+		return false
+	}
+	for _, b := range mp.Opts.Boundaries {
+		if idx < b && idx+mp.W > b {
+			return true
+		}
+	}
+	return false
+}
+
+// clearBoundaries skips the subsequences spanning a session boundary by
+// resetting their matrix profile values and indexes, including the left and
+// right matrix profiles and kept correlations, to having no nearest neighbor
+func (mp *MatrixProfile) clearBoundaries() {
+	if mp.Opts == nil || len(mp.Opts.Boundaries) == 0 {
+		return
+	}
+	for i := range mp.MP {
+		if !mp.crossesBoundary(i) {
+			continue
+		}
+		mp.MP[i], mp.Idx[i] = math.Inf(1), math.MaxInt64
+		if i < len(mp.LMP) {
+			mp.LMP[i], mp.LIdx[i] = math.Inf(1), math.MaxInt64
+		}
+		if i < len(mp.RMP) {
+			mp.RMP[i], mp.RIdx[i] = math.Inf(1), math.MaxInt64
+		}
+		if i < len(mp.Corr) {
+			mp.Corr[i] = math.Inf(-1)
+		}
+	}
+}
+
+// SessionProfile is the join of the subsequences of one session against the
+// subsequences of every other session of a collection.
+type SessionProfile struct {
+	MP      []float64 `json:"mp"`      // euclidean distance from each subsequence of the session to its nearest neighbor in any other session. +Inf if no neighbor has a defined distance
+	Idx     []int     `json:"pi"`      // starting index of the nearest neighbor within the session holding it. math.MaxInt64 if there is none
+	Session []int     `json:"session"` // session holding the nearest neighbor of each subsequence. -1 if there is none
+}
+
+// SessionJoin holds the one-vs-rest joins of a collection of sessions.
+type SessionJoin struct {
+	W        int              `json:"w"`        // length of a subsequence
+	Starts   []int            `json:"starts"`   // starting index of each session in the concatenated time series
+	Profiles []SessionProfile `json:"profiles"` // join of each session against the concatenation of all other sessions
+}
+
+// JoinSessions joins each session of a collection, such as separate
+// recordings, against the concatenation of all the other sessions using a
+// subsequence length of w. High values of a session profile mark behavior not
+// seen in any other session. The sessions are concatenated once so that the
+// rolling statistics and fourier transform are shared by every join, and
+// subsequences spanning two sessions are never used as nearest neighbors.
+// Every session must be at least w long.
+func JoinSessions(sessions [][]float64, w int) (*SessionJoin, error) {
+	if len(sessions) < 2 {
+		return nil, fmt.Errorf("must provide at least two sessions to join against each other, got %d", len(sessions))
+	}
+
+	sj := &SessionJoin{W: w, Starts: make([]int, len(sessions)), Profiles: make([]SessionProfile, len(sessions))}
+	var concat []float64
+	for s, ses := range sessions {
+		if len(ses) < w {
+			return nil, newError(ErrWindowTooLarge, "session %d of length %d is shorter than the subsequence length %d", s, len(ses), w)
+		}
+		sj.Starts[s] = len(concat)
+		concat = append(concat, ses...)
+	}
+
+	// an AB join of the concatenation with itself has no exclusion zone, so
+	// subsequences at the edges of neighboring sessions still match
+	mp, err := New(concat, concat, w)
+	if err != nil {
+		return nil, err
+	}
+	if err = mp.initCaches(); err != nil {
+		return nil, err
+	}
+
+	prof := make([]float64, len(concat)-w+1)
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for s, ses := range sessions {
+		n := len(ses) - w + 1
+		sp := SessionProfile{Session: make([]int, n)}
+		sp.MP, sp.Idx = initProfile(nil, nil, n, false)
+		for i := range sp.Session {
+			sp.Session[i] = -1
+		}
+
+		for i := 0; i < n; i++ {
+			err = mp.distanceProfile(sj.Starts[s]+i, prof, fft)
+			if Cause(err) == ErrZeroStd {
+				// constant subsequences match nothing
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			other := 0
+			for j, d := range prof {
+				for other+1 < len(sessions) && j >= sj.Starts[other+1] {
+					other++
+				}
+				// constant subsequences have no defined distance
+				if other == s || j+w > sj.Starts[other]+len(sessions[other]) || math.IsNaN(d) {
+					continue
+				}
+				if d < sp.MP[i] {
+					sp.MP[i], sp.Idx[i], sp.Session[i] = d, j-sj.Starts[other], other
+				}
+			}
+		}
+		sj.Profiles[s] = sp
+	}
+
+	return sj, nil
+}
@@ -0,0 +1,188 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestSessionMask(t *testing.T) {
+	testdata := []struct {
+		n, w       int
+		boundaries []int
+		expected   []bool
+	}{
+		{8, 3, nil, []bool{false, false, false, false, false, false}},
+		{8, 3, []int{4}, []bool{false, false, true, true, false, false}},
+		{8, 3, []int{1, 6}, []bool{true, false, false, false, true, true}},
+		{8, 3, []int{0}, nil},
+		{8, 3, []int{8}, nil},
+		{8, 9, nil, nil},
+	}
+
+	for _, d := range testdata {
+		mask, err := SessionMask(d.n, d.w, d.boundaries)
+		if d.expected == nil {
+			if err == nil {
+				t.Errorf("Expected an error for boundaries %v of %d points with a window of %d", d.boundaries, d.n, d.w)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(mask) != len(d.expected) {
+			t.Fatalf("Expected %d mask entries, but got %d", len(d.expected), len(mask))
+		}
+		for i := range mask {
+			if mask[i] != d.expected[i] {
+				t.Errorf("Expected mask %v for boundaries %v, but got %v", d.expected, d.boundaries, mask)
+				break
+			}
+		}
+	}
+}
+
+func TestComputeBoundaries(t *testing.T) {
+	sig := setupData(300)
+	w := 16
+	boundaries := []int{100, 200}
+	crosses := func(i int) bool {
+		return (i > 100-w && i < 100) || (i > 200-w && i < 200)
+	}
+
+	var expected *MatrixProfile
+	for _, algo := range []Algo{AlgoMPX, AlgoSTMP, AlgoSTAMP, AlgoSTOMP} {
+		mp, err := New(sig, nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.Boundaries = boundaries
+		o.LeftRight = true
+		o.Verify = true
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, while calculating %s", err, algo)
+		}
+
+		for i := range mp.MP {
+			if crosses(i) {
+				if !math.IsInf(mp.MP[i], 1) || mp.Idx[i] != math.MaxInt64 || !math.IsInf(mp.LMP[i], 1) || !math.IsInf(mp.RMP[i], 1) {
+					t.Errorf("Expected the subsequence at %d spanning a boundary to be skipped with %s, but got %.4f, %d", i, algo, mp.MP[i], mp.Idx[i])
+					break
+				}
+				continue
+			}
+			if math.IsInf(mp.MP[i], 1) || crosses(mp.Idx[i]) {
+				t.Errorf("Expected a nearest neighbor within a session for %d with %s, but got %d", i, algo, mp.Idx[i])
+				break
+			}
+		}
+
+		if expected == nil {
+			expected = mp
+			continue
+		}
+		for i := range mp.MP {
+			if math.Abs(mp.MP[i]-expected.MP[i]) > 1e-6 && !(math.IsInf(mp.MP[i], 1) && math.IsInf(expected.MP[i], 1)) {
+				t.Errorf("Expected %s to match mpx with session boundaries, but got %.6f instead of %.6f at %d", algo, mp.MP[i], expected.MP[i], i)
+				break
+			}
+		}
+	}
+
+	// neighbors in other sessions are allowed
+	found := false
+	for i, idx := range expected.Idx {
+		if idx != math.MaxInt64 && (i < 100) != (idx < 100) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Errorf("Expected nearest neighbors across sessions")
+	}
+
+	mp, err := New(sig[:150], sig[150:], w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Boundaries = []int{50}
+	if err = mp.Compute(o); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin, but got %v", err)
+	}
+
+	mp, err = New(sig, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Boundaries = []int{len(sig)}
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error for a boundary past the end of the time series")
+	}
+}
+
+func TestJoinSessions(t *testing.T) {
+	sessions := [][]float64{
+		siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.05, 200)),
+		siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 1.5), siggen.Noise(0.05, 150)),
+		siggen.Add(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.05, 200)),
+	}
+	sessions[2], _ = siggen.InjectCollectiveAnomaly(sessions[2], 120, 20, 3)
+	w := 20
+
+	sj, err := JoinSessions(sessions, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sj.Starts[0] != 0 || sj.Starts[1] != 200 || sj.Starts[2] != 350 {
+		t.Errorf("Expected session starts of [0 200 350], but got %v", sj.Starts)
+	}
+
+	for s, ses := range sessions {
+		sp := sj.Profiles[s]
+		if len(sp.MP) != len(ses)-w+1 || len(sp.Idx) != len(sp.MP) || len(sp.Session) != len(sp.MP) {
+			t.Fatalf("Expected profiles of length %d for session %d, but got %d", len(ses)-w+1, s, len(sp.MP))
+		}
+		for i := range sp.MP {
+			want := math.Inf(1)
+			for o, other := range sessions {
+				if o == s {
+					continue
+				}
+				prof, err := util.MassV2(ses[i:i+w], other)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = math.Min(want, floats.Min(prof))
+			}
+			if math.Abs(sp.MP[i]-want) > 1e-6 {
+				t.Errorf("Expected %.6f for subsequence %d of session %d, but got %.6f", want, i, s, sp.MP[i])
+				break
+			}
+			o := sp.Session[i]
+			if o == s || o < 0 || sp.Idx[i]+w > len(sessions[o]) {
+				t.Errorf("Expected a neighbor within another session for %d of session %d, but got %d in %d", i, s, sp.Idx[i], o)
+				break
+			}
+		}
+	}
+
+	// the anomaly was not seen in any other session
+	anomaly := floats.MaxIdx(sj.Profiles[2].MP)
+	if anomaly < 100 || anomaly > 140 {
+		t.Errorf("Expected the highest distance near the anomaly at 120, but got %d", anomaly)
+	}
+
+	if _, err = JoinSessions(sessions[:1], w); err == nil {
+		t.Errorf("Expected an error for a single session")
+	}
+	if _, err = JoinSessions([][]float64{sessions[0], sessions[1][:w-1]}, w); Cause(err) != ErrWindowTooLarge {
+		t.Errorf("Expected ErrWindowTooLarge for a session shorter than the subsequence length, but got %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package siggen
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+// InjectMotif returns a copy of the signal with the motif written over it
+// starting at each of the offsets. Offsets must place every copy of the motif
+// within the signal without overlapping another copy.
+func InjectMotif(sig, motif []float64, offsets []int) ([]float64, error) {
+	sorted := append([]int(nil), offsets...)
+	sort.Ints(sorted)
+	for i, off := range sorted {
+		if off < 0 || off+len(motif) > len(sig) {
+			return nil, fmt.Errorf("motif of length %d at offset %d does not fit in a signal of length %d", len(motif), off, len(sig))
+		}
+		if i > 0 && off < sorted[i-1]+len(motif) {
+			return nil, fmt.Errorf("motif at offset %d overlaps the motif at offset %d", off, sorted[i-1])
+		}
+	}
+
+	out := make([]float64, len(sig))
+	copy(out, sig)
+	for _, off := range offsets {
+		copy(out[off:], motif)
+	}
+	return out, nil
+}
+
+// RandomOffsets picks k random offsets in ascending order at which segments of
+// the given width fit within a signal of length n without overlapping.
+func RandomOffsets(n, width, k int) ([]int, error) {
+	if width <= 0 || k < 0 {
+		return nil, fmt.Errorf("width must be positive and k non-negative, got %d and %d", width, k)
+	}
+	free := n - k*width
+	if free < 0 {
+		return nil, fmt.Errorf("%d segments of width %d do not fit in a signal of length %d", k, width, n)
+	}
+
+	// distribute the free space randomly between the segments
+	gaps := make([]int, k)
+	for i := range gaps {
+		gaps[i] = rand.Intn(free + 1)
+	}
+	sort.Ints(gaps)
+
+	offsets := make([]int, k)
+	for i, g := range gaps {
+		offsets[i] = g + i*width
+	}
+	return offsets, nil
+}
+
+// InjectMotifRandom returns a copy of the signal with k copies of the motif
+// written over it at random non-overlapping offsets along with the offsets
+// used.
+func InjectMotifRandom(sig, motif []float64, k int) ([]float64, []int, error) {
+	offsets, err := RandomOffsets(len(sig), len(motif), k)
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := InjectMotif(sig, motif, offsets)
+	return out, offsets, err
+}
+
+// InjectPointAnomalies returns a copy of the signal with magnitude added to
+// the value at each index.
+func InjectPointAnomalies(sig []float64, idxs []int, magnitude float64) ([]float64, error) {
+	out := make([]float64, len(sig))
+	copy(out, sig)
+	for _, idx := range idxs {
+		if idx < 0 || idx >= len(sig) {
+			return nil, fmt.Errorf("anomaly index %d is beyond the signal of length %d", idx, len(sig))
+		}
+		out[idx] += magnitude
+	}
+	return out, nil
+}
+
+// InjectCollectiveAnomaly returns a copy of the signal with noise of the given
+// magnitude added to the segment of length n starting at start. The noise is
+// centered around 0 in the same manner as Noise.
+func InjectCollectiveAnomaly(sig []float64, start, n int, magnitude float64) ([]float64, error) {
+	if start < 0 || n < 0 || start+n > len(sig) {
+		return nil, fmt.Errorf("anomaly of length %d at %d does not fit in a signal of length %d", n, start, len(sig))
+	}
+	out := make([]float64, len(sig))
+	copy(out, sig)
+	for i, v := range Noise(magnitude, n) {
+		out[start+i] += v
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package siggen
+
+import (
+	"testing"
+)
+
+func TestInjectMotif(t *testing.T) {
+	motif := []float64{1, 2, 3}
+	testdata := []struct {
+		offsets     []int
+		expectedOut []float64
+	}{
+		{[]int{0, 5}, []float64{1, 2, 3, 0, 0, 1, 2, 3}},
+		{[]int{3}, []float64{0, 0, 0, 1, 2, 3, 0, 0}},
+		{[]int{}, []float64{0, 0, 0, 0, 0, 0, 0, 0}},
+		{[]int{6}, nil},
+		{[]int{-1}, nil},
+		{[]int{4, 2}, nil},
+	}
+
+	sig := Line(0, 0, 8)
+	for _, d := range testdata {
+		out, err := InjectMotif(sig, motif, d.offsets)
+		if d.expectedOut == nil {
+			if err == nil {
+				t.Errorf("expected an error for offsets %v", d.offsets)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("did not expect an error, %v, for offsets %v", err, d.offsets)
+			continue
+		}
+		for i, val := range out {
+			if val != d.expectedOut[i] {
+				t.Errorf("expected %v, but got %v for offsets %v", d.expectedOut, out, d.offsets)
+				break
+			}
+		}
+	}
+
+	for _, val := range sig {
+		if val != 0 {
+			t.Errorf("expected the input signal to be unmodified, but got %v", sig)
+			break
+		}
+	}
+}
+
+func TestInjectMotifRandom(t *testing.T) {
+	motif := []float64{1, 2, 3, 4}
+	for i := 0; i < 50; i++ {
+		out, offsets, err := InjectMotifRandom(Line(0, 0, 20), motif, 4)
+		if err != nil {
+			t.Fatalf("did not expect an error, %v", err)
+		}
+		if len(offsets) != 4 {
+			t.Fatalf("expected 4 offsets, but got %v", offsets)
+		}
+		for j, off := range offsets {
+			if j > 0 && off < offsets[j-1]+len(motif) {
+				t.Errorf("expected non-overlapping offsets, but got %v", offsets)
+			}
+			for k, val := range motif {
+				if out[off+k] != val {
+					t.Errorf("expected the motif at offset %d, but got %v", off, out[off:off+len(motif)])
+					break
+				}
+			}
+		}
+	}
+
+	if _, _, err := InjectMotifRandom(Line(0, 0, 20), motif, 6); err == nil {
+		t.Errorf("expected an error when the motifs do not fit")
+	}
+	if _, err := RandomOffsets(20, 0, 2); err == nil {
+		t.Errorf("expected an error for a width of 0")
+	}
+}
+
+func TestInjectPointAnomalies(t *testing.T) {
+	out, err := InjectPointAnomalies(Line(0, 1, 5), []int{1, 3}, 10)
+	if err != nil {
+		t.Fatalf("did not expect an error, %v", err)
+	}
+	expected := []float64{1, 11, 1, 11, 1}
+	for i, val := range out {
+		if val != expected[i] {
+			t.Errorf("expected %v, but got %v", expected, out)
+			break
+		}
+	}
+
+	if _, err = InjectPointAnomalies(Line(0, 1, 5), []int{5}, 10); err == nil {
+		t.Errorf("expected an error for an index beyond the signal")
+	}
+}
+
+func TestInjectCollectiveAnomaly(t *testing.T) {
+	sig := Line(0, 1, 10)
+	out, err := InjectCollectiveAnomaly(sig, 3, 4, 2)
+	if err != nil {
+		t.Fatalf("did not expect an error, %v", err)
+	}
+	for i, val := range out {
+		inside := i >= 3 && i < 7
+		if !inside && val != 1 {
+			t.Errorf("expected value of 1 outside the anomaly at index %d, but got %.3f", i, val)
+		}
+		if inside && (val < 0 || val > 2) {
+			t.Errorf("expected value within the anomaly magnitude at index %d, but got %.3f", i, val)
+		}
+	}
+
+	if _, err = InjectCollectiveAnomaly(sig, 8, 4, 2); err == nil {
+		t.Errorf("expected an error for an anomaly beyond the signal")
+	}
+}
@@ -93,6 +93,101 @@ func Noise(amp float64, n int) []float64 {
 	return out
 }
 
+// RandomWalk creates a random walk starting at 0 where each step adds the
+// drift and gaussian noise with a standard deviation of stepStd
+func RandomWalk(drift, stepStd float64, n int) []float64 {
+	out := make([]float64, n)
+	for i := 1; i < n; i++ {
+		out[i] = out[i-1] + drift + stepStd*rand.NormFloat64()
+	}
+	return out
+}
+
+// ARMA creates an autoregressive moving average process with the given
+// autoregressive and moving average coefficients driven by gaussian noise
+// with a standard deviation of noiseStd. ar[0] and ma[0] apply to a lag of 1.
+func ARMA(ar, ma []float64, noiseStd float64, n int) []float64 {
+	out := make([]float64, n)
+	noise := make([]float64, n)
+	for t := 0; t < n; t++ {
+		noise[t] = noiseStd * rand.NormFloat64()
+		out[t] = noise[t]
+		for i, c := range ar {
+			if t-i-1 >= 0 {
+				out[t] += c * out[t-i-1]
+			}
+		}
+		for j, c := range ma {
+			if t-j-1 >= 0 {
+				out[t] += c * noise[t-j-1]
+			}
+		}
+	}
+	return out
+}
+
+// ecgWave is a single wave of a heart beat modeled as a gaussian bump
+type ecgWave struct {
+	offset float64 // seconds from the R peak
+	amp    float64 // amplitude relative to the R peak
+	width  float64 // standard deviation in seconds
+}
+
+// ecgWaves are the P, Q, R, S and T waves of a heart beat
+var ecgWaves = []ecgWave{
+	{-0.2, 0.15, 0.025},
+	{-0.05, -0.15, 0.01},
+	{0, 1, 0.01},
+	{0.05, -0.25, 0.01},
+	{0.3, 0.3, 0.04},
+}
+
+// ECG creates an electrocardiogram like pulse train with a given amplitude,
+// heart rate in beats per minute, sampleRate and duration in seconds. Each
+// beat is made of the P, Q, R, S and T waves and the time between beats varies
+// randomly by up to the jitter fraction of a beat.
+func ECG(amp, heartRate, jitter, sampleRate, durationSec float64) []float64 {
+	nsamp := int(sampleRate * durationSec)
+	out := make([]float64, nsamp)
+	if heartRate <= 0 {
+		return out
+	}
+
+	beat := 60 / heartRate
+	for peak := beat / 2; peak < durationSec+beat; peak += beat * (1 + jitter*(2*rand.Float64()-1)) {
+		for _, w := range ecgWaves {
+			center := peak + w.offset
+			// only samples within 4 standard deviations contribute
+			start := int(math.Max(0, (center-4*w.width)*sampleRate))
+			end := int(math.Min(float64(nsamp), (center+4*w.width)*sampleRate+1))
+			for i := start; i < end; i++ {
+				x := (float64(i)/sampleRate - center) / w.width
+				out[i] += amp * w.amp * math.Exp(-x*x/2)
+			}
+		}
+	}
+	return out
+}
+
+// SquarePulses creates a train of square pulses with a given amplitude,
+// period and width in seconds, sampleRate and duration in seconds. The start
+// of each pulse is shifted randomly by up to jitter seconds.
+func SquarePulses(amp, period, width, jitter, sampleRate, durationSec float64) []float64 {
+	nsamp := int(sampleRate * durationSec)
+	out := make([]float64, nsamp)
+	if period <= 0 {
+		return out
+	}
+
+	for t := 0.0; t < durationSec; t += period {
+		start := t + jitter*(2*rand.Float64()-1)
+		for i := int(math.Max(0, math.Ceil(start*sampleRate))); i < nsamp && float64(i) < (start+width)*sampleRate; i++ {
+			out[i] = amp
+		}
+	}
+	return out
+}
+
 // Add adds one or more slices of floats together returning a signal
 // with a length equal to the longest signal passed in
 func Add(sig ...[]float64) []float64 {
@@ -1,6 +1,7 @@
 package siggen
 
 import (
+	"math"
 	"testing"
 )
 
@@ -171,3 +172,97 @@ func TestAppend(t *testing.T) {
 		}
 	}
 }
+
+func TestRandomWalk(t *testing.T) {
+	out := RandomWalk(1, 0, 5)
+	expected := []float64{0, 1, 2, 3, 4}
+	for i, val := range out {
+		if val != expected[i] {
+			t.Errorf("expected %v, but got %v", expected, out)
+			break
+		}
+	}
+
+	if out = RandomWalk(0, 1, 100); len(out) != 100 || out[0] != 0 {
+		t.Errorf("expected 100 values starting at 0, but got %d starting at %.3f", len(out), out[0])
+	}
+}
+
+func TestARMA(t *testing.T) {
+	testdata := []struct {
+		ar []float64
+		ma []float64
+	}{
+		{nil, nil},
+		{[]float64{0.5}, nil},
+		{nil, []float64{0.5}},
+		{[]float64{0.5, -0.2}, []float64{0.3}},
+	}
+
+	for _, d := range testdata {
+		out := ARMA(d.ar, d.ma, 0, 50)
+		if len(out) != 50 {
+			t.Errorf("expected output length, 50, but got, %d, for %v", len(out), d)
+		}
+		for _, val := range out {
+			if val != 0 {
+				t.Errorf("expected a process without noise to stay at 0, but got %v for %v", val, d)
+				break
+			}
+		}
+
+		out = ARMA(d.ar, d.ma, 1, 1000)
+		for _, val := range out {
+			if math.IsNaN(val) || math.Abs(val) > 100 {
+				t.Errorf("expected a stationary process, but got %v for %v", val, d)
+				break
+			}
+		}
+	}
+}
+
+func TestECG(t *testing.T) {
+	out := ECG(2, 60, 0, 100, 10)
+	if len(out) != 1000 {
+		t.Fatalf("expected output length, 1000, but got, %d", len(out))
+	}
+
+	// R peaks are half a beat in and then every second
+	for _, peak := range []int{50, 150, 950} {
+		if math.Abs(out[peak]-2) > 0.05 {
+			t.Errorf("expected an R peak of 2 at index %d, but got %.3f", peak, out[peak])
+		}
+	}
+	if math.Abs(out[100]) > 0.05 {
+		t.Errorf("expected a baseline between beats, but got %.3f", out[100])
+	}
+
+	if out = ECG(1, 0, 0, 100, 1); len(out) != 100 {
+		t.Errorf("expected output length, 100, but got, %d", len(out))
+	}
+}
+
+func TestSquarePulses(t *testing.T) {
+	out := SquarePulses(3, 1, 0.25, 0, 8, 2)
+	expected := []float64{3, 3, 0, 0, 0, 0, 0, 0, 3, 3, 0, 0, 0, 0, 0, 0}
+	if len(out) != len(expected) {
+		t.Fatalf("expected output length, %d, but got, %d", len(expected), len(out))
+	}
+	for i, val := range out {
+		if val != expected[i] {
+			t.Errorf("expected %v, but got %v", expected, out)
+			break
+		}
+	}
+
+	out = SquarePulses(1, 1, 0.25, 0.1, 100, 10)
+	var high int
+	for _, val := range out {
+		if val == 1 {
+			high++
+		}
+	}
+	if high < 200 || high > 260 {
+		t.Errorf("expected about 250 samples within pulses, but got %d", high)
+	}
+}
@@ -0,0 +1,97 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+)
+
+// Snippet is a representative segment of a time series along with the
+// fraction of the time series that it best represents.
+type Snippet struct {
+	Idx      int     // starting index of the snippet in the time series
+	Fraction float64 // fraction of the time series closer to this snippet than any other
+}
+
+// SnippetDiscovery finds the k most representative segments of length
+// snippetLen within a time series. Candidate snippets are the non-overlapping
+// segments of the time series and are compared against the rest of the time
+// series using MPDist with a subsequence window of half the snippet length.
+// Snippets are chosen greedily to minimize the total distance of every
+// position in the time series to its closest snippet. This is based on the
+// UCR paper, Matrix Profile XIII: Time Series Snippets, by Imani et al.
+func SnippetDiscovery(ts []float64, snippetLen, k int) ([]Snippet, error) {
+	w := snippetLen / 2
+	if w < 2 {
+		return nil, fmt.Errorf("snippet length must be at least 4, got %d", snippetLen)
+	}
+
+	if snippetLen*2 > len(ts) {
+		return nil, fmt.Errorf("snippet length, %d, must be at most half the length of the time series, %d", snippetLen, len(ts))
+	}
+
+	nSegments := len(ts) / snippetLen
+	if k < 1 || k > nSegments {
+		return nil, fmt.Errorf("number of snippets, %d, must be between 1 and the number of segments, %d", k, nSegments)
+	}
+
+	// compute the MPDist profile of each candidate segment against the full time series
+	profiles := make([][]float64, nSegments)
+	var err error
+	for i := 0; i < nSegments; i++ {
+		profiles[i], err = MPDistVector(ts[i*snippetLen:(i+1)*snippetLen], ts, w, snippetLen, nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// q tracks the distance of each position to its closest snippet found so far
+	q := make([]float64, len(profiles[0]))
+	for i := range q {
+		q[i] = math.Inf(1)
+	}
+
+	chosen := make([]int, 0, k)
+	used := make([]bool, nSegments)
+	for j := 0; j < k; j++ {
+		best, bestArea := -1, math.Inf(1)
+		for i, prof := range profiles {
+			if used[i] {
+				continue
+			}
+			var area float64
+			for x, d := range prof {
+				area += math.Min(d, q[x])
+			}
+			if area < bestArea {
+				best, bestArea = i, area
+			}
+		}
+
+		chosen = append(chosen, best)
+		used[best] = true
+		for x, d := range profiles[best] {
+			q[x] = math.Min(d, q[x])
+		}
+	}
+
+	// each position is attributed to the first snippet attaining the minimum distance
+	counts := make([]int, k)
+	for x := range q {
+		for j, i := range chosen {
+			if profiles[i][x] == q[x] {
+				counts[j]++
+				break
+			}
+		}
+	}
+
+	snippets := make([]Snippet, k)
+	for j, i := range chosen {
+		snippets[j] = Snippet{
+			Idx:      i * snippetLen,
+			Fraction: float64(counts[j]) / float64(len(q)),
+		}
+	}
+
+	return snippets, nil
+}
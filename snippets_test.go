@@ -0,0 +1,50 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestSnippetDiscovery(t *testing.T) {
+	sin := siggen.Sin(1, 4, 0, 0, 100, 3)
+	saw := siggen.Sawtooth(1, 4, 0, 0, 100, 3)
+	ts := siggen.Append(sin, saw)
+
+	snippets, err := SnippetDiscovery(ts, 50, 2)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	if len(snippets) != 2 {
+		t.Fatalf("Expected 2 snippets, but got %d", len(snippets))
+	}
+
+	var total float64
+	for _, s := range snippets {
+		total += s.Fraction
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Expected snippet fractions to sum to 1, but got %.3f", total)
+	}
+
+	if (snippets[0].Idx < len(sin)) == (snippets[1].Idx < len(sin)) {
+		t.Errorf("Expected one snippet from each regime, but got %+v", snippets)
+	}
+
+	testdata := []struct {
+		snippetLen int
+		k          int
+	}{
+		{3, 2},
+		{400, 2},
+		{50, 0},
+		{50, 20},
+	}
+	for _, d := range testdata {
+		if _, err = SnippetDiscovery(ts, d.snippetLen, d.k); err == nil {
+			t.Errorf("Expected an error for %+v", d)
+		}
+	}
+}
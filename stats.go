@@ -0,0 +1,92 @@
+package matrixprofile
+
+import (
+	"math"
+	"sort"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/stat"
+)
+
+// motifCorrelation is the minimum pearson correlation between a subsequence
+// and its nearest neighbor for the subsequence to count towards motif density
+const motifCorrelation = 0.9
+
+// ProfileStats is a compact summary of a computed matrix profile. All
+// distance based values are in the same units as the matrix profile and only
+// consider finite entries.
+type ProfileStats struct {
+	Length       int     `json:"length"`        // number of entries in the matrix profile
+	InfCount     int     `json:"inf_count"`     // number of +Inf or -Inf entries
+	NaNCount     int     `json:"nan_count"`     // number of NaN entries
+	Min          float64 `json:"min"`           // minimum finite value
+	Max          float64 `json:"max"`           // maximum finite value
+	Mean         float64 `json:"mean"`          // mean of finite values
+	Std          float64 `json:"std"`           // standard deviation of finite values
+	P05          float64 `json:"p05"`           // 5th percentile of finite values
+	P25          float64 `json:"p25"`           // 25th percentile of finite values
+	Median       float64 `json:"median"`        // 50th percentile of finite values
+	P75          float64 `json:"p75"`           // 75th percentile of finite values
+	P95          float64 `json:"p95"`           // 95th percentile of finite values
+	MotifDensity float64 `json:"motif_density"` // fraction of subsequences with a nearest neighbor correlated by at least 0.9
+	Complexity   float64 `json:"complexity"`    // complexity estimate of the z-normalized time series per sample
+}
+
+// Stats computes summary statistics of the current matrix profile so that
+// downstream consumers can reason about the profile without the full data.
+func (mp MatrixProfile) Stats() (*ProfileStats, error) {
+	if mp.MP == nil {
+		return nil, ErrNotComputed
+	}
+
+	s := &ProfileStats{Length: len(mp.MP)}
+
+	finite := make([]float64, 0, len(mp.MP))
+	for _, d := range mp.MP {
+		switch {
+		case math.IsNaN(d):
+			s.NaNCount++
+		case math.IsInf(d, 0):
+			s.InfCount++
+		default:
+			finite = append(finite, d)
+		}
+	}
+
+	if len(finite) > 0 {
+		sort.Float64s(finite)
+		s.Min = finite[0]
+		s.Max = finite[len(finite)-1]
+		s.Mean = stat.Mean(finite, nil)
+		if len(finite) > 1 {
+			s.Std = stat.StdDev(finite, nil)
+		}
+		s.P05 = stat.Quantile(0.05, stat.Empirical, finite, nil)
+		s.P25 = stat.Quantile(0.25, stat.Empirical, finite, nil)
+		s.Median = stat.Quantile(0.5, stat.Empirical, finite, nil)
+		s.P75 = stat.Quantile(0.75, stat.Empirical, finite, nil)
+		s.P95 = stat.Quantile(0.95, stat.Empirical, finite, nil)
+
+		var motifCount int
+		euclidean := mp.Opts == nil || mp.Opts.Euclidean
+		motifDist := math.Sqrt(2 * float64(mp.W) * (1 - motifCorrelation))
+		for _, d := range finite {
+			if (euclidean && d <= motifDist) || (!euclidean && d >= motifCorrelation) {
+				motifCount++
+			}
+		}
+		s.MotifDensity = float64(motifCount) / float64(len(mp.MP))
+	}
+
+	if len(mp.A) > 1 {
+		if norm, err := util.ZNormalize(mp.A); err == nil {
+			var ce float64
+			for i := 1; i < len(norm); i++ {
+				ce += (norm[i] - norm[i-1]) * (norm[i] - norm[i-1])
+			}
+			s.Complexity = math.Sqrt(ce / float64(len(norm)-1))
+		}
+	}
+
+	return s, nil
+}
@@ -0,0 +1,49 @@
+package matrixprofile
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	mp := MatrixProfile{}
+	if _, err := mp.Stats(); err == nil {
+		t.Errorf("Expected an error for an uncomputed matrix profile")
+	}
+
+	mp = MatrixProfile{
+		A:    []float64{0, 1, 0, 1, 0, 1, 0, 1},
+		W:    4,
+		MP:   []float64{0, 0.5, 1, 2, 3, math.Inf(1)},
+		Opts: NewMPOpts(),
+	}
+	s, err := mp.Stats()
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	if s.Length != 6 || s.InfCount != 1 || s.NaNCount != 0 {
+		t.Errorf("Expected length 6 with 1 inf entry, but got %+v", s)
+	}
+	if s.Min != 0 || s.Max != 3 {
+		t.Errorf("Expected min 0 and max 3, but got %.3f and %.3f", s.Min, s.Max)
+	}
+	if math.Abs(s.Mean-1.3) > 1e-9 {
+		t.Errorf("Expected mean 1.3, but got %.3f", s.Mean)
+	}
+	if s.Median != 1 {
+		t.Errorf("Expected median 1, but got %.3f", s.Median)
+	}
+	// motif distance threshold for a window of 4 is sqrt(0.8) ~ 0.894
+	if math.Abs(s.MotifDensity-2.0/6) > 1e-9 {
+		t.Errorf("Expected motif density %.3f, but got %.3f", 2.0/6, s.MotifDensity)
+	}
+	if math.Abs(s.Complexity-2) > 1e-9 {
+		t.Errorf("Expected complexity 2, but got %.3f", s.Complexity)
+	}
+
+	if _, err = json.Marshal(s); err != nil {
+		t.Errorf("Expected stats to be serializable, %v", err)
+	}
+}
@@ -0,0 +1,230 @@
+package matrixprofile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/util"
+	"gonum.org/v1/gonum/floats"
+)
+
+// UpdateB appends new values to the b time series of a computed AB join and
+// updates the profiles in place. This suits monitoring a live stream b against
+// a fixed reference a. Only the distance profile of each new subsequence of b
+// against a is computed, in O(len(a)) per point by updating the sliding dot
+// product of the previous subsequence. The distance from each new subsequence
+// to its nearest neighbor in a is appended to the profile indexed by b, the
+// BA join profile for MPX and the matrix profile otherwise. For MPX, the
+// matrix profile indexed by a is also updated with any closer match in b.
+// Only z-normalized, unweighted euclidean distances are supported.
+func (mp *MatrixProfile) UpdateB(newValues []float64) error {
+	if mp.SelfJoin {
+		return newError(ErrNotABJoin, "can only update b of an AB join, use Update for a self join")
+	}
+	if mp.Opts == nil || mp.MP == nil {
+		return ErrNotComputed
+	}
+	if err := mp.checkVerified(); err != nil {
+		return err
+	}
+	if !mp.Opts.Euclidean || mp.Opts.Weights != nil || mp.meanCentered() {
+		return errors.New("can only update b with z-normalized, unweighted euclidean distances")
+	}
+
+	lenA := len(mp.A) - mp.W + 1
+	if len(mp.AMean) != lenA {
+		var err error
+		if mp.AMean, mp.AStd, err = util.MovMeanStd(mp.A, mp.W); err != nil {
+			return err
+		}
+	}
+
+	byA := mp.indexedByA()
+	w := float64(mp.W)
+	dist := make([]float64, lenA)
+	for _, val := range newValues {
+		mp.B = append(mp.B, val)
+		mp.N++
+		k := len(mp.B) - mp.W
+
+		mp.updateBQT(k)
+		mu, sig, err := util.MovMeanStd(mp.B[k:], mp.W)
+		if err != nil {
+			return err
+		}
+		muB, sigB := mu[0], sig[0]
+
+		minVal, minIdx := math.Inf(1), math.MaxInt64
+		for j := range dist {
+			corr := (mp.bQT[j] - w*mp.AMean[j]*muB) / (w * mp.AStd[j] * sigB)
+			dist[j] = math.Sqrt(math.Max(0, 2*w*(1-math.Min(corr, 1))))
+			if dist[j] < minVal {
+				minVal, minIdx = dist[j], j
+			}
+		}
+
+		if byA {
+			mp.MPB = append(mp.MPB, minVal)
+			mp.IdxB = append(mp.IdxB, minIdx)
+			for j, d := range dist {
+				if closerDist(d, k, mp.MP[j], mp.Idx[j]) {
+					mp.MP[j], mp.Idx[j] = d, k
+				}
+			}
+		} else {
+			mp.MP = append(mp.MP, minVal)
+			mp.Idx = append(mp.Idx, minIdx)
+		}
+	}
+
+	// caches of b are derived again when next needed
+	mp.BMean, mp.BStd, mp.BF = nil, nil, nil
+	return nil
+}
+
+// updateBQT sets the cached sliding dot product to that of the subsequence of
+// b at k with every subsequence of a. The previous dot product is updated in
+// place if it was for the subsequence at k-1, otherwise it is computed from
+// scratch.
+func (mp *MatrixProfile) updateBQT(k int) {
+	lenA := len(mp.A) - mp.W + 1
+	q := mp.B[k : k+mp.W]
+
+	if len(mp.bQT) != lenA || mp.bQTIdx != k-1 {
+		mp.bQT = make([]float64, lenA)
+		for j := range mp.bQT {
+			mp.bQT[j] = floats.Dot(q, mp.A[j:j+mp.W])
+		}
+		mp.bQTIdx = k
+		return
+	}
+
+	for j := lenA - 1; j > 0; j-- {
+		mp.bQT[j] = mp.bQT[j-1] - mp.B[k-1]*mp.A[j-1] + mp.B[k+mp.W-1]*mp.A[j+mp.W-1]
+	}
+	mp.bQT[0] = floats.Dot(q, mp.A[:mp.W])
+	mp.bQTIdx = k
+}
+
+// UpdateWindow appends new values to the time series of a self join in the
+// same manner as Update while keeping at most size points, the most recent
+// ones, so that memory stays bounded on an endless stream. Evicted points are
+// dropped along with their profile entries and timestamps, and every index is
+// shifted so that it stays relative to the start of the retained time series.
+// Timestamps are dropped once they no longer cover every point.
+// Entries whose nearest neighbor was evicted are recomputed over the retained
+// time series, so the result matches computing the matrix profile of the last
+// size points from scratch. Only euclidean distances without a mask are
+// supported.
+func (mp *MatrixProfile) UpdateWindow(newValues []float64, size int) error {
+	if !mp.SelfJoin {
+		return newError(ErrNotSelfJoin, "can only update a sliding window of a self join")
+	}
+	if mp.Opts == nil || mp.MP == nil {
+		return ErrNotComputed
+	}
+	if !mp.Opts.Euclidean || mp.Opts.Mask != nil {
+		return errors.New("can only update a sliding window of euclidean distances without a mask")
+	}
+	if size < 2*mp.W {
+		return fmt.Errorf("window size must be at least twice the subsequence length, %d, got %d", 2*mp.W, size)
+	}
+
+	if err := mp.Update(newValues); err != nil {
+		return err
+	}
+	if len(mp.A) <= size {
+		return nil
+	}
+	if err := mp.evict(len(mp.A) - size); err != nil {
+		return err
+	}
+	if mp.Arcs != nil {
+		// every arc shifts along with the evicted points
+		mp.Arcs = NewArcCurve(mp.Idx)
+	}
+	return nil
+}
+
+// evict drops the first n points of a self join time series and shifts the
+// profiles and indexes to match. Entries that referenced an evicted
+// subsequence are recomputed against the retained time series.
+func (mp *MatrixProfile) evict(n int) error {
+	// timestamps are only kept while they still cover every point since
+	// Update does not extend them
+	if len(mp.Times) == len(mp.A) {
+		mp.Times = append([]time.Time(nil), mp.Times[n:]...)
+	} else {
+		mp.Times = nil
+	}
+
+	// copy the retained points so that the evicted ones can be freed
+	mp.A = append([]float64(nil), mp.A[n:]...)
+	mp.B = mp.A
+	mp.N = len(mp.A)
+
+	leftRight := mp.LMP != nil && len(mp.LMP) == len(mp.MP)
+	mp.MP = append([]float64(nil), mp.MP[n:]...)
+	mp.Idx = append([]int(nil), mp.Idx[n:]...)
+	if leftRight {
+		mp.LMP = append([]float64(nil), mp.LMP[n:]...)
+		mp.LIdx = append([]int(nil), mp.LIdx[n:]...)
+		mp.RMP = append([]float64(nil), mp.RMP[n:]...)
+		mp.RIdx = append([]int(nil), mp.RIdx[n:]...)
+	}
+
+	// right neighbors are always after their subsequence so are never evicted
+	var stale []int
+	for i := range mp.Idx {
+		isStale := false
+		if mp.Idx[i] != math.MaxInt64 {
+			mp.Idx[i] -= n
+			isStale = mp.Idx[i] < 0
+		}
+		if leftRight {
+			if mp.LIdx[i] != math.MaxInt64 {
+				mp.LIdx[i] -= n
+				isStale = isStale || mp.LIdx[i] < 0
+			}
+			if mp.RIdx[i] != math.MaxInt64 {
+				mp.RIdx[i] -= n
+			}
+		}
+		if isStale {
+			stale = append(stale, i)
+		}
+	}
+	mp.bQT = nil
+	if len(stale) == 0 {
+		mp.BMean, mp.BStd, mp.BF = nil, nil, nil
+		return nil
+	}
+
+	if err := mp.initCaches(); err != nil {
+		return err
+	}
+	profile := make([]float64, len(mp.MP))
+	fft := getFFT(mp.N)
+	defer putFFT(fft)
+	for _, i := range stale {
+		if err := mp.distanceProfile(i, profile, fft); err != nil {
+			return err
+		}
+
+		mp.MP[i], mp.Idx[i] = math.Inf(1), math.MaxInt64
+		if leftRight {
+			mp.LMP[i], mp.LIdx[i] = math.Inf(1), math.MaxInt64
+		}
+		for j, d := range profile {
+			if d < mp.MP[i] {
+				mp.MP[i], mp.Idx[i] = d, j
+			}
+			if leftRight && j < i && d < mp.LMP[i] {
+				mp.LMP[i], mp.LIdx[i] = d, j
+			}
+		}
+	}
+	return nil
+}
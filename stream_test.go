@@ -0,0 +1,154 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUpdateB(t *testing.T) {
+	a := setupData(150)
+	b := setupData(200)
+	w := 12
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		o := NewMPOpts()
+		o.Algorithm = algo
+
+		expected, err := New(a, b, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = expected.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		mp, err := New(a, append([]float64(nil), b[:120]...), w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+		// updates in several chunks reuse the cached sliding dot product
+		for _, chunk := range [][]float64{b[120:121], b[121:160], b[160:]} {
+			if err = mp.UpdateB(chunk); err != nil {
+				t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+			}
+		}
+
+		if mp.N != len(b) || len(mp.B) != len(b) {
+			t.Fatalf("Expected b of length %d, but got %d for %s", len(b), len(mp.B), algo)
+		}
+		compare := func(name string, got, want []float64) {
+			if len(got) != len(want) {
+				t.Errorf("Expected %s of length %d, but got %d for %s", name, len(want), len(got), algo)
+				return
+			}
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6 {
+					t.Errorf("Expected %s %.6f, but got %.6f at %d for %s", name, want[i], got[i], i, algo)
+					return
+				}
+			}
+		}
+		compare("matrix profile", mp.MP, expected.MP)
+		compare("ba join matrix profile", mp.MPB, expected.MPB)
+	}
+
+	mp, err := New(a, nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.UpdateB(b[:5]); err == nil {
+		t.Errorf("Expected an error for a self join")
+	}
+	mp, err = New(a, b, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.UpdateB(b[:5]); err == nil {
+		t.Errorf("Expected an error for a matrix profile that was not computed")
+	}
+	if err = mp.Compute(&MPOpts{Algorithm: AlgoSTOMP, SamplePct: 1, NJobs: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.UpdateB(b[:5]); err == nil {
+		t.Errorf("Expected an error for pearson correlation profiles")
+	}
+}
+
+func TestUpdateWindow(t *testing.T) {
+	ts := setupData(300)
+	w := 12
+	size := 100
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP} {
+		o := NewMPOpts()
+		o.Algorithm = algo
+		o.LeftRight = true
+
+		expected, err := New(ts[len(ts)-size:], nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = expected.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		mp, err := New(append([]float64(nil), ts[:80]...), nil, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = mp.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+		for _, chunk := range [][]float64{ts[80:81], ts[81:150], ts[150:151], ts[151:]} {
+			if err = mp.UpdateWindow(chunk, size); err != nil {
+				t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+			}
+			if len(mp.A) > size {
+				t.Fatalf("Expected at most %d points, but got %d for %s", size, len(mp.A), algo)
+			}
+		}
+
+		if mp.N != size || len(mp.MP) != size-w+1 {
+			t.Fatalf("Expected %d points and %d profile entries, but got %d and %d for %s", size, size-w+1, mp.N, len(mp.MP), algo)
+		}
+		compare := func(name string, got []float64, idx []int, want []float64) {
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6 {
+					t.Errorf("Expected %s %.6f, but got %.6f at %d for %s", name, want[i], got[i], i, algo)
+					return
+				}
+				if !math.IsInf(got[i], 1) && (idx[i] < 0 || idx[i] >= len(want)) {
+					t.Errorf("Expected %s index within the window, but got %d at %d for %s", name, idx[i], i, algo)
+					return
+				}
+			}
+		}
+		compare("matrix profile", mp.MP, mp.Idx, expected.MP)
+		compare("left matrix profile", mp.LMP, mp.LIdx, expected.LMP)
+		compare("right matrix profile", mp.RMP, mp.RIdx, expected.RMP)
+	}
+
+	mp, err := New(ts[:80], ts[:90], w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.UpdateWindow(ts[90:95], size); err == nil {
+		t.Errorf("Expected an error for an ab join")
+	}
+	mp, err = New(append([]float64(nil), ts[:80]...), nil, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.UpdateWindow(ts[80:85], w); err == nil {
+		t.Errorf("Expected an error for a window smaller than twice the subsequence length")
+	}
+}
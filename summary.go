@@ -0,0 +1,143 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WindowKind is the feature an interesting window was selected for
+type WindowKind string
+
+const (
+	WindowSegment WindowKind = "segment" // WindowSegment is centered on a boundary between regimes
+	WindowDiscord WindowKind = "discord" // WindowDiscord holds a discord
+	WindowMotif   WindowKind = "motif"   // WindowMotif holds a member of the closest pair of a motif group
+)
+
+// windowKinds is the order of the kinds among windows of the same rank
+var windowKinds = map[WindowKind]int{WindowSegment: 0, WindowDiscord: 1, WindowMotif: 2}
+
+// InterestingWindow is a range of the time series worth retaining, such as
+// for sampling or labeling, along with the feature it was selected for.
+type InterestingWindow struct {
+	Kind  WindowKind `json:"kind"`  // feature the window was selected for
+	Rank  int        `json:"rank"`  // rank of the feature among those of its kind, starting from 0 for the strongest
+	Idx   int        `json:"idx"`   // index of the subsequence or regime boundary the window was selected for
+	Start int        `json:"start"` // first point of the window including its context
+	End   int        `json:"end"`   // point after the last point of the window including its context
+}
+
+// WindowOpts are parameters to vary the windows returned by InterestingWindows
+type WindowOpts struct {
+	Motifs   int `json:"motifs"`   // number of motif groups whose closest pair is retained
+	Discords int `json:"discords"` // number of discords retained
+	Regimes  int `json:"regimes"`  // number of regimes whose boundaries are retained. Disabled if less than 2
+	Context  int `json:"context"`  // number of points retained on either side of each feature. Defaults to the subsequence length if 0
+}
+
+// NewWindowOpts returns a default WindowOpts which retains the top 3 motifs
+// and discords and the boundary of 2 regimes
+func NewWindowOpts() *WindowOpts {
+	return &WindowOpts{
+		Motifs:   3,
+		Discords: 3,
+		Regimes:  2,
+	}
+}
+
+// InterestingWindows summarizes where to look in a long time series as a
+// prioritized list of windows to retain. Regime boundaries, discords and the
+// closest pair of each motif group are discovered, each widened by the
+// context on both sides and clipped to the time series. The strongest
+// feature of every kind comes first, then the second strongest and so on,
+// with regime boundaries before discords before motifs within a rank. Windows
+// that fall entirely within a window earlier in the list are dropped. Only
+// applies to self joins.
+func (mp *MatrixProfile) InterestingWindows(o *WindowOpts) ([]InterestingWindow, error) {
+	if o == nil {
+		o = NewWindowOpts()
+	}
+	if o.Motifs < 0 || o.Discords < 0 || o.Context < 0 {
+		return nil, fmt.Errorf("number of motifs and discords and the context must be at least 0, got %d, %d and %d", o.Motifs, o.Discords, o.Context)
+	}
+	if !mp.SelfJoin {
+		return nil, newError(ErrNotSelfJoin, "can only find interesting windows of a self join")
+	}
+	if err := mp.ensureComputed(); err != nil {
+		return nil, err
+	}
+
+	context := o.Context
+	if context == 0 {
+		context = mp.W
+	}
+	window := func(kind WindowKind, rank, idx, length int) InterestingWindow {
+		iw := InterestingWindow{Kind: kind, Rank: rank, Idx: idx, Start: idx - context, End: idx + length + context}
+		if iw.Start < 0 {
+			iw.Start = 0
+		}
+		if iw.End > len(mp.A) {
+			iw.End = len(mp.A)
+		}
+		return iw
+	}
+
+	var windows []InterestingWindow
+	if o.Regimes > 1 {
+		regimes, err := mp.DiscoverRegimes(o.Regimes, 0)
+		if err != nil {
+			return nil, err
+		}
+		// the lowest corrected arc curve is the strongest boundary
+		sort.SliceStable(regimes, func(i, j int) bool { return regimes[i].Score < regimes[j].Score })
+		for rank, r := range regimes {
+			windows = append(windows, window(WindowSegment, rank, r.Idx, 0))
+		}
+	}
+	if o.Discords > 0 {
+		discords, err := mp.DiscoverDiscords(o.Discords, mp.W/2)
+		if err != nil {
+			return nil, err
+		}
+		for rank, idx := range discords {
+			windows = append(windows, window(WindowDiscord, rank, idx, mp.W))
+		}
+	}
+	if o.Motifs > 0 {
+		motifs, err := mp.DiscoverMotifs(o.Motifs, 2, 10, mp.W/2)
+		if err != nil {
+			return nil, err
+		}
+		for rank, mg := range motifs {
+			if len(mg.Idx) == 0 {
+				continue
+			}
+			windows = append(windows, window(WindowMotif, rank, mg.Center, mp.W))
+			if pair := mp.Idx[mg.Center]; pair >= 0 && pair < len(mp.MP) {
+				windows = append(windows, window(WindowMotif, rank, pair, mp.W))
+			}
+		}
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		if windows[i].Rank != windows[j].Rank {
+			return windows[i].Rank < windows[j].Rank
+		}
+		return windowKinds[windows[i].Kind] < windowKinds[windows[j].Kind]
+	})
+
+	out := make([]InterestingWindow, 0, len(windows))
+	for _, w := range windows {
+		covered := false
+		for _, prev := range out {
+			if w.Start >= prev.Start && w.End <= prev.End {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			out = append(out, w)
+		}
+	}
+	return out, nil
+}
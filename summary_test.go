@@ -0,0 +1,70 @@
+package matrixprofile
+
+import (
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestInterestingWindows(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 5), siggen.Sin(1, 10, 0, 0, 100, 5))
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+	sig, err := siggen.InjectCollectiveAnomaly(sig, 250, 20, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(NewMPOpts()); err != nil {
+		t.Fatal(err)
+	}
+
+	windows, err := mp.InterestingWindows(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(windows) == 0 {
+		t.Fatal("Expected interesting windows, but got none")
+	}
+
+	if windows[0].Kind != WindowSegment || windows[0].Idx < 450 || windows[0].Idx > 550 {
+		t.Errorf("Expected the regime boundary near 500 first, but got %+v", windows[0])
+	}
+	foundAnomaly := false
+	for i, w := range windows {
+		if w.Start < 0 || w.End > len(sig) || w.Start >= w.End {
+			t.Errorf("Expected a window within the time series, but got %+v", w)
+		}
+		if i > 0 && w.Rank < windows[i-1].Rank {
+			t.Errorf("Expected windows ordered by rank, but got %+v after %+v", w, windows[i-1])
+		}
+		for _, prev := range windows[:i] {
+			if w.Start >= prev.Start && w.End <= prev.End {
+				t.Errorf("Expected %+v to be dropped as it lies within %+v", w, prev)
+			}
+		}
+		if w.Kind == WindowDiscord && w.Start < 270 && w.End > 250 {
+			foundAnomaly = true
+		}
+	}
+	if !foundAnomaly {
+		t.Errorf("Expected a discord window over the anomaly at 250, but got %+v", windows)
+	}
+
+	o := NewWindowOpts()
+	o.Context = -1
+	if _, err = mp.InterestingWindows(o); err == nil {
+		t.Errorf("Expected an error for a negative context")
+	}
+
+	mp, err = New(sig[:500], sig[500:], 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.InterestingWindows(nil); Cause(err) != ErrNotSelfJoin {
+		t.Errorf("Expected ErrNotSelfJoin, but got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package matrixprofile
+
+import (
+	"errors"
+	"sort"
+)
+
+// IndexRange is a run of consecutive subsequence indexes.
+type IndexRange struct {
+	Start int `json:"start"` // first index of the run
+	End   int `json:"end"`   // index after the last index of the run
+}
+
+// IndicesAboveCorrelation returns the index of every subsequence whose
+// pearson correlation with its nearest neighbor is greater than r in
+// ascending order. The correlations kept with the KeepCorr option are used if
+// available. Otherwise they are derived from the matrix profile, which
+// requires z-normalized distances without weights.
+func (mp MatrixProfile) IndicesAboveCorrelation(r float64) ([]int, error) {
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+
+	corr := mp.Corr
+	if len(corr) != len(mp.MP) {
+		if mp.meanCentered() || mp.Opts != nil && mp.Opts.Weights != nil {
+			return nil, errors.New("correlations are only defined for z-normalized distances without weights")
+		}
+		corr = mp.MP
+		if mp.Opts == nil || mp.Opts.Euclidean {
+			corr = append([]float64(nil), mp.MP...)
+			euclideanToPearson(corr, mp.W)
+		}
+	}
+
+	var idx []int
+	for i, c := range corr {
+		// unset entries of +Inf have no neighbor
+		if c > r && c <= 1 {
+			idx = append(idx, i)
+		}
+	}
+	return idx, nil
+}
+
+// IndicesBelowDistance returns the index of every subsequence whose euclidean
+// distance to its nearest neighbor is less than d in ascending order. Matrix
+// profiles of pearson correlations are converted to z-normalized euclidean
+// distances first.
+func (mp MatrixProfile) IndicesBelowDistance(d float64) ([]int, error) {
+	if err := mp.checkComputed(); err != nil {
+		return nil, err
+	}
+
+	dist := mp.MP
+	if mp.Opts != nil && !mp.Opts.Euclidean {
+		dist = append([]float64(nil), mp.MP...)
+		pearsonToEuclidean(dist, mp.W)
+	}
+
+	var idx []int
+	for i, v := range dist {
+		if v < d {
+			idx = append(idx, i)
+		}
+	}
+	return idx, nil
+}
+
+// MergeIndices merges indexes, such as from IndicesAboveCorrelation or
+// IndicesBelowDistance, into runs of consecutive indexes. The indexes don't
+// need to be sorted and duplicates are ignored.
+func MergeIndices(idx []int) []IndexRange {
+	if len(idx) == 0 {
+		return nil
+	}
+	sorted := append([]int(nil), idx...)
+	sort.Ints(sorted)
+
+	ranges := []IndexRange{{Start: sorted[0], End: sorted[0] + 1}}
+	for _, i := range sorted[1:] {
+		last := &ranges[len(ranges)-1]
+		if i <= last.End {
+			if i == last.End {
+				last.End++
+			}
+			continue
+		}
+		ranges = append(ranges, IndexRange{Start: i, End: i + 1})
+	}
+	return ranges
+}
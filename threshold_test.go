@@ -0,0 +1,90 @@
+package matrixprofile
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestIndicesThreshold(t *testing.T) {
+	inf := math.Inf(1)
+	a := []float64{0, 1, 2, 3, 4, 5, 6, 7}
+	mp := MatrixProfile{A: a, B: a, W: 4, SelfJoin: true, Opts: NewMPOpts()}
+
+	if _, err := mp.IndicesBelowDistance(1); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed, but got %v", err)
+	}
+	if _, err := mp.IndicesAboveCorrelation(0.9); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed, but got %v", err)
+	}
+
+	// distances of 0, 0.5, 1 and 2 for a window of 4 are correlations of
+	// 1, 0.96875, 0.875 and 0.5
+	mp.MP = []float64{0.5, 0, inf, 2, 1}
+	mp.Idx = []int{4, 3, math.MaxInt64, 1, 0}
+
+	testdata := []struct {
+		euclidean bool
+		dist      float64
+		corr      float64
+		expected  []int
+	}{
+		{true, 1, 0.875, []int{0, 1}},
+		{true, 1.5, 0.6, []int{0, 1, 4}},
+		{true, 0, 1, nil},
+		{false, 1, 0.875, []int{0, 1}},
+		{false, 3, -1, []int{0, 1, 3, 4}},
+	}
+
+	for _, d := range testdata {
+		mp.Opts.Euclidean = d.euclidean
+		mp.MP = []float64{0.5, 0, inf, 2, 1}
+		if !d.euclidean {
+			euclideanToPearson(mp.MP, mp.W)
+		}
+
+		idx, err := mp.IndicesBelowDistance(d.dist)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(idx, d.expected) {
+			t.Errorf("Expected %v below a distance of %.3f, but got %v for %+v", d.expected, d.dist, idx, d)
+		}
+		if idx, err = mp.IndicesAboveCorrelation(d.corr); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(idx, d.expected) {
+			t.Errorf("Expected %v above a correlation of %.3f, but got %v for %+v", d.expected, d.corr, idx, d)
+		}
+	}
+
+	// kept correlations take precedence over the matrix profile
+	mp.Corr = []float64{0.1, 0.99, inf, 0.97, 0.2}
+	if idx, _ := mp.IndicesAboveCorrelation(0.95); !reflect.DeepEqual(idx, []int{1, 3}) {
+		t.Errorf("Expected the kept correlations to be used, but got %v", idx)
+	}
+	mp.Corr = nil
+
+	mp.Opts.Normalization = NormMean
+	if _, err := mp.IndicesAboveCorrelation(0.9); err == nil {
+		t.Errorf("Expected an error for correlations of mean-centered distances")
+	}
+}
+
+func TestMergeIndices(t *testing.T) {
+	testdata := []struct {
+		idx      []int
+		expected []IndexRange
+	}{
+		{nil, nil},
+		{[]int{3}, []IndexRange{{3, 4}}},
+		{[]int{0, 1, 2, 5, 6, 9}, []IndexRange{{0, 3}, {5, 7}, {9, 10}}},
+		{[]int{6, 5, 1, 5, 0}, []IndexRange{{0, 2}, {5, 7}}},
+	}
+
+	for _, d := range testdata {
+		if out := MergeIndices(d.idx); !reflect.DeepEqual(out, d.expected) {
+			t.Errorf("Expected %v, but got %v for %v", d.expected, out, d.idx)
+		}
+	}
+}
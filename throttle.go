@@ -0,0 +1,51 @@
+package matrixprofile
+
+import (
+	"runtime"
+	"time"
+)
+
+// throttleSlice is how long a worker computes before pausing to keep within
+// the cpu limit. Longer slices mean fewer and coarser sleeps.
+const throttleSlice = 10 * time.Millisecond
+
+// throttle keeps a worker busy for at most a fraction of the time by sleeping
+// between units of work. A nil throttle never sleeps.
+type throttle struct {
+	duty  float64   // fraction of the time the worker may be busy
+	start time.Time // start of the current busy period
+}
+
+// newThrottle returns a throttle for each of the given number of concurrent
+// workers so that together they stay within MaxCPUPercent of the total cpu
+// capacity. Workers beyond the number of cpus take turns on them, so the
+// duty is split across all workers. Returns nil if no limit is set or the
+// workers can't exceed it.
+func (mp MatrixProfile) newThrottle(workers int) *throttle {
+	if mp.Opts == nil || mp.Opts.MaxCPUPercent <= 0 {
+		return nil
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	duty := mp.Opts.MaxCPUPercent / 100 * float64(runtime.NumCPU()) / float64(workers)
+	if duty >= 1 {
+		return nil
+	}
+	return &throttle{duty: duty, start: time.Now()}
+}
+
+// pause sleeps once the worker has been busy for a full slice, long enough
+// that the time spent busy is the duty fraction of the total time
+func (t *throttle) pause() {
+	if t == nil {
+		return
+	}
+	busy := time.Since(t.start)
+	if busy < throttleSlice {
+		return
+	}
+	time.Sleep(time.Duration(float64(busy) * (1 - t.duty) / t.duty))
+	t.start = time.Now()
+}
@@ -0,0 +1,99 @@
+package matrixprofile
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewThrottle(t *testing.T) {
+	ncpu := float64(runtime.NumCPU())
+
+	testdata := []struct {
+		pct          float64
+		workers      int
+		expectedDuty float64
+	}{
+		{0, 1, 0},
+		{100, 1, 0},
+		{100, runtime.NumCPU(), 0},
+		{50, runtime.NumCPU(), 0.5},
+		{50, 2 * runtime.NumCPU(), 0.25},
+		{10, 1, 0.1 * ncpu},
+	}
+
+	for _, d := range testdata {
+		mp := MatrixProfile{Opts: &MPOpts{MaxCPUPercent: d.pct}}
+		thr := mp.newThrottle(d.workers)
+		switch {
+		case d.expectedDuty == 0 || d.expectedDuty >= 1:
+			if thr != nil {
+				t.Errorf("Expected no throttle, but got a duty of %.3f for %+v", thr.duty, d)
+			}
+		case thr == nil:
+			t.Errorf("Expected a throttle with a duty of %.3f for %+v", d.expectedDuty, d)
+		case thr.duty != d.expectedDuty:
+			t.Errorf("Expected a duty of %.3f, but got %.3f for %+v", d.expectedDuty, thr.duty, d)
+		}
+	}
+}
+
+func TestThrottlePause(t *testing.T) {
+	var thr *throttle
+	thr.pause()
+
+	thr = &throttle{duty: 0.5, start: time.Now()}
+	thr.pause()
+	if time.Since(thr.start) > throttleSlice {
+		t.Errorf("Expected no pause before a full slice of work")
+	}
+
+	// busy for twice the slice at a duty of 0.5 sleeps at least as long
+	thr.start = time.Now().Add(-2 * throttleSlice)
+	start := time.Now()
+	thr.pause()
+	if elapsed := time.Since(start); elapsed < 2*throttleSlice {
+		t.Errorf("Expected a pause of at least %v, but got %v", 2*throttleSlice, elapsed)
+	}
+}
+
+func TestComputeMaxCPUPercent(t *testing.T) {
+	a := setupData(300)
+
+	for _, algo := range []Algo{AlgoMPX, AlgoSTOMP, AlgoSTAMP, AlgoSTMP} {
+		exact, err := New(a, nil, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = algo
+		if err = exact.Compute(o); err != nil {
+			t.Fatal(err)
+		}
+
+		mp, err := New(a, nil, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.MaxCPUPercent = 1
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, for %s", err, algo)
+		}
+		for i := range exact.MP {
+			if mp.MP[i] != exact.MP[i] {
+				t.Errorf("Expected the same matrix profile with a cpu limit for %s", algo)
+				break
+			}
+		}
+	}
+
+	mp, err := New(a, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.MaxCPUPercent = 101
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error for a cpu limit above 100 percent")
+	}
+}
@@ -0,0 +1,245 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// TimeSeries is a set of values along with the timestamp each value was
+// observed at. Missing values are represented by NaN.
+type TimeSeries struct {
+	Times  []time.Time `json:"times"`  // timestamp of each value in strictly increasing order
+	Values []float64   `json:"values"` // observed values
+}
+
+// NewTimeSeries creates a time series from a set of timestamps and values of
+// equal length. The timestamps must be strictly increasing.
+func NewTimeSeries(times []time.Time, values []float64) (*TimeSeries, error) {
+	if len(times) != len(values) {
+		return nil, fmt.Errorf("number of timestamps, %d, must match the number of values, %d", len(times), len(values))
+	}
+	for i := 1; i < len(times); i++ {
+		if !times[i].After(times[i-1]) {
+			return nil, fmt.Errorf("timestamps must be strictly increasing, %v at %d is not after %v", times[i], i, times[i-1])
+		}
+	}
+	return &TimeSeries{Times: times, Values: values}, nil
+}
+
+// Step returns the sampling interval of the time series and whether every
+// pair of consecutive timestamps is that interval apart with no missing
+// values. A matrix profile is only meaningful over a regular time series.
+func (ts TimeSeries) Step() (time.Duration, bool) {
+	if len(ts.Times) < 2 {
+		return 0, false
+	}
+	step := ts.Times[1].Sub(ts.Times[0])
+	for i := range ts.Times {
+		if i > 0 && ts.Times[i].Sub(ts.Times[i-1]) != step {
+			return step, false
+		}
+		if math.IsNaN(ts.Values[i]) {
+			return step, false
+		}
+	}
+	return step, true
+}
+
+// FillMethod is the method used to fill in values while resampling
+type FillMethod string
+
+const (
+	// FillLinear linearly interpolates between the surrounding observations
+	FillLinear FillMethod = "linear"
+
+	// FillLOCF carries the last observation forward
+	FillLOCF FillMethod = "locf"
+)
+
+// Resample returns a regular time series with values every step starting from
+// the first timestamp up to the last timestamp. Values at each new timestamp
+// are filled in from the non missing observations with the given method.
+// Timestamps before the first observation take on its value.
+func (ts TimeSeries) Resample(step time.Duration, method FillMethod) (*TimeSeries, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("resampling step must be positive, got %v", step)
+	}
+	if method != FillLinear && method != FillLOCF {
+		return nil, fmt.Errorf("unsupported fill method, %s", method)
+	}
+
+	// only observed values are used to fill in the new timestamps
+	var times []time.Time
+	var values []float64
+	for i, v := range ts.Values {
+		if !math.IsNaN(v) {
+			times = append(times, ts.Times[i])
+			values = append(values, v)
+		}
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("time series has no observed values to resample")
+	}
+
+	start, end := ts.Times[0], ts.Times[len(ts.Times)-1]
+	n := int(end.Sub(start)/step) + 1
+	out := &TimeSeries{
+		Times:  make([]time.Time, n),
+		Values: make([]float64, n),
+	}
+
+	// j is the index of the last observation at or before the current timestamp
+	j := -1
+	for i := 0; i < n; i++ {
+		t := start.Add(time.Duration(i) * step)
+		for j+1 < len(times) && !times[j+1].After(t) {
+			j++
+		}
+		out.Times[i] = t
+
+		switch {
+		case j < 0:
+			out.Values[i] = values[0]
+		case method == FillLOCF || j == len(times)-1 || times[j].Equal(t):
+			out.Values[i] = values[j]
+		default:
+			frac := float64(t.Sub(times[j])) / float64(times[j+1].Sub(times[j]))
+			out.Values[i] = values[j] + frac*(values[j+1]-values[j])
+		}
+	}
+
+	return out, nil
+}
+
+// timesOf returns the timestamps at each index or nil if no timestamps are set.
+// Indexes beyond the timestamps, such as unset profile indexes, are reported
+// as the zero time.
+func timesOf(times []time.Time, idxs []int) []time.Time {
+	if times == nil {
+		return nil
+	}
+	out := make([]time.Time, len(idxs))
+	for i, idx := range idxs {
+		if idx >= 0 && idx < len(times) {
+			out[i] = times[idx]
+		}
+	}
+	return out
+}
+
+// alignTimes returns the index in a and b of the first timestamp shared by
+// both, which is the later of their first timestamps. Both must be strictly
+// increasing. Returns false if that timestamp is missing from the other.
+func alignTimes(a, b []time.Time) (int, int, bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, false
+	}
+	if a[0].After(b[0]) {
+		j, i, ok := alignTimes(b, a)
+		return i, j, ok
+	}
+	j := sort.Search(len(a), func(i int) bool { return !a[i].Before(b[0]) })
+	if j == len(a) || !a[j].Equal(b[0]) {
+		return 0, 0, false
+	}
+	return j, 0, true
+}
+
+// regularValues returns the values of a time series after checking that it is
+// regularly sampled
+func regularValues(ts *TimeSeries) ([]float64, error) {
+	if ts == nil {
+		return nil, nil
+	}
+	if _, ok := ts.Step(); !ok {
+		return nil, fmt.Errorf("time series must be regularly sampled without missing values, resample it first")
+	}
+	return ts.Values, nil
+}
+
+// NewFromTimeSeries creates a matrix profile in the same manner as New from
+// regularly sampled time series. If b is nil, then a self join on a is
+// performed. The timestamps of a are kept so that discovered features can be
+// reported as timestamps.
+func NewFromTimeSeries(a, b *TimeSeries, w int) (*MatrixProfile, error) {
+	if a == nil {
+		return nil, newError(ErrEmptySeries, "first time series is nil")
+	}
+	aVals, err := regularValues(a)
+	if err != nil {
+		return nil, err
+	}
+	bVals, err := regularValues(b)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, err := New(aVals, bVals, w)
+	if err != nil {
+		return nil, err
+	}
+	mp.Times = a.Times
+	return mp, nil
+}
+
+// NewPMPFromTimeSeries creates a pan matrix profile in the same manner as
+// NewPMP from regularly sampled time series. If b is nil, then a self join on a
+// is performed.
+func NewPMPFromTimeSeries(a, b *TimeSeries, lowerM, upperM int) (*PMP, error) {
+	if a == nil {
+		return nil, newError(ErrEmptySeries, "first time series is nil")
+	}
+	aVals, err := regularValues(a)
+	if err != nil {
+		return nil, err
+	}
+	bVals, err := regularValues(b)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := NewPMP(aVals, bVals, lowerM, upperM)
+	if err != nil {
+		return nil, err
+	}
+	p.Times = a.Times
+	return p, nil
+}
+
+// NewKMPFromTimeSeries creates a k-dimensional matrix profile in the same
+// manner as NewKMP from regularly sampled time series. Every dimension must
+// share the same timestamps.
+func NewKMPFromTimeSeries(t []*TimeSeries, w int) (*KMP, error) {
+	if len(t) == 0 {
+		return nil, newError(ErrEmptySeries, "slice is nil or has a length of 0 dimensions")
+	}
+
+	vals := make([][]float64, len(t))
+	var step time.Duration
+	for d, ts := range t {
+		if ts == nil {
+			return nil, fmt.Errorf("time series %d is nil", d)
+		}
+		v, err := regularValues(ts)
+		if err != nil {
+			return nil, fmt.Errorf("time series %d, %v", d, err)
+		}
+		s, _ := ts.Step()
+		if d == 0 {
+			step = s
+		}
+		if s != step || len(ts.Times) != len(t[0].Times) || !ts.Times[0].Equal(t[0].Times[0]) {
+			return nil, fmt.Errorf("time series %d does not share the timestamps of the first time series", d)
+		}
+		vals[d] = v
+	}
+
+	k, err := NewKMP(vals, w)
+	if err != nil {
+		return nil, err
+	}
+	k.Times = t[0].Times
+	return k, nil
+}
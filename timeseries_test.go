@@ -0,0 +1,194 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func timestamps(start time.Time, step time.Duration, offsets ...int) []time.Time {
+	out := make([]time.Time, len(offsets))
+	for i, o := range offsets {
+		out[i] = start.Add(time.Duration(o) * step)
+	}
+	return out
+}
+
+func TestNewTimeSeries(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testdata := []struct {
+		times       []time.Time
+		values      []float64
+		expectedErr bool
+	}{
+		{timestamps(start, time.Minute, 0, 1, 2), []float64{1, 2, 3}, false},
+		{timestamps(start, time.Minute, 0, 1), []float64{1, 2, 3}, true},
+		{timestamps(start, time.Minute, 0, 2, 1), []float64{1, 2, 3}, true},
+		{timestamps(start, time.Minute, 0, 1, 1), []float64{1, 2, 3}, true},
+	}
+
+	for _, d := range testdata {
+		_, err := NewTimeSeries(d.times, d.values)
+		if d.expectedErr && err == nil {
+			t.Errorf("Expected an error for %v", d.times)
+		}
+		if !d.expectedErr && err != nil {
+			t.Errorf("Did not expect an error, %v, for %v", err, d.times)
+		}
+	}
+}
+
+func TestTimeSeriesStep(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	testdata := []struct {
+		ts       TimeSeries
+		expected bool
+	}{
+		{TimeSeries{timestamps(start, time.Second, 0, 1, 2, 3), []float64{1, 2, 3, 4}}, true},
+		{TimeSeries{timestamps(start, time.Second, 0, 1, 3, 4), []float64{1, 2, 3, 4}}, false},
+		{TimeSeries{timestamps(start, time.Second, 0, 1, 2, 3), []float64{1, math.NaN(), 3, 4}}, false},
+		{TimeSeries{timestamps(start, time.Second, 0), []float64{1}}, false},
+	}
+
+	for _, d := range testdata {
+		step, ok := d.ts.Step()
+		if ok != d.expected {
+			t.Errorf("Expected regular sampling to be %v, but got %v for %v", d.expected, ok, d.ts)
+		}
+		if ok && step != time.Second {
+			t.Errorf("Expected a step of %v, but got %v", time.Second, step)
+		}
+	}
+}
+
+func TestTimeSeriesResample(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ts := TimeSeries{
+		Times:  timestamps(start, time.Minute, 0, 1, 4, 5, 6),
+		Values: []float64{math.NaN(), 2, 8, math.NaN(), 4},
+	}
+
+	testdata := []struct {
+		method      FillMethod
+		step        time.Duration
+		expected    []float64
+		expectedErr bool
+	}{
+		{FillLinear, time.Minute, []float64{2, 2, 4, 6, 8, 6, 4}, false},
+		{FillLOCF, time.Minute, []float64{2, 2, 2, 2, 8, 8, 4}, false},
+		{FillLinear, 2 * time.Minute, []float64{2, 4, 8, 4}, false},
+		{FillLinear, 0, nil, true},
+		{"spline", time.Minute, nil, true},
+	}
+
+	for _, d := range testdata {
+		out, err := ts.Resample(d.step, d.method)
+		if d.expectedErr {
+			if err == nil {
+				t.Errorf("Expected an error for %s every %v", d.method, d.step)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %s every %v", err, d.method, d.step)
+			continue
+		}
+		if len(out.Values) != len(d.expected) {
+			t.Errorf("Expected %v, but got %v for %s every %v", d.expected, out.Values, d.method, d.step)
+			continue
+		}
+		for i, v := range out.Values {
+			if math.Abs(v-d.expected[i]) > 1e-9 {
+				t.Errorf("Expected %v, but got %v for %s every %v", d.expected, out.Values, d.method, d.step)
+				break
+			}
+			if !out.Times[i].Equal(start.Add(time.Duration(i) * d.step)) {
+				t.Errorf("Expected timestamp %v, but got %v", start.Add(time.Duration(i)*d.step), out.Times[i])
+			}
+		}
+		if _, ok := out.Step(); !ok {
+			t.Errorf("Expected a regularly sampled time series for %s every %v", d.method, d.step)
+		}
+	}
+}
+
+func TestNewFromTimeSeries(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sig := setupData(200)
+	times := make([]time.Time, len(sig))
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * time.Hour)
+	}
+	ts := &TimeSeries{Times: times, Values: sig}
+
+	mp, err := NewFromTimeSeries(ts, nil, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	ao := NewAnalyzeOpts()
+	ao.OutputFilename = ""
+	res, err := mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+
+	if len(res.MotifTimes) != len(res.Motifs) {
+		t.Fatalf("Expected %d motif timestamps, but got %d", len(res.Motifs), len(res.MotifTimes))
+	}
+	for i, m := range res.Motifs {
+		for j, idx := range m.Idx {
+			if !res.MotifTimes[i][j].Equal(times[idx]) {
+				t.Errorf("Expected motif timestamp %v, but got %v", times[idx], res.MotifTimes[i][j])
+			}
+		}
+	}
+	for i, idx := range res.Discords {
+		if !res.DiscordTimes[i].Equal(times[idx]) {
+			t.Errorf("Expected discord timestamp %v, but got %v", times[idx], res.DiscordTimes[i])
+		}
+	}
+	if res.SegmentTime == nil || !res.SegmentTime.Equal(times[res.SegmentIdx]) {
+		t.Errorf("Expected segment timestamp %v, but got %v", times[res.SegmentIdx], res.SegmentTime)
+	}
+
+	// irregular time series must be resampled first
+	irregular := &TimeSeries{Times: append([]time.Time{}, times...), Values: sig}
+	irregular.Times[10] = irregular.Times[10].Add(time.Minute)
+	if _, err = NewFromTimeSeries(irregular, nil, 16); err == nil {
+		t.Errorf("Expected an error for an irregular time series")
+	}
+	if _, err = NewPMPFromTimeSeries(irregular, nil, 16, 20); err == nil {
+		t.Errorf("Expected an error for an irregular time series")
+	}
+
+	p, err := NewPMPFromTimeSeries(ts, nil, 16, 20)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(p.Times) != len(times) {
+		t.Errorf("Expected %d timestamps, but got %d", len(times), len(p.Times))
+	}
+
+	k, err := NewKMPFromTimeSeries([]*TimeSeries{ts, ts}, 16)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(k.Times) != len(times) {
+		t.Errorf("Expected %d timestamps, but got %d", len(times), len(k.Times))
+	}
+
+	shifted := &TimeSeries{Times: timestamps(start.Add(time.Hour), time.Hour, makeRange(len(sig))...), Values: sig}
+	if _, err = NewKMPFromTimeSeries([]*TimeSeries{ts, shifted}, 16); err == nil {
+		t.Errorf("Expected an error for dimensions with different timestamps")
+	}
+}
+
+func makeRange(n int) []int {
+	out := make([]int, n)
+	for i := range out {
+		out[i] = i
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package util
+
+import (
+	"fmt"
+	"math"
+)
+
+// DTW computes the dynamic time warping distance between two series of the
+// same length constrained to a Sakoe-Chiba band, where the i-th point of a can
+// only be aligned with points of b at most band positions away. The cost of an
+// alignment is the square root of the sum of squared differences along the
+// warping path, so a band of 0 gives the euclidean distance. This takes
+// O(len(a)*band) comparisons and is meant for short subsequences.
+func DTW(a, b []float64, band int) (float64, error) {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0, fmt.Errorf("dtw requires two non-empty series of the same length, got %d and %d", len(a), len(b))
+	}
+	if band < 0 {
+		return 0, fmt.Errorf("sakoe-chiba band must be at least 0, got %d", band)
+	}
+
+	n := len(a)
+	prev := make([]float64, n+1)
+	cur := make([]float64, n+1)
+	for j := range prev {
+		prev[j] = math.Inf(1)
+	}
+	prev[0] = 0
+
+	for i := 1; i <= n; i++ {
+		for j := range cur {
+			cur[j] = math.Inf(1)
+		}
+		lo, hi := i-band, i+band
+		if lo < 1 {
+			lo = 1
+		}
+		if hi > n {
+			hi = n
+		}
+		for j := lo; j <= hi; j++ {
+			d := a[i-1] - b[j-1]
+			cur[j] = d*d + math.Min(prev[j-1], math.Min(prev[j], cur[j-1]))
+		}
+		prev, cur = cur, prev
+	}
+	return math.Sqrt(prev[n]), nil
+}
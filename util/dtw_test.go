@@ -0,0 +1,42 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDTW(t *testing.T) {
+	testdata := []struct {
+		a        []float64
+		b        []float64
+		band     int
+		expected float64
+	}{
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, 1, 0},
+		{[]float64{0, 1, 0, 0}, []float64{0, 0, 1, 0}, 0, math.Sqrt(2)},
+		{[]float64{0, 1, 0, 0}, []float64{0, 0, 1, 0}, 1, 0},
+		{[]float64{0, 1, 0, 0, 0}, []float64{0, 0, 0, 1, 0}, 1, math.Sqrt(2)},
+		{[]float64{0, 1, 0, 0, 0}, []float64{0, 0, 0, 1, 0}, 2, 0},
+		{[]float64{1, 2, 3}, []float64{2, 3, 4}, 5, math.Sqrt(2)},
+		{[]float64{}, []float64{}, 1, -1},
+		{[]float64{1, 2}, []float64{1, 2, 3}, 1, -1},
+		{[]float64{1, 2}, []float64{1, 2}, -1, -1},
+	}
+
+	for _, d := range testdata {
+		out, err := DTW(d.a, d.b, d.band)
+		if d.expected < 0 {
+			if err == nil {
+				t.Errorf("Expected an error, but got none for %v", d)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Did not expect an error, %v, for %v", err, d)
+			continue
+		}
+		if math.Abs(out-d.expected) > 1e-9 {
+			t.Errorf("Expected %.6f, but got %.6f for %v", d.expected, out, d)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package util
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/dsp/fourier"
+)
+
+// MassV2 computes the z-normalized euclidean distance between a query and
+// every subsequence of the same length in series using Mueen's algorithm for
+// similarity search (MASS). A single fourier transform over the whole series
+// is used. Subsequences of series with a standard deviation of zero are at a
+// distance of sqrt(len(query)). Returns ErrZeroStd if the query has a standard
+// deviation of zero.
+func MassV2(query, series []float64) ([]float64, error) {
+	qnorm, std, err := massSetup(query, series)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := make([]float64, len(series)-len(query)+1)
+	massPiece(qnorm, series, std, fourier.NewFFT(len(series)), profile)
+	return profile, nil
+}
+
+// MassV3 computes the same distance profile as MassV2, but splits the series
+// into overlapping pieces with a power of two length of at least four times the
+// query length. This keeps the fourier transforms short, which is faster and
+// uses less memory for long series with short queries.
+func MassV3(query, series []float64) ([]float64, error) {
+	qnorm, std, err := massSetup(query, series)
+	if err != nil {
+		return nil, err
+	}
+
+	w := len(query)
+	k := 1
+	for k < 4*w {
+		k <<= 1
+	}
+	profile := make([]float64, len(series)-w+1)
+	if k >= len(series) {
+		massPiece(qnorm, series, std, fourier.NewFFT(len(series)), profile)
+		return profile, nil
+	}
+
+	// consecutive pieces overlap by w-1 points so that every subsequence is
+	// fully contained in exactly one piece
+	fft := fourier.NewFFT(k)
+	for start := 0; start < len(profile); start += k - w + 1 {
+		end := start + k
+		if end > len(series) {
+			end = len(series)
+			fft = fourier.NewFFT(end - start)
+		}
+		massPiece(qnorm, series[start:end], std[start:], fft, profile[start:end-w+1])
+	}
+	return profile, nil
+}
+
+// massSetup validates the query and series for MASS and returns the
+// z-normalized query with the standard deviation of every subsequence in series
+func massSetup(query, series []float64) ([]float64, []float64, error) {
+	if len(query) < 2 {
+		return nil, nil, fmt.Errorf("query must have at least 2 points, but got %d", len(query))
+	}
+	if len(query) > len(series) {
+		return nil, nil, fmt.Errorf("query length %d is greater than the series length %d", len(query), len(series))
+	}
+
+	qnorm, err := ZNormalize(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, std, err := MovMeanStd(series, len(query))
+	if err != nil {
+		return nil, nil, err
+	}
+	return qnorm, std, nil
+}
+
+// massPiece writes the distance between the z-normalized query and every
+// subsequence of ts to profile using a fourier transform of length len(ts).
+// std holds the standard deviation of each subsequence of ts.
+func massPiece(qnorm, ts, std []float64, fft *fourier.FFT, profile []float64) {
+	n := len(ts)
+	w := len(qnorm)
+
+	qpad := make([]float64, n)
+	for i := 0; i < w; i++ {
+		qpad[i] = qnorm[w-i-1]
+	}
+	qf := fft.Coefficients(nil, qpad)
+	tf := fft.Coefficients(nil, ts)
+	for i := range qf {
+		qf[i] *= tf[i]
+	}
+	dot := fft.Sequence(nil, qf)
+
+	for i := range profile {
+		profile[i] = massDist(dot[w-1+i]/float64(n), std[i], w)
+	}
+}
+
+// massDist converts the dot product between a z-normalized query and a
+// subsequence with a standard deviation of std to a z-normalized euclidean
+// distance. Since the query sums to zero the dot product doesn't depend on the
+// mean of the subsequence.
+func massDist(dot, std float64, w int) float64 {
+	if !(std > 0) {
+		// a constant subsequence normalizes to all zeros
+		return math.Sqrt(float64(w))
+	}
+	return math.Sqrt(math.Max(0, 2*(float64(w)-dot/std)))
+}
@@ -0,0 +1,84 @@
+package util
+
+import (
+	"math"
+	"testing"
+)
+
+// naiveMass computes the z-normalized euclidean distance profile directly
+func naiveMass(query, series []float64) []float64 {
+	qnorm, _ := ZNormalize(query)
+	profile := make([]float64, len(series)-len(query)+1)
+	for i := range profile {
+		snorm, _ := ZNormalize(series[i : i+len(query)])
+		var d float64
+		for k := range qnorm {
+			d += (qnorm[k] - snorm[k]) * (qnorm[k] - snorm[k])
+		}
+		profile[i] = math.Sqrt(d)
+	}
+	return profile
+}
+
+func TestMass(t *testing.T) {
+	series := make([]float64, 300)
+	for i := range series {
+		series[i] = math.Sin(float64(i)/5) + 0.3*math.Cos(float64(i*i)/7)
+	}
+	// a flat stretch has constant subsequences
+	for i := 100; i < 120; i++ {
+		series[i] = 2
+	}
+
+	testdata := []struct {
+		query  []float64
+		series []float64
+	}{
+		{series[10:18], series},
+		{series[40:50], series[:50]},
+		{series[200:232], series},
+		{[]float64{0, 1, 0, -1}, series[:60]},
+		{[]float64{1, 2}, series},
+	}
+
+	for _, d := range testdata {
+		expected := naiveMass(d.query, d.series)
+		for name, mass := range map[string]func(q, s []float64) ([]float64, error){"MassV2": MassV2, "MassV3": MassV3} {
+			profile, err := mass(d.query, d.series)
+			if err != nil {
+				t.Fatalf("Did not expect an error, %v, from %s", err, name)
+			}
+			if len(profile) != len(expected) {
+				t.Fatalf("Expected %d elements from %s, but got %d", len(expected), name, len(profile))
+			}
+			// squared distances are compared since the square root amplifies
+			// rounding errors near exact matches
+			for i := range expected {
+				if math.Abs(profile[i]*profile[i]-expected[i]*expected[i]) > 1e-6 {
+					t.Errorf("Expected %.6f at %d from %s, but got %.6f for a query of length %d", expected[i], i, name, profile[i], len(d.query))
+					break
+				}
+			}
+		}
+	}
+
+	for _, d := range []struct {
+		query  []float64
+		series []float64
+	}{
+		{[]float64{}, series},
+		{[]float64{1}, series},
+		{series[:10], series[:5]},
+		{[]float64{1, 1, 1, 1}, series},
+	} {
+		if _, err := MassV2(d.query, d.series); err == nil {
+			t.Errorf("Expected an error from MassV2 for a query of %v", d.query)
+		}
+		if _, err := MassV3(d.query, d.series); err == nil {
+			t.Errorf("Expected an error from MassV3 for a query of %v", d.query)
+		}
+	}
+	if _, err := MassV2([]float64{1, 1, 1, 1}, series); err != ErrZeroStd {
+		t.Errorf("Expected ErrZeroStd for a constant query, but got %v", err)
+	}
+}
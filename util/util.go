@@ -2,12 +2,17 @@
 package util
 
 import (
+	"errors"
 	"fmt"
 	"math"
 
 	"gonum.org/v1/gonum/stat"
 )
 
+// ErrZeroStd is returned when a subsequence has a standard deviation of zero
+// so it can't be z-normalized
+var ErrZeroStd = errors.New("standard deviation is zero")
+
 // ZNormalize computes a z-normalized version of a slice of floats.
 // This is represented by y[i] = (x[i] - mean(x))/std(x)
 func ZNormalize(ts []float64) ([]float64, error) {
@@ -31,7 +36,7 @@ func ZNormalize(ts []float64) ([]float64, error) {
 	std = math.Sqrt(std / float64(len(out)))
 
 	if std == 0 {
-		return out, fmt.Errorf("standard deviation is zero")
+		return out, ErrZeroStd
 	}
 
 	for i = 0; i < len(ts); i++ {
@@ -96,6 +101,31 @@ func ApplyExclusionZone(profile []float64, idx, zoneSize int) {
 	}
 }
 
+// ApplyExclusionMask performs an in place operation on a given matrix
+// profile setting distances to +Inf at every index where the mask is true.
+// Indexes beyond the mask are left untouched.
+func ApplyExclusionMask(profile []float64, mask []bool) {
+	for i, excl := range mask {
+		if excl && i < len(profile) {
+			profile[i] = math.Inf(1)
+		}
+	}
+}
+
+// ApplyPeriodicExclusionZone performs an in place operation on a given matrix
+// profile setting distances around an index and around every index in the
+// same phase of the period to +Inf. A period of 0 or less only excludes the
+// zone around the index.
+func ApplyPeriodicExclusionZone(profile []float64, idx, zoneSize, period int) {
+	if period <= 0 {
+		ApplyExclusionZone(profile, idx, zoneSize)
+		return
+	}
+	for i := idx % period; i < len(profile)+zoneSize; i += period {
+		ApplyExclusionZone(profile, i, zoneSize)
+	}
+}
+
 func MuInvN(a []float64, w int) ([]float64, []float64) {
 	mu := Sum2s(a, w)
 	sig := make([]float64, len(a)-w+1)
@@ -261,6 +291,38 @@ func DiagBatchingScheme(l, p int) []Batch {
 	return batchScheme
 }
 
+// DiagRangeBatchingScheme computes a balanced batching scheme for only the
+// diagonals from start up to, but not including, end out of l diagonals. Each
+// diagonal is weighted by its length so batches have a similar amount of work.
+// Batches left without any diagonals have a size of 0.
+func DiagRangeBatchingScheme(l, start, end, p int) []Batch {
+	var total int
+	for d := start; d < end; d++ {
+		total += l - d
+	}
+
+	batchScheme := make([]Batch, p)
+	for i := range batchScheme {
+		batchScheme[i].Idx = end
+	}
+	if start >= end {
+		return batchScheme
+	}
+
+	var pi, sum int
+	batchScheme[0].Idx = start
+	for d := start; d < end; d++ {
+		batchScheme[pi].Size += 1
+		sum += l - d
+		if pi < p-1 && float64(sum) >= float64(total)*float64(pi+1)/float64(p) && d+1 < end {
+			pi += 1
+			batchScheme[pi].Idx = d + 1
+		}
+	}
+
+	return batchScheme
+}
+
 // P2E converts a slice of pearson correlation values to euclidean distances. This
 // is only valid for z-normalized time series.
 func P2E(mp []float64, w int) {
@@ -97,6 +97,56 @@ func TestMovmeanstd(t *testing.T) {
 	}
 }
 
+func TestApplyExclusionMask(t *testing.T) {
+	inf := math.Inf(1)
+	testdata := []struct {
+		mask     []bool
+		expected []float64
+	}{
+		{nil, []float64{1, 2, 3, 4}},
+		{[]bool{true, false, false, true}, []float64{inf, 2, 3, inf}},
+		{[]bool{false, true}, []float64{1, inf, 3, 4}},
+		{[]bool{false, false, false, false, true, true}, []float64{1, 2, 3, 4}},
+	}
+
+	for _, d := range testdata {
+		out := []float64{1, 2, 3, 4}
+		ApplyExclusionMask(out, d.mask)
+		for i := range out {
+			if out[i] != d.expected[i] {
+				t.Errorf("Expected %v, but got %v for %v", d.expected, out, d.mask)
+				break
+			}
+		}
+	}
+}
+
+func TestApplyPeriodicExclusionZone(t *testing.T) {
+	inf := math.Inf(1)
+	testdata := []struct {
+		idx      int
+		zone     int
+		period   int
+		expected []float64
+	}{
+		{4, 1, 0, []float64{0, 0, 0, inf, inf, 0, 0, 0, 0, 0}},
+		{4, 1, 3, []float64{inf, inf, 0, inf, inf, 0, inf, inf, 0, inf}},
+		{5, 2, 5, []float64{inf, inf, 0, inf, inf, inf, inf, 0, inf, inf}},
+		{9, 1, 20, []float64{0, 0, 0, 0, 0, 0, 0, 0, inf, inf}},
+	}
+
+	for _, d := range testdata {
+		out := make([]float64, 10)
+		ApplyPeriodicExclusionZone(out, d.idx, d.zone, d.period)
+		for i := range out {
+			if out[i] != d.expected[i] {
+				t.Errorf("Expected %v, but got %v for %v", d.expected, out, d)
+				break
+			}
+		}
+	}
+}
+
 func TestMuInvN(t *testing.T) {
 	testdata := []struct {
 		a           []float64
@@ -190,3 +240,29 @@ func TestDiagBatchingScheme(t *testing.T) {
 		}
 	}
 }
+
+func TestDiagRangeBatchingScheme(t *testing.T) {
+	testdata := []struct {
+		l, start, end, p int
+		expected         []Batch
+	}{
+		{10, 0, 10, 2, []Batch{{0, 4}, {4, 6}}},
+		{10, 4, 8, 2, []Batch{{4, 2}, {6, 2}}},
+		{10, 2, 4, 4, []Batch{{2, 1}, {3, 1}, {4, 0}, {4, 0}}},
+		{10, 5, 5, 2, []Batch{{5, 0}, {5, 0}}},
+	}
+
+	for _, d := range testdata {
+		res := DiagRangeBatchingScheme(d.l, d.start, d.end, d.p)
+		if len(res) != len(d.expected) {
+			t.Errorf("Expected result length of %d, but got %d for %v", len(d.expected), len(res), d)
+			break
+		}
+		for i, v := range res {
+			if v != d.expected[i] {
+				t.Errorf("Expected %v at index, %d, but got %v for %v", d.expected[i], i, v, d)
+				break
+			}
+		}
+	}
+}
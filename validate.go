@@ -0,0 +1,158 @@
+package matrixprofile
+
+import (
+	"fmt"
+	"math"
+)
+
+// ValidateOpts are parameters to vary how a matrix profile is validated.
+type ValidateOpts struct {
+	AllowNaN bool `json:"allow_nan"` // NaN entries are not reported, such as from implementations that mark constant subsequences with NaN
+	Repair   bool `json:"repair"`    // repairable issues are fixed in place. Entries without a valid neighbor are reset to +Inf with an index of math.MaxInt64.
+}
+
+// NewValidateOpts returns a default ValidateOpts which reports every issue
+// without repairing any
+func NewValidateOpts() *ValidateOpts {
+	return &ValidateOpts{}
+}
+
+// ValidationIssue describes an entry of a matrix profile or its index that
+// breaks one of the invariants of a computed matrix profile.
+type ValidationIssue struct {
+	Profile  string `json:"profile"`  // json name of the profile with the issue, such as mp or pi_ba
+	Idx      int    `json:"idx"`      // index of the entry with the issue, or -1 if the whole profile is affected
+	Problem  string `json:"problem"`  // description of the broken invariant
+	Repaired bool   `json:"repaired"` // whether the entry was repaired in place
+}
+
+// profileCheck is a matrix profile and its index along with the expected
+// length and the number of subsequences its neighbors can point to
+type profileCheck struct {
+	name, idxName string
+	prof          []float64
+	idx           []int
+	length        int
+	neighbors     int
+	side          int // -1 for neighbors before each entry, 1 for after and 0 for either
+}
+
+// Validate checks the invariants of a computed matrix profile, such as after
+// loading one produced by an older version or another implementation. Every
+// profile must match the length of the time series it indexes, every index
+// must point to a subsequence of the other time series or be math.MaxInt64
+// for an entry without a neighbor at +Inf, euclidean distances must not be
+// negative and pearson correlations must be within [-1, 1]. The left and
+// right matrix profiles must only point before and after each subsequence.
+// Returns every issue found and an error if any of them were not repaired.
+// Profiles with the wrong length can't be repaired.
+func (mp *MatrixProfile) Validate(o *ValidateOpts) ([]ValidationIssue, error) {
+	if o == nil {
+		o = NewValidateOpts()
+	}
+	if mp.MP == nil {
+		return nil, ErrNotComputed
+	}
+	if mp.W < 2 {
+		return nil, newError(ErrWindowTooSmall, "subsequence length must be at least 2")
+	}
+
+	lenA := len(mp.A) - mp.W + 1
+	lenB := len(mp.B) - mp.W + 1
+	mpLen, mpNeighbors := lenB, lenA
+	if mp.indexedByA() {
+		mpLen, mpNeighbors = lenA, lenB
+	}
+
+	checks := []profileCheck{{name: "mp", idxName: "pi", prof: mp.MP, idx: mp.Idx, length: mpLen, neighbors: mpNeighbors}}
+	if mp.MPB != nil || mp.IdxB != nil {
+		mpbLen := len(mp.MPB)
+		if !mp.SelfJoin && mp.indexedByA() {
+			mpbLen = lenB
+		}
+		checks = append(checks, profileCheck{name: "mp_ba", idxName: "pi_ba", prof: mp.MPB, idx: mp.IdxB, length: mpbLen, neighbors: lenA})
+	}
+	if mp.LMP != nil || mp.LIdx != nil {
+		checks = append(checks, profileCheck{name: "lmp", idxName: "lpi", prof: mp.LMP, idx: mp.LIdx, length: len(mp.MP), neighbors: mpNeighbors, side: -1})
+	}
+	if mp.RMP != nil || mp.RIdx != nil {
+		checks = append(checks, profileCheck{name: "rmp", idxName: "rpi", prof: mp.RMP, idx: mp.RIdx, length: len(mp.MP), neighbors: mpNeighbors, side: 1})
+	}
+
+	euclidean := mp.Opts == nil || mp.Opts.Euclidean
+	var issues []ValidationIssue
+	for _, c := range checks {
+		issues = append(issues, c.validate(euclidean, o)...)
+	}
+
+	var unrepaired int
+	for _, issue := range issues {
+		if !issue.Repaired {
+			unrepaired++
+		}
+	}
+	if unrepaired > 0 {
+		return issues, newError(ErrInvalidProfile, "%d of %d matrix profile issues were not repaired", unrepaired, len(issues))
+	}
+	return issues, nil
+}
+
+// validate checks every entry of the profile, repairing them if requested
+func (c profileCheck) validate(euclidean bool, o *ValidateOpts) []ValidationIssue {
+	if len(c.prof) != c.length || len(c.idx) != c.length {
+		return []ValidationIssue{{
+			Profile: c.name,
+			Idx:     -1,
+			Problem: fmt.Sprintf("%s and %s have lengths %d and %d but %d subsequences are indexed", c.name, c.idxName, len(c.prof), len(c.idx), c.length),
+		}}
+	}
+
+	var issues []ValidationIssue
+	report := func(i int, problem string) {
+		issues = append(issues, ValidationIssue{Profile: c.name, Idx: i, Problem: problem, Repaired: o.Repair})
+	}
+	unset := func(i int) {
+		if o.Repair {
+			c.prof[i], c.idx[i] = math.Inf(1), math.MaxInt64
+		}
+	}
+
+	for i, v := range c.prof {
+		j := c.idx[i]
+		switch {
+		case math.IsNaN(v):
+			if !o.AllowNaN {
+				report(i, "NaN value")
+				unset(i)
+			}
+		case math.IsInf(v, 1):
+			if j != math.MaxInt64 {
+				report(i, fmt.Sprintf("neighbor %d set for an entry at +Inf", j))
+				unset(i)
+			}
+		case math.IsInf(v, -1):
+			report(i, "value at -Inf")
+			unset(i)
+		case j == math.MaxInt64:
+			report(i, fmt.Sprintf("value %.3f set without a neighbor", v))
+			unset(i)
+		case j < 0 || j >= c.neighbors:
+			report(i, fmt.Sprintf("neighbor %d out of bounds of the %d subsequences", j, c.neighbors))
+			unset(i)
+		case c.side < 0 && j >= i, c.side > 0 && j <= i:
+			report(i, fmt.Sprintf("neighbor %d on the wrong side of the subsequence", j))
+			unset(i)
+		case euclidean && v < 0:
+			report(i, fmt.Sprintf("negative distance %.3f", v))
+			if o.Repair {
+				c.prof[i] = 0
+			}
+		case !euclidean && math.Abs(v) > 1:
+			report(i, fmt.Sprintf("correlation %.3f outside of [-1, 1]", v))
+			if o.Repair {
+				c.prof[i] = math.Max(-1, math.Min(1, v))
+			}
+		}
+	}
+	return issues
+}
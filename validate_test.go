@@ -0,0 +1,117 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestValidateComputed(t *testing.T) {
+	a := setupData(150)
+	b := setupData(200)
+
+	testdata := []struct {
+		b         []float64
+		algo      Algo
+		euclidean bool
+	}{
+		{nil, AlgoMPX, true},
+		{nil, AlgoMPX, false},
+		{nil, AlgoSTOMP, true},
+		{nil, AlgoSTAMP, false},
+		{b, AlgoMPX, true},
+		{b, AlgoSTOMP, true},
+	}
+
+	for _, d := range testdata {
+		mp, err := New(a, d.b, 16)
+		if err != nil {
+			t.Fatalf("Did not expect an error, %v", err)
+		}
+		o := NewMPOpts()
+		o.Algorithm = d.algo
+		o.Euclidean = d.euclidean
+		o.LeftRight = d.b == nil
+		if err = mp.Compute(o); err != nil {
+			t.Fatalf("Did not expect an error, %v, for %+v", err, d)
+		}
+
+		issues, err := mp.Validate(nil)
+		if err != nil || len(issues) != 0 {
+			t.Errorf("Expected a valid matrix profile, but got %v, %+v, for %s", err, issues, d.algo)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	inf := math.Inf(1)
+	a := []float64{0, 1, 2, 3, 4, 5, 6, 7}
+	mp := MatrixProfile{A: a, B: a, W: 4, SelfJoin: true, Opts: NewMPOpts()}
+
+	if _, err := mp.Validate(nil); Cause(err) != ErrNotComputed {
+		t.Errorf("Expected ErrNotComputed, but got %v", err)
+	}
+
+	corrupt := func() {
+		mp.MP = []float64{1, math.NaN(), -0.001, 2, inf}
+		mp.Idx = []int{3, 4, 4, 9, 1}
+		mp.LMP = []float64{inf, 1, 1, 1, 1}
+		mp.LIdx = []int{math.MaxInt64, 0, 4, 0, 1}
+	}
+
+	corrupt()
+	issues, err := mp.Validate(nil)
+	if Cause(err) != ErrInvalidProfile {
+		t.Errorf("Expected ErrInvalidProfile, but got %v", err)
+	}
+	expected := []ValidationIssue{
+		{Profile: "mp", Idx: 1},
+		{Profile: "mp", Idx: 2},
+		{Profile: "mp", Idx: 3},
+		{Profile: "mp", Idx: 4},
+		{Profile: "lmp", Idx: 2},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %d issues, but got %+v", len(expected), issues)
+	}
+	for i, issue := range issues {
+		if issue.Profile != expected[i].Profile || issue.Idx != expected[i].Idx || issue.Repaired {
+			t.Errorf("Expected an unrepaired issue in %s at %d, but got %+v", expected[i].Profile, expected[i].Idx, issue)
+		}
+	}
+	if !math.IsNaN(mp.MP[1]) {
+		t.Errorf("Expected the matrix profile to be unchanged without repairs, but got %v", mp.MP)
+	}
+
+	// NaN entries are allowed when flagged
+	issues, _ = mp.Validate(&ValidateOpts{AllowNaN: true})
+	if len(issues) != len(expected)-1 {
+		t.Errorf("Expected %d issues when allowing NaN, but got %+v", len(expected)-1, issues)
+	}
+
+	issues, err = mp.Validate(&ValidateOpts{Repair: true})
+	if err != nil || len(issues) != len(expected) {
+		t.Errorf("Expected %d repaired issues without an error, but got %v, %+v", len(expected), err, issues)
+	}
+	expectedMP := []float64{1, inf, 0, inf, inf}
+	expectedIdx := []int{3, math.MaxInt64, 4, math.MaxInt64, math.MaxInt64}
+	for i := range expectedMP {
+		if mp.MP[i] != expectedMP[i] || mp.Idx[i] != expectedIdx[i] {
+			t.Errorf("Expected %v, %v, but got %v, %v", expectedMP, expectedIdx, mp.MP, mp.Idx)
+			break
+		}
+	}
+	if mp.LMP[2] != inf || mp.LIdx[2] != math.MaxInt64 {
+		t.Errorf("Expected the left neighbor after the subsequence to be removed, but got %v, %v", mp.LMP, mp.LIdx)
+	}
+	if issues, err = mp.Validate(nil); err != nil || len(issues) != 0 {
+		t.Errorf("Expected a valid matrix profile after repairs, but got %v, %+v", err, issues)
+	}
+
+	// profiles of the wrong length can't be repaired
+	corrupt()
+	mp.Idx = mp.Idx[:4]
+	issues, err = mp.Validate(&ValidateOpts{Repair: true})
+	if Cause(err) != ErrInvalidProfile || len(issues) == 0 || issues[0].Idx != -1 || issues[0].Repaired {
+		t.Errorf("Expected an unrepaired length issue, but got %v, %+v", err, issues)
+	}
+}
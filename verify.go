@@ -0,0 +1,170 @@
+package matrixprofile
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+const (
+	// verifyRows is the number of matrix profile values recomputed naively
+	// by the Verify option
+	verifyRows = 32
+
+	// verifyTolerance is the largest difference between the squared
+	// distances of the optimized and naive computations, relative to the sum
+	// of the squared norms of both normalized subsequences, accepted by the
+	// Verify option. For z-normalized subsequences this is a difference in
+	// pearson correlation.
+	verifyTolerance = 1e-6
+)
+
+// Verification holds the statistics of a random sample of matrix profile
+// values recomputed with a naive O(n*w) dot product by the Verify option.
+// Errors are differences of squared euclidean distances relative to the sum
+// of the squared norms of both normalized subsequences.
+type Verification struct {
+	Rows      []int   `json:"rows"`      // indexes of the matrix profile values recomputed
+	MaxErr    float64 `json:"max_err"`   // largest relative error. +Inf if a value was set by only one of the computations
+	MeanErr   float64 `json:"mean_err"`  // mean relative error over the values set by both computations
+	Tolerance float64 `json:"tolerance"` // largest relative error accepted
+	Failed    []int   `json:"failed"`    // indexes of the values beyond the tolerance
+}
+
+// verify recomputes a random sample of the matrix profile values naively and
+// stores the statistics in mp.Verification. Returns ErrInvalidProfile if any
+// value differs by more than the tolerance.
+func (mp *MatrixProfile) verify() error {
+	n := len(mp.MP)
+	rows := rand.New(rand.NewSource(mp.Opts.Seed)).Perm(n)
+	if len(rows) > verifyRows {
+		rows = rows[:verifyRows]
+	}
+	sort.Ints(rows)
+
+	v := &Verification{Rows: rows, Tolerance: verifyTolerance}
+	var sum float64
+	var count int
+	for _, i := range rows {
+		got := mp.MP[i]
+		if !mp.Opts.Euclidean {
+			d := []float64{got}
+			pearsonToEuclidean(d, mp.W)
+			got = d[0]
+		}
+		want, scale, ok := mp.naiveProfileValue(i)
+		if !ok {
+			// constant subsequences have no defined distance
+			continue
+		}
+
+		var e float64
+		switch {
+		case math.IsInf(got, 1) && math.IsInf(want, 1):
+			continue
+		case math.IsInf(got, 1) || math.IsInf(want, 1) || math.IsNaN(got):
+			e = math.Inf(1)
+		default:
+			e = math.Abs(got*got-want*want) / scale
+			sum += e
+			count++
+		}
+		if e > v.MaxErr {
+			v.MaxErr = e
+		}
+		if e > verifyTolerance {
+			v.Failed = append(v.Failed, i)
+		}
+	}
+	if count > 0 {
+		v.MeanErr = sum / float64(count)
+	}
+
+	mp.Verification = v
+	if len(v.Failed) > 0 {
+		return newError(ErrInvalidProfile, "%d of %d verified matrix profile values differ from a naive computation, largest relative error %.3g", len(v.Failed), len(rows), v.MaxErr)
+	}
+	return nil
+}
+
+// naiveProfileValue computes the distance from the subsequence behind the
+// matrix profile value at i to its nearest neighbor by comparing it against
+// every subsequence of the other time series. The exclusion zone, mask and
+// session boundaries apply to self joins and negative correlations are remapped if set in the
+// options for the MPX kernels that support it. Also returns the scale of the
+// relative error for that neighbor, or false if the subsequence is constant.
+func (mp MatrixProfile) naiveProfileValue(i int) (float64, float64, bool) {
+	query, other := mp.A, mp.B
+	if !mp.SelfJoin && !mp.indexedByA() {
+		query, other = mp.B, mp.A
+	}
+	remap := mp.Opts.RemapNegCorr && mp.indexedByA()
+
+	best, scale := math.Inf(1), 1.0
+	if mp.SelfJoin && mp.crossesBoundary(i) {
+		return best, scale, true
+	}
+	x, ok := mp.naiveNormalize(query[i : i+mp.W])
+	if !ok {
+		return best, scale, false
+	}
+
+	zone := mp.exclusionZone()
+	for j := 0; j+mp.W <= len(other); j++ {
+		if mp.SelfJoin && (j > i-zone && j < i+zone || mp.masked(j)) {
+			continue
+		}
+		y, ok := mp.naiveNormalize(other[j : j+mp.W])
+		if !ok {
+			continue
+		}
+
+		var d, dNeg, norm float64
+		for k := range x {
+			wt := 1.0
+			if mp.Opts.Weights != nil {
+				wt = mp.Opts.Weights[k]
+			}
+			d += wt * (x[k] - y[k]) * (x[k] - y[k])
+			dNeg += wt * (x[k] + y[k]) * (x[k] + y[k])
+			norm += wt * (x[k]*x[k] + y[k]*y[k])
+		}
+		if remap && dNeg < d {
+			d = dNeg
+		}
+		if d < best*best {
+			best, scale = math.Sqrt(d), norm
+		}
+	}
+	if scale <= 0 {
+		scale = 1
+	}
+	return best, scale, true
+}
+
+// naiveNormalize returns a copy of a subsequence normalized in the same manner
+// as the computation, or false if it is constant and can't be z-normalized
+func (mp MatrixProfile) naiveNormalize(s []float64) ([]float64, bool) {
+	var mean, ss float64
+	for _, v := range s {
+		mean += v
+	}
+	mean /= float64(len(s))
+	out := make([]float64, len(s))
+	for k, v := range s {
+		out[k] = v - mean
+		ss += out[k] * out[k]
+	}
+	if mp.meanCentered() {
+		return out, true
+	}
+
+	std := math.Sqrt(ss / float64(len(s)))
+	if std == 0 {
+		return nil, false
+	}
+	for k := range out {
+		out[k] /= std
+	}
+	return out, true
+}
@@ -0,0 +1,60 @@
+package matrixprofile
+
+import "testing"
+
+func TestComputeVerify(t *testing.T) {
+	a := setupData(300)
+	b := setupData(200)
+
+	testdata := []struct {
+		b    []float64
+		opts *MPOpts
+	}{
+		{nil, &MPOpts{Algorithm: AlgoMPX, SamplePct: 1, NJobs: 2, Euclidean: true}},
+		{nil, &MPOpts{Algorithm: AlgoMPX, SamplePct: 1, NJobs: 2, Euclidean: false, RemapNegCorr: true}},
+		{nil, &MPOpts{Algorithm: AlgoSTOMP, SamplePct: 1, NJobs: 2, Euclidean: true}},
+		{nil, &MPOpts{Algorithm: AlgoSTOMP, SamplePct: 1, NJobs: 1, Euclidean: true, Normalization: NormMean}},
+		{b, &MPOpts{Algorithm: AlgoMPX, SamplePct: 1, NJobs: 2, Euclidean: true}},
+		{b, &MPOpts{Algorithm: AlgoSTAMP, SamplePct: 1, NJobs: 2, Euclidean: true}},
+	}
+
+	for _, d := range testdata {
+		mp, err := New(a, d.b, 16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		d.opts.Verify = true
+		if err = mp.Compute(d.opts); err != nil {
+			t.Errorf("Did not expect an error, %v, for %+v", err, d.opts)
+			continue
+		}
+		v := mp.Verification
+		if v == nil || len(v.Rows) != verifyRows || len(v.Failed) != 0 || v.MaxErr > v.Tolerance {
+			t.Errorf("Expected a passing verification of %d rows, but got %+v for %+v", verifyRows, v, d.opts)
+		}
+	}
+
+	mp, err := New(a, nil, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.Verify = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range mp.Verification.Rows {
+		mp.MP[i] *= 0.5
+	}
+	if err = mp.verify(); Cause(err) != ErrInvalidProfile {
+		t.Errorf("Expected ErrInvalidProfile for a corrupted matrix profile, but got %v", err)
+	}
+	if len(mp.Verification.Failed) == 0 {
+		t.Errorf("Expected failed rows in the verification, but got %+v", mp.Verification)
+	}
+
+	o.SamplePct = 0.5
+	if err = mp.Compute(o); err == nil {
+		t.Errorf("Expected an error verifying a sampled matrix profile")
+	}
+}
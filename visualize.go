@@ -1,17 +1,160 @@
+//go:build !noplot
+// +build !noplot
+
 package matrixprofile
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"math"
+	"strconv"
 
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
 	"gonum.org/v1/plot"
 	"gonum.org/v1/plot/plotter"
 	"gonum.org/v1/plot/plotutil"
 	"gonum.org/v1/plot/vg"
 	"gonum.org/v1/plot/vg/draw"
 	"gonum.org/v1/plot/vg/vgimg"
+	"gonum.org/v1/plot/vg/vgpdf"
+	"gonum.org/v1/plot/vg/vgsvg"
 )
 
+// VisualizeTo renders the matrix profile along with any discovered motifs and
+// discords to w using the provided visualization options.
+func (mp MatrixProfile) VisualizeTo(w io.Writer, o *VisualizeOpts) error {
+	if o == nil {
+		o = NewVisualizeOpts()
+	}
+
+	sigPts := points(mp.A, len(mp.A))
+	mpPts := points(mp.MP, len(mp.A))
+	motifPts := make([][]plotter.XYs, len(mp.Motifs))
+	discordPts := make([]plotter.XYs, len(mp.Discords))
+	discordLabels := make([]string, len(mp.Discords))
+
+	for i := 0; i < len(mp.Motifs); i++ {
+		motifPts[i] = make([]plotter.XYs, len(mp.Motifs[i].Idx))
+	}
+
+	for i := 0; i < len(mp.Motifs); i++ {
+		for j, idx := range mp.Motifs[i].Idx {
+			motifPts[i][j] = points(mp.A[idx:idx+mp.W], mp.W)
+		}
+	}
+
+	for i, idx := range mp.Discords {
+		discordPts[i] = points(mp.A[idx:idx+mp.W], mp.W)
+		discordLabels[i] = strconv.Itoa(idx)
+	}
+
+	var cacPts plotter.XYs
+	if o.CAC && mp.Idx != nil {
+		_, _, cac := mp.DiscoverSegments()
+		cacPts = points(cac, len(mp.A))
+	}
+
+	// shows how a non default annotation vector reshapes the matrix profile
+	// used for discovery
+	var avPts, correctedPts plotter.XYs
+	if o.AV && mp.AV != "" && mp.AV != av.Default && mp.MP != nil {
+		avec, err := av.Create(mp.AV, mp.A, mp.W)
+		if err != nil {
+			return err
+		}
+		corrected, _, err := mp.ApplyAV()
+		if err != nil {
+			return err
+		}
+		avPts = points(avec, len(mp.A))
+		correctedPts = points(corrected, len(mp.A))
+	}
+
+	return plotMP(sigPts, mpPts, cacPts, avPts, correctedPts, motifPts, discordPts, discordLabels, w, o)
+}
+
+// VisualizeChainTo renders a time series chain from DiscoverChains to w with a
+// panel marking where each link occurs in the signal and a panel overlaying
+// the links, analogous to the motif panels. Only the format and size of the
+// visualization options apply.
+func (mp MatrixProfile) VisualizeChainTo(w io.Writer, chain []int, o *VisualizeOpts) error {
+	if o == nil {
+		o = NewVisualizeOpts()
+	}
+	if len(chain) == 0 {
+		return errors.New("no chain links to visualize")
+	}
+
+	posPts := []plotter.XYs{points(mp.A, len(mp.A))}
+	linkPts := make([]plotter.XYs, len(chain))
+	linkLabels := make([]string, len(chain))
+	for i, idx := range chain {
+		if idx < 0 || idx+mp.W > len(mp.A) {
+			return fmt.Errorf("chain link %d is out of range for a time series of length %d", idx, len(mp.A))
+		}
+		linkPts[i] = points(mp.A[idx:idx+mp.W], mp.W)
+		linkLabels[i] = strconv.Itoa(idx)
+
+		pos := points(mp.A[idx:idx+mp.W], mp.W)
+		for j := range pos {
+			pos[j].X += float64(idx)
+		}
+		posPts = append(posPts, pos)
+	}
+
+	pos, err := createPlot(posPts, nil, "chain positions")
+	if err != nil {
+		return err
+	}
+	links, err := createPlot(linkPts, linkLabels, "chain")
+	if err != nil {
+		return err
+	}
+	return renderPlots([][]*plot.Plot{{pos}, {links}}, w, o)
+}
+
+// VisualizeTo renders the k-dimensional matrix profile to w using the provided
+// visualization options. Only the signal, matrix profile, index and subspace
+// panels apply. The index and subspace panels of each dimension are drawn
+// beside its matrix profile panel. A subspace panel has a row per time series
+// with a mark at every index where that time series is part of the best
+// subspace behind the matrix profile value.
+func (k KMP) VisualizeTo(w io.Writer, o *VisualizeOpts) error {
+	if o == nil {
+		o = newKMPVisualizeOpts()
+	}
+
+	sigPts := make([]plotter.XYs, len(k.T))
+	for i := 0; i < len(k.T); i++ {
+		sigPts[i] = points(k.T[i], len(k.T[0]))
+	}
+
+	mpPts := make([]plotter.XYs, len(k.MP))
+	idxPts := make([]plotter.XYs, len(k.Idx))
+	for i := 0; i < len(k.MP); i++ {
+		mpPts[i] = points(k.MP[i], len(k.T[0]))
+		idxPts[i] = indexPoints(k.Idx[i], len(k.T[0]))
+	}
+
+	// one set of marks per time series for each dimension of the profile
+	subPts := make([][]plotter.XYs, len(k.SubspaceIdx))
+	for d := range k.SubspaceIdx {
+		subPts[d] = make([]plotter.XYs, len(k.T))
+		for i := range k.MP[d] {
+			sub, err := k.Subspace(d, i)
+			if err != nil {
+				continue
+			}
+			for _, dim := range sub {
+				subPts[d][dim] = appendPoint(subPts[d][dim], float64(i), float64(dim))
+			}
+		}
+	}
+
+	return plotKMP(sigPts, mpPts, idxPts, subPts, w, o)
+}
+
 func points(a []float64, n int) plotter.XYs {
 	pts := make(plotter.XYs, n)
 	for i := 0; i < n; i++ {
@@ -23,6 +166,51 @@ func points(a []float64, n int) plotter.XYs {
 	return pts
 }
 
+// indexPoints returns the points of a matrix profile index, leaving out unset
+// entries so that they don't stretch the axis
+func indexPoints(idx []int, n int) plotter.XYs {
+	var pts plotter.XYs
+	for i := 0; i < n && i < len(idx); i++ {
+		if idx[i] >= 0 && idx[i] < math.MaxInt64 {
+			pts = appendPoint(pts, float64(i), float64(idx[i]))
+		}
+	}
+	return pts
+}
+
+// appendPoint appends the point x, y to pts
+func appendPoint(pts plotter.XYs, x, y float64) plotter.XYs {
+	pts = append(pts, make(plotter.XYs, 1)...)
+	pts[len(pts)-1].X, pts[len(pts)-1].Y = x, y
+	return pts
+}
+
+// createBandPlot creates a plot marking each set of points with small boxes
+// in its own color, such as the dimensions of a subspace at each index
+func createBandPlot(pts []plotter.XYs, title string) (*plot.Plot, error) {
+	p, err := plot.New()
+	if err != nil {
+		return p, err
+	}
+
+	p.Title.Text = title
+	for i := 0; i < len(pts); i++ {
+		if len(pts[i]) == 0 {
+			continue
+		}
+		s, err := plotter.NewScatter(pts[i])
+		if err != nil {
+			return p, err
+		}
+		s.GlyphStyle.Color = plotutil.Color(i)
+		s.GlyphStyle.Shape = draw.BoxGlyph{}
+		s.GlyphStyle.Radius = vg.Points(1)
+		p.Add(s)
+	}
+	p.Y.Min, p.Y.Max = -0.5, float64(len(pts))-0.5
+	return p, nil
+}
+
 func createPlot(pts []plotter.XYs, labels []string, title string) (*plot.Plot, error) {
 	if labels != nil && len(pts) != len(labels) {
 		return nil, fmt.Errorf("number of XYs, %d, does not match number of labels, %d", len(pts), len(labels))
@@ -50,115 +238,191 @@ func createPlot(pts []plotter.XYs, labels []string, title string) (*plot.Plot, e
 	return p, err
 }
 
-func plotMP(sigPts, mpPts plotter.XYs, motifPts [][]plotter.XYs, discordPts []plotter.XYs, discordLabels []string, filename string) error {
+func plotMP(sigPts, mpPts, cacPts, avPts, correctedPts plotter.XYs, motifPts [][]plotter.XYs, discordPts []plotter.XYs, discordLabels []string, w io.Writer, o *VisualizeOpts) error {
+	var left []*plot.Plot
+	var p *plot.Plot
 	var err error
-	rows, cols := len(motifPts), 2
-	if rows < 4 {
-		rows = 4
-	}
-	plots := make([][]*plot.Plot, rows)
 
-	for i := 0; i < rows; i++ {
-		plots[i] = make([]*plot.Plot, cols)
+	if o.Signal {
+		if p, err = createPlot([]plotter.XYs{sigPts}, nil, "signal"); err != nil {
+			return err
+		}
+		left = append(left, p)
 	}
 
-	plots[0][0], err = createPlot([]plotter.XYs{sigPts}, nil, "signal")
-	if err != nil {
-		return err
+	if o.MP {
+		if p, err = createPlot([]plotter.XYs{mpPts}, nil, "matrix profile"); err != nil {
+			return err
+		}
+		left = append(left, p)
 	}
 
-	plots[1][0], err = createPlot([]plotter.XYs{mpPts}, nil, "matrix profile")
-	if err != nil {
-		return err
+	if o.AV && avPts != nil {
+		if p, err = createPlot([]plotter.XYs{avPts}, nil, "annotation vector"); err != nil {
+			return err
+		}
+		left = append(left, p)
+		if p, err = createPlot([]plotter.XYs{correctedPts}, nil, "corrected matrix profile"); err != nil {
+			return err
+		}
+		left = append(left, p)
 	}
 
-	plots[2][0], err = createPlot(discordPts, discordLabels, "discords")
-	if err != nil {
-		return err
+	if o.CAC && cacPts != nil {
+		if p, err = createPlot([]plotter.XYs{cacPts}, nil, "corrected arc curve"); err != nil {
+			return err
+		}
+		left = append(left, p)
 	}
 
-	for i := 0; i < len(motifPts); i++ {
-		plots[i][1], err = createPlot(motifPts[i], nil, fmt.Sprintf("motif %d", i))
-		if err != nil {
+	if o.Discords {
+		if p, err = createPlot(discordPts, discordLabels, "discords"); err != nil {
 			return err
 		}
+		left = append(left, p)
 	}
 
-	img := vgimg.New(vg.Points(1200), vg.Points(600))
-	dc := draw.New(img)
-
-	t := draw.Tiles{
-		Rows: rows,
-		Cols: cols,
+	var right []*plot.Plot
+	if o.Motifs {
+		for i := 0; i < len(motifPts); i++ {
+			if p, err = createPlot(motifPts[i], nil, fmt.Sprintf("motif %d", i)); err != nil {
+				return err
+			}
+			right = append(right, p)
+		}
 	}
 
-	canvases := plot.Align(plots, t, dc)
-	for j := 0; j < rows; j++ {
-		for i := 0; i < cols; i++ {
-			if plots[j][i] != nil {
-				plots[j][i].Draw(canvases[j][i])
-			}
+	rows, cols := len(left), 1
+	if len(right) > 0 {
+		cols = 2
+		if len(right) > rows {
+			rows = len(right)
 		}
 	}
+	if rows == 0 {
+		return errors.New("no panels selected to visualize")
+	}
 
-	w, err := os.Create(filename)
-	if err != nil {
-		return err
+	plots := make([][]*plot.Plot, rows)
+	for i := 0; i < rows; i++ {
+		plots[i] = make([]*plot.Plot, cols)
+		if i < len(left) {
+			plots[i][0] = left[i]
+		}
+		if i < len(right) {
+			plots[i][cols-1] = right[i]
+		}
 	}
 
-	png := vgimg.PngCanvas{Canvas: img}
-	_, err = png.WriteTo(w)
-	return err
+	return renderPlots(plots, w, o)
 }
 
-func plotKMP(sigPts, mpPts []plotter.XYs, filename string) error {
-	var err error
+func plotKMP(sigPts, mpPts, idxPts []plotter.XYs, subPts [][]plotter.XYs, w io.Writer, o *VisualizeOpts) error {
+	var plots [][]*plot.Plot
 
-	rows, cols := len(sigPts)*2, 1
-
-	plots := make([][]*plot.Plot, rows)
-
-	for i := 0; i < len(sigPts)*2; i++ {
-		plots[i] = make([]*plot.Plot, cols)
+	cols := 1
+	if o.Index {
+		cols++
+	}
+	if o.Subspace {
+		cols++
 	}
 
-	for i := 0; i < len(sigPts); i++ {
-		plots[i][0], err = createPlot([]plotter.XYs{sigPts[i]}, nil, fmt.Sprintf("signal%d", i))
-		if err != nil {
-			return err
+	if o.Signal {
+		for i := 0; i < len(sigPts); i++ {
+			p, err := createPlot([]plotter.XYs{sigPts[i]}, nil, fmt.Sprintf("signal%d", i))
+			if err != nil {
+				return err
+			}
+			row := make([]*plot.Plot, cols)
+			row[0] = p
+			plots = append(plots, row)
 		}
 	}
 
-	for i := 0; i < len(sigPts); i++ {
-		plots[len(sigPts)+i][0], err = createPlot([]plotter.XYs{mpPts[i]}, nil, fmt.Sprintf("mp%d", i))
-		if err != nil {
-			return err
+	if o.MP || o.Index || o.Subspace {
+		for i := 0; i < len(mpPts); i++ {
+			row := make([]*plot.Plot, cols)
+			col := 0
+			if o.MP {
+				p, err := createPlot([]plotter.XYs{mpPts[i]}, nil, fmt.Sprintf("mp%d", i))
+				if err != nil {
+					return err
+				}
+				row[col] = p
+			}
+			col++
+			if o.Index {
+				if len(idxPts[i]) > 0 {
+					p, err := createPlot([]plotter.XYs{idxPts[i]}, nil, fmt.Sprintf("mp index%d", i))
+					if err != nil {
+						return err
+					}
+					row[col] = p
+				}
+				col++
+			}
+			if o.Subspace && i < len(subPts) {
+				p, err := createBandPlot(subPts[i], fmt.Sprintf("subspace%d", i))
+				if err != nil {
+					return err
+				}
+				row[col] = p
+			}
+			plots = append(plots, row)
 		}
 	}
 
-	img := vgimg.New(vg.Points(600), vg.Points(600))
-	dc := draw.New(img)
+	if len(plots) == 0 {
+		return errors.New("no panels selected to visualize")
+	}
+
+	return renderPlots(plots, w, o)
+}
+
+// renderPlots draws a grid of plots onto a canvas matching the output format
+// and writes the encoded result to w. Nil plots leave their tile empty.
+func renderPlots(plots [][]*plot.Plot, w io.Writer, o *VisualizeOpts) error {
+	width, height := vg.Points(o.Width), vg.Points(o.Height)
+
+	var c vg.CanvasWriterTo
+	switch o.Format {
+	case "", "png", "jpg", "jpeg", "tif", "tiff":
+		dpi := o.DPI
+		if dpi <= 0 {
+			dpi = vgimg.DefaultDPI
+		}
+		img := vgimg.NewWith(vgimg.UseWH(width, height), vgimg.UseDPI(dpi))
+		switch o.Format {
+		case "jpg", "jpeg":
+			c = vgimg.JpegCanvas{Canvas: img}
+		case "tif", "tiff":
+			c = vgimg.TiffCanvas{Canvas: img}
+		default:
+			c = vgimg.PngCanvas{Canvas: img}
+		}
+	case "svg":
+		c = vgsvg.New(width, height)
+	case "pdf":
+		c = vgpdf.New(width, height)
+	default:
+		return newError(ErrInvalidFormat, "invalid visualization format, %s", o.Format)
+	}
 
 	t := draw.Tiles{
-		Rows: rows,
-		Cols: cols,
+		Rows: len(plots),
+		Cols: len(plots[0]),
 	}
 
-	canvases := plot.Align(plots, t, dc)
-	for j := 0; j < rows; j++ {
-		for i := 0; i < cols; i++ {
+	canvases := plot.Align(plots, t, draw.New(c))
+	for j := 0; j < len(plots); j++ {
+		for i := 0; i < len(plots[j]); i++ {
 			if plots[j][i] != nil {
 				plots[j][i].Draw(canvases[j][i])
 			}
 		}
 	}
 
-	w, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-
-	png := vgimg.PngCanvas{Canvas: img}
-	_, err = png.WriteTo(w)
+	_, err := c.WriteTo(w)
 	return err
 }
@@ -0,0 +1,24 @@
+//go:build noplot
+// +build noplot
+
+package matrixprofile
+
+import "io"
+
+// VisualizeTo is not available when built with the noplot tag and always
+// returns an error.
+func (mp MatrixProfile) VisualizeTo(w io.Writer, o *VisualizeOpts) error {
+	return ErrNoPlot
+}
+
+// VisualizeChainTo is not available when built with the noplot tag and
+// always returns an error.
+func (mp MatrixProfile) VisualizeChainTo(w io.Writer, chain []int, o *VisualizeOpts) error {
+	return ErrNoPlot
+}
+
+// VisualizeTo is not available when built with the noplot tag and always
+// returns an error.
+func (k KMP) VisualizeTo(w io.Writer, o *VisualizeOpts) error {
+	return ErrNoPlot
+}
@@ -0,0 +1,56 @@
+package matrixprofile
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// defaultDPI matches the default resolution of the gonum plot image canvases
+const defaultDPI = 96
+
+// VisualizeOpts are parameters to vary how a matrix profile is rendered.
+type VisualizeOpts struct {
+	Format   string  // output format which is one of png, jpg, tiff, svg or pdf
+	Width    float64 // width of the output in points
+	Height   float64 // height of the output in points
+	DPI      int     // dots per inch, only applicable to png, jpg and tiff
+	Signal   bool    // include the time series panel
+	MP       bool    // include the matrix profile panel
+	CAC      bool    // include the corrected arc curve panel
+	AV       bool    // include the annotation vector and corrected matrix profile panels when an annotation vector other than the default is set
+	Motifs   bool    // include a panel for each discovered motif
+	Discords bool    // include the discovered discords panel
+	Index    bool    // include a matrix profile index panel per dimension, only applicable to the k-dimensional matrix profile
+	Subspace bool    // include a panel per dimension marking the dimensions of the best subspace at each index, only applicable to the k-dimensional matrix profile
+}
+
+// NewVisualizeOpts returns a default VisualizeOpts which renders a png with all
+// panels included.
+func NewVisualizeOpts() *VisualizeOpts {
+	return &VisualizeOpts{
+		Format:   "png",
+		Width:    1200,
+		Height:   600,
+		DPI:      defaultDPI,
+		Signal:   true,
+		MP:       true,
+		CAC:      true,
+		AV:       true,
+		Motifs:   true,
+		Discords: true,
+		Index:    true,
+		Subspace: true,
+	}
+}
+
+// formatFromFilename returns the output format matching the extension of the
+// filename or def if the extension is not a supported format.
+func formatFromFilename(fn, def string) string {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(fn), "."))
+	switch ext {
+	case "png", "jpg", "jpeg", "tif", "tiff", "svg", "pdf":
+		return ext
+	default:
+		return def
+	}
+}
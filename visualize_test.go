@@ -0,0 +1,224 @@
+//go:build !noplot
+// +build !noplot
+
+package matrixprofile
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/matrix-profile-foundation/go-matrixprofile/av"
+	"github.com/matrix-profile-foundation/go-matrixprofile/siggen"
+)
+
+func TestVisualizeTo(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.3, 100))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverMotifs(2, 2, 10, mp.W/2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = mp.DiscoverDiscords(2, mp.W/2); err != nil {
+		t.Fatal(err)
+	}
+
+	testdata := []struct {
+		format string
+		prefix string
+	}{
+		{"png", "\x89PNG"},
+		{"svg", "<?xml"},
+		{"pdf", "%PDF"},
+	}
+
+	for _, d := range testdata {
+		o := NewVisualizeOpts()
+		o.Format = d.format
+		var buf bytes.Buffer
+		if err = mp.VisualizeTo(&buf, o); err != nil {
+			t.Errorf("Did not expect an error rendering %s, %v", d.format, err)
+			continue
+		}
+		if !bytes.HasPrefix(buf.Bytes(), []byte(d.prefix)) {
+			t.Errorf("Expected %s output to start with %q", d.format, d.prefix)
+		}
+	}
+
+	o := NewVisualizeOpts()
+	o.Format = "bmp"
+	if err = mp.VisualizeTo(&bytes.Buffer{}, o); err == nil {
+		t.Errorf("Expected an error for an invalid format")
+	}
+
+	o = &VisualizeOpts{Format: "png", Width: 100, Height: 100}
+	if err = mp.VisualizeTo(&bytes.Buffer{}, o); err == nil {
+		t.Errorf("Expected an error when no panels are selected")
+	}
+}
+
+func TestVisualizeAV(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.3, 100))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = mp.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the annotation vector panels add to the rendered output
+	var plain, annotated bytes.Buffer
+	o := NewVisualizeOpts()
+	o.Format = "svg"
+	if err = mp.VisualizeTo(&plain, o); err != nil {
+		t.Fatal(err)
+	}
+	mp.AV = av.Complexity
+	if err = mp.VisualizeTo(&annotated, o); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if !bytes.Contains(annotated.Bytes(), []byte("corrected matrix profile")) || bytes.Contains(plain.Bytes(), []byte("corrected matrix profile")) {
+		t.Errorf("Expected the corrected matrix profile panel only with a non default annotation vector")
+	}
+
+	o.AV = false
+	annotated.Reset()
+	if err = mp.VisualizeTo(&annotated, o); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(annotated.Bytes(), []byte("corrected matrix profile")) {
+		t.Errorf("Expected no annotation vector panels when disabled")
+	}
+
+	o.AV = true
+	mp.AV = "bogus"
+	if err = mp.VisualizeTo(&bytes.Buffer{}, o); err == nil {
+		t.Errorf("Expected an error for an invalid annotation vector")
+	}
+}
+
+func TestVisualizeChainTo(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.3, 100))
+	mp, err := New(sig, nil, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := NewMPOpts()
+	o.LeftRight = true
+	if err = mp.Compute(o); err != nil {
+		t.Fatal(err)
+	}
+	chains, err := mp.DiscoverChains()
+	if err != nil || len(chains) == 0 {
+		t.Fatalf("Expected a chain, but got %v, %v", chains, err)
+	}
+
+	vo := NewVisualizeOpts()
+	vo.Format = "svg"
+	var buf bytes.Buffer
+	if err = mp.VisualizeChainTo(&buf, chains[0], vo); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("chain positions")) {
+		t.Errorf("Expected a chain positions panel")
+	}
+
+	if err = mp.VisualizeChainTo(&bytes.Buffer{}, nil, vo); err == nil {
+		t.Errorf("Expected an error for an empty chain")
+	}
+	if err = mp.VisualizeChainTo(&bytes.Buffer{}, []int{len(sig)}, vo); err == nil {
+		t.Errorf("Expected an error for a link out of range")
+	}
+
+	fn := "mp_chain.png"
+	defer os.Remove(fn)
+	if err = mp.VisualizeChain(fn, chains[0]); err != nil {
+		t.Errorf("Did not expect an error saving the chain, %v", err)
+	}
+}
+
+func TestVisualizeKMP(t *testing.T) {
+	sig := [][]float64{
+		siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Noise(0.3, 100)),
+		siggen.Append(siggen.Noise(0.3, 100), siggen.Sin(1, 5, 0, 0, 100, 2)),
+	}
+	k, err := NewKMP(sig, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = k.Compute(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	o := newKMPVisualizeOpts()
+	o.Format = "svg"
+	var buf bytes.Buffer
+	if err = k.VisualizeTo(&buf, o); err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	for _, title := range []string{"mp1", "mp index1", "subspace1"} {
+		if !bytes.Contains(buf.Bytes(), []byte(title)) {
+			t.Errorf("Expected a %s panel", title)
+		}
+	}
+
+	o.Index, o.Subspace = false, false
+	buf.Reset()
+	if err = k.VisualizeTo(&buf, o); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(buf.Bytes(), []byte("mp index")) || bytes.Contains(buf.Bytes(), []byte("subspace")) {
+		t.Errorf("Expected no index or subspace panels when disabled")
+	}
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	testdata := []struct {
+		fn       string
+		expected string
+	}{
+		{"mp.png", "png"},
+		{"mp.SVG", "svg"},
+		{"out/mp.pdf", "pdf"},
+		{"mp", "png"},
+		{"mp.txt", "png"},
+	}
+
+	for _, d := range testdata {
+		if out := formatFromFilename(d.fn, "png"); out != d.expected {
+			t.Errorf("Expected %s, but got %s for %s", d.expected, out, d.fn)
+		}
+	}
+}
+
+func TestAnalyzeOutput(t *testing.T) {
+	sig := siggen.Append(siggen.Sin(1, 5, 0, 0, 100, 2), siggen.Sin(0.25, 10, 0, 0.75, 100, 1))
+	sig = siggen.Add(sig, siggen.Noise(0.01, len(sig)))
+
+	mp, err := New(sig, nil, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ao := NewAnalyzeOpts()
+	ao.Motifs = false
+	ao.Segments = false
+	ao.OutputFilename = "mp_analyze.svg"
+	defer os.Remove(ao.OutputFilename)
+	res, err := mp.Analyze(nil, ao)
+	if err != nil {
+		t.Fatalf("Did not expect an error, %v", err)
+	}
+	if len(res.Discords) != ao.KDiscords {
+		t.Errorf("Expected %d discords, but got %d", ao.KDiscords, len(res.Discords))
+	}
+	if _, err = os.Stat(ao.OutputFilename); err != nil {
+		t.Errorf("Expected visualization to be written, %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package matrixprofile
+
+import (
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// SuggestWindows suggests candidate subsequence lengths for a time series from
+// its periodicity. The autocorrelation of the time series is computed with a
+// fast fourier transform and every lag between 4 and max, and at most half the
+// length of the time series, that is a positive local maximum of the
+// autocorrelation is returned. Candidates are ordered from the strongest to
+// the weakest autocorrelation. Returns nil if no periodicity is found.
+func SuggestWindows(ts []float64, max int) []int {
+	if max > len(ts)/2 {
+		max = len(ts) / 2
+	}
+	if max < 4 {
+		return nil
+	}
+
+	acf := autocorrelation(ts)
+	if acf == nil {
+		return nil
+	}
+
+	var windows []int
+	for l := 4; l <= max && l+1 < len(acf); l++ {
+		if acf[l] > 0 && acf[l] > acf[l-1] && acf[l] >= acf[l+1] {
+			windows = append(windows, l)
+		}
+	}
+
+	sort.SliceStable(windows, func(i, j int) bool {
+		return acf[windows[i]] > acf[windows[j]]
+	})
+	return windows
+}
+
+// autocorrelation computes the autocorrelation of a time series for every lag
+// normalized so that lag 0 has a value of 1. Returns nil for a constant time
+// series.
+func autocorrelation(ts []float64) []float64 {
+	n := len(ts)
+	mean := stat.Mean(ts, nil)
+
+	// zero pad to twice the length so the circular correlation is linear
+	x := make([]float64, 2*n)
+	for i, v := range ts {
+		x[i] = v - mean
+	}
+
+	fft := getFFT(len(x))
+	defer putFFT(fft)
+	coeff := fft.Coefficients(nil, x)
+	for i, c := range coeff {
+		coeff[i] = complex(real(c)*real(c)+imag(c)*imag(c), 0)
+	}
+	acf := fft.Sequence(nil, coeff)[:n]
+
+	if acf[0] <= 0 {
+		return nil
+	}
+	norm := acf[0]
+	for i := range acf {
+		acf[i] /= norm
+	}
+	return acf
+}
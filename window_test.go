@@ -0,0 +1,55 @@
+package matrixprofile
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSuggestWindows(t *testing.T) {
+	sine := make([]float64, 400)
+	for i := range sine {
+		sine[i] = math.Sin(2*math.Pi*float64(i)/25) + 0.1*math.Sin(float64(i)*1.7)
+	}
+
+	testdata := []struct {
+		ts       []float64
+		max      int
+		expected []int
+	}{
+		{sine, 30, []int{25}},
+		{sine, 60, []int{25, 50}},
+		{sine, 20, nil},
+		{make([]float64, 100), 30, nil},
+		{sine[:6], 30, nil},
+	}
+
+	for _, d := range testdata {
+		out := SuggestWindows(d.ts, d.max)
+		if len(out) != len(d.expected) {
+			t.Errorf("Expected %v, but got %v for a max of %d", d.expected, out, d.max)
+			continue
+		}
+		for i := range out {
+			if out[i] != d.expected[i] {
+				t.Errorf("Expected %v, but got %v for a max of %d", d.expected, out, d.max)
+				break
+			}
+		}
+	}
+}
+
+func TestAutocorrelation(t *testing.T) {
+	ts := []float64{1, 2, 3, 4}
+	expected := []float64{1, 0.25, -0.3, -0.45}
+
+	out := autocorrelation(ts)
+	if len(out) != len(expected) {
+		t.Fatalf("Expected %v, but got %v", expected, out)
+	}
+	for i := range out {
+		if math.Abs(out[i]-expected[i]) > 1e-9 {
+			t.Errorf("Expected %v, but got %v", expected, out)
+			break
+		}
+	}
+}